@@ -0,0 +1,232 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tlsconfig builds the TLS configuration of components that connect to a server.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Names of the fields of Metadata, as used in the components' metadata and in the returned errors.
+const (
+	FieldCACert        = "caCert"
+	FieldCAPem         = "caPem"
+	FieldCAPath        = "caPath"
+	FieldClientCert    = "clientCert"
+	FieldClientKey     = "clientKey"
+	FieldSkipVerify    = "skipVerify"
+	FieldTLSServerName = "tlsServerName"
+	FieldTLSMinVersion = "tlsMinVersion"
+)
+
+const pemPrefix = "-----BEGIN"
+
+// Metadata is the normalized set of TLS options of a component.
+// Components whose metadata keys differ from the field names map their own keys onto it.
+type Metadata struct {
+	// CACert is a CA certificate, either inline PEM or the path of a PEM file.
+	CACert string
+	// CAPem is an inline PEM CA certificate. It takes precedence over CAPath and CACert.
+	CAPem string
+	// CAPath is a folder whose files are all read as PEM CA certificates. It takes precedence over CACert.
+	CAPath string
+	// ClientCert is a client certificate for mTLS, either inline PEM or the path of a PEM file.
+	ClientCert string
+	// ClientKey is the private key of ClientCert, either inline PEM or the path of a PEM file.
+	ClientKey string
+	// SkipVerify disables the verification of the server certificate.
+	SkipVerify bool
+	// ServerName is the name used to verify the server certificate.
+	ServerName string
+	// MinVersion is the minimum TLS version, such as "1.2". Defaults to 1.2.
+	MinVersion string
+}
+
+// FieldError is a validation error of a Metadata field.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// NewConfig returns the TLS configuration for the given metadata.
+// If any field is invalid, the returned error joins a *FieldError for each of them.
+// When no CA is configured, the system certificates are trusted.
+func NewConfig(md Metadata) (*tls.Config, error) {
+	var errs []error
+
+	conf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: md.SkipVerify, //nolint:gosec
+	}
+
+	if md.MinVersion != "" {
+		minVersion, err := parseVersion(md.MinVersion)
+		if err != nil {
+			errs = append(errs, &FieldError{Field: FieldTLSMinVersion, Err: err})
+		}
+		conf.MinVersion = minVersion
+	}
+
+	// Server certificates are not verified at all when skipVerify is set, so no CA is loaded
+	if !md.SkipVerify {
+		rootCAs, err := rootCAs(md)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		conf.RootCAs = rootCAs
+		conf.ServerName = md.ServerName
+	}
+
+	switch {
+	case md.ClientCert == "" && md.ClientKey == "":
+	case md.ClientCert == "":
+		errs = append(errs, &FieldError{Field: FieldClientCert, Err: fmt.Errorf("required when %s is set", FieldClientKey)})
+	case md.ClientKey == "":
+		errs = append(errs, &FieldError{Field: FieldClientKey, Err: fmt.Errorf("required when %s is set", FieldClientCert)})
+	default:
+		cert, err := clientCertificate(md.ClientCert, md.ClientKey)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			conf.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return conf, nil
+}
+
+// rootCAs returns the pool of the configured CA certificates, or the system certificates if there's none.
+func rootCAs(md Metadata) (*x509.CertPool, error) {
+	certPool := x509.NewCertPool()
+	switch {
+	case md.CAPem != "":
+		if ok := certPool.AppendCertsFromPEM([]byte(md.CAPem)); !ok {
+			return nil, &FieldError{Field: FieldCAPem, Err: errors.New("couldn't read PEM")}
+		}
+	case md.CAPath != "":
+		if err := readCertificateFolder(certPool, md.CAPath); err != nil {
+			return nil, &FieldError{Field: FieldCAPath, Err: err}
+		}
+	case md.CACert != "":
+		pemBytes, err := readPEM(md.CACert)
+		if err != nil {
+			return nil, &FieldError{Field: FieldCACert, Err: err}
+		}
+		if ok := certPool.AppendCertsFromPEM(pemBytes); !ok {
+			return nil, &FieldError{Field: FieldCACert, Err: errors.New("couldn't read PEM")}
+		}
+	default:
+		systemPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read system certs: %w", err)
+		}
+		return systemPool, nil
+	}
+
+	return certPool, nil
+}
+
+// clientCertificate loads a client certificate and its key.
+func clientCertificate(certVal, keyVal string) (tls.Certificate, error) {
+	certPEM, err := readPEM(certVal)
+	if err != nil {
+		return tls.Certificate{}, &FieldError{Field: FieldClientCert, Err: err}
+	}
+	keyPEM, err := readPEM(keyVal)
+	if err != nil {
+		return tls.Certificate{}, &FieldError{Field: FieldClientKey, Err: err}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, &FieldError{Field: FieldClientCert, Err: fmt.Errorf("couldn't load key pair: %w", err)}
+	}
+
+	return cert, nil
+}
+
+// readPEM returns a value given as inline PEM, or reads it from the file at the given path.
+func readPEM(val string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(val), pemPrefix) {
+		return []byte(val), nil
+	}
+
+	b, err := os.ReadFile(val)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read file from disk: %w", err)
+	}
+
+	return b, nil
+}
+
+// readCertificateFolder adds every certificate found in a folder to the pool.
+func readCertificateFolder(certPool *x509.CertPool, path string) error {
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		pemFile, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("couldn't read CA file from disk: %w", err)
+		}
+		if ok := certPool.AppendCertsFromPEM(pemFile); !ok {
+			return fmt.Errorf("couldn't read PEM from %s", p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't read certificates at %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// parseVersion parses a TLS version such as "1.2" or "TLS1.2".
+func parseVersion(val string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(val)), "tls") {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q, accepted values are 1.0, 1.1, 1.2 or 1.3", val)
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPEM holds PEM material generated for the tests, both inline and written to files.
+type testPEM struct {
+	caCert, clientCert, clientKey             string
+	caCertFile, clientCertFile, clientKeyFile string
+	caFolder                                  string
+	caSubject                                 []byte
+}
+
+func newTestPEM(t *testing.T) testPEM {
+	t.Helper()
+
+	newCert := func(template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		keyDer, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+
+		return cert, key,
+			string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+			string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+	}
+
+	ca, caKey, caPEM, _ := newCert(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	_, _, clientPEM, clientKeyPEM := newCert(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	caFolder := filepath.Join(dir, "cas")
+	require.NoError(t, os.Mkdir(caFolder, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(caFolder, "ca.pem"), []byte(caPEM), 0o600))
+
+	return testPEM{
+		caCert:         caPEM,
+		clientCert:     clientPEM,
+		clientKey:      clientKeyPEM,
+		caCertFile:     writeFile("ca.pem", caPEM),
+		clientCertFile: writeFile("client.pem", clientPEM),
+		clientKeyFile:  writeFile("client-key.pem", clientKeyPEM),
+		caFolder:       caFolder,
+		caSubject:      ca.RawSubject,
+	}
+}
+
+// fieldErrors returns the fields of the *FieldError joined in err.
+func fieldErrors(err error) []string {
+	var joined interface{ Unwrap() []error }
+	errs := []error{err}
+	if errors.As(err, &joined) {
+		errs = joined.Unwrap()
+	}
+
+	res := []string{}
+	for _, e := range errs {
+		var fieldErr *FieldError
+		if errors.As(e, &fieldErr) {
+			res = append(res, fieldErr.Field)
+		}
+	}
+
+	return res
+}
+
+func TestNewConfig(t *testing.T) {
+	p := newTestPEM(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		conf, err := NewConfig(Metadata{})
+		require.NoError(t, err)
+		assert.Equal(t, uint16(tls.VersionTLS12), conf.MinVersion)
+		assert.False(t, conf.InsecureSkipVerify)
+		assert.NotNil(t, conf.RootCAs, "expected the system pool")
+		assert.Empty(t, conf.Certificates)
+	})
+
+	t.Run("CA material", func(t *testing.T) {
+		tests := map[string]Metadata{
+			"inline caPem":          {CAPem: p.caCert},
+			"inline caCert":         {CACert: p.caCert},
+			"file caCert":           {CACert: p.caCertFile},
+			"caPath folder":         {CAPath: p.caFolder},
+			"caPem over others":     {CAPem: p.caCert, CAPath: "/does/not/exist", CACert: "/does/not/exist"},
+			"caPath over caCert":    {CAPath: p.caFolder, CACert: "/does/not/exist"},
+			"with tlsServerName":    {CACert: p.caCertFile, ServerName: "vault.local"},
+			"with tlsMinVersion":    {CAPem: p.caCert, MinVersion: "1.3"},
+			"with TLS-prefixed ver": {CAPem: p.caCert, MinVersion: "TLS1.3"},
+		}
+
+		for name, md := range tests {
+			t.Run(name, func(t *testing.T) {
+				conf, err := NewConfig(md)
+				require.NoError(t, err)
+				require.NotNil(t, conf.RootCAs)
+				//nolint:staticcheck
+				assert.Equal(t, [][]byte{p.caSubject}, conf.RootCAs.Subjects())
+				assert.Equal(t, md.ServerName, conf.ServerName)
+				if md.MinVersion != "" {
+					assert.Equal(t, uint16(tls.VersionTLS13), conf.MinVersion)
+				}
+			})
+		}
+	})
+
+	t.Run("client certificate material", func(t *testing.T) {
+		tests := map[string]Metadata{
+			"inline cert and inline key": {ClientCert: p.clientCert, ClientKey: p.clientKey},
+			"file cert and file key":     {ClientCert: p.clientCertFile, ClientKey: p.clientKeyFile},
+			"inline cert and file key":   {ClientCert: p.clientCert, ClientKey: p.clientKeyFile},
+			"file cert and inline key":   {ClientCert: p.clientCertFile, ClientKey: p.clientKey},
+		}
+
+		for name, md := range tests {
+			t.Run(name, func(t *testing.T) {
+				md.CAPem = p.caCert
+				conf, err := NewConfig(md)
+				require.NoError(t, err)
+				require.Len(t, conf.Certificates, 1)
+				leaf, err := x509.ParseCertificate(conf.Certificates[0].Certificate[0])
+				require.NoError(t, err)
+				assert.Equal(t, "test-client", leaf.Subject.CommonName)
+			})
+		}
+	})
+
+	t.Run("skipVerify ignores CA material", func(t *testing.T) {
+		conf, err := NewConfig(Metadata{SkipVerify: true, CACert: "/does/not/exist", ServerName: "vault.local"})
+		require.NoError(t, err)
+		assert.True(t, conf.InsecureSkipVerify)
+		assert.Nil(t, conf.RootCAs)
+		assert.Empty(t, conf.ServerName)
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		tests := map[string]struct {
+			md     Metadata
+			fields []string
+		}{
+			"invalid caPem":            {Metadata{CAPem: "not a PEM"}, []string{FieldCAPem}},
+			"missing caCert file":      {Metadata{CACert: "/does/not/exist"}, []string{FieldCACert}},
+			"caCert file not a PEM":    {Metadata{CACert: p.clientKeyFile}, []string{FieldCACert}},
+			"missing caPath":           {Metadata{CAPath: "/does/not/exist"}, []string{FieldCAPath}},
+			"client cert without key":  {Metadata{ClientCert: p.clientCert}, []string{FieldClientKey}},
+			"client key without cert":  {Metadata{ClientKey: p.clientKey}, []string{FieldClientCert}},
+			"missing client cert file": {Metadata{ClientCert: "/does/not/exist", ClientKey: p.clientKey}, []string{FieldClientCert}},
+			"missing client key file":  {Metadata{ClientCert: p.clientCert, ClientKey: "/does/not/exist"}, []string{FieldClientKey}},
+			"mismatched key pair":      {Metadata{ClientCert: p.caCert, ClientKey: p.clientKey}, []string{FieldClientCert}},
+			"invalid tlsMinVersion":    {Metadata{MinVersion: "1.4"}, []string{FieldTLSMinVersion}},
+			"every invalid field is reported": {
+				Metadata{CAPem: "not a PEM", ClientCert: p.clientCert, MinVersion: "ssl3"},
+				[]string{FieldTLSMinVersion, FieldCAPem, FieldClientKey},
+			},
+		}
+
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				conf, err := NewConfig(tt.md)
+				require.Error(t, err)
+				assert.Nil(t, conf)
+				assert.ElementsMatch(t, tt.fields, fieldErrors(err))
+			})
+		}
+	})
+}
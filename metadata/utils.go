@@ -170,6 +170,118 @@ func DecodeMetadata(input any, result any) error {
 	return err
 }
 
+// DecodeAndValidateMetadata decodes metadata into a struct like DecodeMetadata, and additionally:
+// - Sets the value of the "mddefault" tag on the fields that are not present in the metadata
+// - Requires the fields with a truthy "mdrequired" tag to be present and not empty
+// - Reads the fields from the names in the "mdaliases" tag when they are not present under their own name
+// Instead of stopping at the first one, the returned error lists all the invalid fields.
+func DecodeAndValidateMetadata(input any, result any) error {
+	props, ok := input.(map[string]string)
+	if !ok {
+		v := reflect.ValueOf(input)
+		if v.Kind() == reflect.Struct {
+			f := v.FieldByName("Properties")
+			if f.IsValid() && f.Kind() == reflect.Map {
+				props, ok = f.Interface().(map[string]string)
+			}
+		}
+		if !ok {
+			return fmt.Errorf("unsupported metadata type: %T", input)
+		}
+	}
+
+	t := reflect.TypeOf(result)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("result must be a pointer to a struct, got %T", result)
+	}
+
+	// Keys are matched case-insensitively, like mapstructure does
+	lcProps := make(map[string]string, len(props))
+	values := make(map[string]string, len(props))
+	for k, v := range props {
+		lcProps[strings.ToLower(k)] = v
+		values[k] = v
+	}
+
+	var errs []error
+	for _, field := range annotatedMetadataFields(t.Elem()) {
+		val, present := lcProps[strings.ToLower(field.name)]
+		for _, alias := range field.aliases {
+			if present {
+				break
+			}
+			if val, present = lcProps[strings.ToLower(alias)]; present {
+				// Aliases are decoded as if the canonical name had been used
+				values[field.name] = val
+			}
+		}
+
+		if !present && field.hasDefault {
+			values[field.name] = field.defaultValue
+			val, present = field.defaultValue, true
+		}
+		if field.required && (!present || strings.TrimSpace(val) == "") {
+			errs = append(errs, fmt.Errorf("missing required metadata property '%s'", field.name))
+		}
+	}
+
+	if err := DecodeMetadata(values, result); err != nil {
+		var decodeErr *mapstructure.Error
+		if errors.As(err, &decodeErr) {
+			errs = append(errs, decodeErr.WrappedErrors()...)
+		} else {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// annotatedMetadataField is a metadata field with its default value and validation rules.
+type annotatedMetadataField struct {
+	name         string
+	aliases      []string
+	defaultValue string
+	hasDefault   bool
+	required     bool
+}
+
+// annotatedMetadataFields returns the metadata fields of a struct, including the ones of squashed embedded structs.
+func annotatedMetadataFields(t reflect.Type) []annotatedMetadataField {
+	res := make([]annotatedMetadataField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		currentField := t.Field(i)
+		if !currentField.IsExported() {
+			continue
+		}
+		mapStructureTags := strings.Split(currentField.Tag.Get("mapstructure"), ",")
+		if mapStructureTags[0] == "-" {
+			continue
+		}
+		numTags := len(mapStructureTags)
+		if numTags > 1 && mapStructureTags[numTags-1] == "squash" && currentField.Anonymous {
+			res = append(res, annotatedMetadataFields(currentField.Type)...)
+			continue
+		}
+
+		field := annotatedMetadataField{
+			name:     currentField.Name,
+			required: utils.IsTruthy(currentField.Tag.Get("mdrequired")),
+		}
+		if mapStructureTags[0] != "" {
+			field.name = mapStructureTags[0]
+		}
+		if mdAliasesTag := currentField.Tag.Get("mdaliases"); mdAliasesTag != "" {
+			field.aliases = strings.Split(mdAliasesTag, ",")
+		}
+		field.defaultValue, field.hasDefault = currentField.Tag.Lookup("mddefault")
+
+		res = append(res, field)
+	}
+
+	return res
+}
+
 func toTruthyBoolHookFunc() mapstructure.DecodeHookFunc {
 	return func(
 		f reflect.Type,
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsRawPayload(t *testing.T) {
@@ -229,6 +230,170 @@ func TestMetadataDecode(t *testing.T) {
 	})
 }
 
+func TestDecodeAndValidateMetadata(t *testing.T) {
+	t.Run("coercion rules", func(t *testing.T) {
+		type testMetadata struct {
+			Bool     bool
+			Int      int
+			Uint     uint
+			Float    float64
+			Duration time.Duration
+			Strings  []string
+		}
+
+		tests := []struct {
+			name     string
+			key      string
+			values   []string
+			expected any
+			field    func(m testMetadata) any
+		}{
+			{"truthy bools", "bool", []string{"true", "True", "TRUE", " true ", "1", "y", "yes", "Yes", "t", "on", "ON"}, true, func(m testMetadata) any { return m.Bool }},
+			{"falsy bools", "bool", []string{"false", "False", "0", "n", "no", "off", "", "anything"}, false, func(m testMetadata) any { return m.Bool }},
+			{"decimal int", "int", []string{"42", "+42"}, 42, func(m testMetadata) any { return m.Int }},
+			{"negative int", "int", []string{"-7"}, -7, func(m testMetadata) any { return m.Int }},
+			{"prefixed int", "int", []string{"0x10", "0o20", "0b10000"}, 16, func(m testMetadata) any { return m.Int }},
+			{"empty int", "int", []string{""}, 0, func(m testMetadata) any { return m.Int }},
+			{"uint", "uint", []string{"7"}, uint(7), func(m testMetadata) any { return m.Uint }},
+			{"float", "float", []string{"1.5", "15e-1"}, 1.5, func(m testMetadata) any { return m.Float }},
+			{"duration with unit", "duration", []string{"90s", "1m30s", "1.5m"}, 90 * time.Second, func(m testMetadata) any { return m.Duration }},
+			{"duration in seconds", "duration", []string{"90"}, 90 * time.Second, func(m testMetadata) any { return m.Duration }},
+			{"empty duration", "duration", []string{""}, time.Duration(0), func(m testMetadata) any { return m.Duration }},
+			{"comma-separated strings", "strings", []string{"a,b,c"}, []string{"a", "b", "c"}, func(m testMetadata) any { return m.Strings }},
+			{"single string", "strings", []string{"a"}, []string{"a"}, func(m testMetadata) any { return m.Strings }},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				for _, val := range tt.values {
+					var m testMetadata
+					err := DecodeAndValidateMetadata(map[string]string{tt.key: val}, &m)
+					require.NoError(t, err, val)
+					assert.Equal(t, tt.expected, tt.field(m), val)
+				}
+			})
+		}
+
+		t.Run("invalid values", func(t *testing.T) {
+			for key, val := range map[string]string{
+				"int":      "one",
+				"uint":     "-1",
+				"float":    "1,5",
+				"duration": "soon",
+			} {
+				var m testMetadata
+				err := DecodeAndValidateMetadata(map[string]string{key: val}, &m)
+				assert.Error(t, err, key)
+			}
+		})
+	})
+
+	t.Run("keys are case-insensitive", func(t *testing.T) {
+		type testMetadata struct {
+			MyField string `mapstructure:"myField" mdrequired:"true"`
+		}
+
+		var m testMetadata
+		err := DecodeAndValidateMetadata(map[string]string{"MYFIELD": "value"}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "value", m.MyField)
+	})
+
+	t.Run("default values", func(t *testing.T) {
+		type testMetadata struct {
+			Enabled  bool          `mapstructure:"enabled" mddefault:"true"`
+			Interval time.Duration `mapstructure:"interval" mddefault:"1m"`
+			Name     string        `mapstructure:"name" mddefault:"dapr"`
+			Count    int           `mapstructure:"count"`
+		}
+
+		var m testMetadata
+		err := DecodeAndValidateMetadata(map[string]string{}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, testMetadata{Enabled: true, Interval: time.Minute, Name: "dapr"}, m)
+
+		// Defaults apply only to missing properties, not to empty ones
+		m = testMetadata{}
+		err = DecodeAndValidateMetadata(map[string]string{"Enabled": "false", "interval": "5s", "name": ""}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, testMetadata{Enabled: false, Interval: 5 * time.Second, Name: ""}, m)
+	})
+
+	t.Run("required values", func(t *testing.T) {
+		type testMetadata struct {
+			Host  string `mapstructure:"host" mdrequired:"true"`
+			Token string `mapstructure:"token" mdrequired:"true" mdaliases:"authToken"`
+			Port  int    `mapstructure:"port" mdrequired:"true" mddefault:"8200"`
+		}
+
+		var m testMetadata
+		err := DecodeAndValidateMetadata(map[string]string{"host": "localhost", "authToken": "abc"}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, 8200, m.Port)
+		assert.Equal(t, "abc", m.Token)
+
+		// The canonical name takes precedence over the aliases
+		m = testMetadata{}
+		err = DecodeAndValidateMetadata(map[string]string{"host": "localhost", "token": "def", "authToken": "abc"}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "def", m.Token)
+
+		err = DecodeAndValidateMetadata(map[string]string{"host": "  "}, &m)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "missing required metadata property 'host'")
+		assert.ErrorContains(t, err, "missing required metadata property 'token'")
+		assert.NotContains(t, err.Error(), "'port'")
+	})
+
+	t.Run("all errors are reported at once", func(t *testing.T) {
+		type testMetadata struct {
+			Host     string        `mapstructure:"host" mdrequired:"true"`
+			Port     int           `mapstructure:"port"`
+			Timeout  time.Duration `mapstructure:"timeout"`
+			Replicas uint          `mapstructure:"replicas"`
+		}
+
+		var m testMetadata
+		err := DecodeAndValidateMetadata(map[string]string{
+			"port":     "eighty",
+			"timeout":  "forever",
+			"replicas": "3",
+		}, &m)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "'host'")
+		assert.ErrorContains(t, err, "'port'")
+		assert.ErrorContains(t, err, "'timeout'")
+		assert.NotContains(t, err.Error(), "'replicas'")
+		// Valid fields are still decoded
+		assert.Equal(t, uint(3), m.Replicas)
+	})
+
+	t.Run("squashed structs and metadata.Base input", func(t *testing.T) {
+		type Embedded struct {
+			Shared string `mapstructure:"shared" mddefault:"embedded"`
+		}
+		type testMetadata struct {
+			Embedded `mapstructure:",squash"`
+			Own      string `mapstructure:"own" mdrequired:"true"`
+			Ignored  string `mapstructure:"-" mdrequired:"true"`
+		}
+
+		var m testMetadata
+		err := DecodeAndValidateMetadata(Base{Properties: map[string]string{"own": "value"}}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "embedded", m.Shared)
+		assert.Equal(t, "value", m.Own)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		type testMetadata struct{}
+
+		var m testMetadata
+		assert.Error(t, DecodeAndValidateMetadata(map[string]string{}, m))
+		assert.Error(t, DecodeAndValidateMetadata(42, &m))
+	})
+}
+
 func TestMetadataStructToStringMap(t *testing.T) {
 	t.Run("Test metadata struct to metadata info conversion", func(t *testing.T) {
 		type NestedStruct struct {
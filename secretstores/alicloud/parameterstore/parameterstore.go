@@ -194,7 +194,7 @@ func (o *oosSecretStore) getPathFromMetadata(metadata map[string]string) *string
 
 // Features returns the features available in this secret store.
 func (o *oosSecretStore) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetSecret}
 }
 
 func (o *oosSecretStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
@@ -205,8 +205,8 @@ func TestGetFeatures(t *testing.T) {
 	m := secretstores.Metadata{}
 	s := NewParameterStore(logger.NewLogger("test"))
 	s.Init(context.Background(), m)
-	t.Run("no features are advertised", func(t *testing.T) {
+	t.Run("only BULK_GET_SECRET is advertised", func(t *testing.T) {
 		f := s.Features()
-		assert.Empty(t, f)
+		assert.Equal(t, []secretstores.Feature{secretstores.FeatureBulkGetSecret}, f)
 	})
 }
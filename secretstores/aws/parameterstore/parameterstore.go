@@ -169,7 +169,7 @@ func (s *ssmSecretStore) getSecretManagerMetadata(spec secretstores.Metadata) (*
 
 // Features returns the features available in this secret store.
 func (s *ssmSecretStore) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetSecret}
 }
 
 func (s *ssmSecretStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
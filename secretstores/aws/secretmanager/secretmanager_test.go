@@ -154,8 +154,8 @@ func TestGetSecret(t *testing.T) {
 
 func TestGetFeatures(t *testing.T) {
 	s := smSecretStore{}
-	t.Run("no features are advertised", func(t *testing.T) {
+	t.Run("only BULK_GET_SECRET is advertised", func(t *testing.T) {
 		f := s.Features()
-		assert.Empty(t, f)
+		assert.Equal(t, []secretstores.Feature{secretstores.FeatureBulkGetSecret}, f)
 	})
 }
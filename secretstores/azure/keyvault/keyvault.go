@@ -15,7 +15,9 @@ package keyvault
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
@@ -118,6 +120,10 @@ func (k *keyvaultSecretStore) GetSecret(ctx context.Context, req secretstores.Ge
 
 	secretResp, err := k.vaultClient.GetSecret(ctx, req.Name, version, nil)
 	if err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return secretstores.GetSecretResponse{}, fmt.Errorf("secret %s: %w", req.Name, secretstores.ErrSecretNotFound)
+		}
 		return secretstores.GetSecretResponse{}, err
 	}
 
@@ -203,7 +209,7 @@ func (k *keyvaultSecretStore) getMaxResultsFromMetadata(metadata map[string]stri
 
 // Features returns the features available in this secret store.
 func (k *keyvaultSecretStore) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetSecret}
 }
 
 func (k *keyvaultSecretStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
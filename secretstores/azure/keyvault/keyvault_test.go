@@ -93,8 +93,8 @@ func TestInit(t *testing.T) {
 func TestGetFeatures(t *testing.T) {
 	s := NewAzureKeyvaultSecretStore(logger.NewLogger("test"))
 	// Yes, we are skipping initialization as feature retrieval doesn't depend on it.
-	t.Run("no features are advertised", func(t *testing.T) {
+	t.Run("only BULK_GET_SECRET is advertised", func(t *testing.T) {
 		f := s.Features()
-		assert.Empty(t, f)
+		assert.Equal(t, []secretstores.Feature{secretstores.FeatureBulkGetSecret}, f)
 	})
 }
@@ -0,0 +1,387 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstores
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/exp/maps"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// DefaultCachingTTL is the time-to-live of the cached responses when CachingOptions doesn't set one.
+const DefaultCachingTTL = 5 * time.Minute
+
+// cachingLoadTimeout bounds a request to the wrapped store, which isn't canceled with the context of the caller that started it.
+const cachingLoadTimeout = time.Minute
+
+// Operations used to tag the metrics recorded by the caching store.
+const (
+	cachingOperationGet     = "get"
+	cachingOperationBulkGet = "bulk_get"
+)
+
+var (
+	cachingOperationKey = tag.MustNewKey("operation")
+
+	cachingHits = stats.Int64(
+		"secretstore_cache_hits",
+		"The number of secret store responses served from the cache.",
+		stats.UnitDimensionless)
+	cachingMisses = stats.Int64(
+		"secretstore_cache_misses",
+		"The number of secret store responses not found in the cache.",
+		stats.UnitDimensionless)
+	cachingEvictions = stats.Int64(
+		"secretstore_cache_evictions",
+		"The number of cached responses evicted because the cache was full.",
+		stats.UnitDimensionless)
+
+	// Views are process-wide: they are registered only once regardless of the number of caching stores.
+	registerCachingViewsOnce sync.Once
+	errRegisterCachingViews  error
+)
+
+// CachingOptions configures a caching store.
+type CachingOptions struct {
+	// TTL is the time-to-live of the cached responses. Defaults to DefaultCachingTTL.
+	TTL time.Duration
+	// MaxEntries is the maximum number of cached responses: the least recently used is evicted when it's reached.
+	// Zero means no limit.
+	MaxEntries int
+	// CacheNotFound enables caching the responses of secrets that don't exist, which are otherwise always requested again.
+	CacheNotFound bool
+}
+
+// cachingMetadata holds the common metadata keys that enable caching for any secret store.
+type cachingMetadata struct {
+	Enabled       bool          `mapstructure:"caching.enabled"`
+	TTL           time.Duration `mapstructure:"caching.ttl"`
+	MaxEntries    int           `mapstructure:"caching.maxEntries"`
+	CacheNotFound bool          `mapstructure:"caching.cacheNotFound"`
+}
+
+// CachingOptionsFromMetadata reads the caching options from the common metadata keys of a component:
+// caching.enabled, caching.ttl, caching.maxEntries and caching.cacheNotFound.
+// It returns false if caching isn't enabled.
+func CachingOptionsFromMetadata(properties map[string]string) (CachingOptions, bool, error) {
+	m := cachingMetadata{}
+	if err := metadata.DecodeMetadata(properties, &m); err != nil {
+		return CachingOptions{}, false, err
+	}
+	if !m.Enabled {
+		return CachingOptions{}, false, nil
+	}
+	if m.TTL < 0 {
+		return CachingOptions{}, false, errors.New("caching.ttl must not be negative")
+	}
+	if m.MaxEntries < 0 {
+		return CachingOptions{}, false, errors.New("caching.maxEntries must not be negative")
+	}
+
+	return CachingOptions{
+		TTL:           m.TTL,
+		MaxEntries:    m.MaxEntries,
+		CacheNotFound: m.CacheNotFound,
+	}, true, nil
+}
+
+// cachingStore is a SecretStore that caches the responses of the secret store it wraps.
+//
+// Responses are cached per request: GetSecret responses are indexed by secret name and request metadata,
+// while each BulkGetSecret response is cached as a whole, indexed by its request metadata. The two never
+// share entries, so a bulk read doesn't populate the entries of the secrets it returns.
+// Concurrent requests for the same uncached entry are sent only once to the wrapped store.
+type cachingStore struct {
+	inner SecretStore
+	opts  CachingOptions
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	// lru holds the entries from the most to the least recently used.
+	lru   *list.List
+	group singleflight.Group
+	now   func() time.Time
+}
+
+type cachingEntry struct {
+	key       string
+	get       GetSecretResponse
+	bulk      BulkGetSecretResponse
+	err       error
+	expiresAt time.Time
+}
+
+// NewCachingStore returns a SecretStore that caches the responses of inner.
+// It advertises the same features and metadata as inner.
+func NewCachingStore(inner SecretStore, opts CachingOptions) SecretStore {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCachingTTL
+	}
+
+	return &cachingStore{
+		inner:   inner,
+		opts:    opts,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// Init initializes the wrapped store.
+func (c *cachingStore) Init(ctx context.Context, metadata Metadata) error {
+	if err := registerCachingViews(); err != nil {
+		return err
+	}
+
+	return c.inner.Init(ctx, metadata)
+}
+
+// GetSecret returns the cached response for the request, or reads it from the wrapped store.
+func (c *cachingStore) GetSecret(ctx context.Context, req GetSecretRequest) (GetSecretResponse, error) {
+	key := cachingKey(cachingOperationGet, req.Name, req.Metadata)
+	entry, err := c.getOrLoad(ctx, cachingOperationGet, key, func(ctx context.Context) (*cachingEntry, error) {
+		resp, err := c.inner.GetSecret(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &cachingEntry{get: resp}, nil
+	})
+	if err != nil {
+		return GetSecretResponse{Data: nil}, err
+	}
+
+	return GetSecretResponse{
+		Data:     maps.Clone(entry.get.Data),
+		Metadata: maps.Clone(entry.get.Metadata),
+	}, nil
+}
+
+// BulkGetSecret returns the cached response for the request, or reads it from the wrapped store.
+func (c *cachingStore) BulkGetSecret(ctx context.Context, req BulkGetSecretRequest) (BulkGetSecretResponse, error) {
+	key := cachingKey(cachingOperationBulkGet, "", req.Metadata)
+	entry, err := c.getOrLoad(ctx, cachingOperationBulkGet, key, func(ctx context.Context) (*cachingEntry, error) {
+		resp, err := c.inner.BulkGetSecret(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &cachingEntry{bulk: resp}, nil
+	})
+	if err != nil {
+		return BulkGetSecretResponse{Data: nil}, err
+	}
+
+	resp := BulkGetSecretResponse{
+		Metadata: maps.Clone(entry.bulk.Metadata),
+	}
+	if entry.bulk.Data != nil {
+		resp.Data = make(map[string]map[string]string, len(entry.bulk.Data))
+		for name, data := range entry.bulk.Data {
+			resp.Data[name] = maps.Clone(data)
+		}
+	}
+
+	return resp, nil
+}
+
+// Features returns the features of the wrapped store.
+func (c *cachingStore) Features() []Feature {
+	return c.inner.Features()
+}
+
+// GetComponentMetadata returns the metadata of the wrapped store.
+func (c *cachingStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	// The method is part of SecretStore only when the metadata build tag is set
+	if inner, ok := c.inner.(interface{ GetComponentMetadata() metadata.MetadataMap }); ok {
+		return inner.GetComponentMetadata()
+	}
+
+	return nil
+}
+
+// Ping pings the wrapped store, if it supports it.
+func (c *cachingStore) Ping(ctx context.Context) error {
+	return Ping(ctx, c.inner)
+}
+
+// Close closes the wrapped store, if it supports it, and empties the cache.
+func (c *cachingStore) Close() error {
+	c.lock.Lock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.lock.Unlock()
+
+	if closer, ok := c.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// getOrLoad returns the cached entry for key, loading it with load when it's missing or expired.
+// Errors are cached only when they are not-found errors and CacheNotFound is enabled.
+// Concurrent callers share a single load, which runs with a context detached from theirs: a caller whose context is
+// done stops waiting for it without failing the others.
+func (c *cachingStore) getOrLoad(ctx context.Context, operation, key string, load func(ctx context.Context) (*cachingEntry, error)) (*cachingEntry, error) {
+	if entry, ok := c.get(key); ok {
+		recordCachingCount(ctx, cachingHits, operation)
+		return entry, entry.err
+	}
+	recordCachingCount(ctx, cachingMisses, operation)
+
+	resCh := c.group.DoChan(key, func() (any, error) {
+		loadCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, cachingLoadTimeout)
+		defer cancel()
+
+		entry, err := load(loadCtx)
+		if err != nil {
+			if c.opts.CacheNotFound && errors.Is(err, ErrSecretNotFound) {
+				c.set(loadCtx, operation, key, &cachingEntry{err: err})
+			}
+			return nil, err
+		}
+		c.set(loadCtx, operation, key, entry)
+		return entry, nil
+	})
+
+	select {
+	case res := <-resCh:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*cachingEntry), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// get returns the entry for key, if present and not expired, and marks it as the most recently used.
+func (c *cachingStore) get(key string) (*cachingEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cachingEntry)
+	if c.now().After(entry.expiresAt) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+
+	return entry, true
+}
+
+// set stores the entry for key, evicting the least recently used entry if the cache is full.
+func (c *cachingStore) set(ctx context.Context, operation, key string, entry *cachingEntry) {
+	entry.key = key
+	entry.expiresAt = c.now().Add(c.opts.TTL)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+
+	if c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachingEntry).key)
+		recordCachingCount(ctx, cachingEvictions, operation)
+	}
+}
+
+// cachingKey returns the cache key of a request, which includes its metadata in a stable order.
+func cachingKey(operation, name string, md map[string]string) string {
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(operation)
+	b.WriteByte(0)
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(md[k])
+	}
+
+	return b.String()
+}
+
+// detachedContext keeps the values of its parent but is never canceled, like context.WithoutCancel in Go 1.21.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
+
+func registerCachingViews() error {
+	registerCachingViewsOnce.Do(func() {
+		views := make([]*view.View, 0, 3)
+		for _, m := range []*stats.Int64Measure{cachingHits, cachingMisses, cachingEvictions} {
+			views = append(views, &view.View{
+				Name:        m.Name(),
+				Description: m.Description(),
+				Measure:     m,
+				TagKeys:     []tag.Key{cachingOperationKey},
+				Aggregation: view.Count(),
+			})
+		}
+		errRegisterCachingViews = view.Register(views...)
+	})
+
+	return errRegisterCachingViews
+}
+
+func recordCachingCount(ctx context.Context, m *stats.Int64Measure, operation string) {
+	stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(cachingOperationKey, operation)}, m.M(1))
+}
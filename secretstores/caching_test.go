@@ -0,0 +1,350 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// mockStore returns the name and metadata of the requested secret as its value and counts the calls.
+type mockStore struct {
+	getCalls  atomic.Int64
+	bulkCalls atomic.Int64
+	// block, if set, is waited for before responding to GetSecret.
+	block chan struct{}
+	// getErr holds the error of the context of the last GetSecret call, once it has responded.
+	getErr   atomic.Value
+	features []Feature
+	closed   bool
+}
+
+func (m *mockStore) Init(context.Context, Metadata) error {
+	return nil
+}
+
+func (m *mockStore) GetSecret(ctx context.Context, req GetSecretRequest) (GetSecretResponse, error) {
+	m.getCalls.Add(1)
+	if m.block != nil {
+		<-m.block
+	}
+	m.getErr.Store(fmt.Sprint(ctx.Err()))
+	switch req.Name {
+	case "missing":
+		return GetSecretResponse{}, fmt.Errorf("mock: %w", ErrSecretNotFound)
+	case "broken":
+		return GetSecretResponse{}, errors.New("mock: broken")
+	}
+
+	return GetSecretResponse{
+		Data:     map[string]string{req.Name: fmt.Sprint(req.Metadata)},
+		Metadata: map[string]string{"calls": fmt.Sprint(m.getCalls.Load())},
+	}, nil
+}
+
+func (m *mockStore) BulkGetSecret(_ context.Context, req BulkGetSecretRequest) (BulkGetSecretResponse, error) {
+	m.bulkCalls.Add(1)
+
+	return BulkGetSecretResponse{Data: map[string]map[string]string{
+		"first":  {"first": fmt.Sprint(req.Metadata)},
+		"second": {"second": fmt.Sprint(req.Metadata)},
+	}}, nil
+}
+
+func (m *mockStore) Features() []Feature {
+	return m.features
+}
+
+func (m *mockStore) GetComponentMetadata() metadata.MetadataMap {
+	return metadata.MetadataMap{}
+}
+
+func (m *mockStore) Close() error {
+	m.closed = true
+	return nil
+}
+
+// newTestCachingStore returns an initialized caching store wrapping inner, with a clock controlled by the test.
+func newTestCachingStore(t *testing.T, inner SecretStore, opts CachingOptions) (*cachingStore, *time.Time) {
+	t.Helper()
+
+	store := NewCachingStore(inner, opts).(*cachingStore)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	require.NoError(t, store.Init(context.Background(), Metadata{}))
+
+	return store, &now
+}
+
+func TestCachingStoreGetSecret(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("responses are cached per name and metadata", func(t *testing.T) {
+		inner := &mockStore{}
+		store, _ := newTestCachingStore(t, inner, CachingOptions{})
+
+		for i := 0; i < 3; i++ {
+			resp, err := store.GetSecret(ctx, GetSecretRequest{Name: "secret"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"secret": "map[]"}, resp.Data)
+			assert.Equal(t, map[string]string{"calls": "1"}, resp.Metadata)
+		}
+		assert.Equal(t, int64(1), inner.getCalls.Load())
+
+		resp, err := store.GetSecret(ctx, GetSecretRequest{Name: "secret", Metadata: map[string]string{"version_id": "2"}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"secret": "map[version_id:2]"}, resp.Data)
+		_, err = store.GetSecret(ctx, GetSecretRequest{Name: "other"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), inner.getCalls.Load())
+	})
+
+	t.Run("callers can't modify the cached responses", func(t *testing.T) {
+		store, _ := newTestCachingStore(t, &mockStore{}, CachingOptions{})
+
+		resp, err := store.GetSecret(ctx, GetSecretRequest{Name: "secret"})
+		require.NoError(t, err)
+		resp.Data["secret"] = "changed"
+
+		resp, err = store.GetSecret(ctx, GetSecretRequest{Name: "secret"})
+		require.NoError(t, err)
+		assert.Equal(t, "map[]", resp.Data["secret"])
+	})
+
+	t.Run("expired responses are read again", func(t *testing.T) {
+		inner := &mockStore{}
+		store, now := newTestCachingStore(t, inner, CachingOptions{TTL: time.Minute})
+
+		_, err := store.GetSecret(ctx, GetSecretRequest{Name: "secret"})
+		require.NoError(t, err)
+		*now = now.Add(59 * time.Second)
+		_, err = store.GetSecret(ctx, GetSecretRequest{Name: "secret"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), inner.getCalls.Load())
+
+		*now = now.Add(2 * time.Second)
+		resp, err := store.GetSecret(ctx, GetSecretRequest{Name: "secret"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"calls": "2"}, resp.Metadata)
+	})
+
+	t.Run("the least recently used response is evicted when the cache is full", func(t *testing.T) {
+		inner := &mockStore{}
+		store, _ := newTestCachingStore(t, inner, CachingOptions{MaxEntries: 2})
+
+		for _, name := range []string{"first", "second", "first", "third"} {
+			_, err := store.GetSecret(ctx, GetSecretRequest{Name: name})
+			require.NoError(t, err)
+		}
+		require.Equal(t, int64(3), inner.getCalls.Load())
+
+		// "second" was evicted when "third" was added
+		for _, name := range []string{"first", "third"} {
+			_, err := store.GetSecret(ctx, GetSecretRequest{Name: name})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int64(3), inner.getCalls.Load())
+		_, err := store.GetSecret(ctx, GetSecretRequest{Name: "second"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), inner.getCalls.Load())
+	})
+
+	t.Run("concurrent requests are sent once", func(t *testing.T) {
+		inner := &mockStore{block: make(chan struct{})}
+		store, _ := newTestCachingStore(t, inner, CachingOptions{})
+
+		const callers = 10
+		var wg sync.WaitGroup
+		wg.Add(callers)
+		for i := 0; i < callers; i++ {
+			go func() {
+				defer wg.Done()
+				resp, err := store.GetSecret(ctx, GetSecretRequest{Name: "secret"})
+				assert.NoError(t, err)
+				assert.Equal(t, map[string]string{"secret": "map[]"}, resp.Data)
+			}()
+		}
+
+		// Wait for the first request to reach the wrapped store before letting it respond
+		assert.Eventually(t, func() bool { return inner.getCalls.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(inner.block)
+		wg.Wait()
+
+		assert.Equal(t, int64(1), inner.getCalls.Load())
+	})
+
+	t.Run("canceled caller doesn't cancel the shared request", func(t *testing.T) {
+		inner := &mockStore{block: make(chan struct{})}
+		store, _ := newTestCachingStore(t, inner, CachingOptions{})
+
+		canceledCtx, cancel := context.WithCancel(ctx)
+		canceledErr := make(chan error)
+		go func() {
+			_, err := store.GetSecret(canceledCtx, GetSecretRequest{Name: "secret"})
+			canceledErr <- err
+		}()
+		assert.Eventually(t, func() bool { return inner.getCalls.Load() == 1 }, time.Second, time.Millisecond)
+
+		waitingResp := make(chan GetSecretResponse)
+		go func() {
+			resp, err := store.GetSecret(ctx, GetSecretRequest{Name: "secret"})
+			assert.NoError(t, err)
+			waitingResp <- resp
+		}()
+
+		cancel()
+		require.ErrorIs(t, <-canceledErr, context.Canceled)
+
+		close(inner.block)
+		assert.Equal(t, map[string]string{"secret": "map[]"}, (<-waitingResp).Data)
+		assert.Equal(t, int64(1), inner.getCalls.Load())
+		assert.Equal(t, "<nil>", inner.getErr.Load())
+	})
+
+	t.Run("not found responses are cached only when enabled", func(t *testing.T) {
+		for _, cacheNotFound := range []bool{false, true} {
+			inner := &mockStore{}
+			store, _ := newTestCachingStore(t, inner, CachingOptions{CacheNotFound: cacheNotFound})
+
+			for i := 0; i < 2; i++ {
+				_, err := store.GetSecret(ctx, GetSecretRequest{Name: "missing"})
+				require.ErrorIs(t, err, ErrSecretNotFound)
+			}
+
+			expectedCalls := int64(2)
+			if cacheNotFound {
+				expectedCalls = 1
+			}
+			assert.Equal(t, expectedCalls, inner.getCalls.Load(), "cacheNotFound=%v", cacheNotFound)
+		}
+	})
+
+	t.Run("other errors are never cached", func(t *testing.T) {
+		inner := &mockStore{}
+		store, _ := newTestCachingStore(t, inner, CachingOptions{CacheNotFound: true})
+
+		for i := 0; i < 2; i++ {
+			_, err := store.GetSecret(ctx, GetSecretRequest{Name: "broken"})
+			require.EqualError(t, err, "mock: broken")
+		}
+		assert.Equal(t, int64(2), inner.getCalls.Load())
+	})
+}
+
+func TestCachingStoreBulkGetSecret(t *testing.T) {
+	ctx := context.Background()
+	inner := &mockStore{}
+	store, _ := newTestCachingStore(t, inner, CachingOptions{})
+
+	for i := 0; i < 2; i++ {
+		resp, err := store.BulkGetSecret(ctx, BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"first":  {"first": "map[]"},
+			"second": {"second": "map[]"},
+		}, resp.Data)
+	}
+	assert.Equal(t, int64(1), inner.bulkCalls.Load())
+
+	_, err := store.BulkGetSecret(ctx, BulkGetSecretRequest{Metadata: map[string]string{"version_id": "2"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), inner.bulkCalls.Load())
+
+	// Bulk responses don't populate the entries of single secrets
+	_, err = store.GetSecret(ctx, GetSecretRequest{Name: "first"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), inner.getCalls.Load())
+}
+
+func TestCachingStorePassThrough(t *testing.T) {
+	inner := &mockStore{features: []Feature{FeatureMultipleKeyValuesPerSecret}}
+	store, _ := newTestCachingStore(t, inner, CachingOptions{})
+
+	assert.Equal(t, []Feature{FeatureMultipleKeyValuesPerSecret}, store.Features())
+	assert.Equal(t, metadata.MetadataMap{}, store.GetComponentMetadata())
+	require.NoError(t, store.Close())
+	assert.True(t, inner.closed)
+}
+
+func TestCachingStoreMetrics(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestCachingStore(t, &mockStore{}, CachingOptions{MaxEntries: 1})
+
+	count := func(viewName string) int64 {
+		rows, err := view.RetrieveData(viewName)
+		require.NoError(t, err)
+		for _, row := range rows {
+			for _, tag := range row.Tags {
+				if tag.Key == cachingOperationKey && tag.Value == cachingOperationGet {
+					return row.Data.(*view.CountData).Value
+				}
+			}
+		}
+		return 0
+	}
+	hits, misses, evictions := count(cachingHits.Name()), count(cachingMisses.Name()), count(cachingEvictions.Name())
+
+	for _, name := range []string{"first", "first", "second"} {
+		_, err := store.GetSecret(ctx, GetSecretRequest{Name: name})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, hits+1, count(cachingHits.Name()))
+	assert.Equal(t, misses+2, count(cachingMisses.Name()))
+	assert.Equal(t, evictions+1, count(cachingEvictions.Name()))
+}
+
+func TestCachingOptionsFromMetadata(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		_, enabled, err := CachingOptionsFromMetadata(map[string]string{"caching.ttl": "1m"})
+		require.NoError(t, err)
+		assert.False(t, enabled)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		opts, enabled, err := CachingOptionsFromMetadata(map[string]string{
+			"caching.enabled":       "true",
+			"caching.ttl":           "30s",
+			"caching.maxEntries":    "100",
+			"caching.cacheNotFound": "yes",
+			"otherKey":              "ignored",
+		})
+		require.NoError(t, err)
+		assert.True(t, enabled)
+		assert.Equal(t, CachingOptions{TTL: 30 * time.Second, MaxEntries: 100, CacheNotFound: true}, opts)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		for _, properties := range []map[string]string{
+			{"caching.enabled": "true", "caching.ttl": "soon"},
+			{"caching.enabled": "true", "caching.ttl": "-1s"},
+			{"caching.enabled": "true", "caching.maxEntries": "-1"},
+		} {
+			_, _, err := CachingOptionsFromMetadata(properties)
+			assert.Error(t, err, properties)
+		}
+	})
+}
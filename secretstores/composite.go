@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// CompositeDelegate is one of the secret stores read by a composite store.
+type CompositeDelegate struct {
+	// Name identifies the delegate in errors, and prefixes the metadata keys of its configuration.
+	Name  string
+	Store SecretStore
+}
+
+// compositeStore is a SecretStore that reads secrets from an ordered list of secret stores, falling back to the
+// next store when a secret isn't found in the previous ones.
+//
+// Each delegate is configured by the metadata keys of the composite store prefixed with its name and a dot:
+// for example, "vault.vaultAddr" is passed as "vaultAddr" to the delegate named "vault".
+type compositeStore struct {
+	delegates []CompositeDelegate
+}
+
+// NewCompositeStore returns a SecretStore that reads secrets from the delegates, in order.
+//
+// GetSecret returns the secret from the first delegate that has it, and reports it as not found only when every
+// delegate misses it. Any other error stops the search and is returned, so a failing delegate is never masked by
+// the following ones. BulkGetSecret merges the secrets of all the delegates: when several of them have a secret
+// with the same name, the first one wins. Delegates that don't support bulk reads are skipped.
+func NewCompositeStore(delegates ...CompositeDelegate) SecretStore {
+	return &compositeStore{
+		delegates: delegates,
+	}
+}
+
+// Init initializes the delegates, in order, with their own metadata.
+// If a delegate fails, the ones already initialized are closed.
+func (c *compositeStore) Init(ctx context.Context, meta Metadata) error {
+	if len(c.delegates) == 0 {
+		return errors.New("composite secret store requires at least one secret store")
+	}
+
+	for i, d := range c.delegates {
+		if err := d.Store.Init(ctx, delegateMetadata(meta, d.Name)); err != nil {
+			err = fmt.Errorf("failed to init secret store %s: %w", d.Name, err)
+			return errors.Join(err, closeDelegates(c.delegates[:i]))
+		}
+	}
+
+	return nil
+}
+
+// GetSecret returns the secret from the first delegate that has it.
+func (c *compositeStore) GetSecret(ctx context.Context, req GetSecretRequest) (GetSecretResponse, error) {
+	for _, d := range c.delegates {
+		resp, err := d.Store.GetSecret(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if !errors.Is(err, ErrSecretNotFound) {
+			return GetSecretResponse{Data: nil}, fmt.Errorf("secret store %s: %w", d.Name, err)
+		}
+	}
+
+	return GetSecretResponse{Data: nil}, fmt.Errorf("secret %s isn't in any secret store: %w", req.Name, ErrSecretNotFound)
+}
+
+// BulkGetSecret merges the secrets of all the delegates, keeping the first one for each name.
+// Delegates that don't support bulk reads are skipped, unless none of them does.
+func (c *compositeStore) BulkGetSecret(ctx context.Context, req BulkGetSecretRequest) (BulkGetSecretResponse, error) {
+	data := map[string]map[string]string{}
+	supported := false
+	for _, d := range c.delegates {
+		resp, err := d.Store.BulkGetSecret(ctx, req)
+		if errors.Is(err, ErrBulkGetSecretNotSupported) {
+			continue
+		}
+		if err != nil {
+			return BulkGetSecretResponse{Data: nil}, fmt.Errorf("secret store %s: %w", d.Name, err)
+		}
+		for name, secret := range resp.Data {
+			if _, ok := data[name]; !ok {
+				data[name] = secret
+			}
+		}
+		supported = true
+	}
+	if !supported {
+		return BulkGetSecretResponse{Data: nil}, ErrBulkGetSecretNotSupported
+	}
+
+	return BulkGetSecretResponse{Data: data}, nil
+}
+
+// Features returns the features supported by every delegate, except pagination as the merged bulk reads aren't paginated.
+func (c *compositeStore) Features() []Feature {
+	if len(c.delegates) == 0 {
+		return nil
+	}
+
+	res := []Feature{}
+	for _, f := range c.delegates[0].Store.Features() {
+		supported := f != FeatureBulkGetSecretPagination
+		for _, d := range c.delegates[1:] {
+			if !f.IsPresent(d.Store.Features()) {
+				supported = false
+				break
+			}
+		}
+		if supported {
+			res = append(res, f)
+		}
+	}
+
+	return res
+}
+
+// GetComponentMetadata returns the metadata of the delegates, prefixed with their names.
+func (c *compositeStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	metadataInfo = metadata.MetadataMap{}
+	for _, d := range c.delegates {
+		// The method is part of SecretStore only when the metadata build tag is set
+		inner, ok := d.Store.(interface{ GetComponentMetadata() metadata.MetadataMap })
+		if !ok {
+			continue
+		}
+		for key, field := range inner.GetComponentMetadata() {
+			metadataInfo[d.Name+"."+key] = field
+		}
+	}
+
+	return metadataInfo
+}
+
+// Ping pings the delegates that support it.
+func (c *compositeStore) Ping(ctx context.Context) error {
+	for _, d := range c.delegates {
+		if err := Ping(ctx, d.Store); err != nil {
+			return fmt.Errorf("secret store %s: %w", d.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the delegates that support it.
+func (c *compositeStore) Close() error {
+	return closeDelegates(c.delegates)
+}
+
+// closeDelegates closes the delegates that support it, returning all their errors.
+func closeDelegates(delegates []CompositeDelegate) error {
+	var errs []error
+	for _, d := range delegates {
+		if closer, ok := d.Store.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("secret store %s: %w", d.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// delegateMetadata returns the metadata of the delegate with the given name: the properties prefixed with the
+// name and a dot, without the prefix.
+func delegateMetadata(meta Metadata, name string) Metadata {
+	prefix := strings.ToLower(name) + "."
+	properties := make(map[string]string)
+	for k, v := range meta.Properties {
+		if strings.HasPrefix(strings.ToLower(k), prefix) {
+			properties[k[len(prefix):]] = v
+		}
+	}
+
+	res := meta
+	res.Properties = properties
+
+	return res
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// mapStore serves the secrets of a map, or fails every request with err if set.
+type mapStore struct {
+	secrets    map[string]map[string]string
+	err        error
+	initErr    error
+	features   []Feature
+	properties map[string]string
+	closed     bool
+}
+
+func (m *mapStore) Init(_ context.Context, meta Metadata) error {
+	m.properties = meta.Properties
+	return m.initErr
+}
+
+func (m *mapStore) GetSecret(_ context.Context, req GetSecretRequest) (GetSecretResponse, error) {
+	if m.err != nil {
+		return GetSecretResponse{}, m.err
+	}
+	secret, ok := m.secrets[req.Name]
+	if !ok {
+		return GetSecretResponse{}, fmt.Errorf("secret %s: %w", req.Name, ErrSecretNotFound)
+	}
+
+	return GetSecretResponse{Data: secret}, nil
+}
+
+func (m *mapStore) BulkGetSecret(context.Context, BulkGetSecretRequest) (BulkGetSecretResponse, error) {
+	if m.err != nil {
+		return BulkGetSecretResponse{}, m.err
+	}
+
+	return BulkGetSecretResponse{Data: m.secrets}, nil
+}
+
+func (m *mapStore) Features() []Feature {
+	return m.features
+}
+
+func (m *mapStore) GetComponentMetadata() metadata.MetadataMap {
+	return metadata.MetadataMap{"address": {}}
+}
+
+func (m *mapStore) Close() error {
+	m.closed = true
+	return nil
+}
+
+func newTestCompositeStore(t *testing.T, primary, secondary *mapStore) SecretStore {
+	t.Helper()
+
+	store := NewCompositeStore(
+		CompositeDelegate{Name: "primary", Store: primary},
+		CompositeDelegate{Name: "secondary", Store: secondary},
+	)
+	require.NoError(t, store.Init(context.Background(), Metadata{}))
+
+	return store
+}
+
+func TestCompositeStoreGetSecret(t *testing.T) {
+	ctx := context.Background()
+	primary := &mapStore{secrets: map[string]map[string]string{
+		"shared":      {"owner": "primary"},
+		"primaryonly": {"owner": "primary"},
+	}}
+	secondary := &mapStore{secrets: map[string]map[string]string{
+		"shared":        {"owner": "secondary"},
+		"secondaryonly": {"owner": "secondary"},
+	}}
+
+	t.Run("the first store with the secret wins", func(t *testing.T) {
+		store := newTestCompositeStore(t, primary, secondary)
+
+		for name, owner := range map[string]string{"shared": "primary", "primaryonly": "primary", "secondaryonly": "secondary"} {
+			resp, err := store.GetSecret(ctx, GetSecretRequest{Name: name})
+			require.NoError(t, err, name)
+			assert.Equal(t, map[string]string{"owner": owner}, resp.Data, name)
+		}
+	})
+
+	t.Run("not found only when every store misses", func(t *testing.T) {
+		store := newTestCompositeStore(t, primary, secondary)
+
+		_, err := store.GetSecret(ctx, GetSecretRequest{Name: "missing"})
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+	})
+
+	t.Run("errors of a store aren't masked by the following ones", func(t *testing.T) {
+		broken := &mapStore{err: errors.New("connection refused")}
+		store := newTestCompositeStore(t, broken, secondary)
+
+		for _, name := range []string{"secondaryonly", "missing"} {
+			_, err := store.GetSecret(ctx, GetSecretRequest{Name: name})
+			require.EqualError(t, err, "secret store primary: connection refused", name)
+			assert.NotErrorIs(t, err, ErrSecretNotFound, name)
+		}
+	})
+}
+
+func TestCompositeStoreBulkGetSecret(t *testing.T) {
+	ctx := context.Background()
+	primary := &mapStore{secrets: map[string]map[string]string{
+		"shared":      {"owner": "primary"},
+		"primaryonly": {"owner": "primary"},
+	}}
+	secondary := &mapStore{secrets: map[string]map[string]string{
+		"shared":        {"owner": "secondary"},
+		"secondaryonly": {"owner": "secondary"},
+	}}
+
+	t.Run("secrets are merged and the first store wins on conflicts", func(t *testing.T) {
+		store := newTestCompositeStore(t, primary, secondary)
+
+		resp, err := store.BulkGetSecret(ctx, BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"shared":        {"owner": "primary"},
+			"primaryonly":   {"owner": "primary"},
+			"secondaryonly": {"owner": "secondary"},
+		}, resp.Data)
+	})
+
+	t.Run("errors of any store are returned", func(t *testing.T) {
+		store := newTestCompositeStore(t, primary, &mapStore{err: errors.New("connection refused")})
+
+		_, err := store.BulkGetSecret(ctx, BulkGetSecretRequest{})
+		assert.EqualError(t, err, "secret store secondary: connection refused")
+	})
+
+	t.Run("stores without bulk reads are skipped", func(t *testing.T) {
+		store := newTestCompositeStore(t, &mapStore{err: fmt.Errorf("primary: %w", ErrBulkGetSecretNotSupported)}, secondary)
+
+		resp, err := store.BulkGetSecret(ctx, BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, secondary.secrets, resp.Data)
+	})
+
+	t.Run("not supported when no store has bulk reads", func(t *testing.T) {
+		unsupported := &mapStore{err: ErrBulkGetSecretNotSupported}
+		store := newTestCompositeStore(t, unsupported, unsupported)
+
+		_, err := store.BulkGetSecret(ctx, BulkGetSecretRequest{})
+		assert.ErrorIs(t, err, ErrBulkGetSecretNotSupported)
+	})
+}
+
+func TestCompositeStoreInit(t *testing.T) {
+	t.Run("delegates receive their own metadata", func(t *testing.T) {
+		primary, secondary := &mapStore{}, &mapStore{}
+		store := NewCompositeStore(
+			CompositeDelegate{Name: "vault", Store: primary},
+			CompositeDelegate{Name: "file", Store: secondary},
+		)
+
+		err := store.Init(context.Background(), Metadata{Base: metadata.Base{Properties: map[string]string{
+			"vault.vaultAddr":  "http://127.0.0.1:8200",
+			"Vault.vaultToken": "token",
+			"file.secretsFile": "secrets.json",
+			"unprefixed":       "ignored",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"vaultAddr": "http://127.0.0.1:8200", "vaultToken": "token"}, primary.properties)
+		assert.Equal(t, map[string]string{"secretsFile": "secrets.json"}, secondary.properties)
+	})
+
+	t.Run("initialized delegates are closed when a later one fails", func(t *testing.T) {
+		primary, secondary, third := &mapStore{}, &mapStore{initErr: errors.New("connection refused")}, &mapStore{}
+		store := NewCompositeStore(
+			CompositeDelegate{Name: "primary", Store: primary},
+			CompositeDelegate{Name: "secondary", Store: secondary},
+			CompositeDelegate{Name: "third", Store: third},
+		)
+
+		err := store.Init(context.Background(), Metadata{})
+		require.EqualError(t, err, "failed to init secret store secondary: connection refused")
+		assert.True(t, primary.closed)
+		assert.False(t, secondary.closed)
+		assert.False(t, third.closed)
+	})
+
+	t.Run("at least one delegate is required", func(t *testing.T) {
+		err := NewCompositeStore().Init(context.Background(), Metadata{})
+		assert.Error(t, err)
+	})
+}
+
+func TestCompositeStorePassThrough(t *testing.T) {
+	primary := &mapStore{features: []Feature{FeatureBulkGetSecret, FeatureMultipleKeyValuesPerSecret}}
+	secondary := &mapStore{features: []Feature{FeatureBulkGetSecret}}
+	store := newTestCompositeStore(t, primary, secondary).(*compositeStore)
+
+	// Only the features supported by every store are advertised
+	assert.Equal(t, []Feature{FeatureBulkGetSecret}, store.Features())
+	assert.Equal(t, metadata.MetadataMap{"primary.address": {}, "secondary.address": {}}, store.GetComponentMetadata())
+	require.NoError(t, store.Close())
+	assert.True(t, primary.closed)
+	assert.True(t, secondary.closed)
+}
@@ -23,6 +23,11 @@ type Feature string
 const (
 	// FeatureMultipleKeyValuesPerSecret advertises that this SecretStore supports multiple keys-values under a single secret.
 	FeatureMultipleKeyValuesPerSecret Feature = "MULTIPLE_KEY_VALUES_PER_SECRET"
+	// FeatureBulkGetSecret advertises that this SecretStore supports listing and retrieving all its secrets with BulkGetSecret.
+	FeatureBulkGetSecret Feature = "BULK_GET_SECRET"
+	// FeatureBulkGetSecretPagination advertises that this SecretStore returns the secrets of BulkGetSecret in pages,
+	// as requested with the BulkGetSecretLimitKey and BulkGetSecretContinuationTokenKey metadata keys.
+	FeatureBulkGetSecretPagination Feature = "BULK_GET_SECRET_PAGINATION"
 )
 
 // IsPresent checks if a given feature is present in the list.
@@ -0,0 +1,205 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstores
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// Common metadata keys that restrict the secrets that can be read from any secret store.
+const (
+	FilterAllowedSecretsKey = "allowedSecrets"
+	FilterDeniedSecretsKey  = "deniedSecrets"
+)
+
+// filteringMetadata holds the common metadata keys that restrict the secrets that can be read.
+type filteringMetadata struct {
+	AllowedSecrets []string `mapstructure:"allowedSecrets"`
+	DeniedSecrets  []string `mapstructure:"deniedSecrets"`
+}
+
+// filteringStore is a SecretStore that only exposes the secrets of the store it wraps whose names are allowed.
+//
+// The allowed and denied secrets are comma-separated lists of glob patterns, as accepted by path.Match, read
+// from the allowedSecrets and deniedSecrets metadata keys on Init. A secret is allowed when it matches no
+// denied pattern and, if there are allowed patterns, at least one of them: denied patterns take precedence.
+// Without any pattern, every secret is allowed.
+type filteringStore struct {
+	inner   SecretStore
+	allowed []string
+	denied  []string
+}
+
+// FilterableStore is implemented by the secret stores whose requests can read another secret than the one they
+// name, for example with request metadata selecting another path or namespace, or with a case-insensitive lookup.
+// NewFilteringStore then matches the secret actually read against the allowed and denied secrets.
+type FilterableStore interface {
+	// ValidateFilteredRequest returns an error if the metadata of a request selects secrets that can't be filtered by
+	// name, such as secrets read by path or from another namespace.
+	ValidateFilteredRequest(metadata map[string]string) error
+	// ResolveSecretName returns the name of the secret that a GetSecret request reads.
+	ResolveSecretName(ctx context.Context, req GetSecretRequest) (string, error)
+}
+
+// NewFilteringStore returns a SecretStore that restricts the secrets of inner that can be read.
+// Secrets that aren't allowed are reported as not found by GetSecret, and are left out of the BulkGetSecret responses.
+// If inner implements FilterableStore, the requests that can't be filtered by name are rejected, and GetSecret
+// requests must be allowed under both their name and the name of the secret they read.
+// It advertises the same features and metadata as inner.
+func NewFilteringStore(inner SecretStore) SecretStore {
+	return &filteringStore{
+		inner: inner,
+	}
+}
+
+// Init reads the allowed and denied secrets, and initializes the wrapped store.
+func (f *filteringStore) Init(ctx context.Context, meta Metadata) error {
+	m := filteringMetadata{}
+	if err := metadata.DecodeMetadata(meta.Properties, &m); err != nil {
+		return err
+	}
+
+	var err error
+	f.allowed, err = parseSecretPatterns(FilterAllowedSecretsKey, m.AllowedSecrets)
+	if err != nil {
+		return err
+	}
+	f.denied, err = parseSecretPatterns(FilterDeniedSecretsKey, m.DeniedSecrets)
+	if err != nil {
+		return err
+	}
+
+	return f.inner.Init(ctx, meta)
+}
+
+// GetSecret returns the secret from the wrapped store if it's allowed, or a not-found error.
+func (f *filteringStore) GetSecret(ctx context.Context, req GetSecretRequest) (GetSecretResponse, error) {
+	if !f.isAllowed(req.Name) {
+		return GetSecretResponse{Data: nil}, fmt.Errorf("secret %s: %w", req.Name, ErrSecretNotFound)
+	}
+
+	if resolver, ok := f.inner.(FilterableStore); ok && f.isFiltering() {
+		if err := resolver.ValidateFilteredRequest(req.Metadata); err != nil {
+			return GetSecretResponse{Data: nil}, fmt.Errorf("secret %s can't be filtered: %w", req.Name, err)
+		}
+		name, err := resolver.ResolveSecretName(ctx, req)
+		if err != nil {
+			return GetSecretResponse{Data: nil}, fmt.Errorf("secret %s can't be filtered: %w", req.Name, err)
+		}
+		if !f.isAllowed(name) {
+			return GetSecretResponse{Data: nil}, fmt.Errorf("secret %s: %w", req.Name, ErrSecretNotFound)
+		}
+	}
+
+	return f.inner.GetSecret(ctx, req)
+}
+
+// BulkGetSecret returns the allowed secrets of the wrapped store.
+func (f *filteringStore) BulkGetSecret(ctx context.Context, req BulkGetSecretRequest) (BulkGetSecretResponse, error) {
+	if resolver, ok := f.inner.(FilterableStore); ok && f.isFiltering() {
+		if err := resolver.ValidateFilteredRequest(req.Metadata); err != nil {
+			return BulkGetSecretResponse{Data: nil}, fmt.Errorf("secrets can't be filtered: %w", err)
+		}
+	}
+
+	resp, err := f.inner.BulkGetSecret(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	for name := range resp.Data {
+		if !f.isAllowed(name) {
+			delete(resp.Data, name)
+		}
+	}
+
+	return resp, nil
+}
+
+// Features returns the features of the wrapped store.
+func (f *filteringStore) Features() []Feature {
+	return f.inner.Features()
+}
+
+// GetComponentMetadata returns the metadata of the wrapped store.
+func (f *filteringStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
+	// The method is part of SecretStore only when the metadata build tag is set
+	if inner, ok := f.inner.(interface{ GetComponentMetadata() metadata.MetadataMap }); ok {
+		return inner.GetComponentMetadata()
+	}
+
+	return nil
+}
+
+// Ping pings the wrapped store, if it supports it.
+func (f *filteringStore) Ping(ctx context.Context) error {
+	return Ping(ctx, f.inner)
+}
+
+// Close closes the wrapped store, if it supports it.
+func (f *filteringStore) Close() error {
+	if closer, ok := f.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// isFiltering returns true if there are allowed or denied patterns, which restrict the secrets that can be read.
+func (f *filteringStore) isFiltering() bool {
+	return len(f.allowed) > 0 || len(f.denied) > 0
+}
+
+// isAllowed returns true if the secret matches no denied pattern and, if there are any, one of the allowed patterns.
+func (f *filteringStore) isAllowed(name string) bool {
+	if matchesAnyPattern(f.denied, name) {
+		return false
+	}
+
+	return len(f.allowed) == 0 || matchesAnyPattern(f.allowed, name)
+}
+
+// parseSecretPatterns returns the non-empty patterns of the list, after checking they are valid.
+func parseSecretPatterns(key string, patterns []string) ([]string, error) {
+	res := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %w", key, pattern, err)
+		}
+		res = append(res, pattern)
+	}
+
+	return res, nil
+}
+
+func matchesAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		// Patterns were validated on Init
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstores
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// newTestFilteringStore returns a filtering store wrapping inner, initialized with the given properties.
+func newTestFilteringStore(t *testing.T, inner SecretStore, properties map[string]string) SecretStore {
+	t.Helper()
+
+	store := NewFilteringStore(inner)
+	require.NoError(t, store.Init(context.Background(), Metadata{Base: metadata.Base{Properties: properties}}))
+
+	return store
+}
+
+func TestFilteringStoreGetSecret(t *testing.T) {
+	tests := map[string]struct {
+		properties map[string]string
+		allowed    []string
+		denied     []string
+	}{
+		"no lists allow every secret": {
+			properties: map[string]string{},
+			allowed:    []string{"first", "second", "team/db"},
+		},
+		"allowlist only": {
+			properties: map[string]string{FilterAllowedSecretsKey: "first, team/*"},
+			allowed:    []string{"first", "team/db"},
+			denied:     []string{"second", "team/db/nested"},
+		},
+		"denylist only": {
+			properties: map[string]string{FilterDeniedSecretsKey: "sec*"},
+			allowed:    []string{"first", "team/db"},
+			denied:     []string{"second"},
+		},
+		"denylist takes precedence over allowlist": {
+			properties: map[string]string{
+				FilterAllowedSecretsKey: "*,team/*",
+				FilterDeniedSecretsKey:  "second,team/admin*",
+			},
+			allowed: []string{"first", "team/db"},
+			denied:  []string{"second", "team/admin-db"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			inner := &mockStore{}
+			store := newTestFilteringStore(t, inner, tt.properties)
+
+			for _, secret := range tt.allowed {
+				resp, err := store.GetSecret(context.Background(), GetSecretRequest{Name: secret})
+				require.NoError(t, err, secret)
+				assert.Contains(t, resp.Data, secret)
+			}
+			for _, secret := range tt.denied {
+				_, err := store.GetSecret(context.Background(), GetSecretRequest{Name: secret})
+				require.ErrorIs(t, err, ErrSecretNotFound, secret)
+			}
+			// Denied secrets are never requested from the wrapped store
+			assert.Equal(t, int64(len(tt.allowed)), inner.getCalls.Load())
+		})
+	}
+}
+
+func TestFilteringStoreBulkGetSecret(t *testing.T) {
+	store := newTestFilteringStore(t, &mockStore{}, map[string]string{
+		FilterAllowedSecretsKey: "first,second",
+		FilterDeniedSecretsKey:  "second",
+	})
+
+	resp, err := store.BulkGetSecret(context.Background(), BulkGetSecretRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"first": {"first": "map[]"},
+	}, resp.Data)
+}
+
+// resolvingStore is a mockStore that resolves the names of the secrets ignoring their case, and rejects the
+// requests reading from another scope, as the FilterableStore implementations do.
+type resolvingStore struct {
+	mockStore
+}
+
+func (r *resolvingStore) ValidateFilteredRequest(metadata map[string]string) error {
+	if metadata["scope"] != "" {
+		return errors.New("mock: the scope can't be filtered")
+	}
+
+	return nil
+}
+
+func (r *resolvingStore) ResolveSecretName(_ context.Context, req GetSecretRequest) (string, error) {
+	return strings.ToLower(req.Name), nil
+}
+
+func TestFilteringStoreResolvesNames(t *testing.T) {
+	inner := &resolvingStore{}
+	store := newTestFilteringStore(t, inner, map[string]string{FilterDeniedSecretsKey: "second"})
+
+	t.Run("the resolved name is filtered", func(t *testing.T) {
+		_, err := store.GetSecret(context.Background(), GetSecretRequest{Name: "SECOND"})
+		require.ErrorIs(t, err, ErrSecretNotFound)
+
+		_, err = store.GetSecret(context.Background(), GetSecretRequest{Name: "First"})
+		require.NoError(t, err)
+	})
+
+	t.Run("requests that can't be filtered are rejected", func(t *testing.T) {
+		calls := inner.getCalls.Load()
+		_, err := store.GetSecret(context.Background(), GetSecretRequest{Name: "first", Metadata: map[string]string{"scope": "other"}})
+		require.ErrorContains(t, err, "secret first can't be filtered: mock: the scope can't be filtered")
+		_, err = store.BulkGetSecret(context.Background(), BulkGetSecretRequest{Metadata: map[string]string{"scope": "other"}})
+		require.ErrorContains(t, err, "secrets can't be filtered")
+		assert.Equal(t, calls, inner.getCalls.Load())
+	})
+
+	t.Run("requests aren't checked without patterns", func(t *testing.T) {
+		store := newTestFilteringStore(t, &resolvingStore{}, map[string]string{})
+
+		_, err := store.GetSecret(context.Background(), GetSecretRequest{Name: "SECOND", Metadata: map[string]string{"scope": "other"}})
+		require.NoError(t, err)
+	})
+}
+
+func TestFilteringStoreInvalidPatterns(t *testing.T) {
+	for _, key := range []string{FilterAllowedSecretsKey, FilterDeniedSecretsKey} {
+		store := NewFilteringStore(&mockStore{})
+		err := store.Init(context.Background(), Metadata{Base: metadata.Base{Properties: map[string]string{
+			key: "first,[unclosed",
+		}}})
+		assert.ErrorContains(t, err, "invalid "+key+" pattern")
+	}
+}
+
+func TestFilteringStorePassThrough(t *testing.T) {
+	features := []Feature{FeatureBulkGetSecret, FeatureMultipleKeyValuesPerSecret}
+	inner := &mockStore{features: features}
+	store := newTestFilteringStore(t, inner, map[string]string{FilterDeniedSecretsKey: "*"})
+
+	assert.Equal(t, features, store.Features())
+	require.NoError(t, store.(*filteringStore).Close())
+	assert.True(t, inner.closed)
+}
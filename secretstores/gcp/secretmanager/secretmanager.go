@@ -200,7 +200,7 @@ func (s *Store) Close() error {
 
 // Features returns the features available in this secret store.
 func (s *Store) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetSecret}
 }
 
 func (s *Store) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
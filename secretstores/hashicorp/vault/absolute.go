@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// sanitizeAbsolutePath returns the given Vault API path without its leading and trailing slashes, and checks that
+// it can't address anything else than the path it names.
+func sanitizeAbsolutePath(path string) (string, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", errors.New("absolute path is empty")
+	}
+	if strings.ContainsAny(path, "?#%\\") {
+		return "", fmt.Errorf("absolute path %s contains invalid characters", path)
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("absolute path %s contains an empty or relative segment", path)
+		}
+	}
+
+	return path, nil
+}
+
+// getSecretByAbsolutePath reads the secret at the given Vault API path, relative to /v1/, without prepending the
+// engine path nor the KV prefix. Secrets of KV version 2 engines are read from their data endpoint, with their
+// metadata; for the other engines, such as cubbyhole or KV version 1, the fields of the response data are returned.
+func (v *vaultSecretStore) getSecretByAbsolutePath(ctx context.Context, path string, reqMetadata map[string]string) (secretstores.GetSecretResponse, error) {
+	path, err := sanitizeAbsolutePath(path)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, err
+	}
+
+	addr := v.vaultAddress + "/v1/" + path
+	if version, ok := reqMetadata[versionID]; ok {
+		addr += "?version=" + url.QueryEscape(version)
+	}
+
+	httpReq, err := v.newVaultRequest(ctx, http.MethodGet, addr, nil)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("couldn't generate request: %w", err)
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationGet)
+		return secretstores.GetSecretResponse{}, fmt.Errorf("couldn't get secret: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusNotFound {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("getSecret %s failed %w", path, ErrNotFound)
+	}
+	if httpresp.StatusCode != http.StatusOK {
+		recordCount(ctx, requestErrors, operationGet)
+		io.Copy(io.Discard, httpresp.Body)
+		return secretstores.GetSecretResponse{}, fmt.Errorf("couldn't get successful response, status code %d", httpresp.StatusCode)
+	}
+
+	b, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("couldn't read response: %w", err)
+	}
+
+	var info vaultKVResponseInfo
+	var d struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err = json.Unmarshal(b, &info); err != nil {
+		return secretstores.GetSecretResponse{}, decodeError(err)
+	}
+	if err = json.Unmarshal(b, &d); err != nil {
+		return secretstores.GetSecretResponse{}, decodeError(err)
+	}
+
+	fields := d.Data
+	// KV version 2 nests the fields of the secret under data, next to its metadata
+	if inner, ok := d.Data[DataStr]; ok && d.Data["metadata"] != nil {
+		fields = nil
+		if err = json.Unmarshal(inner, &fields); err != nil {
+			return secretstores.GetSecretResponse{}, decodeError(err)
+		}
+	}
+	if len(fields) == 0 {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("getSecret %s failed, no data %w", path, ErrNotFound)
+	}
+
+	data := make(map[string]string, len(fields))
+	for key, raw := range fields {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			data[key] = s
+		} else {
+			// Values that aren't strings are returned as JSON
+			data[key] = string(raw)
+		}
+	}
+
+	return secretstores.GetSecretResponse{
+		Data:     data,
+		Metadata: info.secretMetadata(),
+	}, nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+// newAllowedFields returns the set of the fields in vaultAllowedFields, or nil if all the fields are allowed.
+func newAllowedFields(fields []string) map[string]struct{} {
+	fields = trimmedValues(fields)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	res := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		res[field] = struct{}{}
+	}
+
+	return res
+}
+
+// isFieldAllowed returns true if the key of a secret can be returned, as all the fields are allowed when
+// vaultAllowedFields isn't set.
+func (v *vaultSecretStore) isFieldAllowed(key string) bool {
+	if v.allowedFields == nil {
+		return true
+	}
+	_, ok := v.allowedFields[key]
+
+	return ok
+}
+
+// filterAllowedFields returns the values of a secret without the keys that aren't allowed. The values are returned
+// as they are if all the fields are allowed, and copied otherwise, as they may be cached.
+func (v *vaultSecretStore) filterAllowedFields(values map[string]string) map[string]string {
+	if v.allowedFields == nil {
+		return values
+	}
+
+	res := make(map[string]string, len(v.allowedFields))
+	for key, value := range values {
+		if v.isFieldAllowed(key) {
+			res[key] = value
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestVaultAllowedFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/metadata/dapr/":
+			w.Write([]byte(`{"data":{"keys":["creds"]}}`))
+		case "/v1/secret/metadata/dapr/creds":
+			w.Write([]byte(`{"data":{"versions":{"1":{"created_time":"2023-01-01T00:00:00Z"}}}}`))
+		case "/v1/secret/data/dapr/creds":
+			w.Write([]byte(`{"data":{"data":{"username":"admin","password":"s3cr3t","host":"db","port":"5432","notes":"rotate yearly"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	initStore := func(t *testing.T, allowedFields string) *vaultSecretStore {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		properties := map[string]string{
+			componentVaultAddress: server.URL,
+			componentVaultToken:   expectedTok,
+		}
+		if allowedFields != "" {
+			properties[componentAllowedFields] = allowedFields
+		}
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}}))
+		t.Cleanup(func() { v.Close() })
+		return v
+	}
+	getSecret := func(t *testing.T, v *vaultSecretStore, reqMetadata map[string]string) map[string]string {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "creds", Metadata: reqMetadata})
+		require.NoError(t, err)
+		return resp.Data
+	}
+	allowed := map[string]string{"username": "admin", "password": "s3cr3t"}
+
+	t.Run("all the fields are returned by default", func(t *testing.T) {
+		v := initStore(t, "")
+
+		assert.Len(t, getSecret(t, v, nil), 5)
+	})
+
+	t.Run("only the allowed fields are returned", func(t *testing.T) {
+		v := initStore(t, " username, password ,")
+
+		assert.Equal(t, allowed, getSecret(t, v, nil))
+
+		resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{"creds": allowed}, resp.Data)
+	})
+
+	t.Run("the fields of all the versions are filtered", func(t *testing.T) {
+		v := initStore(t, "username,password")
+
+		assert.Equal(t, map[string]string{"v1.username": "admin", "v1.password": "s3cr3t"},
+			getSecret(t, v, map[string]string{allVersions: "true"}))
+	})
+
+	t.Run("cached secrets keep all their fields", func(t *testing.T) {
+		v := initStore(t, "username,password")
+		v.cache = newSecretCache(time.Minute)
+
+		assert.Equal(t, allowed, getSecret(t, v, nil))
+		assert.Equal(t, allowed, getSecret(t, v, nil))
+
+		v.allowedFields = nil
+		assert.Len(t, getSecret(t, v, nil), 5)
+	})
+}
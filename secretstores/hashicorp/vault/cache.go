@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// secretCache keeps the secrets read from Vault in memory for a limited time.
+// Entries are indexed by secret name and then by version, so all the versions
+// of a secret can be invalidated at once.
+type secretCache struct {
+	ttl     time.Duration
+	lock    sync.RWMutex
+	entries map[string]map[string]secretCacheEntry
+	now     func() time.Time
+}
+
+type secretCacheEntry struct {
+	resp      secretstores.GetSecretResponse
+	expiresAt time.Time
+}
+
+func newSecretCache(ttl time.Duration) *secretCache {
+	return &secretCache{
+		ttl:     ttl,
+		entries: make(map[string]map[string]secretCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns a copy of the cached response for a secret version, if present and not expired.
+func (c *secretCache) get(name, version string) (secretstores.GetSecretResponse, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	entry, ok := c.entries[name][version]
+	if !ok || c.now().After(entry.expiresAt) {
+		return secretstores.GetSecretResponse{}, false
+	}
+
+	return copySecretResponse(entry.resp), true
+}
+
+// set stores a copy of the response for a secret version.
+func (c *secretCache) set(name, version string, resp secretstores.GetSecretResponse) {
+	entry := secretCacheEntry{
+		resp:      copySecretResponse(resp),
+		expiresAt: c.now().Add(c.ttl),
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	versions, ok := c.entries[name]
+	if !ok {
+		versions = make(map[string]secretCacheEntry)
+		c.entries[name] = versions
+	}
+	versions[version] = entry
+}
+
+// invalidate removes all the cached versions of the given secrets.
+func (c *secretCache) invalidate(names ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, name := range names {
+		delete(c.entries, name)
+	}
+}
+
+// invalidateAll removes every entry from the cache.
+func (c *secretCache) invalidateAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries = make(map[string]map[string]secretCacheEntry)
+}
+
+// InvalidateCache removes all the cached versions of the given secrets, so that they're read from Vault again on
+// their next retrieval, for example after rotating them. It does nothing if vaultCacheTTL isn't set.
+func (v *vaultSecretStore) InvalidateCache(ctx context.Context, names ...string) {
+	if v.cache == nil || len(names) == 0 {
+		return
+	}
+
+	v.cache.invalidate(names...)
+	v.logger.Debugf("Invalidated the cached versions of %d secrets", len(names))
+}
+
+// InvalidateAllCache removes every secret from the cache. It does nothing if vaultCacheTTL isn't set.
+func (v *vaultSecretStore) InvalidateAllCache(ctx context.Context) {
+	if v.cache == nil {
+		return
+	}
+
+	v.cache.invalidateAll()
+	v.logger.Debug("Invalidated all the cached secrets")
+}
+
+func copySecretResponse(resp secretstores.GetSecretResponse) secretstores.GetSecretResponse {
+	return secretstores.GetSecretResponse{
+		Data:     maps.Clone(resp.Data),
+		Metadata: maps.Clone(resp.Metadata),
+	}
+}
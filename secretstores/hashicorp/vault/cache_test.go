@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+func TestSecretCache(t *testing.T) {
+	now := time.Now()
+	c := newSecretCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.set("mysecret", "0", secretstores.GetSecretResponse{
+		Data:     map[string]string{"key": "latest"},
+		Metadata: map[string]string{"version": "2"},
+	})
+	c.set("mysecret", "1", secretstores.GetSecretResponse{Data: map[string]string{"key": "first"}})
+	c.set("other", "0", secretstores.GetSecretResponse{
+		Data:     map[string]string{"key": "other"},
+		Metadata: map[string]string{"version": "1"},
+	})
+
+	t.Run("returns cached versions", func(t *testing.T) {
+		resp, ok := c.get("mysecret", "0")
+		assert.True(t, ok)
+		assert.Equal(t, map[string]string{"key": "latest"}, resp.Data)
+		assert.Equal(t, map[string]string{"version": "2"}, resp.Metadata)
+
+		resp, ok = c.get("mysecret", "1")
+		assert.True(t, ok)
+		assert.Equal(t, map[string]string{"key": "first"}, resp.Data)
+		assert.Nil(t, resp.Metadata)
+
+		_, ok = c.get("mysecret", "2")
+		assert.False(t, ok)
+	})
+
+	t.Run("returned data is a copy", func(t *testing.T) {
+		resp, _ := c.get("other", "0")
+		resp.Data["key"] = "changed"
+		resp.Metadata["version"] = "changed"
+
+		resp, _ = c.get("other", "0")
+		assert.Equal(t, "other", resp.Data["key"])
+		assert.Equal(t, "1", resp.Metadata["version"])
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		defer func() { now = now.Add(-2 * time.Minute) }()
+
+		_, ok := c.get("mysecret", "0")
+		assert.False(t, ok)
+	})
+
+	t.Run("invalidate removes all versions of a secret", func(t *testing.T) {
+		c.invalidate("mysecret")
+
+		_, ok := c.get("mysecret", "0")
+		assert.False(t, ok)
+		_, ok = c.get("mysecret", "1")
+		assert.False(t, ok)
+		_, ok = c.get("other", "0")
+		assert.True(t, ok)
+
+		c.invalidateAll()
+		_, ok = c.get("other", "0")
+		assert.False(t, ok)
+	})
+}
+
+func TestInvalidateCache(t *testing.T) {
+	fake := &fakeVersionedVault{}
+	fake.version.Store(1)
+
+	getPassword := func(t *testing.T, v *vaultSecretStore) string {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db"})
+		require.NoError(t, err)
+		return resp.Data["password"]
+	}
+
+	t.Run("invalidated secrets are read again from Vault", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, fake)
+		v.cache = newSecretCache(time.Hour)
+
+		fake.version.Store(1)
+		assert.Equal(t, "v1", getPassword(t, v))
+		fake.version.Store(2)
+		assert.Equal(t, "v1", getPassword(t, v))
+
+		v.InvalidateCache(context.Background(), "other")
+		assert.Equal(t, "v1", getPassword(t, v))
+
+		v.InvalidateCache(context.Background(), "db")
+		assert.Equal(t, "v2", getPassword(t, v))
+	})
+
+	t.Run("invalidating all the secrets empties the cache", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, fake)
+		v.cache = newSecretCache(time.Hour)
+
+		fake.version.Store(1)
+		assert.Equal(t, "v1", getPassword(t, v))
+		fake.version.Store(2)
+
+		v.InvalidateAllCache(context.Background())
+		assert.Equal(t, "v2", getPassword(t, v))
+	})
+
+	t.Run("invalidation does nothing without cache", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, fake)
+
+		v.InvalidateCache(context.Background(), "db")
+		v.InvalidateAllCache(context.Background())
+		assert.Nil(t, v.cache)
+	})
+}
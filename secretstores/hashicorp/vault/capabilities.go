@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+)
+
+const (
+	// rootPolicy grants every capability on every path.
+	rootPolicy = "root"
+
+	capabilityRead = "read"
+	capabilityRoot = "root"
+)
+
+// AllowedPaths returns the sorted names of the secrets under the KV prefix that the token is allowed to read, so
+// that tools can filter the secrets before reading them. The token needs the list capability on the metadata of
+// the secrets; the read capability of each of them is checked with sys/capabilities-self, unless the token has the
+// root policy.
+func (v *vaultSecretStore) AllowedPaths(ctx context.Context) ([]string, error) {
+	if v.engineType == engineTypeDatabase || v.engineType == engineTypeCubbyhole {
+		return nil, fmt.Errorf("allowed paths are only supported with %s %s", componentVaultEngineType, engineTypeKV)
+	}
+
+	if err := v.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+
+	token, err := v.lookupToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := v.listKeysUnderPath(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	for _, policy := range token.Data.Policies {
+		if policy == rootPolicy {
+			return keys, nil
+		}
+	}
+	if len(keys) == 0 {
+		return keys, nil
+	}
+
+	paths := make([]string, len(keys))
+	for i, key := range keys {
+		paths[i] = v.kvUnescapedPath(v.vaultEnginePath, "data", key)
+	}
+	capabilities, err := v.capabilities(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make([]string, 0, len(keys))
+	for i, key := range keys {
+		for _, capability := range capabilities[paths[i]] {
+			if capability == capabilityRead || capability == capabilityRoot {
+				allowed = append(allowed, key)
+				break
+			}
+		}
+	}
+
+	return allowed, nil
+}
+
+// capabilities returns the capabilities of the token on each of the given paths.
+func (v *vaultSecretStore) capabilities(ctx context.Context, paths []string) (map[string][]string, error) {
+	body, err := json.Marshal(map[string][]string{"paths": paths})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode request body: %w", err)
+	}
+	httpReq, err := v.newVaultRequest(ctx, http.MethodPost, v.vaultAddress+"/v1/sys/capabilities-self", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate request: %w", err)
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationLookup)
+		return nil, fmt.Errorf("couldn't lookup capabilities: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusForbidden {
+		return nil, v.permissionDenied(ctx, operationLookup, httpReq.URL.Path)
+	}
+	if httpresp.StatusCode != http.StatusOK {
+		recordCount(ctx, requestErrors, operationLookup)
+		io.Copy(io.Discard, httpresp.Body)
+		return nil, fmt.Errorf("couldn't lookup capabilities, status code %d", httpresp.StatusCode)
+	}
+
+	// The capabilities are returned both under data and at the top level, next to fields such as request_id
+	var d struct {
+		Data map[string][]string `json:"data"`
+	}
+	if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
+		return nil, decodeError(err)
+	}
+
+	return d.Data, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowedPaths(t *testing.T) {
+	// policies are the capabilities of the scoped token, as written in its policies
+	policies := map[string][]string{
+		"secret/data/dapr/db":         {"read"},
+		"secret/data/dapr/api key":    {"deny"},
+		"secret/data/dapr/team/cache": {"read", "list"},
+		"secret/data/dapr/team/admin": {"create", "update"},
+	}
+	newHandler := func(tokenPolicies string, requested *[]string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/v1/auth/token/lookup-self":
+				w.Write([]byte(`{"data":{"ttl":3600,"policies":["default","` + tokenPolicies + `"]}}`))
+			case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/":
+				w.Write([]byte(`{"data":{"keys":["db","api key","team/"]}}`))
+			case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/team/":
+				w.Write([]byte(`{"data":{"keys":["cache","admin"]}}`))
+			case r.Method == http.MethodPost && r.URL.Path == "/v1/sys/capabilities-self":
+				var body struct {
+					Paths []string `json:"paths"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				*requested = body.Paths
+				res := map[string][]string{}
+				for _, path := range body.Paths {
+					res[path] = policies[path]
+				}
+				json.NewEncoder(w).Encode(map[string]any{"data": res})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+	}
+
+	t.Run("a scoped token reports only the paths it can read", func(t *testing.T) {
+		var requested []string
+		v := newTestVaultSecretStore(t, newHandler("app", &requested))
+
+		paths, err := v.AllowedPaths(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"db", "team/cache"}, paths)
+		// The paths are checked as written in policies, not escaped
+		assert.Contains(t, requested, "secret/data/dapr/api key")
+	})
+
+	t.Run("a root token can read every path", func(t *testing.T) {
+		var requested []string
+		v := newTestVaultSecretStore(t, newHandler(rootPolicy, &requested))
+
+		paths, err := v.AllowedPaths(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api key", "db", "team/admin", "team/cache"}, paths)
+		assert.Nil(t, requested)
+	})
+
+	t.Run("the token can't be looked up", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+
+		_, err := v.AllowedPaths(context.Background())
+		assert.ErrorContains(t, err, "couldn't lookup token, status code 403")
+	})
+
+	t.Run("only the KV engine is supported", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, http.NotFoundHandler())
+		v.engineType = engineTypeDatabase
+
+		_, err := v.AllowedPaths(context.Background())
+		assert.ErrorContains(t, err, "allowed paths are only supported with vaultEngineType kv")
+	})
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultCircuitBreakerMinBackoff = time.Second
+	defaultCircuitBreakerMaxBackoff = 10 * time.Second
+)
+
+// ErrCircuitOpen is returned without contacting Vault while the circuit breaker is open, after consecutive
+// failures to reach the server.
+var ErrCircuitOpen = errors.New("vault is unreachable, requests are suspended")
+
+// circuitBreakerTransport stops sending requests to Vault after threshold consecutive transport failures, so an
+// unreachable server isn't hammered and callers get a fast error. While the circuit is open, a single request is
+// let through as a probe each time the backoff elapses: the circuit closes when a probe succeeds, otherwise the
+// backoff doubles, up to maxBackoff.
+// Only transport failures count: responses with an error status code mean that Vault is reachable.
+type circuitBreakerTransport struct {
+	next       http.RoundTripper
+	threshold  int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	logger     logger.Logger
+	now        func() time.Time
+
+	lock      sync.Mutex
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreakerTransport(next http.RoundTripper, threshold int, maxBackoff time.Duration, logger logger.Logger) *circuitBreakerTransport {
+	if maxBackoff <= 0 {
+		maxBackoff = defaultCircuitBreakerMaxBackoff
+	}
+	minBackoff := defaultCircuitBreakerMinBackoff
+	if minBackoff > maxBackoff {
+		minBackoff = maxBackoff
+	}
+
+	return &circuitBreakerTransport{
+		next:       next,
+		threshold:  threshold,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	probe, err := t.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	// Canceled requests say nothing about the server
+	if err != nil && req.Context().Err() != nil {
+		if probe {
+			t.lock.Lock()
+			t.probing = false
+			t.lock.Unlock()
+		}
+		return nil, err
+	}
+	t.record(probe, err)
+
+	return resp, err
+}
+
+// allow returns an error when the circuit is open, and whether the request is the probe of an open circuit.
+func (t *circuitBreakerTransport) allow() (bool, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.openUntil.IsZero() {
+		return false, nil
+	}
+	if t.probing || t.now().Before(t.openUntil) {
+		return false, fmt.Errorf("%w after %d consecutive failures", ErrCircuitOpen, t.failures)
+	}
+	t.probing = true
+
+	return true, nil
+}
+
+func (t *circuitBreakerTransport) record(probe bool, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if probe {
+		t.probing = false
+	}
+
+	if err == nil {
+		if !t.openUntil.IsZero() {
+			t.logger.Infof("Vault is reachable again, resuming requests")
+		}
+		t.failures = 0
+		t.backoff = 0
+		t.openUntil = time.Time{}
+		return
+	}
+
+	t.failures++
+	switch {
+	case probe:
+		t.backoff *= 2
+		if t.backoff > t.maxBackoff {
+			t.backoff = t.maxBackoff
+		}
+	case t.openUntil.IsZero() && t.failures >= t.threshold:
+		t.backoff = t.minBackoff
+		t.logger.Warnf("Vault is unreachable after %d consecutive failures, suspending requests: %v", t.failures, err)
+	default:
+		return
+	}
+	t.openUntil = t.now().Add(t.backoff)
+}
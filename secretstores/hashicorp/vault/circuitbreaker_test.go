@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+// flakyNetwork fails the requests while it's down, as if the connectivity to Vault was interrupted.
+type flakyNetwork struct {
+	next     http.RoundTripper
+	down     atomic.Bool
+	requests atomic.Int64
+}
+
+func (n *flakyNetwork) RoundTrip(req *http.Request) (*http.Response, error) {
+	n.requests.Add(1)
+	if n.down.Load() {
+		return nil, errors.New("connection refused")
+	}
+
+	return n.next.RoundTrip(req)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+	}))
+	defer server.Close()
+
+	newStore := func(t *testing.T, properties map[string]string) (*vaultSecretStore, *flakyNetwork) {
+		properties[componentVaultAddress] = server.URL
+		properties[componentVaultToken] = expectedTok
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}}))
+
+		breaker, ok := v.client.Transport.(*circuitBreakerTransport)
+		require.True(t, ok)
+		network := &flakyNetwork{next: breaker.next}
+		breaker.next = network
+
+		return v, network
+	}
+	getSecret := func(v *vaultSecretStore) error {
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		return err
+	}
+
+	t.Run("fails fast while Vault is unreachable and recovers afterwards", func(t *testing.T) {
+		v, network := newStore(t, map[string]string{
+			componentCircuitBreaker:    "3",
+			componentCircuitBreakerMax: "4s",
+		})
+		breaker := v.client.Transport.(*circuitBreakerTransport)
+		now := time.Now()
+		breaker.now = func() time.Time { return now }
+
+		require.NoError(t, getSecret(v))
+
+		network.down.Store(true)
+		for i := 0; i < 3; i++ {
+			err := getSecret(v)
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, ErrCircuitOpen)
+		}
+
+		// The circuit is open: requests fail without reaching the network
+		sent := network.requests.Load()
+		for i := 0; i < 10; i++ {
+			assert.ErrorIs(t, getSecret(v), ErrCircuitOpen)
+		}
+		assert.Equal(t, sent, network.requests.Load())
+
+		// A probe is let through after the backoff, which doubles when it fails
+		now = now.Add(time.Second)
+		assert.NotErrorIs(t, getSecret(v), ErrCircuitOpen)
+		assert.Equal(t, sent+1, network.requests.Load())
+		assert.ErrorIs(t, getSecret(v), ErrCircuitOpen)
+		now = now.Add(time.Second)
+		assert.ErrorIs(t, getSecret(v), ErrCircuitOpen)
+		now = now.Add(time.Second)
+		assert.NotErrorIs(t, getSecret(v), ErrCircuitOpen)
+		assert.Equal(t, sent+2, network.requests.Load())
+
+		// The backoff doesn't exceed the maximum
+		now = now.Add(4 * time.Second)
+		assert.NotErrorIs(t, getSecret(v), ErrCircuitOpen)
+		now = now.Add(4 * time.Second)
+		assert.NotErrorIs(t, getSecret(v), ErrCircuitOpen)
+		assert.Equal(t, sent+4, network.requests.Load())
+
+		// Vault is back: the next probe closes the circuit
+		network.down.Store(false)
+		assert.ErrorIs(t, getSecret(v), ErrCircuitOpen)
+		now = now.Add(4 * time.Second)
+		require.NoError(t, getSecret(v))
+		require.NoError(t, getSecret(v))
+
+		// Failures are counted again from zero
+		network.down.Store(true)
+		for i := 0; i < 2; i++ {
+			assert.NotErrorIs(t, getSecret(v), ErrCircuitOpen)
+		}
+		network.down.Store(false)
+		require.NoError(t, getSecret(v))
+	})
+
+	t.Run("error responses don't open the circuit", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failing.Close()
+
+		v, _ := newStore(t, map[string]string{})
+		v.client.Transport.(*circuitBreakerTransport).next = http.DefaultTransport
+		v.vaultAddress = failing.URL
+
+		for i := 0; i < 10; i++ {
+			err := getSecret(v)
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, ErrCircuitOpen)
+		}
+	})
+
+	t.Run("canceled requests don't open the circuit", func(t *testing.T) {
+		v, network := newStore(t, map[string]string{componentCircuitBreaker: "1"})
+		network.down.Store(true)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := v.GetSecret(ctx, secretstores.GetSecretRequest{Name: "mysecret"})
+		require.Error(t, err)
+
+		network.down.Store(false)
+		assert.NoError(t, getSecret(v))
+	})
+
+	t.Run("disabled with a threshold of 0", func(t *testing.T) {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress:   server.URL,
+			componentVaultToken:     expectedTok,
+			componentCircuitBreaker: "0",
+		}}}))
+
+		_, ok := v.client.Transport.(*circuitBreakerTransport)
+		assert.False(t, ok)
+	})
+
+	t.Run("negative values are rejected", func(t *testing.T) {
+		err := ValidateMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken:     expectedTok,
+			componentCircuitBreaker: "-1",
+		}}})
+		assert.ErrorContains(t, err, "vaultCircuitBreakerThreshold and vaultCircuitBreakerMaxBackoff must not be negative")
+	})
+}
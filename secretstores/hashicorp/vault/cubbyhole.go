@@ -0,0 +1,30 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// cubbyholeEnginePath is where Vault mounts the cubbyhole engine, which can't be moved.
+const cubbyholeEnginePath = "cubbyhole"
+
+// getCubbyholeSecret reads a secret from the cubbyhole engine. The cubbyhole is private to a token: only the
+// secrets written with the token used by the component can be read, and they are lost when the token is replaced,
+// for example by re-authenticating.
+func (v *vaultSecretStore) getCubbyholeSecret(ctx context.Context, name string) (secretstores.GetSecretResponse, error) {
+	return v.getSecretByAbsolutePath(ctx, cubbyholeEnginePath+"/"+name, nil)
+}
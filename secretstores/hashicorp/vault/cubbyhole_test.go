@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeCubbyholes serves the cubbyhole engine, with a cubbyhole per token.
+type fakeCubbyholes struct {
+	mu         sync.Mutex
+	cubbyholes map[string]map[string]map[string]any
+}
+
+func (f *fakeCubbyholes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutPrefix(r.URL.Path, "/v1/cubbyhole/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	token := r.Header.Get(vaultHTTPHeader)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		var data map[string]any
+		json.NewDecoder(r.Body).Decode(&data)
+		if f.cubbyholes[token] == nil {
+			f.cubbyholes[token] = map[string]map[string]any{}
+		}
+		f.cubbyholes[token][name] = data
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		data, ok := f.cubbyholes[token][name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}
+}
+
+func TestCubbyholeEngine(t *testing.T) {
+	server := httptest.NewServer(&fakeCubbyholes{cubbyholes: map[string]map[string]map[string]any{}})
+	defer server.Close()
+
+	write := func(t *testing.T, token, name, body string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/cubbyhole/"+name, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(vaultHTTPHeader, token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	initStore := func(properties map[string]string) (*vaultSecretStore, error) {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		properties[componentVaultAddress] = server.URL
+		properties[componentVaultToken] = expectedTok
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+		return v, err
+	}
+
+	v, err := initStore(map[string]string{componentVaultEngineType: "cubbyhole"})
+	require.NoError(t, err)
+	defer v.Close()
+	assert.Equal(t, engineTypeCubbyhole, v.engineType)
+	assert.Equal(t, []secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret}, v.Features())
+
+	t.Run("secrets written with the token of the component are read back", func(t *testing.T) {
+		write(t, expectedTok, "temporary", `{"username":"app","password":"s3cr3t","port":5432}`)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "temporary"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"username": "app", "password": "s3cr3t", "port": "5432"}, resp.Data)
+	})
+
+	t.Run("secrets written with another token can't be read", func(t *testing.T) {
+		write(t, "another-token", "private", `{"key":"value"}`)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "private"})
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+	})
+
+	t.Run("names can't escape the cubbyhole", func(t *testing.T) {
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "../secret/data/dapr/foo"})
+		assert.ErrorContains(t, err, "contains an empty or relative segment")
+	})
+
+	t.Run("bulk get is not supported", func(t *testing.T) {
+		_, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		assert.ErrorIs(t, err, secretstores.ErrBulkGetSecretNotSupported)
+	})
+
+	t.Run("the engine path can't be set", func(t *testing.T) {
+		_, err := initStore(map[string]string{componentVaultEngineType: "cubbyhole", vaultEnginePath: "secret"})
+		assert.ErrorContains(t, err, "enginePath and vaultEnginePaths can't be set with vaultEngineType cubbyhole")
+	})
+}
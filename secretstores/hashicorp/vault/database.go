@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// getDatabaseCredentials generates credentials for a role of the database secrets engine.
+// The returned metadata contains the lease of the credentials.
+func (v *vaultSecretStore) getDatabaseCredentials(ctx context.Context, role string) (secretstores.GetSecretResponse, error) {
+	httpReq, err := v.newVaultRequest(ctx, http.MethodGet, v.vaultAddress+"/v1/"+v.vaultEnginePath+"/creds/"+escapeSecretPath(role), nil)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("couldn't generate request: %w", err)
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationGet)
+		return secretstores.GetSecretResponse{}, fmt.Errorf("couldn't get database credentials: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, httpresp.Body)
+		v.logger.Debugf("getDatabaseCredentials %s couldn't get successful response, status code %d", role, httpresp.StatusCode)
+		if httpresp.StatusCode == http.StatusNotFound {
+			return secretstores.GetSecretResponse{}, fmt.Errorf("getDatabaseCredentials %s failed %w", role, ErrNotFound)
+		}
+		if httpresp.StatusCode == http.StatusForbidden {
+			return secretstores.GetSecretResponse{}, v.permissionDenied(ctx, operationGet, httpReq.URL.Path)
+		}
+
+		recordCount(ctx, requestErrors, operationGet)
+		return secretstores.GetSecretResponse{}, fmt.Errorf("couldn't get successful response, status code %d", httpresp.StatusCode)
+	}
+
+	b, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("couldn't read response: %w", err)
+	}
+
+	var info vaultKVResponseInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return secretstores.GetSecretResponse{}, decodeError(err)
+	}
+
+	data := v.json.Get(b, DataStr)
+	resp := secretstores.GetSecretResponse{
+		Data:     make(map[string]string, data.Size()),
+		Metadata: info.secretMetadata(),
+	}
+	for _, key := range data.Keys() {
+		resp.Data[key] = data.Get(key).ToString()
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestDatabaseEngine(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/database/creds/readonly":
+			w.Write([]byte(`{"lease_id":"database/creds/readonly/abc","lease_duration":3600,"renewable":true,` +
+				`"data":{"username":"v-token-readonly-xyz","password":"generated"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	newDatabaseStore := func(t *testing.T) *vaultSecretStore {
+		v := newTestVaultSecretStore(t, handler)
+		v.engineType = engineTypeDatabase
+		v.vaultEnginePath = defaultVaultDatabaseEnginePath
+		return v
+	}
+
+	t.Run("credentials are generated for the role", func(t *testing.T) {
+		v := newDatabaseStore(t)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "readonly"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"username": "v-token-readonly-xyz", "password": "generated"}, resp.Data)
+		assert.Equal(t, "database/creds/readonly/abc", resp.Metadata["lease_id"])
+		assert.Equal(t, "3600", resp.Metadata["lease_duration"])
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		v := newDatabaseStore(t)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "missing"})
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+	})
+
+	t.Run("bulk get is not supported", func(t *testing.T) {
+		v := newDatabaseStore(t)
+
+		_, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		assert.ErrorIs(t, err, secretstores.ErrBulkGetSecretNotSupported)
+	})
+
+	t.Run("init", func(t *testing.T) {
+		initStore := func(properties map[string]string) (*vaultSecretStore, error) {
+			v := &vaultSecretStore{logger: logger.NewLogger("test")}
+			properties[componentVaultToken] = expectedTok
+			err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+			return v, err
+		}
+
+		v, err := initStore(map[string]string{componentVaultEngineType: "database"})
+		require.NoError(t, err)
+		assert.Equal(t, engineTypeDatabase, v.engineType)
+		assert.Equal(t, defaultVaultDatabaseEnginePath, v.vaultEnginePath)
+
+		v, err = initStore(map[string]string{componentVaultEngineType: "database", vaultEnginePath: "postgres"})
+		require.NoError(t, err)
+		assert.Equal(t, "postgres", v.vaultEnginePath)
+
+		v, err = initStore(map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, engineTypeKV, v.engineType)
+		assert.Equal(t, defaultVaultEnginePath, v.vaultEnginePath)
+
+		_, err = initStore(map[string]string{componentVaultEngineType: "transit"})
+		assert.ErrorContains(t, err, "invalid vaultEngineType transit")
+	})
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"encoding/base64"
+
+	"github.com/dapr/components-contrib/internal/utils"
+)
+
+// shouldDecodeBase64 returns true if the values of the secrets read by a request must be decoded from base64.
+// The vaultDecodeBase64 key of the request metadata overrides the component setting.
+func (v *vaultSecretStore) shouldDecodeBase64(reqMetadata map[string]string) bool {
+	if val, ok := reqMetadata[componentVaultDecodeBase64]; ok {
+		return utils.IsTruthy(val)
+	}
+
+	return v.decodeBase64
+}
+
+// decodeBase64Values returns a copy of the values of a secret, decoded from base64.
+// Values that aren't valid base64 are returned as they are.
+func (v *vaultSecretStore) decodeBase64Values(secret string, values map[string]string) map[string]string {
+	res := make(map[string]string, len(values))
+	for key, val := range values {
+		decoded, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			// The value is not logged, as it's a secret
+			v.logger.Warnf("The value of key %s of secret %s is not valid base64, returning it as it is", key, secret)
+			res[key] = val
+			continue
+		}
+		res[key] = string(decoded)
+	}
+
+	return res
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// operationCtxKey is the key of the operation of the requests sent with a context, which replaces the one guessed
+// from their path.
+type operationCtxKey struct{}
+
+// withOperation returns a context whose requests to Vault are recorded as part of operation, such as the reads and
+// lists of a bulk retrieval.
+func withOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationCtxKey{}, operation)
+}
+
+// requestOperation returns the operation a request is recorded as: the one of its context if any, or else the one
+// of its path.
+func requestOperation(req *http.Request) string {
+	if operation, ok := req.Context().Value(operationCtxKey{}).(string); ok {
+		return operation
+	}
+
+	path := req.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/v1/auth/") && (strings.HasSuffix(path, "/login") || strings.Contains(path, "/login/")):
+		return operationLogin
+	case strings.HasSuffix(path, "/renew") || strings.HasSuffix(path, "/renew-self"):
+		return operationRenew
+	case strings.HasSuffix(path, "/revoke-self"):
+		return operationRevoke
+	case strings.HasSuffix(path, "/lookup-self") || strings.HasSuffix(path, "/capabilities-self"):
+		return operationLookup
+	case strings.HasPrefix(path, "/v1/sys/mounts"):
+		return operationMounts
+	case req.Method == "LIST":
+		return operationList
+	default:
+		return operationGet
+	}
+}
+
+// durationTransport records the duration of the requests to Vault, until the headers of their responses, by
+// operation. The requests that fail are recorded too, as their duration, such as a timeout, matters as much.
+type durationTransport struct {
+	next http.RoundTripper
+}
+
+func newDurationTransport(next http.RoundTripper) *durationTransport {
+	return &durationTransport{next: next}
+}
+
+func (t *durationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	recordDuration(req.Context(), requestOperation(req), time.Since(start))
+
+	return resp, err
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/dapr/kit/logger"
+)
+
+// failoverTransport sends requests to the active Vault address and fails over to the next configured
+// address when the server can't be reached. Requests are built against the first address: only their
+// scheme and host are replaced.
+// The active address is kept for the following requests, so an unreachable server isn't probed on each call.
+//
+// When several addresses are set in vaultAddr, such as performance standbys, the reads are spread across them in
+// turn instead, and fail over to the next address. The other requests, such as logins, go to the primary address
+// if one is set.
+type failoverTransport struct {
+	next      http.RoundTripper
+	addresses []*url.URL
+	// replicas is the number of addresses, at the start of addresses, that the reads are spread across.
+	replicas int
+	// primary is the address of the requests other than reads, if set.
+	primary *url.URL
+	active  atomic.Int32
+	turn    atomic.Uint32
+	logger  logger.Logger
+}
+
+// newFailoverTransport returns a transport that fails over between the given addresses, in order. The reads are
+// spread across the first replicas addresses if there are more than one, and the other requests are sent to
+// primary if it isn't empty.
+func newFailoverTransport(next http.RoundTripper, addresses []string, replicas int, primary string, logger logger.Logger) (*failoverTransport, error) {
+	t := &failoverTransport{
+		next:      next,
+		addresses: make([]*url.URL, len(addresses)),
+		replicas:  replicas,
+		logger:    logger,
+	}
+	for i, address := range addresses {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, err
+		}
+		t.addresses[i] = u
+	}
+	if primary != "" {
+		u, err := url.Parse(primary)
+		if err != nil {
+			return nil, err
+		}
+		t.primary = u
+	}
+
+	return t, nil
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	read := isReadRequest(req)
+	switch {
+	case !read && t.primary != nil:
+		return t.next.RoundTrip(withAddress(req, t.primary))
+	case read && t.replicas > 1:
+		return t.roundTrip(req, int((t.turn.Add(1)-1)%uint32(t.replicas)), false)
+	default:
+		return t.roundTrip(req, int(t.active.Load()), true)
+	}
+}
+
+// roundTrip sends the request to the address at index start, and fails over to the next ones. With sticky, the
+// address that answered becomes the active one.
+func (t *failoverTransport) roundTrip(req *http.Request, start int, sticky bool) (*http.Response, error) {
+	var lastErr error
+	for i := range t.addresses {
+		idx := (start + i) % len(t.addresses)
+		address := t.addresses[idx]
+
+		r := withAddress(req, address)
+		if i > 0 && req.Body != nil && req.Body != http.NoBody {
+			// The body was consumed by the previous attempt
+			if req.GetBody == nil {
+				return nil, lastErr
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, lastErr
+			}
+			r.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(r)
+		if err == nil {
+			if sticky && idx != start && t.active.CompareAndSwap(int32(start), int32(idx)) {
+				t.logger.Warnf("Vault server at %s is unreachable, failed over to %s", t.addresses[start].Host, address.Host)
+			}
+			return resp, nil
+		}
+
+		// Don't try other servers when the request was canceled
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		t.logger.Debugf("Vault server at %s is unreachable: %v", address.Host, err)
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// withAddress returns a copy of the request sent to the given address.
+func withAddress(req *http.Request, address *url.URL) *http.Request {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = address.Scheme
+	r.URL.Host = address.Host
+	r.Host = ""
+
+	return r
+}
+
+// isReadRequest returns whether the request reads from Vault, and can be served by a performance standby.
+func isReadRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, "LIST":
+		return true
+	default:
+		return false
+	}
+}
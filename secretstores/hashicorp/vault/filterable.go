@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// ValidateFilteredRequest rejects the requests that secretstores.NewFilteringStore can't filter by name: those
+// reading a secret by absolute path, and those reading the secrets of another namespace than the component's.
+func (v *vaultSecretStore) ValidateFilteredRequest(metadata map[string]string) error {
+	if utils.IsTruthy(metadata[absolutePath]) {
+		return fmt.Errorf("the %s request metadata reads secrets outside of the engine path", absolutePath)
+	}
+	if namespace, ok := metadata[requestNamespace]; ok && strings.Trim(namespace, "/") != v.vaultNamespace {
+		return fmt.Errorf("the %s request metadata reads the secrets of another namespace", requestNamespace)
+	}
+
+	return nil
+}
+
+// ResolveSecretName returns the name of the secret that a GetSecret request reads, for
+// secretstores.NewFilteringStore. With vaultCaseInsensitiveLookup, it's the name of the secret found ignoring case,
+// searched in the engine paths like GetSecret does, which lists the folders of the secret.
+func (v *vaultSecretStore) ResolveSecretName(ctx context.Context, req secretstores.GetSecretRequest) (string, error) {
+	if !v.caseInsensitive || v.engineType == engineTypeDatabase || v.engineType == engineTypeCubbyhole {
+		return req.Name, nil
+	}
+	if err := v.ensureConnected(ctx); err != nil {
+		return "", err
+	}
+
+	enginePaths := v.vaultEnginePaths
+	if len(enginePaths) == 0 {
+		enginePaths = []string{v.vaultEnginePath}
+	}
+	for _, enginePath := range enginePaths {
+		name, err := v.findSecretNameIgnoringCase(ctx, enginePath, req.Name)
+		if err != nil {
+			return "", err
+		}
+		if name != "" {
+			return name, nil
+		}
+	}
+
+	return req.Name, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestFilteringStoreWithVault(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/":
+			w.Write([]byte(`{"data":{"keys":["Admin","Public"]}}`))
+		case r.URL.Path == "/v1/secret/data/dapr/Admin":
+			w.Write([]byte(`{"data":{"data":{"password":"admin"}}}`))
+		case r.URL.Path == "/v1/secret/data/dapr/Public":
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	store := secretstores.NewFilteringStore(NewHashiCorpVaultSecretStore(logger.NewLogger("test")))
+	require.NoError(t, store.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		componentVaultAddress:               server.URL,
+		componentVaultToken:                 expectedTok,
+		componentVaultNamespace:             "team",
+		componentCaseInsensitive:            "true",
+		componentAllowAbsolutePaths:         "true",
+		secretstores.FilterDeniedSecretsKey: "Admin",
+	}}}))
+	getSecret := func(name string, metadata map[string]string) (secretstores.GetSecretResponse, error) {
+		return store.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name, Metadata: metadata})
+	}
+
+	t.Run("allowed secrets are read", func(t *testing.T) {
+		resp, err := getSecret("public", map[string]string{requestNamespace: "/team/"})
+		require.NoError(t, err)
+		assert.Equal(t, "value", resp.Data["key"])
+	})
+
+	t.Run("a denied secret can't be read under another case", func(t *testing.T) {
+		_, err := getSecret("ADMIN", nil)
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+	})
+
+	t.Run("a denied secret can't be read by absolute path", func(t *testing.T) {
+		_, err := getSecret("secret/data/dapr/Admin", map[string]string{absolutePath: "true"})
+		assert.ErrorContains(t, err, "can't be filtered: the absolutePath request metadata reads secrets outside of the engine path")
+	})
+
+	t.Run("a denied secret can't be read from another namespace", func(t *testing.T) {
+		_, err := getSecret("public", map[string]string{requestNamespace: "other"})
+		assert.ErrorContains(t, err, "can't be filtered: the namespace request metadata reads the secrets of another namespace")
+
+		_, err = store.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+			Metadata: map[string]string{requestNamespace: "other"},
+		})
+		assert.ErrorContains(t, err, "secrets can't be filtered")
+	})
+}
+
+func TestFindSecretNameIgnoringCasePrefersExactName(t *testing.T) {
+	v := newTestVaultSecretStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"keys":["MYSECRET","MySecret","mysecret"]}}`))
+	}))
+
+	name, err := v.findSecretNameIgnoringCase(context.Background(), defaultVaultEnginePath, "MySecret")
+	require.NoError(t, err)
+	assert.Equal(t, "MySecret", name)
+}
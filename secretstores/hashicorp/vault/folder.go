@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrSecretIsFolder is returned when the name of a secret is the path of a folder of the KV engine, which has secrets
+// under it but no data of its own. It doesn't match secretstores.ErrSecretNotFound, so that it's never mistaken for
+// a missing secret.
+var ErrSecretIsFolder = errors.New("path is a folder, not a secret")
+
+// errStopListing stops the listing of a folder once its first key is read.
+var errStopListing = errors.New("stop listing")
+
+// folderError returns an error wrapping ErrSecretIsFolder if the name of a secret that wasn't found is a folder of the
+// engine, or nil otherwise. KV v2 answers a read of a folder with a 404, like for a missing secret: the folder is
+// told apart by listing it. Errors of the listing, such as a policy that doesn't allow it, are ignored, as the secret
+// is then reported as not found like before.
+func (v *vaultSecretStore) folderError(ctx context.Context, enginePath, secret string) error {
+	folder := strings.TrimSuffix(secret, "/") + "/"
+	httpReq, err := v.newVaultRequest(ctx, "LIST", v.vaultAddress+"/v1/"+v.kvEnginePath(enginePath, "metadata", folder), nil)
+	if err != nil {
+		return nil
+	}
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		return nil
+	}
+	defer httpresp.Body.Close()
+	if httpresp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, httpresp.Body)
+		return nil
+	}
+
+	var child string
+	err = decodeListKeys(httpresp.Body, func(key string) error {
+		child = key
+		return errStopListing
+	})
+	if child == "" || (err != nil && !errors.Is(err, errStopListing)) {
+		return nil
+	}
+
+	return fmt.Errorf("getSecret %s failed, %w: read a secret under it, such as %s, or use BulkGetSecret to read all the secrets under it",
+		secret, ErrSecretIsFolder, folder+strings.TrimSuffix(child, "/"))
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+func TestGetSecretFolder(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/team/":
+			w.Write([]byte(`{"data":{"keys":["apps/","db"]}}`))
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/restricted/":
+			w.WriteHeader(http.StatusForbidden)
+		case r.Method == "LIST" && r.URL.Path == "/v1/other/metadata/dapr/team/":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/v1/other/data/dapr/team":
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	t.Run("reading a folder returns a descriptive error", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "team"})
+		require.ErrorIs(t, err, ErrSecretIsFolder)
+		assert.NotErrorIs(t, err, secretstores.ErrSecretNotFound)
+		assert.EqualError(t, err, "getSecret team failed, path is a folder, not a secret: "+
+			"read a secret under it, such as team/apps, or use BulkGetSecret to read all the secrets under it")
+
+		_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "team/"})
+		assert.ErrorIs(t, err, ErrSecretIsFolder)
+	})
+
+	t.Run("folders aren't suppressed like missing secrets", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.suppressNotFound = true
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "team"})
+		assert.ErrorIs(t, err, ErrSecretIsFolder)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "missing"})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Data)
+	})
+
+	t.Run("missing secrets and unlistable paths are not found", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "missing"})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "restricted"})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("a secret of another engine path is read over a folder", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultEnginePaths = []string{"secret", "other"}
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "team"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+
+		v.vaultEnginePaths = []string{"other", "secret"}
+		_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "team/apps"})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
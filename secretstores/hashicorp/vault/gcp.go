@@ -0,0 +1,226 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// gcpAuthTypeIAM logs in with a JWT signed by the IAM Credentials API for the service account of the workload,
+	// which works on GCE and on GKE with Workload Identity.
+	gcpAuthTypeIAM = "iam"
+	// gcpAuthTypeGCE logs in with the identity token of the GCE instance.
+	gcpAuthTypeGCE = "gce"
+
+	// defaultGCPMetadataHost is the host of the metadata server of GCE and GKE. It can be overridden with the
+	// GCE_METADATA_HOST environment variable, like the Google Cloud client libraries do.
+	defaultGCPMetadataHost = "metadata.google.internal"
+	envGCPMetadataHost     = "GCE_METADATA_HOST"
+	// defaultIAMCredentialsURL is the address of the IAM Credentials API, which signs the JWTs of the iam type.
+	defaultIAMCredentialsURL = "https://iamcredentials.googleapis.com"
+
+	// gcpRequestTimeout is how long a request to the metadata server or the IAM Credentials API can take, so that
+	// Init fails quickly outside of GCP.
+	gcpRequestTimeout = 10 * time.Second
+	// gcpJWTLifetime is the lifetime of the JWTs signed for the iam type, below the 15 minutes accepted by Vault.
+	gcpJWTLifetime = 10 * time.Minute
+)
+
+// gcpAuth logs in with the GCP auth method, with a JWT signed for the service account or the instance the
+// component runs as.
+type gcpAuth struct {
+	v         *vaultSecretStore
+	mountPath string
+	role      string
+	authType  string
+	// metadataHost is the host of the metadata server, and iamURL the address of the IAM Credentials API.
+	metadataHost string
+	iamURL       string
+	client       *http.Client
+	now          func() time.Time
+}
+
+func newGCPAuth(v *vaultSecretStore, mountPath, role, authType string) gcpAuth {
+	metadataHost := os.Getenv(envGCPMetadataHost)
+	if metadataHost == "" {
+		metadataHost = defaultGCPMetadataHost
+	}
+	if authType == "" {
+		authType = gcpAuthTypeIAM
+	}
+
+	return gcpAuth{
+		v:            v,
+		mountPath:    mountPath,
+		role:         role,
+		authType:     authType,
+		metadataHost: metadataHost,
+		iamURL:       defaultIAMCredentialsURL,
+		// The metadata server is never reached through a proxy
+		client: &http.Client{Transport: &http.Transport{Proxy: nil}, Timeout: gcpRequestTimeout},
+		now:    time.Now,
+	}
+}
+
+func (a gcpAuth) String() string {
+	return fmt.Sprintf("GCP %s auth of role %s on mount %s", a.authType, a.role, a.mountPath)
+}
+
+func (a gcpAuth) login(ctx context.Context) (string, error) {
+	var (
+		jwt string
+		err error
+	)
+	if a.authType == gcpAuthTypeGCE {
+		jwt, err = a.gceJWT(ctx)
+	} else {
+		jwt, err = a.iamJWT(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	loginURL := fmt.Sprintf("%s/v1/auth/%s/login", a.v.vaultAddress, a.mountPath)
+	return a.v.login(ctx, loginURL, map[string]string{"role": a.role, "jwt": jwt}, "with GCP role "+a.role)
+}
+
+// gceJWT returns the identity token of the instance, for the audience expected by Vault.
+func (a gcpAuth) gceJWT(ctx context.Context) (string, error) {
+	query := url.Values{"audience": {"http://vault/" + a.role}, "format": {"full"}}
+	token, err := a.metadata(ctx, "instance/service-accounts/default/identity?"+query.Encode())
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimSpace(token)), nil
+}
+
+// iamJWT returns a JWT for the service account of the workload, signed by the IAM Credentials API with the access
+// token of the service account.
+func (a gcpAuth) iamJWT(ctx context.Context) (string, error) {
+	email, err := a.metadata(ctx, "instance/service-accounts/default/email")
+	if err != nil {
+		return "", err
+	}
+	tokenResp, err := a.metadata(ctx, "instance/service-accounts/default/token")
+	if err != nil {
+		return "", err
+	}
+	var accessToken struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.Unmarshal(tokenResp, &accessToken); err != nil {
+		return "", fmt.Errorf("couldn't decode the access token of the GCP metadata server: %w", decodeError(err))
+	}
+
+	serviceAccount := string(bytes.TrimSpace(email))
+	payload, err := json.Marshal(map[string]any{
+		"aud": "vault/" + a.role,
+		"sub": serviceAccount,
+		"exp": a.now().Add(gcpJWTLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(map[string]string{"payload": string(payload)})
+	if err != nil {
+		return "", err
+	}
+
+	signURL := fmt.Sprintf("%s/v1/projects/-/serviceAccounts/%s:signJwt", a.iamURL, url.PathEscape(serviceAccount))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, signURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("couldn't sign a JWT for service account %s with the IAM Credentials API: %w", serviceAccount, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("couldn't sign a JWT for service account %s with the IAM Credentials API, status code %d: the service account needs the iam.serviceAccounts.signJwt permission",
+			serviceAccount, resp.StatusCode)
+	}
+
+	var signed struct {
+		SignedJWT string `json:"signedJwt"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&signed); err != nil {
+		return "", decodeError(err)
+	}
+	if signed.SignedJWT == "" {
+		return "", errors.New("the IAM Credentials API didn't return a signed JWT")
+	}
+
+	return signed.SignedJWT, nil
+}
+
+// metadata returns the value of an entry of the metadata server.
+func (a gcpAuth) metadata(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+a.metadataHost+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	entry, _, _ := strings.Cut(path, "?")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't reach the GCP metadata server at %s, %s requires running on GCE or GKE: %w",
+			a.metadataHost, componentGCPRole, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("couldn't read %s from the GCP metadata server, status code %d", entry, resp.StatusCode)
+	}
+
+	// The entries are tokens, which are never included in the errors
+	return io.ReadAll(io.LimitReader(resp.Body, defaultMaxResponseBytes))
+}
+
+// validateGCPOptions checks the GCP auth type, and that the GCP role isn't combined with other credentials.
+func validateGCPOptions(m VaultMetadata) error {
+	var errs []error
+	switch m.VaultGCPAuthType {
+	case "", gcpAuthTypeIAM, gcpAuthTypeGCE:
+	default:
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s %q, accepted values are %q and %q",
+			componentGCPAuthType, m.VaultGCPAuthType, gcpAuthTypeIAM, gcpAuthTypeGCE))
+	}
+	if m.VaultToken != "" || m.VaultTokenMountPath != "" || m.VaultLDAPUsername != "" || m.VaultLDAPPassword != "" {
+		errs = append(errs, fmt.Errorf("vault init error, %s can't be used with %s, %s or the LDAP credentials",
+			componentGCPRole, componentVaultToken, componentVaultTokenMountPath))
+	}
+	if m.VaultUnwrapToken {
+		errs = append(errs, fmt.Errorf("vault init error, %s can't be used with %s", componentVaultUnwrapToken, componentGCPRole))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestGCPAuth(t *testing.T) {
+	const (
+		role           = "my-role"
+		serviceAccount = "app@project.iam.gserviceaccount.com"
+		accessToken    = "gcp-access-token"
+		gceJWT         = "gce-identity-jwt"
+		iamJWT         = "iam-signed-jwt"
+		gcpToken       = "gcp-vault-token"
+		secretPath     = "/v1/secret/data/dapr/conftestsecret"
+	)
+
+	// The metadata server of GCE, and the IAM Credentials API
+	var (
+		gotAudience   string
+		signedPayload map[string]any
+	)
+	gcp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/computeMetadata/") && r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/service-accounts/default/identity":
+			if !strings.HasPrefix(r.URL.Query().Get("audience"), "http://vault/") || r.URL.Query().Get("format") != "full" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			gotAudience = r.URL.Query().Get("audience")
+			w.Write([]byte(gceJWT))
+		case "/computeMetadata/v1/instance/service-accounts/default/email":
+			w.Write([]byte(serviceAccount))
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			fmt.Fprintf(w, `{"access_token":%q,"expires_in":3599,"token_type":"Bearer"}`, accessToken)
+		case "/v1/projects/-/serviceAccounts/" + serviceAccount + ":signJwt":
+			if r.Header.Get("Authorization") != "Bearer "+accessToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			var body struct {
+				Payload string `json:"payload"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			json.Unmarshal([]byte(body.Payload), &signedPayload)
+			fmt.Fprintf(w, `{"keyId":"1","signedJwt":%q}`, iamJWT)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer gcp.Close()
+
+	var logins []string
+	vault := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/gcp/login", "/v1/auth/gcp-prod/login":
+			var body struct {
+				Role string `json:"role"`
+				JWT  string `json:"jwt"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			logins = append(logins, r.URL.Path+" "+body.JWT)
+			if body.Role != role || (body.JWT != gceJWT && body.JWT != iamJWT) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["role \"` + body.Role + `\" not found"]}`))
+				return
+			}
+			fmt.Fprintf(w, `{"auth":{"client_token":%q}}`, gcpToken)
+		case secretPath:
+			if r.Header.Get(vaultHTTPHeader) != gcpToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"data":{"data":{"conftestsecret":"abcd"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	initStore := func(properties map[string]string) (*vaultSecretStore, error) {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		properties[componentVaultAddress] = server.URL
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+		return v, err
+	}
+
+	t.Run("gce logs in with the identity token of the instance", func(t *testing.T) {
+		t.Setenv(envGCPMetadataHost, strings.TrimPrefix(gcp.URL, "http://"))
+		logins = nil
+
+		v, err := initStore(map[string]string{
+			componentGCPRole:      role,
+			componentGCPAuthType:  gcpAuthTypeGCE,
+			componentGCPMountPath: "/gcp-prod/",
+		})
+		require.NoError(t, err)
+		defer v.Close()
+		assert.Equal(t, "http://vault/"+role, gotAudience)
+		assert.Equal(t, []string{"/v1/auth/gcp-prod/login " + gceJWT}, logins)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "conftestsecret"})
+		require.NoError(t, err)
+		assert.Equal(t, "abcd", resp.Data["conftestsecret"])
+	})
+
+	t.Run("iam logs in with a JWT signed for the service account", func(t *testing.T) {
+		t.Setenv(envGCPMetadataHost, strings.TrimPrefix(gcp.URL, "http://"))
+		logins = nil
+		now := time.Unix(1700000000, 0)
+
+		v := newTestVaultSecretStore(t, vault)
+		auth := newGCPAuth(v, "gcp", role, "")
+		auth.iamURL = gcp.URL
+		auth.now = func() time.Time { return now }
+
+		token, err := auth.login(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, gcpToken, token)
+		assert.Equal(t, []string{"/v1/auth/gcp/login " + iamJWT}, logins)
+		assert.Equal(t, map[string]any{
+			"aud": "vault/" + role,
+			"sub": serviceAccount,
+			"exp": float64(now.Add(gcpJWTLifetime).Unix()),
+		}, signedPayload)
+	})
+
+	t.Run("an unreachable metadata server fails Init", func(t *testing.T) {
+		t.Setenv(envGCPMetadataHost, "127.0.0.1:1")
+
+		_, err := initStore(map[string]string{componentGCPRole: role})
+		assert.ErrorContains(t, err, "couldn't reach the GCP metadata server at 127.0.0.1:1, vaultGCPRole requires running on GCE or GKE")
+	})
+
+	t.Run("a rejected login fails Init without revealing the JWT", func(t *testing.T) {
+		t.Setenv(envGCPMetadataHost, strings.TrimPrefix(gcp.URL, "http://"))
+
+		_, err := initStore(map[string]string{
+			componentGCPRole:     "other-role",
+			componentGCPAuthType: gcpAuthTypeGCE,
+		})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `couldn't log in with GCP role other-role, status code 400: role "other-role" not found`)
+		assert.NotContains(t, err.Error(), gceJWT)
+	})
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The interval between the attempts to connect during Init doubles after each attempt, up to the max.
+var (
+	initRetryInterval    = 500 * time.Millisecond
+	initRetryMaxInterval = 5 * time.Second
+)
+
+// connect reads the token, or logs in to obtain it, and unwraps it if needed. With vaultInitRetryTimeout, it also checks that Vault accepts
+// the token, and retries for up to the timeout, so that Init doesn't fail when Vault, or the agent writing the token
+// file, starts after the sidecar.
+func (v *vaultSecretStore) connect(ctx context.Context, m VaultMetadata) error {
+	unwrapped := false
+	attempt := func() error {
+		if err := v.authenticate(ctx); err != nil {
+			return err
+		}
+		// A wrapping token can be unwrapped only once, even if a later check fails
+		if m.VaultUnwrapToken && !unwrapped {
+			if err := v.unwrapToken(ctx); err != nil {
+				return fmt.Errorf("vault init error: %w", err)
+			}
+			unwrapped = true
+		}
+		if m.VaultInitRetryTimeout > 0 {
+			if _, err := v.lookupToken(ctx); err != nil {
+				return fmt.Errorf("vault init error, couldn't authenticate with %s: %w", v.vaultAddress, err)
+			}
+		}
+
+		return nil
+	}
+
+	err := attempt()
+	if err == nil || m.VaultInitRetryTimeout <= 0 {
+		return err
+	}
+
+	deadline := time.Now().Add(m.VaultInitRetryTimeout)
+	interval := initRetryInterval
+	for attempts := 1; ; attempts++ {
+		if errors.Is(err, ErrWrappingTokenInvalid) {
+			// Retrying can't make an invalid wrapping token valid
+			return err
+		}
+		left := time.Until(deadline)
+		if left <= 0 {
+			return fmt.Errorf("%w (gave up after %d attempts in %v)", err, attempts, m.VaultInitRetryTimeout)
+		}
+		wait := interval
+		if wait > left {
+			wait = left
+		}
+		v.logger.Infof("Vault isn't ready, retrying in %v: %v", wait, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (gave up after %d attempts: %w)", err, attempts, ctx.Err())
+		case <-time.After(wait):
+		}
+
+		if err = attempt(); err == nil {
+			v.logger.Infof("Connected to Vault after %d attempts", attempts+1)
+			return nil
+		}
+		interval *= 2
+		if interval > initRetryMaxInterval {
+			interval = initRetryMaxInterval
+		}
+	}
+}
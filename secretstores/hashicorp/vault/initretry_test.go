@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestVaultInitRetry(t *testing.T) {
+	interval, maxInterval := initRetryInterval, initRetryMaxInterval
+	initRetryInterval, initRetryMaxInterval = 20*time.Millisecond, 100*time.Millisecond
+	t.Cleanup(func() {
+		initRetryInterval, initRetryMaxInterval = interval, maxInterval
+	})
+
+	var lookups atomic.Int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/token/lookup-self" && r.Header.Get(vaultHTTPHeader) == expectedTok {
+			lookups.Add(1)
+			w.Write([]byte(`{"data":{"ttl":0,"expire_time":null}}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	initStore := func(properties map[string]string) error {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		return v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+	}
+
+	// freeAddress returns an address where nothing listens yet.
+	freeAddress := func(t *testing.T) string {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer l.Close()
+		return l.Addr().String()
+	}
+
+	t.Run("the component starts before Vault", func(t *testing.T) {
+		address := freeAddress(t)
+		started := make(chan *httptest.Server, 1)
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			l, err := net.Listen("tcp", address)
+			if err != nil {
+				started <- nil
+				return
+			}
+			server := httptest.NewUnstartedServer(handler)
+			server.Listener = l
+			server.Start()
+			started <- server
+		}()
+
+		err := initStore(map[string]string{
+			componentVaultAddress:     "http://" + address,
+			componentVaultToken:       expectedTok,
+			componentInitRetryTimeout: "10s",
+		})
+		server := <-started
+		require.NotNil(t, server, "couldn't listen on %s", address)
+		defer server.Close()
+
+		require.NoError(t, err)
+		assert.Positive(t, lookups.Load())
+	})
+
+	t.Run("the token file is written after the component starts", func(t *testing.T) {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			os.WriteFile(tokenFile, []byte(expectedTok), 0o600)
+		}()
+
+		err := initStore(map[string]string{
+			componentVaultAddress:        server.URL,
+			componentVaultTokenMountPath: tokenFile,
+			componentInitRetryTimeout:    "10s",
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("gives up after the timeout", func(t *testing.T) {
+		address := freeAddress(t)
+
+		start := time.Now()
+		err := initStore(map[string]string{
+			componentVaultAddress:     "http://" + address,
+			componentVaultToken:       expectedTok,
+			componentInitRetryTimeout: "300ms",
+		})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "couldn't authenticate with http://"+address)
+		assert.ErrorContains(t, err, "gave up after")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("a rejected token is retried until the timeout", func(t *testing.T) {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		err := initStore(map[string]string{
+			componentVaultAddress:     server.URL,
+			componentVaultToken:       "badToken",
+			componentInitRetryTimeout: "100ms",
+		})
+		assert.ErrorContains(t, err, "status code 403")
+	})
+
+	t.Run("Vault isn't contacted without a timeout", func(t *testing.T) {
+		err := initStore(map[string]string{
+			componentVaultAddress: "http://" + freeAddress(t),
+			componentVaultToken:   expectedTok,
+		})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// keyNameTransform is the transform applied to the keys of the secrets returned, set with vaultKeyNameTransform.
+type keyNameTransform string
+
+const (
+	keyNameTransformUpper keyNameTransform = "upper"
+	keyNameTransformLower keyNameTransform = "lower"
+	// keyNameTransformEnvVar turns the keys into environment variable names, such as MY_KEY for my-key.
+	keyNameTransformEnvVar keyNameTransform = "envvar"
+)
+
+// ErrKeyNameCollision is returned when several keys of a secret have the same name once transformed with
+// vaultKeyNameTransform, such as my-key and MY_KEY with envvar, as one of their values would be lost.
+var ErrKeyNameCollision = errors.New("keys of the secret collide once transformed")
+
+// apply returns the transformed name of a key.
+func (t keyNameTransform) apply(key string) string {
+	switch t {
+	case keyNameTransformUpper:
+		return strings.ToUpper(key)
+	case keyNameTransformLower:
+		return strings.ToLower(key)
+	case keyNameTransformEnvVar:
+		return strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+				return r
+			default:
+				return '_'
+			}
+		}, key)
+	default:
+		return key
+	}
+}
+
+// transformKeys returns the values of a secret with their keys transformed with vaultKeyNameTransform, or as they
+// are if it isn't set. The values are copied, as they may be cached.
+func (v *vaultSecretStore) transformKeys(secret string, values map[string]string) (map[string]string, error) {
+	if v.keyNameTransform == "" {
+		return values, nil
+	}
+
+	// The keys are sorted so that the error of a collision always names them in the same order
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	res := make(map[string]string, len(values))
+	from := make(map[string]string, len(values))
+	for _, key := range keys {
+		name := v.keyNameTransform.apply(key)
+		if other, ok := from[name]; ok {
+			return nil, fmt.Errorf("%w: %q and %q of secret %s are both %q with %s %s",
+				ErrKeyNameCollision, other, key, secret, name, componentKeyNameTransform, v.keyNameTransform)
+		}
+		from[name] = key
+		res[name] = values[key]
+	}
+
+	return res, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+func TestKeyNameTransform(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/metadata/dapr/":
+			w.Write([]byte(`{"data":{"keys":["app"]}}`))
+		case "/v1/secret/metadata/dapr/app":
+			w.Write([]byte(`{"data":{"versions":{"1":{"created_time":"2023-01-01T00:00:00Z"}}}}`))
+		case "/v1/secret/data/dapr/app":
+			w.Write([]byte(`{"data":{"data":{"my-key":"my-Value","db.Host":"db"}}}`))
+		case "/v1/secret/data/dapr/colliding":
+			w.Write([]byte(`{"data":{"data":{"my-key":"a","my_key":"b"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	getSecret := func(v *vaultSecretStore, name string, reqMetadata map[string]string) (map[string]string, error) {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name, Metadata: reqMetadata})
+		return resp.Data, err
+	}
+
+	t.Run("the keys are transformed and the values untouched", func(t *testing.T) {
+		for transform, expected := range map[keyNameTransform]map[string]string{
+			"":                     {"my-key": "my-Value", "db.Host": "db"},
+			keyNameTransformEnvVar: {"MY_KEY": "my-Value", "DB_HOST": "db"},
+			keyNameTransformUpper:  {"MY-KEY": "my-Value", "DB.HOST": "db"},
+			keyNameTransformLower:  {"my-key": "my-Value", "db.host": "db"},
+		} {
+			v := newTestVaultSecretStore(t, handler)
+			v.keyNameTransform = transform
+
+			data, err := getSecret(v, "app", nil)
+			require.NoError(t, err)
+			assert.Equal(t, expected, data, transform)
+		}
+	})
+
+	t.Run("the keys of bulk retrieval and all the versions are transformed", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.keyNameTransform = keyNameTransformEnvVar
+
+		resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{"app": {"MY_KEY": "my-Value", "DB_HOST": "db"}}, resp.Data)
+
+		data, err := getSecret(v, "app", map[string]string{allVersions: "true"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"v1.MY_KEY": "my-Value", "v1.DB_HOST": "db"}, data)
+	})
+
+	t.Run("colliding keys fail the read", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.keyNameTransform = keyNameTransformEnvVar
+
+		_, err := getSecret(v, "colliding", nil)
+		require.ErrorIs(t, err, ErrKeyNameCollision)
+		assert.EqualError(t, err, `keys of the secret collide once transformed: "my-key" and "my_key" of secret colliding are both "MY_KEY" with vaultKeyNameTransform envvar`)
+
+		v.keyNameTransform = keyNameTransformUpper
+		data, err := getSecret(v, "colliding", nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"MY-KEY": "a", "MY_KEY": "b"}, data)
+	})
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// lazyConnection connects to Vault on first use instead of during Init, with vaultLazyInit. Like sync.Once, the
+// concurrent first calls wait for a single attempt; unlike sync.Once, a failed attempt isn't remembered, so that the
+// next call tries again and the component recovers once Vault is up.
+type lazyConnection struct {
+	connect func(ctx context.Context) error
+
+	lock sync.Mutex
+	done atomic.Bool
+}
+
+func newLazyConnection(connect func(ctx context.Context) error) *lazyConnection {
+	return &lazyConnection{connect: connect}
+}
+
+// do connects, unless a previous call did already.
+func (l *lazyConnection) do(ctx context.Context) error {
+	if l.done.Load() {
+		return nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.done.Load() {
+		return nil
+	}
+	if err := l.connect(ctx); err != nil {
+		return err
+	}
+	l.done.Store(true)
+
+	return nil
+}
+
+// ensureConnected connects to Vault if the connection was deferred to the first use with vaultLazyInit.
+func (v *vaultSecretStore) ensureConnected(ctx context.Context) error {
+	if v.lazy == nil {
+		return nil
+	}
+	if err := v.lazy.do(ctx); err != nil {
+		return fmt.Errorf("couldn't connect to Vault on first use: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestLazyInit(t *testing.T) {
+	const ldapToken = "ldap-token"
+
+	var logins atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/ldap/login/jdoe":
+			logins.Add(1)
+			fmt.Fprintf(w, `{"auth":{"client_token":%q}}`, ldapToken)
+		case "/v1/secret/data/dapr/conftestsecret":
+			if r.Header.Get(vaultHTTPHeader) != ldapToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"data":{"data":{"conftestsecret":"abcd"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	// Vault isn't up yet: nothing listens on its address until startVault is called
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	startVault := func(t *testing.T) {
+		ln, err := net.Listen("tcp", addr)
+		require.NoError(t, err)
+		server := httptest.NewUnstartedServer(handler)
+		server.Listener.Close()
+		server.Listener = ln
+		server.Start()
+		t.Cleanup(server.Close)
+	}
+
+	properties := func(lazy string) map[string]string {
+		return map[string]string{
+			componentVaultAddress: "http://" + addr,
+			componentLDAPUsername: "jdoe",
+			componentLDAPPassword: "s3cr3t",
+			componentLazyInit:     lazy,
+		}
+	}
+	getSecret := func(v *vaultSecretStore) (secretstores.GetSecretResponse, error) {
+		return v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "conftestsecret"})
+	}
+
+	t.Run("Init fails while Vault is down by default", func(t *testing.T) {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties("false")}})
+		assert.ErrorContains(t, err, "LDAP auth of user jdoe")
+	})
+
+	t.Run("the component recovers once Vault is up", func(t *testing.T) {
+		logins.Store(0)
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties("true")}}))
+		defer v.Close()
+
+		_, err := getSecret(v)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "couldn't connect to Vault on first use")
+
+		startVault(t)
+
+		// The concurrent first requests log in once
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := getSecret(v)
+				if assert.NoError(t, err) {
+					assert.Equal(t, "abcd", resp.Data["conftestsecret"])
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), logins.Load())
+
+		_, err = getSecret(v)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), logins.Load())
+	})
+}
+
+func TestLazyConnection(t *testing.T) {
+	errDown := errors.New("vault is down")
+	var attempts int
+	l := newLazyConnection(func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errDown
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, l.do(context.Background()), errDown)
+	assert.ErrorIs(t, l.do(context.Background()), errDown)
+	assert.NoError(t, l.do(context.Background()))
+	assert.NoError(t, l.do(context.Background()))
+	assert.Equal(t, 3, attempts)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ldapAuth logs in with the LDAP auth method, with the credentials of an LDAP user.
+type ldapAuth struct {
+	v         *vaultSecretStore
+	mountPath string
+	username  string
+	password  string
+}
+
+// String describes the auth method without its password, so that it's never logged.
+func (a ldapAuth) String() string {
+	return fmt.Sprintf("LDAP auth of user %s on mount %s", a.username, a.mountPath)
+}
+
+// GoString is like String, for the %#v verb.
+func (a ldapAuth) GoString() string {
+	return a.String()
+}
+
+func (a ldapAuth) login(ctx context.Context) (string, error) {
+	loginURL := fmt.Sprintf("%s/v1/auth/%s/login/%s", a.v.vaultAddress, a.mountPath, url.PathEscape(a.username))
+	return a.v.login(ctx, loginURL, map[string]string{"password": a.password}, "as LDAP user "+a.username)
+}
+
+// validateLDAPOptions checks that the LDAP credentials are either both set or both unset, and aren't combined with
+// a token.
+func validateLDAPOptions(m VaultMetadata) error {
+	if m.VaultLDAPUsername == "" && m.VaultLDAPPassword == "" {
+		return nil
+	}
+
+	var errs []error
+	if m.VaultLDAPUsername == "" || m.VaultLDAPPassword == "" {
+		errs = append(errs, fmt.Errorf("vault init error, %s and %s must be set together", componentLDAPUsername, componentLDAPPassword))
+	}
+	if m.VaultToken != "" || m.VaultTokenMountPath != "" {
+		errs = append(errs, fmt.Errorf("vault init error, the LDAP credentials can't be used with %s or %s", componentVaultToken, componentVaultTokenMountPath))
+	}
+	if m.VaultUnwrapToken {
+		errs = append(errs, fmt.Errorf("vault init error, %s can't be used with the LDAP credentials", componentVaultUnwrapToken))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestLDAPAuth(t *testing.T) {
+	const (
+		username   = "jdoe"
+		password   = "s3cr3t-p4ssw0rd"
+		ldapToken  = "ldap-token"
+		secretPath = "/v1/secret/data/dapr/conftestsecret"
+	)
+
+	var logins []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/ldap/login/" + username, "/v1/auth/corp-ldap/login/" + username:
+			var body struct {
+				Password string `json:"password"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			logins = append(logins, r.URL.Path)
+			if body.Password != password || r.Header.Get(vaultHTTPHeader) != "" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["ldap operation failed: failed to bind as user"]}`))
+				return
+			}
+			fmt.Fprintf(w, `{"auth":{"client_token":%q}}`, ldapToken)
+		case secretPath:
+			if r.Header.Get(vaultHTTPHeader) != ldapToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"data":{"data":{"conftestsecret":"abcd"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	initStore := func(properties map[string]string) (*vaultSecretStore, error) {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		properties[componentVaultAddress] = server.URL
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+		return v, err
+	}
+
+	t.Run("logs in and uses the token", func(t *testing.T) {
+		logins = nil
+		v, err := initStore(map[string]string{
+			componentLDAPUsername: username,
+			componentLDAPPassword: password,
+		})
+		require.NoError(t, err)
+		defer v.Close()
+		assert.Equal(t, []string{"/v1/auth/ldap/login/" + username}, logins)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "conftestsecret"})
+		require.NoError(t, err)
+		assert.Equal(t, "abcd", resp.Data["conftestsecret"])
+	})
+
+	t.Run("custom mount path", func(t *testing.T) {
+		logins = nil
+		v, err := initStore(map[string]string{
+			componentLDAPUsername:  username,
+			componentLDAPPassword:  password,
+			componentLDAPMountPath: "/corp-ldap/",
+		})
+		require.NoError(t, err)
+		defer v.Close()
+		assert.Equal(t, []string{"/v1/auth/corp-ldap/login/" + username}, logins)
+	})
+
+	t.Run("invalid credentials fail Init without revealing the password", func(t *testing.T) {
+		_, err := initStore(map[string]string{
+			componentLDAPUsername: username,
+			componentLDAPPassword: "wrong-" + password,
+		})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "couldn't log in as LDAP user jdoe, status code 400: ldap operation failed")
+		assert.NotContains(t, err.Error(), password)
+	})
+
+	t.Run("the password is redacted when the auth method is printed", func(t *testing.T) {
+		auth := ldapAuth{mountPath: "ldap", username: username, password: password}
+		for _, format := range []string{"%v", "%+v", "%s", "%#v"} {
+			assert.NotContains(t, fmt.Sprintf(format, auth), password, format)
+		}
+	})
+}
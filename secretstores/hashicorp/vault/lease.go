@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"fmt"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// Keys under which the lease of a secret is added to its values with the "includeLeaseInfo" request metadata, as the
+// metadata of the responses isn't returned to applications by the Dapr API. Their prefix sets them apart from the
+// keys of the secrets.
+const (
+	leaseInfoKeyPrefix     = "__vault."
+	leaseInfoLeaseID       = leaseInfoKeyPrefix + secretMetadataLeaseID
+	leaseInfoLeaseDuration = leaseInfoKeyPrefix + secretMetadataLeaseDuration
+	leaseInfoRenewable     = leaseInfoKeyPrefix + secretMetadataRenewable
+)
+
+// withLeaseInfo returns a copy of the values of the secret with its lease ID, its duration in seconds and whether
+// it's renewable. Secrets without a lease, such as the ones of the KV engine, have an empty lease ID and a duration
+// of 0. It fails if the secret has a key reserved for the lease, rather than replacing its value.
+func withLeaseInfo(secret string, resp secretstores.GetSecretResponse) (map[string]string, error) {
+	res := make(map[string]string, len(resp.Data)+3)
+	for key, value := range resp.Data {
+		res[key] = value
+	}
+
+	lease := map[string]string{
+		leaseInfoLeaseID:       resp.Metadata[secretMetadataLeaseID],
+		leaseInfoLeaseDuration: "0",
+		leaseInfoRenewable:     "false",
+	}
+	if duration, ok := resp.Metadata[secretMetadataLeaseDuration]; ok {
+		lease[leaseInfoLeaseDuration] = duration
+		lease[leaseInfoRenewable] = resp.Metadata[secretMetadataRenewable]
+	}
+	for key, value := range lease {
+		if _, ok := res[key]; ok {
+			return nil, fmt.Errorf("couldn't add the lease of secret %s: its key %s is reserved for the lease", secret, key)
+		}
+		res[key] = value
+	}
+
+	return res, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+func TestIncludeLeaseInfo(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/database/creds/readonly":
+			w.Write([]byte(`{"lease_id":"database/creds/readonly/abc","lease_duration":3600,"renewable":true,` +
+				`"data":{"username":"v-token-readonly-xyz","password":"generated"}}`))
+		case "/v1/secret/data/dapr/static":
+			w.Write([]byte(`{"lease_id":"","lease_duration":0,"renewable":false,"data":{"data":{"key":"value"}}}`))
+		case "/v1/secret/data/dapr/reserved":
+			w.Write([]byte(`{"data":{"data":{"__vault.lease_id":"mine"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	withLease := map[string]string{includeLeaseInfo: "true"}
+
+	t.Run("the lease of dynamic secrets is included", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.engineType = engineTypeDatabase
+		v.vaultEnginePath = defaultVaultDatabaseEnginePath
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "readonly", Metadata: withLease})
+		require.NoError(t, err)
+		assert.Equal(t, "v-token-readonly-xyz", resp.Data["username"])
+		assert.Equal(t, "database/creds/readonly/abc", resp.Data[leaseInfoLeaseID])
+
+		duration, err := strconv.Atoi(resp.Data[leaseInfoLeaseDuration])
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, time.Duration(duration)*time.Second)
+		renewable, err := strconv.ParseBool(resp.Data[leaseInfoRenewable])
+		require.NoError(t, err)
+		assert.True(t, renewable)
+	})
+
+	t.Run("the lease is not included by default", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.engineType = engineTypeDatabase
+		v.vaultEnginePath = defaultVaultDatabaseEnginePath
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "readonly"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"username": "v-token-readonly-xyz", "password": "generated"}, resp.Data)
+	})
+
+	t.Run("secrets without a lease", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.cache = newSecretCache(time.Minute)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "static", Metadata: withLease})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"key":                  "value",
+			leaseInfoLeaseID:       "",
+			leaseInfoLeaseDuration: "0",
+			leaseInfoRenewable:     "false",
+		}, resp.Data)
+
+		// The cached secret is not changed
+		resp, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "static"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+	})
+
+	t.Run("keys of the secret are not replaced", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "reserved", Metadata: withLease})
+		assert.ErrorContains(t, err, "its key __vault.lease_id is reserved for the lease")
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "reserved"})
+		require.NoError(t, err)
+		assert.Equal(t, "mine", resp.Data[leaseInfoLeaseID])
+	})
+}
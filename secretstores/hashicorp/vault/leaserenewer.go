@@ -0,0 +1,205 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// errLeaseInvalid is returned when Vault refuses to renew a lease, because it expired, was revoked, or the token
+// isn't allowed to renew it. Renewing it again would fail the same way.
+var errLeaseInvalid = errors.New("the lease can't be renewed")
+
+// vaultLeaseRenewResponse is the response data from Vault's sys/leases/renew endpoint.
+type vaultLeaseRenewResponse struct {
+	LeaseID       string `json:"lease_id"`
+	LeaseDuration int64  `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// leaseRenewer renews the leases of the dynamic secrets returned by GetSecret, such as database credentials, so
+// that they remain valid while the application uses them. Each lease is renewed until it can't be anymore, because
+// it reached its max TTL or Vault refuses to renew it, or until the component is closed.
+type leaseRenewer struct {
+	v   *vaultSecretStore
+	ctx context.Context
+
+	lock   sync.Mutex
+	leases map[string]struct{}
+	closed bool
+}
+
+func newLeaseRenewer(ctx context.Context, v *vaultSecretStore) *leaseRenewer {
+	return &leaseRenewer{
+		v:      v,
+		ctx:    ctx,
+		leases: map[string]struct{}{},
+	}
+}
+
+// trackSecret starts renewing the lease of a secret, if it has a renewable one.
+func (r *leaseRenewer) trackSecret(resp secretstores.GetSecretResponse) {
+	leaseID := resp.Metadata[secretMetadataLeaseID]
+	if leaseID == "" || resp.Metadata[secretMetadataRenewable] != "true" {
+		return
+	}
+	duration, err := strconv.ParseInt(resp.Metadata[secretMetadataLeaseDuration], 10, 64)
+	if err != nil || duration <= 0 {
+		return
+	}
+
+	r.track(leaseID, time.Duration(duration)*time.Second)
+}
+
+// track starts renewing a lease with the given TTL in background. Leases that are tracked already, and the ones
+// read after the component is closed, are ignored.
+func (r *leaseRenewer) track(leaseID string, ttl time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.leases[leaseID]; ok || r.closed {
+		return
+	}
+	r.leases[leaseID] = struct{}{}
+
+	r.v.wg.Add(1)
+	go func() {
+		defer r.v.wg.Done()
+		defer r.drop(leaseID)
+
+		r.renewUntilExpired(leaseID, ttl)
+	}()
+}
+
+// stop prevents new leases from being tracked, so that Close can wait for the renewals in progress.
+func (r *leaseRenewer) stop() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.closed = true
+}
+
+func (r *leaseRenewer) drop(leaseID string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.leases, leaseID)
+}
+
+// tracked returns the number of leases being renewed.
+func (r *leaseRenewer) tracked() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return len(r.leases)
+}
+
+// renewUntilExpired renews a lease when two thirds of its TTL have elapsed, until it can't be renewed anymore or
+// the context is canceled. Failed renewals are retried until the lease expires.
+func (r *leaseRenewer) renewUntilExpired(leaseID string, ttl time.Duration) {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = tokenRenewRetryInitialInterval
+	bo.MaxInterval = tokenRenewRetryMaxInterval
+	bo.MaxElapsedTime = 0
+
+	expiry := time.Now().Add(ttl)
+	wait := ttl * 2 / 3
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		newTTL, renewable, err := r.v.renewLease(r.ctx, leaseID)
+		switch {
+		case r.ctx.Err() != nil:
+			return
+		case errors.Is(err, errLeaseInvalid):
+			r.v.logger.Warnf("Stopped renewing the lease %s: %v", leaseID, err)
+			return
+		case err != nil:
+			wait = bo.NextBackOff()
+			if time.Now().Add(wait).After(expiry) {
+				r.v.logger.Warnf("Stopped renewing the lease %s, which expires before the renewal can be retried: %v", leaseID, err)
+				return
+			}
+			r.v.logger.Warnf("Failed to renew the lease %s, retrying in %v: %v", leaseID, wait, err)
+			continue
+		}
+
+		newExpiry := time.Now().Add(newTTL)
+		if !renewable || newTTL <= 0 || !newExpiry.After(expiry) {
+			// Renewals can't extend the lease past its max TTL: the secret must be read again when it expires
+			r.v.logger.Infof("The lease %s can't be extended anymore, it expires in %v", leaseID, newTTL)
+			return
+		}
+
+		bo.Reset()
+		expiry = newExpiry
+		wait = newTTL * 2 / 3
+	}
+}
+
+// renewLease renews a lease, and returns its new TTL and whether it can be renewed again.
+func (v *vaultSecretStore) renewLease(ctx context.Context, leaseID string) (time.Duration, bool, error) {
+	body, err := json.Marshal(map[string]string{"lease_id": leaseID})
+	if err != nil {
+		return 0, false, fmt.Errorf("couldn't encode request body: %w", err)
+	}
+	httpReq, err := v.newVaultRequest(ctx, http.MethodPut, v.vaultAddress+"/v1/sys/leases/renew", bytes.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("couldn't generate request: %w", err)
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationRenew)
+		return 0, false, fmt.Errorf("couldn't renew lease: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode != http.StatusOK {
+		recordCount(ctx, requestErrors, operationRenew)
+		io.Copy(io.Discard, httpresp.Body)
+		err = fmt.Errorf("couldn't renew lease, status code %d", httpresp.StatusCode)
+		switch httpresp.StatusCode {
+		case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound:
+			return 0, false, fmt.Errorf("%w: %w", errLeaseInvalid, err)
+		}
+		return 0, false, err
+	}
+
+	var d vaultLeaseRenewResponse
+	if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
+		return 0, false, decodeError(err)
+	}
+	ttl := time.Duration(d.LeaseDuration) * time.Second
+	v.logger.Debugf("Renewed the lease %s, which now expires in %v", leaseID, ttl)
+
+	return ttl, d.Renewable, nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestLeaseRenewer(t *testing.T) {
+	const leaseID = "database/creds/readonly/abc"
+
+	// fakeLeases serves credentials with a one second lease, and records the renewals of the lease.
+	type fakeLeases struct {
+		lock      sync.Mutex
+		renewals  []time.Time
+		renewable bool
+		status    int
+	}
+	newHandler := func(f *fakeLeases) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/database/creds/readonly":
+				w.Write([]byte(`{"lease_id":"` + leaseID + `","lease_duration":1,"renewable":true,` +
+					`"data":{"username":"v-token-readonly-xyz","password":"generated"}}`))
+			case "/v1/database/creds/static":
+				w.Write([]byte(`{"lease_id":"database/creds/static/abc","lease_duration":1,"renewable":false,` +
+					`"data":{"username":"static","password":"generated"}}`))
+			case "/v1/sys/leases/renew":
+				var body map[string]string
+				json.NewDecoder(r.Body).Decode(&body)
+				f.lock.Lock()
+				defer f.lock.Unlock()
+				if r.Method != http.MethodPut || body["lease_id"] != leaseID {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				f.renewals = append(f.renewals, time.Now())
+				if f.status != 0 {
+					w.WriteHeader(f.status)
+					w.Write([]byte(`{"errors":["lease not found"]}`))
+					return
+				}
+				json.NewEncoder(w).Encode(vaultLeaseRenewResponse{LeaseID: leaseID, LeaseDuration: 1, Renewable: f.renewable})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+	}
+	renewals := func(f *fakeLeases) []time.Time {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		return append([]time.Time(nil), f.renewals...)
+	}
+	newStore := func(t *testing.T, f *fakeLeases) *vaultSecretStore {
+		v := newTestVaultSecretStore(t, newHandler(f))
+		v.engineType = engineTypeDatabase
+		v.vaultEnginePath = defaultVaultDatabaseEnginePath
+		ctx, cancel := context.WithCancel(context.Background())
+		v.closeCancel = cancel
+		v.leases = newLeaseRenewer(ctx, v)
+		t.Cleanup(func() { v.Close() })
+		return v
+	}
+
+	t.Run("leases are renewed before they expire", func(t *testing.T) {
+		f := &fakeLeases{renewable: true}
+		v := newStore(t, f)
+
+		read := time.Now()
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "readonly"})
+		require.NoError(t, err)
+		// Reading the secret again doesn't renew the lease twice as often
+		_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "readonly"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, v.leases.tracked())
+
+		require.Eventually(t, func() bool { return len(renewals(f)) >= 2 }, 5*time.Second, 10*time.Millisecond)
+		r := renewals(f)
+		assert.Less(t, r[0].Sub(read), time.Second, "the lease was renewed after it expired")
+		assert.Less(t, r[1].Sub(r[0]), time.Second, "the renewed lease was renewed after it expired")
+		assert.Equal(t, 1, v.leases.tracked())
+
+		require.NoError(t, v.Close())
+		count := len(renewals(f))
+		time.Sleep(time.Second)
+		assert.Len(t, renewals(f), count, "the lease was renewed after the component was closed")
+		assert.Equal(t, 0, v.leases.tracked())
+	})
+
+	t.Run("leases that Vault refuses to renew are dropped", func(t *testing.T) {
+		f := &fakeLeases{renewable: true, status: http.StatusBadRequest}
+		v := newStore(t, f)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "readonly"})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return v.leases.tracked() == 0 }, 5*time.Second, 10*time.Millisecond)
+		assert.Len(t, renewals(f), 1)
+	})
+
+	t.Run("leases that reach their max TTL are dropped", func(t *testing.T) {
+		f := &fakeLeases{renewable: false}
+		v := newStore(t, f)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "readonly"})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return v.leases.tracked() == 0 }, 5*time.Second, 10*time.Millisecond)
+		assert.Len(t, renewals(f), 1)
+	})
+
+	t.Run("leases that aren't renewable aren't tracked", func(t *testing.T) {
+		f := &fakeLeases{}
+		v := newStore(t, f)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "static"})
+		require.NoError(t, err)
+		assert.Equal(t, 0, v.leases.tracked())
+	})
+
+	t.Run("leases read after the component is closed aren't tracked", func(t *testing.T) {
+		f := &fakeLeases{renewable: true}
+		v := newStore(t, f)
+		require.NoError(t, v.Close())
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "readonly"})
+		require.NoError(t, err)
+		assert.Equal(t, 0, v.leases.tracked())
+	})
+
+	t.Run("init", func(t *testing.T) {
+		initStore := func(properties map[string]string) *vaultSecretStore {
+			v := &vaultSecretStore{logger: logger.NewLogger("test")}
+			properties[componentVaultToken] = expectedTok
+			require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}}))
+			t.Cleanup(func() { v.Close() })
+			return v
+		}
+
+		assert.NotNil(t, initStore(map[string]string{componentAutoRenewLeases: "true"}).leases)
+		assert.Nil(t, initStore(map[string]string{}).leases)
+	})
+}
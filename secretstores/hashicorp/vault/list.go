@@ -0,0 +1,248 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// errInvalidListResponse is returned when a LIST response doesn't have the expected structure.
+var errInvalidListResponse = errors.New("couldn't decode response body: invalid JSON")
+
+// walkKeysUnderPath calls fn with the keys of the secrets under a path, recursively, as they're listed: the keys of a
+// folder are decoded from the LIST response one at a time, so that the keys of a large mount are never held in memory
+// all together. Only the names of the sub-folders of a folder are kept, to list them once it's done, so that a single
+// LIST response is read at a time. The keys include the path as prefix, which must not start with a slash.
+func (v *vaultSecretStore) walkKeysUnderPath(ctx context.Context, path string, fn func(key string) error) error {
+	if path == "" && v.hasSeparatedPrefix() {
+		return v.walkPrefixedKeys(ctx, fn)
+	}
+
+	var folders []string
+	err := v.listFolder(ctx, v.kvPath("metadata", path), func(key string) error {
+		if !v.isSecretPath(key) {
+			folders = append(folders, path+key)
+			return nil
+		}
+		return fn(path + key)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, folder := range folders {
+		if err := v.walkKeysUnderPath(ctx, folder, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkPrefixedKeys walks the keys when the KV prefix is followed by a separator other than a slash: the secrets at
+// the root are then stored next to the prefix, in its folder, rather than under it.
+func (v *vaultSecretStore) walkPrefixedKeys(ctx context.Context, fn func(key string) error) error {
+	folder, name := splitSecretFolder(v.vaultKVPrefix)
+
+	var folders []string
+	err := v.listFolder(ctx, v.vaultEnginePath+"/metadata/"+folder, func(key string) error {
+		secret, ok := strings.CutPrefix(key, name+v.separator())
+		if !ok || secret == "" {
+			return nil
+		}
+		if !v.isSecretPath(key) {
+			folders = append(folders, secret)
+			return nil
+		}
+		return fn(secret)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, folder := range folders {
+		if err := v.walkKeysUnderPath(ctx, folder, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listKeysUnderPath get all the keys recursively under a given path.(returned keys including path as prefix)
+// path should not has `/` prefix.
+func (v *vaultSecretStore) listKeysUnderPath(ctx context.Context, path string) ([]string, error) {
+	var res []string
+	err := v.walkKeysUnderPath(ctx, path, func(key string) error {
+		res = append(res, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// listFolder calls fn with the keys of a folder of the KV engine, with a trailing slash for the sub-folders, as
+// they're decoded from the response.
+func (v *vaultSecretStore) listFolder(ctx context.Context, path string, fn func(key string) error) error {
+	// Create list secrets url
+	vaultSecretsPathAddr := v.vaultAddress + "/v1/" + path
+
+	httpReq, err := v.newVaultRequest(ctx, "LIST", vaultSecretsPathAddr, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't generate request: %s", err)
+	}
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationList)
+		return fmt.Errorf("couldn't get secret: %w", err)
+	}
+
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusForbidden {
+		return v.permissionDenied(ctx, operationList, httpReq.URL.Path)
+	}
+	if httpresp.StatusCode != http.StatusOK {
+		recordCount(ctx, requestErrors, operationList)
+		io.Copy(io.Discard, httpresp.Body)
+		return fmt.Errorf("list keys of %s couldn't get successful response, status code %d", path, httpresp.StatusCode)
+	}
+
+	return decodeListKeys(httpresp.Body, fn)
+}
+
+// decodeListKeys calls fn with each key of a LIST response, {"data":{"keys":[...]}}, as it's decoded. The other
+// fields of the response are skipped. The errors of fn are returned as they are.
+func decodeListKeys(r io.Reader, fn func(key string) error) error {
+	dec := json.NewDecoder(r)
+	ok, err := openValue(dec, '{')
+	if err != nil || !ok {
+		return err
+	}
+
+	for dec.More() {
+		field, err := dec.Token()
+		if err != nil {
+			return decodeError(err)
+		}
+		if field != "data" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := decodeListData(dec, fn); err != nil {
+			return err
+		}
+	}
+
+	return closeValue(dec)
+}
+
+// decodeListData decodes the data object of a LIST response, calling fn with its keys.
+func decodeListData(dec *json.Decoder, fn func(key string) error) error {
+	ok, err := openValue(dec, '{')
+	if err != nil || !ok {
+		return err
+	}
+
+	for dec.More() {
+		field, err := dec.Token()
+		if err != nil {
+			return decodeError(err)
+		}
+		if field != "keys" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ok, err := openValue(dec, '[')
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		for dec.More() {
+			var key string
+			if err := dec.Decode(&key); err != nil {
+				return decodeError(err)
+			}
+			if err := fn(key); err != nil {
+				return err
+			}
+		}
+		if err := closeValue(dec); err != nil {
+			return err
+		}
+	}
+
+	return closeValue(dec)
+}
+
+// openValue reads the opening delimiter of an object or array, and returns false if the value is null instead.
+func openValue(dec *json.Decoder, delim json.Delim) (bool, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return false, decodeError(err)
+	}
+	if token == nil {
+		return false, nil
+	}
+	if token != delim {
+		return false, errInvalidListResponse
+	}
+
+	return true, nil
+}
+
+// closeValue reads the closing delimiter of the current object or array.
+func closeValue(dec *json.Decoder) error {
+	if _, err := dec.Token(); err != nil {
+		return decodeError(err)
+	}
+
+	return nil
+}
+
+// skipValue skips the next value, which may be an object or an array, without holding it in memory.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return decodeError(err)
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
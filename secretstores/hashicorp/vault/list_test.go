@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+func TestDecodeListKeys(t *testing.T) {
+	decode := func(body string) ([]string, error) {
+		var keys []string
+		err := decodeListKeys(strings.NewReader(body), func(key string) error {
+			keys = append(keys, key)
+			return nil
+		})
+		return keys, err
+	}
+
+	t.Run("keys", func(t *testing.T) {
+		keys, err := decode(`{"request_id":"1","lease_id":"","data":{"keys":["a","b/"]},"wrap_info":null,"warnings":null}`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b/"}, keys)
+	})
+
+	t.Run("other fields of the data are skipped", func(t *testing.T) {
+		keys, err := decode(`{"data":{"key_info":{"a":{"nested":[1,{"x":[]}]}},"keys":["a"],"other":[["c"]]}}`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, keys)
+	})
+
+	t.Run("null values", func(t *testing.T) {
+		keys, err := decode(`{"data":{"keys":null}}`)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+
+		keys, err = decode(`{"data":null}`)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("malformed responses", func(t *testing.T) {
+		_, err := decode(`{"data":{"keys":["a", "s3cr3t}}`)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "s3cr3t")
+
+		_, err = decode(`{"data":{"keys":{"a":"b"}}}`)
+		assert.EqualError(t, err, "couldn't decode response body: invalid JSON")
+
+		_, err = decode(`{"data":{"keys":[1]}}`)
+		assert.ErrorContains(t, err, "isn't a valid string")
+	})
+
+	t.Run("errors of the callback stop decoding", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := decodeListKeys(strings.NewReader(`{"data":{"keys":["a","b","c"]}}`), func(key string) error {
+			calls++
+			return errStop
+		})
+		assert.ErrorIs(t, err, errStop)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+// countingBody counts the bytes read from a response body.
+type countingBody struct {
+	io.ReadCloser
+	read *atomic.Int64
+}
+
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read.Add(int64(n))
+	return n, err
+}
+
+// countingTransport counts the bytes read from the bodies of the LIST responses.
+type countingTransport struct {
+	next http.RoundTripper
+	read atomic.Int64
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.next.RoundTrip(req)
+	if err == nil && req.Method == "LIST" {
+		resp.Body = countingBody{ReadCloser: resp.Body, read: &c.read}
+	}
+	return resp, err
+}
+
+func TestBulkGetSecretManyKeys(t *testing.T) {
+	const (
+		secrets  = 5000
+		pageSize = 8
+	)
+
+	keys := make([]string, secrets)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("secret-%05d", i)
+	}
+	listBody, err := json.Marshal(map[string]any{"data": map[string]any{"keys": keys}})
+	require.NoError(t, err)
+
+	var (
+		transport *countingTransport
+		// listReadAtFirstRead is the number of bytes of the LIST response read when the first secret is read.
+		listReadAtFirstRead   atomic.Int64
+		inFlight, maxInFlight atomic.Int32
+	)
+	listReadAtFirstRead.Store(-1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/secret/metadata/dapr/" {
+			w.Write(listBody)
+			return
+		}
+		listReadAtFirstRead.CompareAndSwap(-1, transport.read.Load())
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		// The reads of a page overlap
+		time.Sleep(time.Millisecond)
+		fmt.Fprintf(w, `{"data":{"data":{"name":%q}}}`, strings.TrimPrefix(r.URL.Path, "/v1/secret/data/dapr/"))
+	})
+	v := newTestVaultSecretStore(t, handler)
+	v.bulkPageSize = pageSize
+	transport = &countingTransport{next: v.client.Transport}
+	v.client.Transport = transport
+
+	resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+	require.NoError(t, err)
+
+	require.Len(t, resp.Data, secrets)
+	assert.Equal(t, map[string]string{"name": "secret-04242"}, resp.Data["secret-04242"])
+	assert.Equal(t, int64(len(listBody)), transport.read.Load())
+	// The secrets are read while the keys are being listed, rather than once they're all held in memory
+	assert.Less(t, listReadAtFirstRead.Load(), int64(len(listBody)/2))
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(pageSize))
+	assert.Greater(t, maxInFlight.Load(), int32(1))
+}
+
+func TestBulkGetSecretPath(t *testing.T) {
+	// Secrets under two subtrees of the KV prefix
+	secrets := map[string]string{
+		"team-a/db":         "a-db",
+		"team-a/nested/api": "a-api",
+		"team-b/db":         "b-db",
+		"team-b/cache":      "b-cache",
+		"root":              "root",
+	}
+	folders := map[string]string{
+		"/v1/secret/metadata/dapr/":               `["root","team-a/","team-b/"]`,
+		"/v1/secret/metadata/dapr/team-a/":        `["db","nested/"]`,
+		"/v1/secret/metadata/dapr/team-a/nested/": `["api"]`,
+		"/v1/secret/metadata/dapr/team-b/":        `["cache","db"]`,
+	}
+	var (
+		lock  sync.Mutex
+		paths []string
+	)
+	v := newTestVaultSecretStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		paths = append(paths, r.URL.Path)
+		lock.Unlock()
+		if r.Method == "LIST" {
+			keys, ok := folders[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"data":{"keys":%s}}`, keys)
+			return
+		}
+		value, ok := secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/dapr/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"data":{"value":%q}}}`, value)
+	}))
+	v.bulkPageSize = 2
+
+	t.Run("only the secrets under the path are listed and read", func(t *testing.T) {
+		for _, path := range []string{"team-a", "/team-a/"} {
+			paths = nil
+			resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+				Metadata: map[string]string{bulkPath: path},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]map[string]string{
+				"team-a/db":         {"value": "a-db"},
+				"team-a/nested/api": {"value": "a-api"},
+			}, resp.Data)
+			for _, p := range paths {
+				assert.Contains(t, p, "/dapr/team-a/", "request outside of the path")
+			}
+		}
+	})
+
+	t.Run("the whole prefix by default", func(t *testing.T) {
+		resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Len(t, resp.Data, len(secrets))
+	})
+
+	t.Run("paths escaping the prefix are rejected", func(t *testing.T) {
+		for _, path := range []string{"..", "team-a/../..", "team-a//db", "./team-a"} {
+			paths = nil
+			_, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+				Metadata: map[string]string{bulkPath: path},
+			})
+			assert.ErrorContains(t, err, "it must be a folder under the KV prefix", path)
+			assert.Empty(t, paths)
+		}
+	})
+}
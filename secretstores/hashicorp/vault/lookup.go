@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// findSecretNameIgnoringCase lists the folder of a secret and returns the name of the secret in that folder
+// that matches the given one ignoring case, or an empty string if there's none. A secret with the exact given name
+// is preferred, as it's the one read.
+func (v *vaultSecretStore) findSecretNameIgnoringCase(ctx context.Context, enginePath, secret string) (string, error) {
+	folder, name := splitSecretFolder(secret)
+
+	listPath := v.kvEnginePath(enginePath, "metadata", folder)
+	keyPrefix := ""
+	if folder == "" && v.hasSeparatedPrefix() {
+		// The secrets at the root are stored next to the prefix, named after it and the separator
+		var prefixFolder string
+		prefixFolder, keyPrefix = splitSecretFolder(v.vaultKVPrefix)
+		keyPrefix += v.separator()
+		listPath = enginePath + "/metadata/" + prefixFolder
+	}
+
+	httpReq, err := v.newVaultRequest(ctx, "LIST", v.vaultAddress+"/v1/"+listPath, nil)
+	if err != nil {
+		return "", fmt.Errorf("couldn't generate request: %w", err)
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationList)
+		return "", fmt.Errorf("couldn't list secrets: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusNotFound {
+		// The folder is empty or doesn't exist
+		return "", nil
+	}
+	if httpresp.StatusCode != http.StatusOK {
+		recordCount(ctx, requestErrors, operationList)
+		io.Copy(io.Discard, httpresp.Body)
+		return "", fmt.Errorf("couldn't list secrets under %s, status code %d", listPath, httpresp.StatusCode)
+	}
+
+	var d vaultListKVResponse
+	if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
+		return "", decodeError(err)
+	}
+
+	found := ""
+	for _, key := range d.Data.Keys {
+		key, ok := strings.CutPrefix(key, keyPrefix)
+		if !ok || !v.isSecretPath(key) {
+			continue
+		}
+		if key == name {
+			return folder + key, nil
+		}
+		if found == "" && strings.EqualFold(key, name) {
+			found = folder + key
+		}
+	}
+
+	return found, nil
+}
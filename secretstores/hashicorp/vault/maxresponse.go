@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// defaultMaxResponseBytes is the default size limit of the responses of Vault, far above the size of any secret.
+const defaultMaxResponseBytes int64 = 32 << 20
+
+// ErrResponseTooLarge is returned when reading a response of Vault larger than vaultMaxResponseBytes.
+var ErrResponseTooLarge = errors.New("the response from vault is too large")
+
+// maxResponseBytesTransport limits the size of the bodies of the responses, so that a misbehaving server can't
+// make the sidecar run out of memory while it parses them. Reading past the limit fails with ErrResponseTooLarge.
+type maxResponseBytesTransport struct {
+	next     http.RoundTripper
+	maxBytes int64
+}
+
+func newMaxResponseBytesTransport(next http.RoundTripper, maxBytes int64) *maxResponseBytesTransport {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxResponseBytes
+	}
+
+	return &maxResponseBytesTransport{
+		next:     next,
+		maxBytes: maxBytes,
+	}
+}
+
+func (t *maxResponseBytesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Body = &limitedBody{
+		Closer:   resp.Body,
+		reader:   io.LimitReader(resp.Body, t.maxBytes+1),
+		maxBytes: t.maxBytes,
+	}
+
+	return resp, nil
+}
+
+// limitedBody reads a response body up to maxBytes, and fails instead of truncating it when it's larger.
+type limitedBody struct {
+	io.Closer
+	reader   io.Reader
+	maxBytes int64
+	read     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	b.read += int64(n)
+	if b.read > b.maxBytes {
+		return n - int(b.read-b.maxBytes), fmt.Errorf("%w: it's larger than the limit of %d bytes set with %s",
+			ErrResponseTooLarge, b.maxBytes, componentMaxResponseBytes)
+	}
+
+	return n, err
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestMaxResponseBytes(t *testing.T) {
+	secret := `{"data":{"data":{"key":"value"}}}`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/dapr/small":
+			w.Write([]byte(secret))
+		case "/v1/secret/data/dapr/huge":
+			// A body far larger than the limit, which must not be read entirely
+			w.Write([]byte(`{"data":{"data":{"key":"`))
+			chunk := []byte(strings.Repeat("x", 1024))
+			for i := 0; i < 1024; i++ {
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+			}
+			w.Write([]byte(`"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	initStore := func(t *testing.T, properties map[string]string) *vaultSecretStore {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		properties[componentVaultAddress] = server.URL
+		properties[componentVaultToken] = expectedTok
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}}))
+		return v
+	}
+
+	t.Run("responses larger than the limit fail", func(t *testing.T) {
+		v := initStore(t, map[string]string{componentMaxResponseBytes: "4096"})
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "huge"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the response from vault is too large: it's larger than the limit of 4096 bytes set with vaultMaxResponseBytes")
+	})
+
+	t.Run("responses under the limit are read", func(t *testing.T) {
+		v := initStore(t, map[string]string{componentMaxResponseBytes: "4096"})
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "small"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+	})
+
+	t.Run("the default limit is finite", func(t *testing.T) {
+		v := initStore(t, map[string]string{})
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "huge"})
+		require.NoError(t, err, "a 1 MiB response is under the default limit")
+		assert.Len(t, resp.Data["key"], 1024*1024)
+		assert.Equal(t, defaultMaxResponseBytes, newMaxResponseBytesTransport(nil, 0).maxBytes)
+	})
+
+	t.Run("a body of exactly the limit is read", func(t *testing.T) {
+		body := &limitedBody{
+			Closer:   io.NopCloser(nil),
+			reader:   io.LimitReader(strings.NewReader(secret), int64(len(secret))+1),
+			maxBytes: int64(len(secret)),
+		}
+		b, err := io.ReadAll(body)
+		require.NoError(t, err)
+		assert.Equal(t, secret, string(b))
+
+		body = &limitedBody{
+			Closer:   io.NopCloser(nil),
+			reader:   io.LimitReader(strings.NewReader(secret), int64(len(secret))),
+			maxBytes: int64(len(secret)) - 1,
+		}
+		b, err = io.ReadAll(body)
+		require.ErrorIs(t, err, ErrResponseTooLarge)
+		assert.Len(t, b, len(secret)-1)
+	})
+}
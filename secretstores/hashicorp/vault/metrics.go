@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// Operations used to tag the metrics recorded by the component.
+const (
+	operationGet    = "get"
+	operationList   = "list"
+	operationLookup = "lookup"
+	operationRenew  = "renew"
+	operationLogin  = "login"
+	operationMounts = "mounts"
+	operationBulk   = "bulk"
+	operationRevoke = "revoke"
+)
+
+// requestDurationBuckets are the bounds, in seconds, of the buckets of the request durations: from the few
+// milliseconds of a Vault on the same network to the seconds of a loaded or distant one.
+var requestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var (
+	operationKey = tag.MustNewKey("operation")
+
+	secretCacheHits = stats.Int64(
+		"vault_secret_cache_hits",
+		"The number of secrets served from the component's cache.",
+		stats.UnitDimensionless)
+	secretCacheMisses = stats.Int64(
+		"vault_secret_cache_misses",
+		"The number of secrets not found in the component's cache.",
+		stats.UnitDimensionless)
+	requestErrors = stats.Int64(
+		"vault_request_errors",
+		"The number of failed requests to Vault.",
+		stats.UnitDimensionless)
+	permissionDenied = stats.Int64(
+		"vault_permission_denied_total",
+		"The number of requests denied by Vault because the policies of the token don't allow them.",
+		stats.UnitDimensionless)
+	requestDuration = stats.Float64(
+		"vault_request_duration_seconds",
+		"The time it took Vault to answer the requests of the component, until the headers of the response.",
+		stats.UnitSeconds)
+
+	// Views are process-wide: they are registered only once regardless of the number of component instances.
+	registerViewsOnce sync.Once
+	errRegisterViews  error
+)
+
+func registerViews() error {
+	registerViewsOnce.Do(func() {
+		errRegisterViews = view.Register(
+			countView(secretCacheHits),
+			countView(secretCacheMisses),
+			countView(requestErrors),
+			countView(permissionDenied),
+			&view.View{
+				Name:        requestDuration.Name(),
+				Description: requestDuration.Description(),
+				Measure:     requestDuration,
+				TagKeys:     []tag.Key{operationKey},
+				Aggregation: view.Distribution(requestDurationBuckets...),
+			},
+		)
+	})
+
+	return errRegisterViews
+}
+
+func countView(m *stats.Int64Measure) *view.View {
+	return &view.View{
+		Name:        m.Name(),
+		Description: m.Description(),
+		Measure:     m,
+		TagKeys:     []tag.Key{operationKey},
+		Aggregation: view.Count(),
+	}
+}
+
+func recordCount(ctx context.Context, m *stats.Int64Measure, operation string) {
+	stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(operationKey, operation)}, m.M(1))
+}
+
+func recordDuration(ctx context.Context, operation string, d time.Duration) {
+	stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(operationKey, operation)}, requestDuration.M(d.Seconds()))
+}
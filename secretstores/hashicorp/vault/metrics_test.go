@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// countFor returns the value recorded by a count view for the given operation.
+func countFor(t *testing.T, viewName, operation string) int64 {
+	t.Helper()
+
+	rows, err := view.RetrieveData(viewName)
+	require.NoError(t, err)
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == operationKey && tag.Value == operation {
+				return row.Data.(*view.CountData).Value
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestMetrics(t *testing.T) {
+	require.NoError(t, registerViews())
+	// Registering again, as another component instance would, must be harmless.
+	require.NoError(t, registerViews())
+
+	v := newTestVaultSecretStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/dapr/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}
+	}))
+	v.cache = newSecretCache(time.Minute)
+
+	hits := countFor(t, secretCacheHits.Name(), operationGet)
+	misses := countFor(t, secretCacheMisses.Name(), operationGet)
+	errs := countFor(t, requestErrors.Name(), operationGet)
+
+	for _, name := range []string{"first", "first", "second", "first", "second"} {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+	}
+	_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "broken"})
+	require.Error(t, err)
+
+	assert.Equal(t, hits+3, countFor(t, secretCacheHits.Name(), operationGet))
+	assert.Equal(t, misses+3, countFor(t, secretCacheMisses.Name(), operationGet))
+	assert.Equal(t, errs+1, countFor(t, requestErrors.Name(), operationGet))
+}
+
+func TestPermissionDenied(t *testing.T) {
+	require.NoError(t, registerViews())
+
+	// The token has no policy for the restricted secret, nor for listing the secrets
+	v := newTestVaultSecretStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "LIST", r.URL.Path == "/v1/secret/data/dapr/restricted":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["1 error occurred:\n\t* permission denied\n\n"]}`))
+		default:
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}
+	}))
+	v.suppressNotFound = true
+
+	denied := countFor(t, permissionDenied.Name(), operationGet)
+	deniedLists := countFor(t, permissionDenied.Name(), operationList)
+	errs := countFor(t, requestErrors.Name(), operationGet)
+
+	_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "restricted"})
+	require.ErrorIs(t, err, ErrPermissionDenied)
+	assert.NotErrorIs(t, err, secretstores.ErrSecretNotFound)
+	assert.ErrorContains(t, err, "/v1/secret/data/dapr/restricted")
+
+	_, err = v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+	require.ErrorIs(t, err, ErrPermissionDenied)
+
+	resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "allowed"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+
+	assert.Equal(t, denied+1, countFor(t, permissionDenied.Name(), operationGet))
+	assert.Equal(t, deniedLists+1, countFor(t, permissionDenied.Name(), operationList))
+	assert.Equal(t, errs+1, countFor(t, requestErrors.Name(), operationGet))
+}
+
+// durationsFor returns the distribution recorded by the request duration view for the given operation.
+func durationsFor(t *testing.T, operation string) *view.DistributionData {
+	t.Helper()
+
+	rows, err := view.RetrieveData(requestDuration.Name())
+	require.NoError(t, err)
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == operationKey && tag.Value == operation {
+				return row.Data.(*view.DistributionData)
+			}
+		}
+	}
+
+	return &view.DistributionData{}
+}
+
+func TestRequestDuration(t *testing.T) {
+	require.NoError(t, registerViews())
+
+	v := newTestVaultSecretStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "LIST":
+			w.Write([]byte(`{"data":{"keys":["first","second"]}}`))
+		case r.URL.Path == "/v1/auth/ldap/login/jdoe":
+			w.Write([]byte(`{"auth":{"client_token":"token"}}`))
+		case r.URL.Path == "/v1/auth/token/renew-self":
+			w.Write([]byte(`{"auth":{"lease_duration":3600,"renewable":true}}`))
+		default:
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}
+	}))
+	v.client.Transport = newDurationTransport(v.client.Transport)
+
+	operations := []string{operationGet, operationBulk, operationLogin, operationRenew}
+	before := map[string]*view.DistributionData{}
+	for _, operation := range operations {
+		before[operation] = durationsFor(t, operation)
+	}
+
+	for _, name := range []string{"first", "second"} {
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+		require.NoError(t, err)
+	}
+	resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 2)
+	_, err = ldapAuth{v: v, mountPath: "ldap", username: "jdoe", password: "secret"}.login(context.Background())
+	require.NoError(t, err)
+	_, _, err = v.renewToken(context.Background())
+	require.NoError(t, err)
+
+	// The bulk retrieval lists the keys, and reads each secret
+	expected := map[string]int64{operationGet: 2, operationBulk: 3, operationLogin: 1, operationRenew: 1}
+	for _, operation := range operations {
+		after := durationsFor(t, operation)
+		assert.Equal(t, before[operation].Count+expected[operation], after.Count, operation)
+		assert.Greater(t, after.Sum(), before[operation].Sum(), operation)
+	}
+}
+
+func TestRequestOperation(t *testing.T) {
+	tests := map[string]string{
+		"GET /v1/secret/data/dapr/mysecret":     operationGet,
+		"LIST /v1/secret/metadata/dapr/":        operationList,
+		"POST /v1/auth/ldap/login/jdoe":         operationLogin,
+		"POST /v1/auth/gcp-prod/login":          operationLogin,
+		"POST /v1/auth/token/renew-self":        operationRenew,
+		"PUT /v1/sys/leases/renew":              operationRenew,
+		"GET /v1/auth/token/lookup-self":        operationLookup,
+		"POST /v1/sys/capabilities-self":        operationLookup,
+		"GET /v1/sys/mounts":                    operationMounts,
+		"POST /v1/auth/token/revoke-self":       operationRevoke,
+		"GET /v1/secret/data/dapr/team/login":   operationGet,
+		"GET /v1/database/creds/readonly-renew": operationGet,
+	}
+	for request, operation := range tests {
+		method, path, _ := strings.Cut(request, " ")
+		req, err := http.NewRequest(method, "http://vault"+path, nil)
+		require.NoError(t, err)
+		assert.Equal(t, operation, requestOperation(req), request)
+	}
+
+	req, err := http.NewRequestWithContext(withOperation(context.Background(), operationBulk), "LIST", "http://vault/v1/secret/metadata/dapr/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, operationBulk, requestOperation(req))
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrMountsPermissionDenied is returned by ListMounts when the token isn't allowed to read sys/mounts.
+// It wraps ErrPermissionDenied.
+var ErrMountsPermissionDenied = fmt.Errorf("%w listing the secret engine mounts, the token needs the read capability on sys/mounts", ErrPermissionDenied)
+
+// kvEngineType is the type of the KV secret engine mounts, whatever their version.
+const kvEngineType = "kv"
+
+type vaultMount struct {
+	Type string `json:"type"`
+}
+
+// vaultMountsResponse is the response of sys/mounts. Recent Vault versions return the mounts under data, older
+// ones at the top level only.
+type vaultMountsResponse struct {
+	Data map[string]vaultMount `json:"data"`
+}
+
+// ListMounts returns the sorted paths of the KV secret engines mounted in Vault, without their trailing slash, so
+// that they can be used as enginePath.
+func (v *vaultSecretStore) ListMounts(ctx context.Context) ([]string, error) {
+	if err := v.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+
+	httpReq, err := v.newVaultRequest(ctx, http.MethodGet, v.vaultAddress+"/v1/sys/mounts", nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate request: %w", err)
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationMounts)
+		return nil, fmt.Errorf("couldn't list mounts: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("%w: %w", ErrMountsPermissionDenied, v.permissionDenied(ctx, operationMounts, httpReq.URL.Path))
+	}
+	if httpresp.StatusCode != http.StatusOK {
+		recordCount(ctx, requestErrors, operationMounts)
+		io.Copy(io.Discard, httpresp.Body)
+		return nil, fmt.Errorf("couldn't list mounts, status code %d", httpresp.StatusCode)
+	}
+
+	body, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read response body: %w", err)
+	}
+
+	var d vaultMountsResponse
+	if err := json.Unmarshal(body, &d); err != nil {
+		return nil, decodeError(err)
+	}
+	mounts := d.Data
+	if mounts == nil {
+		// The top level also has fields that aren't mounts, such as request_id, which are skipped as they don't
+		// decode to a mount
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, decodeError(err)
+		}
+		mounts = make(map[string]vaultMount, len(raw))
+		for path, value := range raw {
+			var m vaultMount
+			if json.Unmarshal(value, &m) == nil {
+				mounts[path] = m
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(mounts))
+	for path, m := range mounts {
+		if m.Type == kvEngineType {
+			paths = append(paths, strings.TrimSuffix(path, "/"))
+		}
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMounts(t *testing.T) {
+	const mounts = `{` +
+		`"secret/":{"type":"kv","options":{"version":"2"}},` +
+		`"kv-team/":{"type":"kv","options":{"version":"1"}},` +
+		`"database/":{"type":"database"},` +
+		`"cubbyhole/":{"type":"cubbyhole"},` +
+		`"sys/":{"type":"system"}}`
+
+	listMounts := func(t *testing.T, status int, body string) ([]string, error) {
+		v := newTestVaultSecretStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/sys/mounts" || r.Header.Get(vaultHTTPHeader) != expectedTok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		return v.ListMounts(context.Background())
+	}
+
+	t.Run("only KV mounts are returned", func(t *testing.T) {
+		paths, err := listMounts(t, http.StatusOK, `{"request_id":"1234","lease_duration":0,"data":`+mounts+`}`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kv-team", "secret"}, paths)
+	})
+
+	t.Run("mounts at the top level of the response", func(t *testing.T) {
+		paths, err := listMounts(t, http.StatusOK, mounts)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kv-team", "secret"}, paths)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		paths, err := listMounts(t, http.StatusForbidden, `{"errors":["permission denied"]}`)
+		require.ErrorIs(t, err, ErrMountsPermissionDenied)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		assert.Nil(t, paths)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		_, err := listMounts(t, http.StatusInternalServerError, `{"errors":["internal error"]}`)
+		assert.ErrorContains(t, err, "couldn't list mounts, status code 500")
+	})
+}
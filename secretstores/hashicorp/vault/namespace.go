@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"strings"
+)
+
+// vaultNamespaceHeader selects the Vault Enterprise namespace of a request.
+const vaultNamespaceHeader = "X-Vault-Namespace"
+
+// namespaceKey is the key of the namespace of a request in its context.
+type namespaceKey struct{}
+
+// withRequestNamespace returns a context carrying the namespace set in the metadata of a request, if any, which
+// replaces the namespace of the component for the requests sent to Vault with that context.
+func withRequestNamespace(ctx context.Context, metadata map[string]string) context.Context {
+	namespace, ok := metadata[requestNamespace]
+	if !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, namespaceKey{}, strings.Trim(namespace, "/"))
+}
+
+// namespace returns the namespace of the requests sent to Vault with ctx: the one of the request if it has one,
+// or the one of the component. An empty namespace is the root namespace.
+func (v *vaultSecretStore) namespace(ctx context.Context) string {
+	if namespace, ok := ctx.Value(namespaceKey{}).(string); ok {
+		return namespace
+	}
+
+	return v.vaultNamespace
+}
+
+// namespaceCacheVersion returns the version under which a secret read with ctx is cached, so that the secrets of
+// other namespaces read with the same component aren't returned from the cache.
+func (v *vaultSecretStore) namespaceCacheVersion(ctx context.Context, version string) string {
+	if namespace := v.namespace(ctx); namespace != v.vaultNamespace {
+		return namespace + "/" + version
+	}
+
+	return version
+}
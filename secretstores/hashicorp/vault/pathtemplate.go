@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Tokens of vaultPathTemplate.
+const (
+	pathTokenPrefix = "{prefix}"
+	pathTokenAppID  = "{appID}"
+	pathTokenSecret = "{secret}"
+
+	// envAppID is the environment variable with the ID of the Dapr app, set for the sidecar by the Dapr CLI and
+	// the sidecar injector.
+	envAppID = "APP_ID"
+)
+
+// parsePathTemplate checks a path template such as "{prefix}/{appID}/{secret}", and returns the part before the
+// secret name, which is used as the KV prefix. The template must end with {secret}, after the separator of the
+// prefix if there's anything before it.
+func parsePathTemplate(template, separator string) (string, error) {
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated token %q", rest[start:])
+		}
+		switch token := rest[start : start+end+1]; token {
+		case pathTokenPrefix, pathTokenAppID, pathTokenSecret:
+		default:
+			return "", fmt.Errorf("unrecognized token %s, accepted tokens are %s, %s and %s",
+				token, pathTokenPrefix, pathTokenAppID, pathTokenSecret)
+		}
+		rest = rest[start+end+1:]
+	}
+	if strings.ContainsRune(rest, '}') {
+		return "", errors.New("unbalanced braces")
+	}
+
+	prefix, ok := strings.CutSuffix(template, pathTokenSecret)
+	if !ok || strings.Contains(prefix, pathTokenSecret) {
+		return "", fmt.Errorf("the template must end with %s, once", pathTokenSecret)
+	}
+	if prefix == "" {
+		return "", nil
+	}
+	prefix, ok = strings.CutSuffix(prefix, separator)
+	if !ok {
+		return "", fmt.Errorf("%s must follow the prefix separator %q", pathTokenSecret, separator)
+	}
+
+	return prefix, nil
+}
+
+// resolvePathTemplate returns the KV prefix of a path template, with its tokens replaced by the configured KV
+// prefix and the ID of the Dapr app.
+func resolvePathTemplate(template, separator, kvPrefix, appID string) (string, error) {
+	prefix, err := parsePathTemplate(template, separator)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(prefix, pathTokenAppID) && appID == "" {
+		return "", fmt.Errorf("the template contains %s, but the app ID isn't set in the %s environment variable", pathTokenAppID, envAppID)
+	}
+
+	prefix = strings.ReplaceAll(prefix, pathTokenPrefix, kvPrefix)
+	prefix = strings.ReplaceAll(prefix, pathTokenAppID, appID)
+	// An empty KV prefix leaves a leading slash
+	resolved, err := normalizeVaultPath(prefix)
+	if err != nil {
+		return "", fmt.Errorf("the template resolves to the invalid path %q: %w", prefix, err)
+	}
+
+	return resolved, nil
+}
+
+// appID returns the ID of the Dapr app running the component.
+func appID() string {
+	return os.Getenv(envAppID)
+}
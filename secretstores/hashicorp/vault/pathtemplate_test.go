@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestPathTemplate(t *testing.T) {
+	t.Run("the template composes the path of the secrets", func(t *testing.T) {
+		t.Setenv(envAppID, "checkout")
+		var paths []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}))
+		defer server.Close()
+
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress:  server.URL,
+			componentVaultToken:    expectedTok,
+			componentVaultKVPrefix: "tenants",
+			componentPathTemplate:  "{prefix}/{appID}/{secret}",
+		}}}))
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db/password"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+		assert.Equal(t, []string{"/v1/secret/data/tenants/checkout/db/password"}, paths)
+	})
+
+	tests := map[string]struct {
+		template  string
+		separator string
+		kvPrefix  string
+		appID     string
+		expected  string
+		err       string
+	}{
+		"prefix and app ID": {
+			template: "{prefix}/{appID}/{secret}",
+			kvPrefix: "dapr",
+			appID:    "checkout",
+			expected: "dapr/checkout",
+		},
+		"fixed segments": {
+			template: "apps/{appID}/secrets/{secret}",
+			appID:    "checkout",
+			expected: "apps/checkout/secrets",
+		},
+		"other separator": {
+			template:  "{prefix}/{appID}-{secret}",
+			separator: "-",
+			kvPrefix:  "dapr",
+			appID:     "checkout",
+			expected:  "dapr/checkout",
+		},
+		"secret only": {
+			template: "{secret}",
+			kvPrefix: "dapr",
+			expected: "",
+		},
+		"empty KV prefix": {
+			template: "{prefix}/{appID}/{secret}",
+			appID:    "checkout",
+			expected: "checkout",
+		},
+		"unrecognized token": {
+			template: "{prefix}/{namespace}/{secret}",
+			err:      "unrecognized token {namespace}, accepted tokens are {prefix}, {appID} and {secret}",
+		},
+		"unterminated token": {
+			template: "{prefix}/{appID",
+			err:      `unterminated token "{appID"`,
+		},
+		"secret not last": {
+			template: "{secret}/{appID}",
+			err:      "the template must end with {secret}, once",
+		},
+		"secret twice": {
+			template: "{secret}/{secret}",
+			err:      "the template must end with {secret}, once",
+		},
+		"secret without separator": {
+			template: "{appID}{secret}",
+			appID:    "checkout",
+			err:      `{secret} must follow the prefix separator "/"`,
+		},
+		"app ID not set": {
+			template: "{prefix}/{appID}/{secret}",
+			kvPrefix: "dapr",
+			err:      "the template contains {appID}, but the app ID isn't set in the APP_ID environment variable",
+		},
+		"empty segment": {
+			template: "{prefix}//{appID}/{secret}",
+			kvPrefix: "dapr",
+			appID:    "checkout",
+			err:      "the path must not contain empty segments",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			separator := tt.separator
+			if separator == "" {
+				separator = defaultPrefixSeparator
+			}
+			prefix, err := resolvePathTemplate(tt.template, separator, tt.kvPrefix, tt.appID)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, prefix)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimitModeQueue makes the requests over the rate limit wait for their turn.
+	rateLimitModeQueue = "queue"
+	// rateLimitModeFail makes the requests over the rate limit fail with ErrRateLimited.
+	rateLimitModeFail = "fail"
+)
+
+// ErrRateLimited is returned without contacting Vault when a request exceeds vaultMaxRequestsPerSecond, with
+// vaultRateLimitMode "fail".
+var ErrRateLimited = errors.New("too many requests to vault")
+
+// rateLimitTransport caps the rate of the requests of the component to Vault with a token bucket, so that a burst of
+// reads of one app doesn't overload a Vault shared with others. The bucket holds up to burst tokens and is refilled
+// with rate tokens per second: each request takes a token, and waits for the next one, or fails with ErrRateLimited
+// with failFast, when the bucket is empty.
+type rateLimitTransport struct {
+	next     http.RoundTripper
+	rate     float64
+	burst    float64
+	failFast bool
+	now      func() time.Time
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimitTransport(next http.RoundTripper, rate float64, burst int, failFast bool) *rateLimitTransport {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimitTransport{
+		next:     next,
+		rate:     rate,
+		burst:    float64(burst),
+		failFast: failFast,
+		now:      time.Now,
+		tokens:   float64(burst),
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait, ok := t.reserve()
+	if !ok {
+		return nil, fmt.Errorf("%w: the limit of %v requests per second set with %s is exceeded",
+			ErrRateLimited, t.rate, componentRateLimit)
+	}
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			t.release()
+			return nil, req.Context().Err()
+		}
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// reserve takes a token, and returns how long the request must wait for it. Without failFast, the bucket goes into
+// debt so that the waiting requests are let through in turn. With failFast, it returns false if the bucket is empty.
+func (t *rateLimitTransport) reserve() (time.Duration, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	if !t.last.IsZero() {
+		t.tokens += now.Sub(t.last).Seconds() * t.rate
+		if t.tokens > t.burst {
+			t.tokens = t.burst
+		}
+	}
+	t.last = now
+
+	if t.tokens >= 1 {
+		t.tokens--
+		return 0, true
+	}
+	if t.failFast {
+		return 0, false
+	}
+	t.tokens--
+
+	return time.Duration(-t.tokens / t.rate * float64(time.Second)), true
+}
+
+// release gives back the token of a request canceled while it was waiting.
+func (t *rateLimitTransport) release() {
+	t.lock.Lock()
+	t.tokens++
+	t.lock.Unlock()
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestRateLimit(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		requests []time.Time
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests = append(requests, time.Now())
+		lock.Unlock()
+		w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+	}))
+	defer server.Close()
+
+	initStore := func(t *testing.T, properties map[string]string) *vaultSecretStore {
+		lock.Lock()
+		requests = nil
+		lock.Unlock()
+
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		properties[componentVaultAddress] = server.URL
+		properties[componentVaultToken] = expectedTok
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}}))
+		return v
+	}
+	readConcurrently := func(v *vaultSecretStore, reads int) []error {
+		errs := make([]error, reads)
+		var wg sync.WaitGroup
+		for i := 0; i < reads; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+			}(i)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	t.Run("queued requests stay under the limit", func(t *testing.T) {
+		const (
+			limit = 50
+			burst = 5
+			reads = 40
+		)
+		v := initStore(t, map[string]string{
+			componentRateLimit:      "50",
+			componentRateLimitBurst: "5",
+		})
+
+		for _, err := range readConcurrently(v, reads) {
+			require.NoError(t, err)
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		require.Len(t, requests, reads)
+		// Besides the burst, the requests are spaced by the rate limit
+		span := requests[len(requests)-1].Sub(requests[0])
+		assert.GreaterOrEqual(t, span, time.Duration(float64(reads-burst)/limit*float64(time.Second))-10*time.Millisecond)
+		for i, at := range requests {
+			window := at.Add(-time.Second)
+			inWindow := 0
+			for _, before := range requests[:i+1] {
+				if before.After(window) {
+					inWindow++
+				}
+			}
+			assert.LessOrEqual(t, inWindow, limit+burst)
+		}
+	})
+
+	t.Run("requests over the limit fail fast", func(t *testing.T) {
+		v := initStore(t, map[string]string{
+			componentRateLimit:      "1",
+			componentRateLimitBurst: "3",
+			componentRateLimitMode:  rateLimitModeFail,
+		})
+
+		limited := 0
+		for _, err := range readConcurrently(v, 10) {
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrRateLimited), err)
+				limited++
+			}
+		}
+		assert.Equal(t, 7, limited)
+		assert.Len(t, requests, 3)
+	})
+
+	t.Run("waiting requests can be canceled", func(t *testing.T) {
+		v := initStore(t, map[string]string{
+			componentRateLimit: "0.1",
+		})
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = v.GetSecret(ctx, secretstores.GetSecretRequest{Name: "mysecret"})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// The token of the canceled request is given back
+		limiter := v.client.Transport.(*rateLimitTransport)
+		limiter.now = func() time.Time { return time.Now().Add(10 * time.Second) }
+		wait, ok := limiter.reserve()
+		assert.True(t, ok)
+		assert.Zero(t, wait)
+	})
+}
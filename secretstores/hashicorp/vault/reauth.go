@@ -0,0 +1,226 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	// Re-authentication happens at a random point between these fractions of the lifetime of the token, so that
+	// many sidecars started together don't all log in at the same time.
+	reauthMinFraction = 0.7
+	reauthMaxFraction = 0.9
+)
+
+// authMethod obtains a new Vault token.
+// Auth methods that log in with credentials, such as AppRole, Kubernetes or AWS, implement it so that a new token
+// is obtained before the current one reaches its max TTL, which renewals can't extend.
+type authMethod interface {
+	// login returns a new token.
+	login(ctx context.Context) (string, error)
+}
+
+// vaultLoginResponse is the response data from the login endpoints of Vault's auth methods.
+type vaultLoginResponse struct {
+	Auth *struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// login posts the credentials of an auth method to its login endpoint, without a token, and returns the token of
+// the response. who describes the identity logging in for the errors, such as "as LDAP user jdoe".
+func (v *vaultSecretStore) login(ctx context.Context, loginURL string, credentials map[string]string, who string) (string, error) {
+	body, err := json.Marshal(credentials)
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := v.newVaultRequest(ctx, http.MethodPost, loginURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("couldn't generate request: %w", err)
+	}
+	// Logging in doesn't need a token
+	httpReq.Header.Del(vaultHTTPHeader)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("couldn't log in %s: %w", who, err)
+	}
+	defer httpresp.Body.Close()
+
+	var d vaultLoginResponse
+	decodeErr := json.NewDecoder(httpresp.Body).Decode(&d)
+
+	switch {
+	case httpresp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("couldn't log in %s, status code %d: %s", who, httpresp.StatusCode, strings.Join(d.Errors, ", "))
+	case decodeErr != nil:
+		return "", decodeError(decodeErr)
+	case d.Auth == nil || d.Auth.ClientToken == "":
+		return "", errors.New("couldn't log in: the response doesn't contain a token")
+	}
+
+	return d.Auth.ClientToken, nil
+}
+
+// tokenFileAuth reads the token from a file, which is replaced by an external agent, such as Vault Agent, before
+// the token expires.
+type tokenFileAuth struct {
+	path string
+}
+
+func (a tokenFileAuth) login(_ context.Context) (string, error) {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read vault token from mount path %s err: %s", a.path, err)
+	}
+
+	return string(bytes.TrimSpace(data)), nil
+}
+
+// reauthDelay returns how long to wait before re-authenticating, for a token with the given lifetime left.
+func reauthDelay(lifetime time.Duration) time.Duration {
+	fraction := reauthMinFraction + rand.Float64()*(reauthMaxFraction-reauthMinFraction) //nolint:gosec
+	return time.Duration(float64(lifetime) * fraction)
+}
+
+// startReauthenticator replaces the token used by the component with a new one obtained from the auth method,
+// before the current one expires or reaches its max TTL, until the context is canceled.
+func (v *vaultSecretStore) startReauthenticator(ctx context.Context, auth authMethod) {
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+
+		bo := backoff.NewExponentialBackOff()
+		bo.InitialInterval = tokenRenewRetryInitialInterval
+		bo.MaxInterval = tokenRenewRetryMaxInterval
+		bo.MaxElapsedTime = 0
+
+		for {
+			lifetime, ok := v.lookupTokenLifetime(ctx, bo)
+			if !ok {
+				return
+			}
+
+			bo.Reset()
+			wait := reauthDelay(lifetime)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+
+				err := v.reauthenticate(ctx, auth)
+				if err == nil {
+					break
+				}
+				wait = bo.NextBackOff()
+				v.logger.Warnf("Failed to obtain a new Vault token, retrying in %v: %v", wait, err)
+			}
+		}
+	}()
+}
+
+// lookupTokenLifetime looks up the token until it succeeds, and returns the time left before it expires or reaches
+// its max TTL, whichever comes first. It returns false when the token never expires, or the context is canceled.
+func (v *vaultSecretStore) lookupTokenLifetime(ctx context.Context, bo backoff.BackOff) (time.Duration, bool) {
+	for {
+		d, err := v.lookupToken(ctx)
+		if err == nil {
+			lifetime := d.lifetime(time.Now())
+			if lifetime == TokenTTLInfinite {
+				return 0, false
+			}
+			return lifetime, true
+		}
+
+		wait := bo.NextBackOff()
+		v.logger.Warnf("Failed to lookup the Vault token, retrying in %v: %v", wait, err)
+		select {
+		case <-ctx.Done():
+			return 0, false
+		case <-time.After(wait):
+		}
+	}
+}
+
+// reauthenticate obtains a new token from the auth method, and uses it for the next requests.
+func (v *vaultSecretStore) reauthenticate(ctx context.Context, auth authMethod) error {
+	token, err := auth.login(ctx)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationLogin)
+		return err
+	}
+	if token == "" {
+		recordCount(ctx, requestErrors, operationLogin)
+		return fmt.Errorf("the auth method returned an empty token")
+	}
+	if token == v.getToken() {
+		return fmt.Errorf("the token hasn't been replaced yet")
+	}
+
+	if _, ok := auth.(tokenFileAuth); ok {
+		v.setToken(token)
+	} else {
+		v.setLoginToken(token)
+	}
+	v.logger.Debugf("Obtained a new Vault token")
+
+	return nil
+}
+
+// getToken returns the token used by the component.
+func (v *vaultSecretStore) getToken() string {
+	v.tokenLock.RLock()
+	defer v.tokenLock.RUnlock()
+
+	return v.vaultToken
+}
+
+// setToken replaces the token used by the component.
+func (v *vaultSecretStore) setToken(token string) {
+	v.tokenLock.Lock()
+	defer v.tokenLock.Unlock()
+
+	v.vaultToken = token
+}
+
+// setLoginToken replaces the token used by the component with a token it obtained by logging in. The token
+// obtained by the previous login is revoked, so that logging in again doesn't leave it valid until its TTL.
+func (v *vaultSecretStore) setLoginToken(token string) {
+	v.tokenLock.Lock()
+	previous := v.loginToken
+	v.vaultToken = token
+	v.loginToken = token
+	v.tokenLock.Unlock()
+
+	if previous != "" && previous != token {
+		v.revokeTokenBestEffort(previous, "the previous Vault token obtained by logging in")
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeLoginVault accepts the tokens it knows until they reach their max TTL, counted from their first use.
+type fakeLoginVault struct {
+	maxTTL map[string]int
+
+	lock   sync.Mutex
+	issued map[string]time.Time
+	used   map[string]int
+}
+
+func (f *fakeLoginVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	token := r.Header.Get(vaultHTTPHeader)
+	maxTTL, ok := f.maxTTL[token]
+	if !ok {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	issued, ok := f.issued[token]
+	if !ok {
+		issued = time.Now()
+		f.issued[token] = issued
+	}
+	left := time.Until(issued.Add(time.Duration(maxTTL) * time.Second))
+	if left <= 0 {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.used[token]++
+
+	switch r.URL.Path {
+	case "/v1/auth/token/lookup-self":
+		fmt.Fprintf(w, `{"data":{"type":"service","ttl":%d,"explicit_max_ttl":%d,"issue_time":%q,"renewable":true}}`,
+			int(left.Seconds()+1), maxTTL, issued.Format(time.RFC3339Nano))
+	default:
+		w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+	}
+}
+
+func (f *fakeLoginVault) uses(token string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.used[token]
+}
+
+func TestTokenReauthentication(t *testing.T) {
+	t.Run("a new token is used before the max TTL of the current one", func(t *testing.T) {
+		fake := &fakeLoginVault{
+			maxTTL: map[string]int{"token-1": 1, "token-2": 3600},
+			issued: map[string]time.Time{},
+			used:   map[string]int{},
+		}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		tokenPath := filepath.Join(t.TempDir(), "token")
+		require.NoError(t, os.WriteFile(tokenPath, []byte("token-1\n"), 0o600))
+
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress:        server.URL,
+			componentVaultTokenMountPath: tokenPath,
+			componentVaultTokenReauth:    "true",
+		}}}))
+		defer v.Close()
+
+		// The agent writes the next token
+		require.NoError(t, os.WriteFile(tokenPath, []byte("token-2\n"), 0o600))
+
+		// Reads keep succeeding past the max TTL of the first token
+		deadline := time.Now().Add(1500 * time.Millisecond)
+		for time.Now().Before(deadline) {
+			_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+			require.NoError(t, err)
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		assert.Equal(t, "token-2", v.getToken())
+		assert.Positive(t, fake.uses("token-1"))
+		assert.Positive(t, fake.uses("token-2"))
+	})
+
+	t.Run("requires the token mount path", func(t *testing.T) {
+		err := ValidateMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken:       expectedTok,
+			componentVaultTokenReauth: "true",
+		}}})
+		assert.ErrorContains(t, err, "vaultTokenReauth requires vaultTokenMountPath")
+	})
+
+	t.Run("the delay is jittered within the lifetime", func(t *testing.T) {
+		delays := map[time.Duration]struct{}{}
+		for i := 0; i < 100; i++ {
+			delay := reauthDelay(time.Hour)
+			assert.GreaterOrEqual(t, delay, 42*time.Minute)
+			assert.LessOrEqual(t, delay, 54*time.Minute)
+			delays[delay] = struct{}{}
+		}
+		assert.Greater(t, len(delays), 1)
+	})
+
+	t.Run("the lifetime is capped by the explicit max TTL", func(t *testing.T) {
+		now := time.Now()
+		issued := now.Add(-50 * time.Minute).Format(time.RFC3339Nano)
+
+		var d vaultTokenLookupResponse
+		d.Data.TTL = 3600
+		d.Data.IssueTime = &issued
+		d.Data.ExplicitMaxTTL = 3600
+		assert.InDelta(t, float64(10*time.Minute), float64(d.lifetime(now)), float64(time.Second))
+
+		d.Data.ExplicitMaxTTL = 0
+		assert.Equal(t, time.Hour, d.lifetime(now))
+
+		d.Data.TTL = 0
+		d.Data.IssueTime = nil
+		assert.Equal(t, TokenTTLInfinite, d.lifetime(now))
+	})
+}
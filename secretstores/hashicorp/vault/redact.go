@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// decodeError returns the error of decoding a response of Vault, without the error of the decoder itself: it can
+// quote the malformed part of the response, which may be the value of a secret or a token. Only the position of a
+// syntax error, or the field and type of a value that doesn't match it, are kept.
+func decodeError(err error) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, ErrResponseTooLarge):
+		return fmt.Errorf("couldn't decode response body: %w", err)
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("couldn't decode response body: invalid JSON at offset %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("couldn't decode response body: the value of field %s isn't a valid %s", typeErr.Field, typeErr.Type)
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("couldn't decode response body: unexpected end of JSON input")
+	default:
+		return errors.New("couldn't decode response body: invalid JSON")
+	}
+}
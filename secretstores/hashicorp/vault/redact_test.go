@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestSecretValuesAreRedacted(t *testing.T) {
+	// A value that must never be seen outside of the responses of the component
+	const secretValue = "s3cr3t-hunter2"
+
+	// Responses of a misbehaving server or proxy, which contain the value of the secret
+	responses := map[string]struct {
+		status int
+		body   string
+	}{
+		"malformed":      {http.StatusOK, `{"data":{"data":{"password":"` + secretValue + `"}}}` + secretValue},
+		"unquoted":       {http.StatusOK, `{"data":{"data":{"password":` + secretValue + `}}}`},
+		"wrong type":     {http.StatusOK, `{"data":{"data":{"password":{"nested":"` + secretValue + `"}}}}`},
+		"truncated":      {http.StatusOK, `{"data":{"data":{"password":"` + secretValue},
+		"echoed in body": {http.StatusBadGateway, `upstream returned {"password":"` + secretValue + `"}`},
+	}
+
+	for name, response := range responses {
+		response := response
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "LIST" {
+					w.Write([]byte(`{"data":{"keys":["mysecret"]}}`))
+					return
+				}
+				w.WriteHeader(response.status)
+				w.Write([]byte(response.body))
+			}))
+			defer server.Close()
+
+			var logs bytes.Buffer
+			log := logger.NewLogger("test")
+			log.SetOutput(&logs)
+			log.SetOutputLevel(logger.DebugLevel)
+			v := &vaultSecretStore{logger: log}
+			require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+				componentVaultAddress: server.URL,
+				componentVaultToken:   expectedTok,
+			}}}))
+
+			_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+			require.Error(t, err)
+			assert.NotContains(t, err.Error(), secretValue)
+			// Nor the quoted characters of the syntax errors of encoding/json
+			assert.NotContains(t, err.Error(), "'s'")
+
+			_, err = v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+			require.Error(t, err)
+			assert.NotContains(t, err.Error(), secretValue)
+
+			assert.NotContains(t, logs.String(), secretValue)
+			assert.NotContains(t, logs.String(), expectedTok)
+		})
+	}
+
+	t.Run("decode errors keep the position and the field", func(t *testing.T) {
+		var d vaultKVResponse
+		err := decodeError(json.Unmarshal([]byte(`{"data":{"data":{"password":1}}}`), &d))
+		assert.EqualError(t, err, "couldn't decode response body: the value of field data.data.password isn't a valid string")
+
+		err = decodeError(json.Unmarshal([]byte(`{"data":x}`), &d))
+		assert.EqualError(t, err, "couldn't decode response body: invalid JSON at offset 9")
+	})
+}
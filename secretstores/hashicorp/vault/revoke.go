@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// revokeTimeout bounds the revocation of the token on close, so that a Vault that can't be reached doesn't block the
+// shutdown of the sidecar.
+var revokeTimeout = 5 * time.Second
+
+// revokeLoginToken revokes the token obtained by logging in, if the component still uses it, so that it doesn't
+// remain valid until its TTL after the component is closed. The tokens supplied to the component, with vaultToken,
+// vaultTokenMountPath or SetToken, are left untouched, as they may be used by others. Errors are logged only.
+func (v *vaultSecretStore) revokeLoginToken() {
+	v.tokenLock.Lock()
+	token := v.loginToken
+	v.loginToken = ""
+	current := v.vaultToken
+	v.tokenLock.Unlock()
+	if token == "" || token != current {
+		return
+	}
+
+	v.revokeTokenBestEffort(token, "the Vault token obtained by logging in")
+}
+
+// revokeTokenBestEffort revokes a token within revokeTimeout. what describes the token for the logs. Errors are
+// logged only.
+func (v *vaultSecretStore) revokeTokenBestEffort(token, what string) {
+	ctx, cancel := context.WithTimeout(context.Background(), revokeTimeout)
+	defer cancel()
+	if err := v.revokeToken(ctx, token); err != nil {
+		recordCount(ctx, requestErrors, operationRevoke)
+		v.logger.Warnf("Failed to revoke %s, it remains valid until it expires: %v", what, err)
+		return
+	}
+	v.logger.Debugf("Revoked %s", what)
+}
+
+// revokeToken revokes the given token, authenticating with it.
+func (v *vaultSecretStore) revokeToken(ctx context.Context, token string) error {
+	httpReq, err := v.newVaultRequest(ctx, http.MethodPost, v.vaultAddress+"/v1/auth/token/revoke-self", nil)
+	if err != nil {
+		return fmt.Errorf("couldn't generate request: %w", err)
+	}
+	httpReq.Header.Set(vaultHTTPHeader, token)
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("couldn't revoke token: %w", err)
+	}
+	defer httpresp.Body.Close()
+	io.Copy(io.Discard, httpresp.Body)
+
+	if httpresp.StatusCode != http.StatusNoContent && httpresp.StatusCode != http.StatusOK {
+		return fmt.Errorf("couldn't revoke token, status code %d", httpresp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestCloseRevokesLoginToken(t *testing.T) {
+	const ldapToken = "ldap-token"
+
+	var (
+		lock    sync.Mutex
+		valid   = map[string]bool{expectedTok: true}
+		revokes int
+		block   chan struct{}
+		// nextToken is the token returned by the next login
+		nextToken = ldapToken
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if r.URL.Path == "/v1/auth/ldap/login/jdoe" {
+			valid[nextToken] = true
+			w.Write([]byte(`{"auth":{"client_token":"` + nextToken + `"}}`))
+			return
+		}
+		token := r.Header.Get(vaultHTTPHeader)
+		if !valid[token] {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/auth/token/revoke-self":
+			revokes++
+			if block != nil {
+				lock.Unlock()
+				<-block
+				lock.Lock()
+			}
+			delete(valid, token)
+			w.WriteHeader(http.StatusNoContent)
+		case "/v1/auth/token/lookup-self":
+			w.Write([]byte(`{"data":{"type":"service","ttl":3600}}`))
+		case "/v1/secret/data/dapr/conftestsecret":
+			w.Write([]byte(`{"data":{"data":{"conftestsecret":"abcd"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	initStore := func(t *testing.T, properties map[string]string) *vaultSecretStore {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		properties[componentVaultAddress] = server.URL
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}}))
+		return v
+	}
+	tokenIsValid := func(token string) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return valid[token]
+	}
+
+	t.Run("the token obtained by logging in is revoked", func(t *testing.T) {
+		v := initStore(t, map[string]string{componentLDAPUsername: "jdoe", componentLDAPPassword: "s3cr3t"})
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "conftestsecret"})
+		require.NoError(t, err)
+		require.True(t, tokenIsValid(ldapToken))
+
+		require.NoError(t, v.Close())
+		assert.False(t, tokenIsValid(ldapToken))
+
+		// Closing again doesn't revoke anything
+		require.NoError(t, v.Close())
+		assert.Equal(t, 1, revokes)
+	})
+
+	t.Run("the previous token obtained by logging in is revoked when logging in again", func(t *testing.T) {
+		revokes = 0
+		v := initStore(t, map[string]string{componentLDAPUsername: "jdoe", componentLDAPPassword: "s3cr3t"})
+		defer v.Close()
+
+		lock.Lock()
+		nextToken = "ldap-token-2"
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			nextToken = ldapToken
+			lock.Unlock()
+		}()
+		require.NoError(t, v.reauthenticate(context.Background(), v.auth))
+
+		assert.Equal(t, "ldap-token-2", v.getToken())
+		assert.False(t, tokenIsValid(ldapToken))
+		assert.True(t, tokenIsValid("ldap-token-2"))
+		assert.Equal(t, 1, revokes)
+	})
+
+	t.Run("static tokens are left untouched", func(t *testing.T) {
+		revokes = 0
+		v := initStore(t, map[string]string{componentVaultToken: expectedTok})
+
+		require.NoError(t, v.Close())
+		assert.True(t, tokenIsValid(expectedTok))
+		assert.Equal(t, 0, revokes)
+	})
+
+	t.Run("tokens set with SetToken are left untouched", func(t *testing.T) {
+		revokes = 0
+		v := initStore(t, map[string]string{componentLDAPUsername: "jdoe", componentLDAPPassword: "s3cr3t"})
+		require.NoError(t, v.SetToken(context.Background(), expectedTok))
+
+		require.NoError(t, v.Close())
+		assert.True(t, tokenIsValid(expectedTok))
+		assert.Equal(t, 0, revokes)
+	})
+
+	t.Run("the revocation is time-bounded", func(t *testing.T) {
+		defer func(timeout time.Duration) { revokeTimeout = timeout }(revokeTimeout)
+		revokeTimeout = 100 * time.Millisecond
+		lock.Lock()
+		block = make(chan struct{})
+		lock.Unlock()
+		defer close(block)
+
+		v := initStore(t, map[string]string{componentLDAPUsername: "jdoe", componentLDAPPassword: "s3cr3t"})
+		start := time.Now()
+		require.NoError(t, v.Close())
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
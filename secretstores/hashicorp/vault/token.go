@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	// tokenTypeBatch is the type of batch tokens, which can't be renewed.
+	tokenTypeBatch = "batch"
+
+	// Failed lookups and renewals are retried with an exponential backoff between these intervals.
+	tokenRenewRetryInitialInterval = time.Second
+	tokenRenewRetryMaxInterval     = time.Minute
+)
+
+// vaultTokenRenewResponse is the response data from Vault's token renew-self endpoint.
+type vaultTokenRenewResponse struct {
+	Auth struct {
+		LeaseDuration int64 `json:"lease_duration"`
+		Renewable     bool  `json:"renewable"`
+	} `json:"auth"`
+}
+
+// SetToken replaces the token used by the component, for example when operators rotate a static vaultToken, without
+// restarting the sidecar. The new token is checked with lookup-self first: the current token is kept if Vault
+// rejects it or can't be reached. With vaultTokenReauth, the token is replaced again on the next login.
+func (v *vaultSecretStore) SetToken(ctx context.Context, token string) error {
+	if token == "" {
+		return errors.New("couldn't set the token: the token is empty")
+	}
+	if _, err := v.lookupTokenAs(ctx, token); err != nil {
+		return fmt.Errorf("couldn't set the token, the current one is kept: %w", err)
+	}
+
+	v.setToken(token)
+	v.logger.Infof("Replaced the Vault token")
+
+	return nil
+}
+
+// startTokenRenewer renews the token used by the component in background until the context is canceled.
+// Batch tokens obtained from an auth method with credentials can't be renewed, so they are replaced by logging in
+// again before they expire.
+func (v *vaultSecretStore) startTokenRenewer(ctx context.Context) {
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+
+		bo := backoff.NewExponentialBackOff()
+		bo.InitialInterval = tokenRenewRetryInitialInterval
+		bo.MaxInterval = tokenRenewRetryMaxInterval
+		bo.MaxElapsedTime = 0
+
+		for {
+			ttl, renewable, relogin := v.lookupRenewableToken(ctx, bo)
+			if renewable {
+				v.renewTokenUntilExpiry(ctx, bo, ttl)
+				return
+			}
+			if !relogin {
+				return
+			}
+
+			bo.Reset()
+			wait := reauthDelay(ttl)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+
+				err := v.reauthenticate(ctx, v.auth)
+				if err == nil {
+					break
+				}
+				wait = bo.NextBackOff()
+				v.logger.Warnf("Failed to replace the Vault batch token, retrying in %v: %v", wait, err)
+			}
+		}
+	}()
+}
+
+// renewTokenUntilExpiry renews the token, which has the given TTL, until it can't be renewed anymore or the context
+// is canceled.
+func (v *vaultSecretStore) renewTokenUntilExpiry(ctx context.Context, bo backoff.BackOff, ttl time.Duration) {
+	renewable := true
+	for renewable {
+		bo.Reset()
+		// Renew when two thirds of the TTL have elapsed, leaving time to retry on errors
+		wait := ttl * 2 / 3
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+
+			var err error
+			ttl, renewable, err = v.renewToken(ctx)
+			if err == nil {
+				break
+			}
+			wait = bo.NextBackOff()
+			v.logger.Warnf("Failed to renew the Vault token, retrying in %v: %v", wait, err)
+		}
+	}
+}
+
+// lookupRenewableToken looks up the token until it succeeds, and returns its TTL, whether it can be renewed, and
+// whether it must be replaced by logging in again because it's a batch token obtained from an auth method.
+func (v *vaultSecretStore) lookupRenewableToken(ctx context.Context, bo backoff.BackOff) (time.Duration, bool, bool) {
+	for {
+		d, err := v.lookupToken(ctx)
+		if err == nil {
+			ttl := d.ttl()
+			switch {
+			case ttl == TokenTTLInfinite:
+				return ttl, false, false
+			case d.Data.Type == tokenTypeBatch && v.auth != nil:
+				v.logger.Debugf("The Vault token is a batch token, which can't be renewed: logging in again before it expires in %v", ttl)
+				return ttl, false, true
+			case d.Data.Type == tokenTypeBatch:
+				v.logger.Warnf("The Vault token is a batch token, which can't be renewed: a new token will be needed when it expires in %v", ttl)
+				return ttl, false, false
+			case !d.Data.Renewable:
+				v.logger.Warnf("The Vault token can't be renewed: a new token will be needed when it expires in %v", ttl)
+				return ttl, false, false
+			}
+			return ttl, true, false
+		}
+
+		wait := bo.NextBackOff()
+		v.logger.Warnf("Failed to lookup the Vault token, retrying in %v: %v", wait, err)
+		select {
+		case <-ctx.Done():
+			return 0, false, false
+		case <-time.After(wait):
+		}
+	}
+}
+
+// renewToken renews the token used by the component, and returns its new TTL and whether it can be renewed again.
+func (v *vaultSecretStore) renewToken(ctx context.Context) (time.Duration, bool, error) {
+	httpReq, err := v.newVaultRequest(ctx, http.MethodPost, v.vaultAddress+"/v1/auth/token/renew-self", nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("couldn't generate request: %w", err)
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationRenew)
+		return 0, false, fmt.Errorf("couldn't renew token: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode != http.StatusOK {
+		recordCount(ctx, requestErrors, operationRenew)
+		io.Copy(io.Discard, httpresp.Body)
+		return 0, false, fmt.Errorf("couldn't renew token, status code %d", httpresp.StatusCode)
+	}
+
+	var d vaultTokenRenewResponse
+	if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
+		return 0, false, decodeError(err)
+	}
+	ttl := time.Duration(d.Auth.LeaseDuration) * time.Second
+	v.logger.Debugf("Renewed the Vault token, which now expires in %v", ttl)
+
+	return ttl, d.Auth.Renewable && ttl > 0, nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeTokenVault serves the lookup-self and renew-self endpoints for a token of the given type and TTL.
+type fakeTokenVault struct {
+	tokenType string
+	ttl       int
+	lookups   atomic.Int64
+	renewals  atomic.Int64
+}
+
+func (f *fakeTokenVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/auth/token/lookup-self":
+		f.lookups.Add(1)
+		fmt.Fprintf(w, `{"data":{"type":%q,"ttl":%d,"expire_time":"2023-07-01T00:00:00Z","renewable":%t}}`,
+			f.tokenType, f.ttl, f.tokenType != tokenTypeBatch)
+	case r.URL.Path == "/v1/auth/token/renew-self" && r.Method == http.MethodPost:
+		f.renewals.Add(1)
+		fmt.Fprintf(w, `{"auth":{"lease_duration":%d,"renewable":true}}`, f.ttl)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func initWithTokenRenewal(t *testing.T, server *httptest.Server) *vaultSecretStore {
+	t.Helper()
+
+	v := &vaultSecretStore{logger: logger.NewLogger("test")}
+	require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		componentVaultAddress:    server.URL,
+		componentVaultToken:      expectedTok,
+		componentVaultTokenRenew: "true",
+	}}}))
+	t.Cleanup(func() { v.Close() })
+
+	return v
+}
+
+func TestTokenRenewal(t *testing.T) {
+	t.Run("service tokens are renewed before they expire", func(t *testing.T) {
+		fake := &fakeTokenVault{tokenType: "service", ttl: 1}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		v := initWithTokenRenewal(t, server)
+
+		assert.Eventually(t, func() bool { return fake.renewals.Load() >= 2 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int64(1), fake.lookups.Load())
+
+		require.NoError(t, v.Close())
+		renewals := fake.renewals.Load()
+		time.Sleep(time.Second)
+		assert.Equal(t, renewals, fake.renewals.Load(), "renewals must stop on close")
+	})
+
+	t.Run("batch tokens are never renewed", func(t *testing.T) {
+		fake := &fakeTokenVault{tokenType: tokenTypeBatch, ttl: 1}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		initWithTokenRenewal(t, server)
+
+		assert.Eventually(t, func() bool { return fake.lookups.Load() == 1 }, time.Second, 10*time.Millisecond)
+		time.Sleep(1500 * time.Millisecond)
+		assert.Equal(t, int64(0), fake.renewals.Load())
+	})
+
+	t.Run("batch tokens from an auth method are replaced by logging in again", func(t *testing.T) {
+		fake := &fakeTokenVault{tokenType: tokenTypeBatch, ttl: 1}
+		var logins atomic.Int64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/auth/ldap/login/jdoe" {
+				fmt.Fprintf(w, `{"auth":{"client_token":"batch-token-%d"}}`, logins.Add(1))
+				return
+			}
+			fake.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress:    server.URL,
+			componentLDAPUsername:    "jdoe",
+			componentLDAPPassword:    "password",
+			componentVaultTokenRenew: "true",
+		}}}))
+		defer v.Close()
+
+		assert.Eventually(t, func() bool { return logins.Load() >= 3 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int64(0), fake.renewals.Load())
+		assert.NotEqual(t, "batch-token-1", v.getToken())
+	})
+
+	t.Run("tokens that never expire are not renewed", func(t *testing.T) {
+		fake := &fakeTokenVault{tokenType: "service", ttl: 0}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/auth/token/lookup-self" {
+				fake.lookups.Add(1)
+				w.Write([]byte(`{"data":{"type":"service","ttl":0,"expire_time":null,"renewable":false}}`))
+				return
+			}
+			fake.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		initWithTokenRenewal(t, server)
+
+		assert.Eventually(t, func() bool { return fake.lookups.Load() == 1 }, time.Second, 10*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, int64(0), fake.renewals.Load())
+	})
+}
+
+func TestSetToken(t *testing.T) {
+	const freshToken = "fresh-token"
+
+	// expectedTok has expired: Vault only accepts the fresh token
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultHTTPHeader) != freshToken {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			w.Write([]byte(`{"data":{"type":"service","ttl":3600,"renewable":true}}`))
+		case "/v1/secret/data/dapr/conftestsecret":
+			w.Write([]byte(`{"data":{"data":{"conftestsecret":"abcd"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	v := newTestVaultSecretStore(t, handler)
+	getSecret := func() (secretstores.GetSecretResponse, error) {
+		return v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "conftestsecret"})
+	}
+
+	_, err := getSecret()
+	require.ErrorIs(t, err, ErrPermissionDenied)
+
+	t.Run("invalid tokens are rejected and the current one is kept", func(t *testing.T) {
+		err := v.SetToken(context.Background(), "revoked-token")
+		assert.ErrorContains(t, err, "couldn't set the token, the current one is kept: couldn't lookup token, status code 403")
+		assert.Equal(t, expectedTok, v.getToken())
+
+		assert.EqualError(t, v.SetToken(context.Background(), ""), "couldn't set the token: the token is empty")
+		assert.Equal(t, expectedTok, v.getToken())
+	})
+
+	t.Run("reads succeed with the new token", func(t *testing.T) {
+		require.NoError(t, v.SetToken(context.Background(), freshToken))
+		assert.Equal(t, freshToken, v.getToken())
+
+		resp, err := getSecret()
+		require.NoError(t, err)
+		assert.Equal(t, "abcd", resp.Data["conftestsecret"])
+	})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// startTokenFileWatcher reloads the token from vaultTokenMountPath whenever the file changes, until the context is
+// canceled. The new token is adopted only once it's checked with lookup-self, as with SetToken.
+//
+// The directory of the file is watched rather than the file itself: Kubernetes updates projected volumes by swapping
+// a symlink, and editors often replace files by renaming, both of which would remove a watch on the file.
+func (v *vaultSecretStore) startTokenFileWatcher(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("vault init error, couldn't watch %s: %w", componentVaultTokenMountPath, err)
+	}
+	path := filepath.Clean(v.vaultTokenMountPath)
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("vault init error, couldn't watch %s: %w", componentVaultTokenMountPath, err)
+	}
+
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if isTokenFileEvent(path, event) {
+					v.reloadTokenFile(ctx)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				v.logger.Warnf("Error watching the Vault token file %s: %v", path, err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// isTokenFileEvent returns whether an event in the directory of the token file may have changed its content: an
+// event on the file itself, or on the "..data" symlink that Kubernetes swaps to update projected volumes.
+func isTokenFileEvent(path string, event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Clean(event.Name)
+
+	return name == path || strings.HasPrefix(filepath.Base(name), "..")
+}
+
+// reloadTokenFile reads the token file and replaces the token used by the component if it changed. Errors are
+// logged only, and the current token is kept.
+func (v *vaultSecretStore) reloadTokenFile(ctx context.Context) {
+	token, err := tokenFileAuth{path: v.vaultTokenMountPath}.login(ctx)
+	if err != nil {
+		v.logger.Warnf("Failed to reload the Vault token, the current one is kept: %v", err)
+		return
+	}
+	// Writing a file often raises several events, and the symlink swaps of Kubernetes raise events for every file
+	if token == "" || token == v.getToken() {
+		return
+	}
+
+	if err = v.SetToken(ctx, token); err != nil {
+		v.logger.Warnf("Failed to reload the Vault token from %s: %v", v.vaultTokenMountPath, err)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestTokenMountPathWatch(t *testing.T) {
+	// Vault accepts the first and the rotated tokens only
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(vaultHTTPHeader)
+		if token != "token-1" && token != "token-2" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			w.Write([]byte(`{"data":{"type":"service","ttl":3600,"renewable":true}}`))
+		default:
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("token-1\n"), 0o600))
+
+	v := &vaultSecretStore{logger: logger.NewLogger("test")}
+	require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		componentVaultAddress:        server.URL,
+		componentVaultTokenMountPath: tokenPath,
+		componentVaultTokenWatch:     "true",
+	}}}))
+	defer v.Close()
+	require.Equal(t, "token-1", v.getToken())
+
+	t.Run("an invalid token written to the file is rejected", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(tokenPath, []byte("revoked-token\n"), 0o600))
+
+		assert.Never(t, func() bool { return v.getToken() != "token-1" }, 300*time.Millisecond, 10*time.Millisecond)
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("a rotated token written to the file is used", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(tokenPath, []byte("token-2\n"), 0o600))
+
+		assert.Eventually(t, func() bool { return v.getToken() == "token-2" }, 5*time.Second, 10*time.Millisecond)
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("a token file replaced by renaming is used", func(t *testing.T) {
+		next := filepath.Join(filepath.Dir(tokenPath), "token.next")
+		require.NoError(t, os.WriteFile(next, []byte("token-1\n"), 0o600))
+		require.NoError(t, os.Rename(next, tokenPath))
+
+		assert.Eventually(t, func() bool { return v.getToken() == "token-1" }, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestTokenMountPathWatchValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[string]string
+		err   string
+	}{
+		{
+			name:  "requires the token mount path",
+			props: map[string]string{componentVaultToken: expectedTok},
+			err:   "vaultTokenMountPathWatch requires vaultTokenMountPath",
+		},
+		{
+			name:  "can't be used with reauthentication",
+			props: map[string]string{componentVaultTokenMountPath: "/token", componentVaultTokenReauth: "true"},
+			err:   "vaultTokenMountPathWatch and vaultTokenReauth are mutually exclusive",
+		},
+		{
+			name:  "can't be used with unwrapping",
+			props: map[string]string{componentVaultTokenMountPath: "/token", componentVaultUnwrapToken: "true"},
+			err:   "vaultTokenMountPathWatch and vaultUnwrapToken are mutually exclusive",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.props[componentVaultTokenWatch] = "true"
+			err := ValidateMetadata(secretstores.Metadata{Base: metadata.Base{Properties: tt.props}})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestIsTokenFileEvent(t *testing.T) {
+	const path = "/var/run/secrets/vault/token"
+
+	assert.True(t, isTokenFileEvent(path, fsnotify.Event{Name: path, Op: fsnotify.Write}))
+	assert.True(t, isTokenFileEvent(path, fsnotify.Event{Name: path, Op: fsnotify.Create}))
+	assert.True(t, isTokenFileEvent(path, fsnotify.Event{Name: "/var/run/secrets/vault/..data", Op: fsnotify.Create}),
+		"Kubernetes swaps the ..data symlink")
+	assert.False(t, isTokenFileEvent(path, fsnotify.Event{Name: path, Op: fsnotify.Chmod}))
+	assert.False(t, isTokenFileEvent(path, fsnotify.Event{Name: "/var/run/secrets/vault/other", Op: fsnotify.Write}))
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrWrappingTokenInvalid is returned when the wrapping token has expired, was already unwrapped, or doesn't exist.
+var ErrWrappingTokenInvalid = errors.New("the wrapping token is expired, was already unwrapped, or is invalid")
+
+// vaultUnwrapResponse is the response data from Vault's sys/wrapping/unwrap endpoint, for a wrapped token.
+type vaultUnwrapResponse struct {
+	Auth *struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// unwrapToken replaces the token used by the component, which is a response-wrapping token, with the token it
+// wraps. A wrapping token can be unwrapped only once.
+func (v *vaultSecretStore) unwrapToken(ctx context.Context) error {
+	httpReq, err := v.newVaultRequest(ctx, http.MethodPost, v.vaultAddress+"/v1/sys/wrapping/unwrap", nil)
+	if err != nil {
+		return fmt.Errorf("couldn't generate request: %w", err)
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("couldn't unwrap token: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	var d vaultUnwrapResponse
+	decodeErr := json.NewDecoder(httpresp.Body).Decode(&d)
+
+	switch {
+	case httpresp.StatusCode == http.StatusBadRequest || httpresp.StatusCode == http.StatusForbidden:
+		// Vault answers with "wrapping token is not valid or does not exist" once the token expired or was used
+		return fmt.Errorf("couldn't unwrap token: %w: %s", ErrWrappingTokenInvalid, strings.Join(d.Errors, ", "))
+	case httpresp.StatusCode != http.StatusOK:
+		return fmt.Errorf("couldn't unwrap token, status code %d: %s", httpresp.StatusCode, strings.Join(d.Errors, ", "))
+	case decodeErr != nil:
+		return decodeError(decodeErr)
+	case d.Auth == nil || d.Auth.ClientToken == "":
+		return errors.New("couldn't unwrap token: the wrapped response doesn't contain a token")
+	}
+
+	v.setToken(d.Auth.ClientToken)
+
+	return nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestVaultUnwrapToken(t *testing.T) {
+	const wrappingTok = "hvs.wrapping"
+
+	unwrapped := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(vaultHTTPHeader)
+		switch {
+		case r.URL.Path == "/v1/sys/wrapping/unwrap" && r.Method == http.MethodPost:
+			if token != wrappingTok || unwrapped > 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["wrapping token is not valid or does not exist"]}`))
+				return
+			}
+			unwrapped++
+			w.Write([]byte(`{"auth":{"client_token":"` + expectedTok + `"}}`))
+		case token == expectedTok:
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	initStore := func(properties map[string]string) (*vaultSecretStore, error) {
+		properties[componentVaultAddress] = server.URL
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		return v, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+	}
+
+	t.Run("the unwrapped token is used", func(t *testing.T) {
+		v, err := initStore(map[string]string{
+			componentVaultToken:       wrappingTok,
+			componentVaultUnwrapToken: "true",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, expectedTok, v.getToken())
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		require.NoError(t, err)
+		assert.Equal(t, "value", resp.Data["key"])
+	})
+
+	t.Run("a wrapping token can't be unwrapped twice", func(t *testing.T) {
+		_, err := initStore(map[string]string{
+			componentVaultToken:       wrappingTok,
+			componentVaultUnwrapToken: "true",
+		})
+		require.ErrorIs(t, err, ErrWrappingTokenInvalid)
+		assert.ErrorContains(t, err, "wrapping token is not valid or does not exist")
+	})
+
+	t.Run("the token isn't unwrapped by default", func(t *testing.T) {
+		v, err := initStore(map[string]string{
+			componentVaultToken: expectedTok,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, expectedTok, v.getToken())
+	})
+
+	t.Run("can't be used with re-authentication", func(t *testing.T) {
+		err := ValidateMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultTokenMountPath: "/var/run/secrets/vault",
+			componentVaultTokenReauth:    "true",
+			componentVaultUnwrapToken:    "true",
+		}}})
+		assert.ErrorContains(t, err, "vaultTokenReauth and vaultUnwrapToken are mutually exclusive")
+	})
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// ValidateMetadata checks the metadata of a component without contacting Vault nor reading the token, for example
+// to validate configurations in CI. Like Init, it returns the errors of all the invalid fields at once.
+func ValidateMetadata(meta secretstores.Metadata) error {
+	_, err := decodeAndValidateMetadata(meta.Properties)
+
+	return err
+}
+
+// decodeAndValidateMetadata decodes the metadata of a component and checks it, returning the errors of all the
+// invalid fields at once so that they can be fixed together.
+func decodeAndValidateMetadata(properties map[string]string) (VaultMetadata, error) {
+	m, err := decodeVaultMetadata(properties)
+	// Validate normalizes m, so it must run before m is returned
+	vErr := m.Validate()
+
+	return m, errors.Join(err, vErr, validateCaching(m, properties))
+}
+
+// validateCaching checks that vaultCacheTTL isn't combined with the common caching.* keys of secret stores: the
+// secrets would be cached twice, with different TTLs, and the watched secrets would only be invalidated in the
+// cache of the component.
+func validateCaching(m VaultMetadata, properties map[string]string) error {
+	// Invalid caching.* keys are reported by whoever wraps the store with the caching store
+	if _, caching, _ := secretstores.CachingOptionsFromMetadata(properties); caching && m.VaultCacheTTL > 0 {
+		return fmt.Errorf("vault init error, %s can't be set when caching.enabled is, as the secrets would be cached twice", componentVaultCacheTTL)
+	}
+
+	return nil
+}
+
+// Validate checks the metadata, and returns the errors of all the invalid fields at once. The slashes around the
+// KV prefix and the engine paths are trimmed, as Init does.
+func (m *VaultMetadata) Validate() error {
+	return errors.Join(m.normalizePaths(), m.validate(), validateAuthOptions(*m))
+}
+
+// prefixSeparators are the characters that can separate the KV prefix from the name of the secrets. They don't need
+// to be escaped in paths, and can't be confused with the segments of a path.
+const prefixSeparators = "/-_.:"
+
+// normalizePaths trims the slashes around the KV prefix and the engine paths, which would otherwise produce request
+// paths that don't match the secrets, and checks that they don't contain empty or relative segments.
+func (m *VaultMetadata) normalizePaths() error {
+	var errs []error
+	var err error
+
+	if m.VaultKVPrefix, err = normalizeVaultPath(m.VaultKVPrefix); err != nil {
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s %q: %w", componentVaultKVPrefix, m.VaultKVPrefix, err))
+	}
+	if m.EnginePath, err = normalizeVaultPath(m.EnginePath); err != nil {
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s %q: %w", vaultEnginePath, m.EnginePath, err))
+	}
+	if m.VaultLDAPMountPath, err = normalizeVaultPath(m.VaultLDAPMountPath); err != nil {
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s %q: %w", componentLDAPMountPath, m.VaultLDAPMountPath, err))
+	}
+	if m.VaultGCPMountPath, err = normalizeVaultPath(m.VaultGCPMountPath); err != nil {
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s %q: %w", componentGCPMountPath, m.VaultGCPMountPath, err))
+	}
+	for i, enginePath := range m.VaultEnginePaths {
+		if m.VaultEnginePaths[i], err = normalizeVaultPath(strings.TrimSpace(enginePath)); err != nil {
+			errs = append(errs, fmt.Errorf("vault init error, invalid %s %q: %w", vaultEnginePaths, enginePath, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// normalizeVaultPath returns the given path without its leading and trailing slashes. It returns the path unchanged
+// with an error if it has empty, "." or ".." segments, or consists of slashes only.
+func normalizeVaultPath(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return path, errors.New("the path must not be made of slashes only")
+	}
+	for _, segment := range strings.Split(trimmed, "/") {
+		switch segment {
+		case "":
+			return path, errors.New("the path must not contain empty segments")
+		case ".", "..":
+			return path, errors.New("the path must not contain relative segments")
+		}
+	}
+
+	return trimmed, nil
+}
+
+// validate checks the values of the metadata and their combinations, and returns the errors of all the invalid ones.
+// The auth options are checked by validateAuthOptions, as the token options are checked again when the token is read.
+func (m *VaultMetadata) validate() error {
+	var errs []error
+
+	for _, address := range vaultAddresses(m.VaultAddr) {
+		if err := validateVaultAddress(address); err != nil {
+			errs = append(errs, fmt.Errorf("vault init error, invalid %s %q: %w", componentVaultAddress, address, err))
+		}
+	}
+	for _, fallback := range trimmedValues(m.VaultAddrFallback) {
+		if err := validateVaultAddress(fallback); err != nil {
+			errs = append(errs, fmt.Errorf("vault init error, invalid %s %q: %w", componentVaultAddrFallback, fallback, err))
+		}
+	}
+
+	if m.VaultPrimaryAddr != "" {
+		if err := validateVaultAddress(m.VaultPrimaryAddr); err != nil {
+			errs = append(errs, fmt.Errorf("vault init error, invalid %s %q: %w", componentVaultPrimaryAddr, m.VaultPrimaryAddr, err))
+		}
+	}
+
+	if m.EnginePath != "" && len(trimmedValues(m.VaultEnginePaths)) > 0 {
+		errs = append(errs, fmt.Errorf("vault init error, %s and %s are mutually exclusive", vaultEnginePath, vaultEnginePaths))
+	}
+
+	switch engineType(m.VaultEngineType) {
+	case "", engineTypeKV, engineTypeDatabase:
+	case engineTypeCubbyhole:
+		if m.EnginePath != "" || len(trimmedValues(m.VaultEnginePaths)) > 0 {
+			errs = append(errs, fmt.Errorf("vault init error, %s and %s can't be set with %s %s, which is always mounted at %s",
+				vaultEnginePath, vaultEnginePaths, componentVaultEngineType, engineTypeCubbyhole, cubbyholeEnginePath))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s %s, accepted values are %s, %s or %s",
+			componentVaultEngineType, m.VaultEngineType, engineTypeKV, engineTypeDatabase, engineTypeCubbyhole))
+	}
+
+	switch valueType(m.VaultValueType) {
+	case "", valueTypeMap, valueTypeText:
+	default:
+		errs = append(errs, fmt.Errorf("vault init error, invalid value type %s, accepted values are map or text", m.VaultValueType))
+	}
+
+	// An empty separator means the default one, as in separator()
+	separator := m.VaultPrefixSeparator
+	if separator == "" {
+		separator = defaultPrefixSeparator
+	} else if !strings.Contains(prefixSeparators, separator) || len(separator) != 1 {
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s %q, accepted values are %s",
+			componentPrefixSeparator, separator, strings.Join(strings.Split(prefixSeparators, ""), " ")))
+	}
+
+	if m.VaultPathTemplate != "" {
+		if _, err := parsePathTemplate(m.VaultPathTemplate, separator); err != nil {
+			errs = append(errs, fmt.Errorf("vault init error, invalid %s %q: %w", componentPathTemplate, m.VaultPathTemplate, err))
+		}
+	}
+
+	if m.TextValueKey != "" && m.TextRawData {
+		errs = append(errs, fmt.Errorf("vault init error, %s and %s are mutually exclusive", vaultTextValueKey, vaultTextRawData))
+	}
+
+	if m.VaultMaxVersionsReturned < 0 {
+		errs = append(errs, fmt.Errorf("vault init error, %s must not be negative", componentMaxVersionsReturned))
+	}
+
+	if _, err := parseVaultHeaders(m.VaultHeaders); err != nil {
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s: %w", componentVaultHeaders, err))
+	}
+
+	tlsConf := metadataToTLSConfig(m)
+	if tlsConf.vaultSkipVerify && (m.TLSStrict || utils.IsTruthy(os.Getenv(envVaultTLSStrict))) {
+		errs = append(errs, fmt.Errorf("vault init error, %s is not allowed when %s is enabled", componentSkipVerify, componentTLSStrict))
+	}
+	if _, err := tlsConf.newTLSClientConfig(); err != nil {
+		errs = append(errs, fmt.Errorf("vault init error, invalid TLS configuration: %w", err))
+	}
+
+	if _, err := parseProxyURL(m.VaultProxyURL); err != nil {
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s: %w", componentVaultProxyURL, err))
+	}
+
+	if m.VaultMaxIdleConns < 0 || m.VaultMaxIdleConnsPerHost < 0 || m.VaultIdleConnTimeout < 0 {
+		errs = append(errs, fmt.Errorf("vault init error, %s, %s and %s must not be negative",
+			componentMaxIdleConns, componentMaxIdleConnsPerHost, componentIdleConnTimeout))
+	}
+
+	if m.VaultCircuitBreakerThreshold < 0 || m.VaultCircuitBreakerMaxBackoff < 0 {
+		errs = append(errs, fmt.Errorf("vault init error, %s and %s must not be negative",
+			componentCircuitBreaker, componentCircuitBreakerMax))
+	}
+
+	if m.VaultMaxResponseBytes < 0 {
+		errs = append(errs, fmt.Errorf("vault init error, %s must not be negative", componentMaxResponseBytes))
+	}
+
+	if m.VaultMaxRequestsPerSecond < 0 || m.VaultRateLimitBurst < 0 {
+		errs = append(errs, fmt.Errorf("vault init error, %s and %s must not be negative",
+			componentRateLimit, componentRateLimitBurst))
+	}
+
+	switch m.VaultRateLimitMode {
+	case "", rateLimitModeQueue, rateLimitModeFail:
+	default:
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s %q, accepted values are %q and %q",
+			componentRateLimitMode, m.VaultRateLimitMode, rateLimitModeQueue, rateLimitModeFail))
+	}
+
+	switch keyNameTransform(m.VaultKeyNameTransform) {
+	case "", keyNameTransformUpper, keyNameTransformLower, keyNameTransformEnvVar:
+	default:
+		errs = append(errs, fmt.Errorf("vault init error, invalid %s %q, accepted values are %q, %q and %q",
+			componentKeyNameTransform, m.VaultKeyNameTransform, keyNameTransformUpper, keyNameTransformLower, keyNameTransformEnvVar))
+	}
+
+	if m.VaultBulkPageSize < 0 {
+		errs = append(errs, fmt.Errorf("vault init error, %s must not be negative", componentBulkPageSize))
+	}
+
+	if m.VaultInitRetryTimeout < 0 {
+		errs = append(errs, fmt.Errorf("vault init error, %s must not be negative", componentInitRetryTimeout))
+	}
+
+	if m.VaultTokenReauth && m.VaultTokenMountPath == "" && m.VaultLDAPUsername == "" && m.VaultGCPRole == "" {
+		errs = append(errs, fmt.Errorf("vault init error, %s requires %s, to read the new tokens from, the LDAP credentials or %s", componentVaultTokenReauth, componentVaultTokenMountPath, componentGCPRole))
+	}
+
+	if m.VaultTokenMountPathWatch {
+		switch {
+		case m.VaultTokenMountPath == "":
+			errs = append(errs, fmt.Errorf("vault init error, %s requires %s", componentVaultTokenWatch, componentVaultTokenMountPath))
+		case m.VaultTokenReauth:
+			errs = append(errs, fmt.Errorf("vault init error, %s and %s are mutually exclusive", componentVaultTokenWatch, componentVaultTokenReauth))
+		case m.VaultUnwrapToken:
+			errs = append(errs, fmt.Errorf("vault init error, %s and %s are mutually exclusive", componentVaultTokenWatch, componentVaultUnwrapToken))
+		}
+	}
+
+	if m.VaultLazyInit && m.VaultInitRetryTimeout > 0 {
+		errs = append(errs, fmt.Errorf("vault init error, %s and %s are mutually exclusive", componentLazyInit, componentInitRetryTimeout))
+	}
+
+	if m.VaultTokenReauth && m.VaultUnwrapToken {
+		errs = append(errs, fmt.Errorf("vault init error, %s and %s are mutually exclusive", componentVaultTokenReauth, componentVaultUnwrapToken))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateAuthOptions checks the options of the auth method: the GCP role or the LDAP credentials if any is set, and
+// the token options otherwise.
+func validateAuthOptions(m VaultMetadata) error {
+	if m.VaultGCPRole != "" {
+		return validateGCPOptions(m)
+	}
+	if m.VaultLDAPUsername != "" || m.VaultLDAPPassword != "" {
+		return validateLDAPOptions(m)
+	}
+
+	return validateTokenOptions(m.VaultToken, m.VaultTokenMountPath)
+}
+
+// validateTokenOptions checks that exactly one of the token and the path of the file with the token is set.
+func validateTokenOptions(token, tokenMountPath string) error {
+	if token == "" && tokenMountPath == "" {
+		return errors.New("token mount path and token not set")
+	}
+	if token != "" && tokenMountPath != "" {
+		return errors.New("token mount path and token both set")
+	}
+
+	return nil
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	validate := func(properties map[string]string) error {
+		return ValidateMetadata(secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+	}
+
+	t.Run("valid metadata", func(t *testing.T) {
+		// Vault is never contacted, nor the token file read
+		var requests atomic.Int64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+		}))
+		defer server.Close()
+
+		for _, properties := range []map[string]string{
+			{componentVaultAddress: server.URL, componentVaultToken: expectedTok},
+			{componentVaultAddress: server.URL, componentVaultTokenMountPath: "/does/not/exist"},
+			{componentVaultAddress: server.URL, componentLDAPUsername: "jdoe", componentLDAPPassword: "secret"},
+			{
+				componentVaultAddress:      server.URL,
+				componentVaultAddrFallback: "https://standby:8200",
+				componentVaultToken:        expectedTok,
+				vaultEnginePaths:           "kv-team,kv-shared",
+				componentVaultEngineType:   "kv",
+				vaultValueType:             "text",
+				vaultTextRawData:           "true",
+				componentVaultHeaders:      "X-Team=payments",
+				componentVaultProxyURL:     "http://proxy:3128",
+				componentTLSMinVersion:     "1.3",
+				componentPrefixSeparator:   ":",
+			},
+			{componentVaultAddress: server.URL, componentVaultToken: expectedTok, componentVaultCacheTTL: "1m", "caching.enabled": "false"},
+		} {
+			assert.NoError(t, validate(properties), properties)
+		}
+		assert.Zero(t, requests.Load())
+	})
+
+	tests := map[string]struct {
+		properties map[string]string
+		err        string
+	}{
+		"token and token mount path both set": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentVaultTokenMountPath: "/token"},
+			err:        "token mount path and token both set",
+		},
+		"neither token nor token mount path set": {
+			properties: map[string]string{},
+			err:        "token mount path and token not set",
+		},
+		"invalid vaultAddr": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentVaultAddress: "ftp://vault:8200"},
+			err:        `invalid vaultAddr "ftp://vault:8200": unsupported scheme "ftp"`,
+		},
+		"invalid vaultAddrFallback": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentVaultAddrFallback: "https://:8200"},
+			err:        `invalid vaultAddrFallback "https://:8200": missing host`,
+		},
+		"enginePath and vaultEnginePaths both set": {
+			properties: map[string]string{componentVaultToken: expectedTok, vaultEnginePath: "kv", vaultEnginePaths: "kv-team"},
+			err:        "enginePath and vaultEnginePaths are mutually exclusive",
+		},
+		"invalid vaultEngineType": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentVaultEngineType: "pki"},
+			err:        "invalid vaultEngineType pki",
+		},
+		"invalid vaultValueType": {
+			properties: map[string]string{componentVaultToken: expectedTok, vaultValueType: "json"},
+			err:        "invalid value type json",
+		},
+		"invalid vaultPrefixSeparator": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentPrefixSeparator: "%"},
+			err:        `invalid vaultPrefixSeparator "%", accepted values are / - _ . :`,
+		},
+		"vaultPrefixSeparator longer than a character": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentPrefixSeparator: "--"},
+			err:        `invalid vaultPrefixSeparator "--"`,
+		},
+		"textValueKey and textRawData both set": {
+			properties: map[string]string{componentVaultToken: expectedTok, vaultTextValueKey: "value", vaultTextRawData: "true"},
+			err:        "textValueKey and textRawData are mutually exclusive",
+		},
+		"negative vaultMaxVersionsReturned": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentMaxVersionsReturned: "-1"},
+			err:        "vaultMaxVersionsReturned must not be negative",
+		},
+		"invalid vaultHeaders": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentVaultHeaders: "X-Team"},
+			err:        "invalid vaultHeaders",
+		},
+		"skipVerify with tlsStrict": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentSkipVerify: "true", componentTLSStrict: "true"},
+			err:        "skipVerify is not allowed when tlsStrict is enabled",
+		},
+		"invalid TLS configuration": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentTLSMinVersion: "1.4"},
+			err:        "invalid TLS configuration",
+		},
+		"invalid vaultTLSRenegotiation": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentTLSRenegotiation: "always"},
+			err:        "invalid vaultTLSRenegotiation",
+		},
+		"invalid vaultProxyURL": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentVaultProxyURL: "ftp://proxy"},
+			err:        "invalid vaultProxyURL",
+		},
+		"negative connection pool settings": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentMaxIdleConns: "-1"},
+			err:        "vaultMaxIdleConns, vaultMaxIdleConnsPerHost and vaultIdleConnTimeout must not be negative",
+		},
+		"negative vaultInitRetryTimeout": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentInitRetryTimeout: "-1s"},
+			err:        "vaultInitRetryTimeout must not be negative",
+		},
+		"vaultLazyInit with vaultInitRetryTimeout": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentLazyInit: "true", componentInitRetryTimeout: "1m"},
+			err:        "vaultLazyInit and vaultInitRetryTimeout are mutually exclusive",
+		},
+		"LDAP username without password": {
+			properties: map[string]string{componentLDAPUsername: "jdoe"},
+			err:        "vaultLDAPUsername and vaultLDAPPassword must be set together",
+		},
+		"LDAP password without username": {
+			properties: map[string]string{componentLDAPPassword: "secret"},
+			err:        "vaultLDAPUsername and vaultLDAPPassword must be set together",
+		},
+		"LDAP credentials with a token": {
+			properties: map[string]string{componentLDAPUsername: "jdoe", componentLDAPPassword: "secret", componentVaultToken: expectedTok},
+			err:        "the LDAP credentials can't be used with vaultToken or vaultTokenMountPath",
+		},
+		"invalid vaultGCPAuthType": {
+			properties: map[string]string{componentGCPRole: "my-role", componentGCPAuthType: "aws"},
+			err:        `invalid vaultGCPAuthType "aws", accepted values are "iam" and "gce"`,
+		},
+		"GCP role with a token": {
+			properties: map[string]string{componentGCPRole: "my-role", componentVaultToken: expectedTok},
+			err:        "vaultGCPRole can't be used with vaultToken, vaultTokenMountPath or the LDAP credentials",
+		},
+		"GCP role with vaultUnwrapToken": {
+			properties: map[string]string{componentGCPRole: "my-role", componentVaultUnwrapToken: "true"},
+			err:        "vaultUnwrapToken can't be used with vaultGCPRole",
+		},
+		"negative vaultMaxResponseBytes": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentMaxResponseBytes: "-1"},
+			err:        "vaultMaxResponseBytes must not be negative",
+		},
+		"negative vaultMaxRequestsPerSecond": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentRateLimit: "-10"},
+			err:        "vaultMaxRequestsPerSecond and vaultRateLimitBurst must not be negative",
+		},
+		"invalid vaultRateLimitMode": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentRateLimit: "10", componentRateLimitMode: "drop"},
+			err:        `invalid vaultRateLimitMode "drop", accepted values are "queue" and "fail"`,
+		},
+		"invalid vaultKeyNameTransform": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentKeyNameTransform: "camel"},
+			err:        `invalid vaultKeyNameTransform "camel", accepted values are "upper", "lower" and "envvar"`,
+		},
+		"negative vaultBulkPageSize": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentBulkPageSize: "-1"},
+			err:        "vaultBulkPageSize must not be negative",
+		},
+		"unrecognized token in vaultPathTemplate": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentPathTemplate: "{prefix}/{tenant}/{secret}"},
+			err:        "unrecognized token {tenant}, accepted tokens are {prefix}, {appID} and {secret}",
+		},
+		"vaultCacheTTL with the common caching": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentVaultCacheTTL: "1m", "caching.enabled": "true"},
+			err:        "vaultCacheTTL can't be set when caching.enabled is, as the secrets would be cached twice",
+		},
+		"undecodable value": {
+			properties: map[string]string{componentVaultToken: expectedTok, componentMaxIdleConns: "many"},
+			err:        "cannot parse 'vaultMaxIdleConns' as int",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorContains(t, validate(tt.properties), tt.err)
+		})
+	}
+
+	t.Run("all errors are returned at once", func(t *testing.T) {
+		err := validate(map[string]string{
+			componentVaultToken:          expectedTok,
+			componentVaultTokenMountPath: "/token",
+			componentVaultAddress:        "ftp://vault:8200",
+			vaultValueType:               "json",
+		})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "token mount path and token both set")
+		assert.ErrorContains(t, err, "invalid vaultAddr")
+		assert.ErrorContains(t, err, "invalid value type json")
+	})
+}
+
+func TestMetadataValidate(t *testing.T) {
+	t.Run("several invalid fields", func(t *testing.T) {
+		m := Metadata{
+			VaultAddr:                "vault:8200",
+			VaultKVPrefix:            "dapr//apps",
+			VaultEngineType:          "pki",
+			VaultPrefixSeparator:     "|",
+			VaultMaxVersionsReturned: -1,
+			TextValueKey:             "value",
+			TextRawData:              true,
+		}
+
+		err := m.Validate()
+		require.Error(t, err)
+		for _, msg := range []string{
+			"invalid vaultAddr",
+			`invalid vaultKVPrefix "dapr//apps"`,
+			"invalid vaultEngineType pki",
+			`invalid vaultPrefixSeparator "|"`,
+			"vaultMaxVersionsReturned must not be negative",
+			"textValueKey and textRawData are mutually exclusive",
+			"token mount path and token not set",
+		} {
+			assert.ErrorContains(t, err, msg)
+		}
+	})
+
+	t.Run("valid fields are normalized", func(t *testing.T) {
+		m := Metadata{
+			VaultToken:           expectedTok,
+			VaultKVPrefix:        "/dapr/apps/",
+			VaultPrefixSeparator: defaultPrefixSeparator,
+		}
+
+		require.NoError(t, m.Validate())
+		assert.Equal(t, "dapr/apps", m.VaultKVPrefix)
+	})
+
+	t.Run("an empty separator is the default one", func(t *testing.T) {
+		m := Metadata{
+			VaultToken:        expectedTok,
+			VaultPathTemplate: "{prefix}/{secret}",
+		}
+
+		require.NoError(t, m.Validate())
+	})
+
+	t.Run("the decoded metadata is returned normalized", func(t *testing.T) {
+		m, err := decodeAndValidateMetadata(map[string]string{
+			componentVaultToken:    expectedTok,
+			componentVaultKVPrefix: "/dapr/apps/",
+			vaultEnginePath:        "/kv/",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "dapr/apps", m.VaultKVPrefix)
+		assert.Equal(t, "kv", m.EnginePath)
+	})
+
+	t.Run("the fields are decoded from their metadata keys", func(t *testing.T) {
+		m, err := decodeVaultMetadata(map[string]string{
+			componentVaultAddress:    "https://vault:8200",
+			componentVaultKVPrefix:   "apps",
+			componentTLSServerName:   "vault.internal",
+			componentMaxIdleConns:    "10",
+			componentVaultToken:      expectedTok,
+			componentVaultEngineType: "kv",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "https://vault:8200", m.VaultAddr)
+		assert.Equal(t, "apps", m.VaultKVPrefix)
+		assert.Equal(t, "vault.internal", m.TLSServerName)
+		assert.Equal(t, 10, m.VaultMaxIdleConns)
+		assert.Equal(t, "kv", m.VaultEngineType)
+	})
+
+	t.Run("credentials are not serialized", func(t *testing.T) {
+		m := Metadata{
+			VaultAddr:         "https://vault:8200",
+			VaultToken:        expectedTok,
+			VaultLDAPPassword: "secret",
+			ClientKey:         "key.pem",
+		}
+
+		b, err := json.Marshal(m)
+		require.NoError(t, err)
+		assert.Contains(t, string(b), `"vaultAddr":"https://vault:8200"`)
+		assert.NotContains(t, string(b), expectedTok)
+		assert.NotContains(t, string(b), "secret")
+		assert.NotContains(t, string(b), "key.pem")
+	})
+}
@@ -14,23 +14,27 @@ limitations under the License.
 package vault
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	"golang.org/x/net/http2"
 
+	"github.com/dapr/components-contrib/internal/tlsconfig"
+	"github.com/dapr/components-contrib/internal/utils"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
@@ -40,23 +44,89 @@ const (
 	defaultVaultAddress          string = "https://127.0.0.1:8200"
 	defaultVaultEnginePath       string = "secret"
 	componentVaultAddress        string = "vaultAddr"
+	componentVaultAddrFallback   string = "vaultAddrFallback"
+	componentVaultPrimaryAddr    string = "vaultPrimaryAddr"
 	componentCaCert              string = "caCert"
 	componentCaPath              string = "caPath"
 	componentCaPem               string = "caPem"
 	componentSkipVerify          string = "skipVerify"
 	componentTLSServerName       string = "tlsServerName"
+	componentVaultTLSServerName  string = "vaultTLSServerName"
+	componentTLSMinVersion       string = "tlsMinVersion"
+	componentClientCert          string = "clientCert"
+	componentClientKey           string = "clientKey"
+	componentTLSStrict           string = "tlsStrict"
 	componentVaultToken          string = "vaultToken"
 	componentVaultTokenMountPath string = "vaultTokenMountPath"
 	componentVaultKVPrefix       string = "vaultKVPrefix"
 	componentVaultKVUsePrefix    string = "vaultKVUsePrefix"
+	componentPrefixSeparator     string = "vaultPrefixSeparator"
 	defaultVaultKVPrefix         string = "dapr"
+	defaultPrefixSeparator       string = "/"
 	vaultHTTPHeader              string = "X-Vault-Token"
 	vaultHTTPRequestHeader       string = "X-Vault-Request"
 	vaultEnginePath              string = "enginePath"
+	vaultEnginePaths             string = "vaultEnginePaths"
 	vaultValueType               string = "vaultValueType"
+	vaultTextValueKey            string = "textValueKey"
+	vaultTextRawData             string = "textRawData"
+	componentVaultHeaders        string = "vaultHeaders"
+	componentVaultNamespace      string = "vaultNamespace"
+	componentVaultProxyURL       string = "vaultProxyURL"
+	componentWatchInterval       string = "vaultWatchInterval"
+	componentVaultExpandEnv      string = "vaultExpandEnv"
+	componentCaseInsensitive     string = "vaultCaseInsensitiveLookup"
 	versionID                    string = "version_id"
+	allVersions                  string = "allVersions"
+	absolutePath                 string = "absolutePath"
+	requestNamespace             string = "namespace"
+	includeLeaseInfo             string = "includeLeaseInfo"
+	bulkPath                     string = "path"
+	componentMaxVersionsReturned string = "vaultMaxVersionsReturned"
+	componentVaultEngineType     string = "vaultEngineType"
+	componentMaxIdleConns        string = "vaultMaxIdleConns"
+	componentMaxIdleConnsPerHost string = "vaultMaxIdleConnsPerHost"
+	componentIdleConnTimeout     string = "vaultIdleConnTimeout"
+	componentVaultTokenRenew     string = "vaultTokenRenew"
+	componentInitRetryTimeout    string = "vaultInitRetryTimeout"
+	componentVaultTokenReauth    string = "vaultTokenReauth"
+	componentVaultUnwrapToken    string = "vaultUnwrapToken"
+	componentVaultDecodeBase64   string = "vaultDecodeBase64"
+	componentCircuitBreaker      string = "vaultCircuitBreakerThreshold"
+	componentCircuitBreakerMax   string = "vaultCircuitBreakerMaxBackoff"
+	componentAllowAbsolutePaths  string = "vaultAllowAbsolutePaths"
+	componentLDAPUsername        string = "vaultLDAPUsername"
+	componentLDAPPassword        string = "vaultLDAPPassword"
+	componentLDAPMountPath       string = "vaultLDAPMountPath"
+	componentMaxResponseBytes    string = "vaultMaxResponseBytes"
+	componentAutoRenewLeases     string = "vaultAutoRenewLeases"
+	componentPathTemplate        string = "vaultPathTemplate"
+	componentTLSRenegotiation    string = "vaultTLSRenegotiation"
+	componentRateLimit           string = "vaultMaxRequestsPerSecond"
+	componentRateLimitBurst      string = "vaultRateLimitBurst"
+	componentRateLimitMode       string = "vaultRateLimitMode"
+	componentAllowedFields       string = "vaultAllowedFields"
+	componentBulkPageSize        string = "vaultBulkPageSize"
+	componentGCPRole             string = "vaultGCPRole"
+	componentGCPMountPath        string = "vaultGCPMountPath"
+	componentGCPAuthType         string = "vaultGCPAuthType"
+	componentLazyInit            string = "vaultLazyInit"
+	componentKeyNameTransform    string = "vaultKeyNameTransform"
+	componentVaultTokenWatch     string = "vaultTokenMountPathWatch"
+	componentVaultCacheTTL       string = "vaultCacheTTL"
+
+	// Keys of the metadata returned with a secret.
+	secretMetadataVersion       string = "version"
+	secretMetadataCreatedTime   string = "created_time"
+	secretMetadataDeletionTime  string = "deletion_time"
+	secretMetadataLeaseID       string = "lease_id"
+	secretMetadataLeaseDuration string = "lease_duration"
+	secretMetadataRenewable     string = "renewable"
 
 	DataStr string = "data"
+
+	// envVaultTLSStrict enforces tlsStrict for every HashiCorp Vault component running in this environment.
+	envVaultTLSStrict string = "DAPR_HASHICORP_VAULT_TLS_STRICT"
 )
 
 type valueType string
@@ -66,44 +136,175 @@ const (
 	valueTypeText valueType = "text"
 )
 
+// engineType is the type of the secrets engine mounted at the engine path.
+type engineType string
+
+const (
+	// engineTypeKV is the KV version 2 secrets engine.
+	engineTypeKV engineType = "kv"
+	// engineTypeDatabase is the database secrets engine, which generates credentials for a role on each read.
+	engineTypeDatabase engineType = "database"
+	// engineTypeCubbyhole is the cubbyhole secrets engine, which stores secrets private to the token of the component.
+	engineTypeCubbyhole engineType = "cubbyhole"
+
+	defaultVaultDatabaseEnginePath string = "database"
+)
+
 var _ secretstores.SecretStore = (*vaultSecretStore)(nil)
 
 func (v valueType) isMapType() bool {
 	return v == valueTypeMap
 }
 
-var ErrNotFound = errors.New("secret key or version not exist")
+// ErrNotFound is returned when the secret or its version doesn't exist. It matches secretstores.ErrSecretNotFound.
+var ErrNotFound = fmt.Errorf("secret key or version not exist: %w", secretstores.ErrSecretNotFound)
+
+// ErrPermissionDenied is returned when Vault denies access to a path with a 403, usually because no policy of the
+// token grants it, as opposed to the path not existing.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// envReferencePattern matches the `${NAME}` references expanded when vaultExpandEnv is enabled.
+var envReferencePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// TokenTTLInfinite is the TTL reported for tokens that never expire, such as root tokens.
+const TokenTTLInfinite time.Duration = -1
+
+// clientFactory creates the HTTP client used to send the requests to Vault.
+// It replaces createHTTPClient when set, for example to inject a transport in tests.
+type clientFactory func(config *tlsConfig, proxyURL *url.URL, pool connPoolConfig) (*http.Client, error)
 
 // vaultSecretStore is a secret store implementation for HashiCorp Vault.
 type vaultSecretStore struct {
 	client              *http.Client
+	newClient           clientFactory
 	vaultAddress        string
 	vaultToken          string
 	vaultTokenMountPath string
 	vaultKVPrefix       string
+	prefixSeparator     string
 	vaultEnginePath     string
+	vaultEnginePaths    []string
 	vaultValueType      valueType
+	engineType          engineType
+	textValueKey        string
+	textRawData         bool
+	vaultHeaders        map[string]string
+	vaultNamespace      string
+	caseInsensitive     bool
+	decodeBase64        bool
+	allowAbsolutePaths  bool
+	suppressNotFound    bool
+	maxVersionsReturned int
+	cache               *secretCache
+	// allowedFields are the only keys of the secrets returned, if set.
+	allowedFields map[string]struct{}
+	// keyNameTransform is applied to the keys of the secrets returned, if set.
+	keyNameTransform keyNameTransform
+	// bulkPageSize is the number of secrets read at once by bulk retrieval.
+	bulkPageSize int
+	// leases renews the leases of the secrets read by the component, if set.
+	leases *leaseRenewer
+	// auth logs in with credentials to obtain the token, if set.
+	auth authMethod
+	// lazy connects to Vault on first use, if set.
+	lazy *lazyConnection
+
+	// loginToken is the token obtained by logging in with auth, which is revoked on close if it's still used.
+	loginToken string
+
+	tokenLock     sync.RWMutex
+	watchLock     sync.RWMutex
+	changeHandler SecretChangeHandler
+	closeCancel   context.CancelFunc
+	wg            sync.WaitGroup
 
 	json jsoniter.API
 
 	logger logger.Logger
 }
 
+// VaultMetadata is the metadata of the component. The fields are decoded from the metadata keys in their mapstructure
+// tags, and serialized to JSON with the same names, without the credentials.
 type VaultMetadata struct {
-	CaCert              string
-	CaPath              string
-	CaPem               string
-	SkipVerify          string
-	TLSServerName       string
-	VaultAddr           string
-	VaultKVPrefix       string
-	VaultKVUsePrefix    bool
-	VaultToken          string
-	VaultTokenMountPath string
-	EnginePath          string
-	VaultValueType      string
+	CaCert                     string        `mapstructure:"caCert" json:"caCert,omitempty"`
+	CaPath                     string        `mapstructure:"caPath" json:"caPath,omitempty"`
+	CaPem                      string        `mapstructure:"caPem" json:"caPem,omitempty"`
+	SkipVerify                 string        `mapstructure:"skipVerify" json:"skipVerify,omitempty"`
+	TLSServerName              string        `mapstructure:"tlsServerName" json:"tlsServerName,omitempty" mdaliases:"vaultTLSServerName"`
+	TLSMinVersion              string        `mapstructure:"tlsMinVersion" json:"tlsMinVersion,omitempty"`
+	ClientCert                 string        `mapstructure:"clientCert" json:"clientCert,omitempty"`
+	ClientKey                  string        `mapstructure:"clientKey" json:"-"`
+	TLSStrict                  bool          `mapstructure:"tlsStrict" json:"tlsStrict,omitempty"`
+	VaultAddr                  string        `mapstructure:"vaultAddr" json:"vaultAddr,omitempty"`
+	VaultAddrFallback          []string      `mapstructure:"vaultAddrFallback" json:"vaultAddrFallback,omitempty"`
+	VaultPrimaryAddr           string        `mapstructure:"vaultPrimaryAddr" json:"vaultPrimaryAddr,omitempty"`
+	VaultKVPrefix              string        `mapstructure:"vaultKVPrefix" json:"vaultKVPrefix,omitempty"`
+	VaultKVUsePrefix           bool          `mapstructure:"vaultKVUsePrefix" json:"vaultKVUsePrefix" mddefault:"true"`
+	VaultPrefixSeparator       string        `mapstructure:"vaultPrefixSeparator" json:"vaultPrefixSeparator,omitempty" mddefault:"/"`
+	VaultToken                 string        `mapstructure:"vaultToken" json:"-"`
+	VaultTokenMountPath        string        `mapstructure:"vaultTokenMountPath" json:"vaultTokenMountPath,omitempty"`
+	EnginePath                 string        `mapstructure:"enginePath" json:"enginePath,omitempty"`
+	VaultEnginePaths           []string      `mapstructure:"vaultEnginePaths" json:"vaultEnginePaths,omitempty"`
+	VaultValueType             string        `mapstructure:"vaultValueType" json:"vaultValueType,omitempty"`
+	VaultEngineType            string        `mapstructure:"vaultEngineType" json:"vaultEngineType,omitempty"`
+	TextValueKey               string        `mapstructure:"textValueKey" json:"textValueKey,omitempty"`
+	TextRawData                bool          `mapstructure:"textRawData" json:"textRawData,omitempty"`
+	VaultHeaders               string        `mapstructure:"vaultHeaders" json:"vaultHeaders,omitempty"`
+	VaultNamespace             string        `mapstructure:"vaultNamespace" json:"vaultNamespace,omitempty"`
+	VaultProxyURL              string        `mapstructure:"vaultProxyURL" json:"vaultProxyURL,omitempty"`
+	VaultCacheTTL              time.Duration `mapstructure:"vaultCacheTTL" json:"vaultCacheTTL,omitempty"`
+	WatchSecrets               []string      `mapstructure:"watchSecrets" json:"watchSecrets,omitempty"`
+	WatchPollInterval          time.Duration `mapstructure:"watchPollInterval" json:"watchPollInterval,omitempty" mdaliases:"vaultWatchInterval"`
+	VaultExpandEnv             bool          `mapstructure:"vaultExpandEnv" json:"vaultExpandEnv,omitempty"`
+	VaultCaseInsensitiveLookup bool          `mapstructure:"vaultCaseInsensitiveLookup" json:"vaultCaseInsensitiveLookup,omitempty"`
+	VaultMaxVersionsReturned   int           `mapstructure:"vaultMaxVersionsReturned" json:"vaultMaxVersionsReturned,omitempty"`
+	VaultMaxIdleConns          int           `mapstructure:"vaultMaxIdleConns" json:"vaultMaxIdleConns,omitempty"`
+	VaultMaxIdleConnsPerHost   int           `mapstructure:"vaultMaxIdleConnsPerHost" json:"vaultMaxIdleConnsPerHost,omitempty"`
+	VaultIdleConnTimeout       time.Duration `mapstructure:"vaultIdleConnTimeout" json:"vaultIdleConnTimeout,omitempty"`
+	VaultTokenRenew            bool          `mapstructure:"vaultTokenRenew" json:"vaultTokenRenew,omitempty"`
+	VaultInitRetryTimeout      time.Duration `mapstructure:"vaultInitRetryTimeout" json:"vaultInitRetryTimeout,omitempty"`
+	VaultTokenReauth           bool          `mapstructure:"vaultTokenReauth" json:"vaultTokenReauth,omitempty"`
+	VaultUnwrapToken           bool          `mapstructure:"vaultUnwrapToken" json:"vaultUnwrapToken,omitempty"`
+	VaultSuppressNotFound      bool          `mapstructure:"vaultSuppressNotFound" json:"vaultSuppressNotFound,omitempty"`
+	VaultDecodeBase64          bool          `mapstructure:"vaultDecodeBase64" json:"vaultDecodeBase64,omitempty"`
+	// Number of consecutive transport failures that open the circuit breaker, 0 disables it
+	VaultCircuitBreakerThreshold  int           `mapstructure:"vaultCircuitBreakerThreshold" json:"vaultCircuitBreakerThreshold,omitempty" mddefault:"5"`
+	VaultCircuitBreakerMaxBackoff time.Duration `mapstructure:"vaultCircuitBreakerMaxBackoff" json:"vaultCircuitBreakerMaxBackoff,omitempty"`
+	VaultAllowAbsolutePaths       bool          `mapstructure:"vaultAllowAbsolutePaths" json:"vaultAllowAbsolutePaths,omitempty"`
+	VaultLDAPUsername             string        `mapstructure:"vaultLDAPUsername" json:"vaultLDAPUsername,omitempty"`
+	VaultLDAPPassword             string        `mapstructure:"vaultLDAPPassword" json:"-"`
+	VaultLDAPMountPath            string        `mapstructure:"vaultLDAPMountPath" json:"vaultLDAPMountPath,omitempty" mddefault:"ldap"`
+	VaultMaxResponseBytes         int64         `mapstructure:"vaultMaxResponseBytes" json:"vaultMaxResponseBytes,omitempty"`
+	VaultAutoRenewLeases          bool          `mapstructure:"vaultAutoRenewLeases" json:"vaultAutoRenewLeases,omitempty"`
+	// Path of the secrets under the engine, such as "{prefix}/{appID}/{secret}", replacing the KV prefix if set
+	VaultPathTemplate string `mapstructure:"vaultPathTemplate" json:"vaultPathTemplate,omitempty"`
+	// TLS renegotiation accepted from Vault or the proxies in front of it: never, once or freely
+	VaultTLSRenegotiation string `mapstructure:"vaultTLSRenegotiation" json:"vaultTLSRenegotiation,omitempty"`
+	// Maximum rate of the requests to Vault, 0 disables the limit
+	VaultMaxRequestsPerSecond float64 `mapstructure:"vaultMaxRequestsPerSecond" json:"vaultMaxRequestsPerSecond,omitempty"`
+	VaultRateLimitBurst       int     `mapstructure:"vaultRateLimitBurst" json:"vaultRateLimitBurst,omitempty" mddefault:"1"`
+	// What requests over the rate limit do: queue, to wait for their turn, or fail
+	VaultRateLimitMode string `mapstructure:"vaultRateLimitMode" json:"vaultRateLimitMode,omitempty" mddefault:"queue"`
+	// Keys of the secrets returned, the other ones are dropped. All the keys are returned if empty
+	VaultAllowedFields []string `mapstructure:"vaultAllowedFields" json:"vaultAllowedFields,omitempty"`
+	// Number of the secrets read at once by bulk retrieval, as they're listed
+	VaultBulkPageSize int `mapstructure:"vaultBulkPageSize" json:"vaultBulkPageSize,omitempty" mddefault:"1"`
+	// Role to log in with the GCP auth method, with a JWT obtained from the metadata server of GCE or GKE
+	VaultGCPRole      string `mapstructure:"vaultGCPRole" json:"vaultGCPRole,omitempty"`
+	VaultGCPMountPath string `mapstructure:"vaultGCPMountPath" json:"vaultGCPMountPath,omitempty" mddefault:"gcp"`
+	// Type of the GCP role: iam, for the service account of the workload, or gce, for the instance
+	VaultGCPAuthType string `mapstructure:"vaultGCPAuthType" json:"vaultGCPAuthType,omitempty" mddefault:"iam"`
+	// Connect to Vault, reading the token or logging in, on first use rather than during Init
+	VaultLazyInit bool `mapstructure:"vaultLazyInit" json:"vaultLazyInit,omitempty"`
+	// Transform of the keys of the secrets returned: upper, lower or envvar. The keys are returned as they are if empty
+	VaultKeyNameTransform string `mapstructure:"vaultKeyNameTransform" json:"vaultKeyNameTransform,omitempty"`
+	// Reload the token when the file of vaultTokenMountPath changes, once Vault accepts the new token
+	VaultTokenMountPathWatch bool `mapstructure:"vaultTokenMountPathWatch" json:"vaultTokenMountPathWatch,omitempty"`
 }
 
+// Metadata is the typed metadata of the component, which can be checked with Validate.
+type Metadata = VaultMetadata
+
 // tlsConfig is TLS configuration to interact with HashiCorp Vault.
 type tlsConfig struct {
 	vaultCAPem      string
@@ -111,6 +312,26 @@ type tlsConfig struct {
 	vaultCAPath     string
 	vaultSkipVerify bool
 	vaultServerName string
+	vaultMinVersion string
+	vaultClientCert string
+	vaultClientKey  string
+	// vaultRenegotiation is the value of vaultTLSRenegotiation.
+	vaultRenegotiation string
+}
+
+// tlsRenegotiations maps the values of vaultTLSRenegotiation to the renegotiation support of the TLS client.
+var tlsRenegotiations = map[string]tls.RenegotiationSupport{
+	"never":  tls.RenegotiateNever,
+	"once":   tls.RenegotiateOnceAsClient,
+	"freely": tls.RenegotiateFreelyAsClient,
+}
+
+// connPoolConfig tunes the pool of connections kept open to Vault.
+// Zero values keep the defaults of http.Transport.
+type connPoolConfig struct {
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
 }
 
 // vaultKVResponse is the response data from Vault KV.
@@ -118,6 +339,47 @@ type vaultKVResponse struct {
 	Data struct {
 		Data map[string]string `json:"data"`
 	} `json:"data"`
+
+	Info vaultKVResponseInfo `json:"-"`
+}
+
+// vaultKVResponseInfo is the information about the secret and its lease in the response data from Vault KV.
+type vaultKVResponseInfo struct {
+	LeaseID       string `json:"lease_id"`
+	LeaseDuration int64  `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+	Data          struct {
+		Metadata struct {
+			CreatedTime  string `json:"created_time"`
+			DeletionTime string `json:"deletion_time"`
+			Version      int    `json:"version"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+// secretMetadata returns the metadata of the secret returned to callers, or nil if there's none.
+func (i vaultKVResponseInfo) secretMetadata() map[string]string {
+	res := map[string]string{}
+	if i.Data.Metadata.Version > 0 {
+		res[secretMetadataVersion] = strconv.Itoa(i.Data.Metadata.Version)
+	}
+	if i.Data.Metadata.CreatedTime != "" {
+		res[secretMetadataCreatedTime] = i.Data.Metadata.CreatedTime
+	}
+	if i.Data.Metadata.DeletionTime != "" {
+		res[secretMetadataDeletionTime] = i.Data.Metadata.DeletionTime
+	}
+	if i.LeaseID != "" || i.LeaseDuration > 0 {
+		res[secretMetadataLeaseID] = i.LeaseID
+		res[secretMetadataLeaseDuration] = strconv.FormatInt(i.LeaseDuration, 10)
+		res[secretMetadataRenewable] = strconv.FormatBool(i.Renewable)
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	return res
 }
 
 // vaultListKVResponse is the response data from Vault KV.
@@ -127,6 +389,52 @@ type vaultListKVResponse struct {
 	} `json:"data"`
 }
 
+// vaultTokenLookupResponse is the response data from Vault's token lookup-self endpoint.
+type vaultTokenLookupResponse struct {
+	Data struct {
+		TTL            int64    `json:"ttl"`
+		ExpireTime     *string  `json:"expire_time"`
+		IssueTime      *string  `json:"issue_time"`
+		ExplicitMaxTTL int64    `json:"explicit_max_ttl"`
+		Type           string   `json:"type"`
+		Renewable      bool     `json:"renewable"`
+		Policies       []string `json:"policies"`
+	} `json:"data"`
+}
+
+// ttl returns the remaining time-to-live of the token, or TokenTTLInfinite if it never expires.
+func (d *vaultTokenLookupResponse) ttl() time.Duration {
+	// Tokens without an expiration time (e.g. root tokens) have a TTL of 0.
+	if d.Data.TTL == 0 && d.Data.ExpireTime == nil {
+		return TokenTTLInfinite
+	}
+
+	return time.Duration(d.Data.TTL) * time.Second
+}
+
+// lifetime returns the time left before the token expires or reaches its explicit max TTL, which renewals can't
+// extend, whichever comes first. Tokens that never expire report TokenTTLInfinite.
+func (d *vaultTokenLookupResponse) lifetime(now time.Time) time.Duration {
+	ttl := d.ttl()
+	if d.Data.ExplicitMaxTTL <= 0 || d.Data.IssueTime == nil {
+		return ttl
+	}
+	issued, err := time.Parse(time.RFC3339Nano, *d.Data.IssueTime)
+	if err != nil {
+		return ttl
+	}
+
+	left := issued.Add(time.Duration(d.Data.ExplicitMaxTTL) * time.Second).Sub(now)
+	if left < 0 {
+		left = 0
+	}
+	if ttl == TokenTTLInfinite || left < ttl {
+		return left
+	}
+
+	return ttl
+}
+
 // NewHashiCorpVaultSecretStore returns a new HashiCorp Vault secret store.
 func NewHashiCorpVaultSecretStore(logger logger.Logger) secretstores.SecretStore {
 	return &vaultSecretStore{
@@ -137,67 +445,249 @@ func NewHashiCorpVaultSecretStore(logger logger.Logger) secretstores.SecretStore
 }
 
 // Init creates a HashiCorp Vault client.
-func (v *vaultSecretStore) Init(_ context.Context, meta secretstores.Metadata) error {
-	m := VaultMetadata{
-		VaultKVUsePrefix: true,
-	}
-	err := metadata.DecodeMetadata(meta.Properties, &m)
+func (v *vaultSecretStore) Init(ctx context.Context, meta secretstores.Metadata) error {
+	m, err := decodeAndValidateMetadata(meta.Properties)
 	if err != nil {
 		return err
 	}
+	v.vaultToken = m.VaultToken
+	v.vaultTokenMountPath = m.VaultTokenMountPath
 
-	// Get Vault address
-	address := m.VaultAddr
-	if address == "" {
-		address = defaultVaultAddress
-	}
+	// Get Vault address: the reads are spread across the addresses of vaultAddr, such as performance standbys
+	replicas := vaultAddresses(m.VaultAddr)
+	v.vaultAddress = replicas[0]
 
-	v.vaultAddress = address
+	// The fallback addresses are tried in order after vaultAddr when a server can't be reached
+	addresses := append(append([]string(nil), replicas...), trimmedValues(m.VaultAddrFallback)...)
 
 	v.vaultEnginePath = defaultVaultEnginePath
 	if m.EnginePath != "" {
 		v.vaultEnginePath = m.EnginePath
 	}
 
-	v.vaultValueType = valueTypeMap
-	if m.VaultValueType != "" {
-		switch valueType(m.VaultValueType) {
-		case valueTypeMap:
-		case valueTypeText:
-			v.vaultValueType = valueTypeText
-		default:
-			return fmt.Errorf("vault init error, invalid value type %s, accepted values are map or text", m.VaultValueType)
+	if enginePaths := trimmedValues(m.VaultEnginePaths); len(enginePaths) > 0 {
+		// The first engine path is the one used to list secrets and watch their versions.
+		v.vaultEnginePath = enginePaths[0]
+		v.vaultEnginePaths = enginePaths
+	}
+
+	v.engineType = engineTypeKV
+	switch engineType(m.VaultEngineType) {
+	case engineTypeDatabase:
+		v.engineType = engineTypeDatabase
+		if m.EnginePath == "" && len(v.vaultEnginePaths) == 0 {
+			v.vaultEnginePath = defaultVaultDatabaseEnginePath
 		}
+	case engineTypeCubbyhole:
+		v.engineType = engineTypeCubbyhole
+		v.vaultEnginePath = cubbyholeEnginePath
 	}
 
-	v.vaultToken = m.VaultToken
-	v.vaultTokenMountPath = m.VaultTokenMountPath
-	initErr := v.initVaultToken()
-	if initErr != nil {
-		return initErr
+	v.vaultValueType = valueTypeMap
+	if valueType(m.VaultValueType) == valueTypeText {
+		v.vaultValueType = valueTypeText
 	}
 
+	v.textValueKey = m.TextValueKey
+	v.textRawData = m.TextRawData
+	v.caseInsensitive = m.VaultCaseInsensitiveLookup
+	v.decodeBase64 = m.VaultDecodeBase64
+	v.allowAbsolutePaths = m.VaultAllowAbsolutePaths
+	v.suppressNotFound = m.VaultSuppressNotFound
+	v.maxVersionsReturned = m.VaultMaxVersionsReturned
+	v.allowedFields = newAllowedFields(m.VaultAllowedFields)
+	v.keyNameTransform = keyNameTransform(m.VaultKeyNameTransform)
+	v.bulkPageSize = m.VaultBulkPageSize
+
+	// Headers were validated already
+	v.vaultHeaders, _ = parseVaultHeaders(m.VaultHeaders)
+	v.vaultNamespace = strings.Trim(m.VaultNamespace, "/")
+
 	vaultKVPrefix := m.VaultKVPrefix
 	if !m.VaultKVUsePrefix {
 		vaultKVPrefix = ""
 	} else if vaultKVPrefix == "" {
 		vaultKVPrefix = defaultVaultKVPrefix
 	}
+	v.prefixSeparator = m.VaultPrefixSeparator
+	if m.VaultPathTemplate != "" {
+		vaultKVPrefix, err = resolvePathTemplate(m.VaultPathTemplate, v.separator(), vaultKVPrefix, appID())
+		if err != nil {
+			return fmt.Errorf("vault init error, invalid %s %q: %w", componentPathTemplate, m.VaultPathTemplate, err)
+		}
+	}
 	v.vaultKVPrefix = vaultKVPrefix
 
 	// Generate TLS config
 	tlsConf := metadataToTLSConfig(&m)
 
-	client, err := v.createHTTPClient(tlsConf)
+	if tlsConf.vaultSkipVerify {
+		v.logger.Warnf("%s is enabled: the TLS certificate presented by Vault will NOT be verified. "+
+			"This option is deprecated and will be rejected when %s is set; use %s, %s or %s to trust a custom CA instead",
+			componentSkipVerify, componentTLSStrict, componentCaCert, componentCaPath, componentCaPem)
+	}
+
+	// The proxy URL was validated already
+	proxyURL, _ := parseProxyURL(m.VaultProxyURL)
+
+	pool := connPoolConfig{
+		maxIdleConns:        m.VaultMaxIdleConns,
+		maxIdleConnsPerHost: m.VaultMaxIdleConnsPerHost,
+		idleConnTimeout:     m.VaultIdleConnTimeout,
+	}
+
+	newClient := v.newClient
+	if newClient == nil {
+		newClient = v.createHTTPClient
+	}
+	client, err := newClient(tlsConf, proxyURL, pool)
 	if err != nil {
 		return fmt.Errorf("couldn't create client using config: %w", err)
 	}
+	if client.Transport == nil {
+		client.Transport = http.DefaultTransport
+	}
+	client.Transport = newMaxResponseBytesTransport(client.Transport, m.VaultMaxResponseBytes)
+	// Inside of the failover, so that each attempt is recorded with the duration of its own server
+	client.Transport = newDurationTransport(client.Transport)
+
+	if len(addresses) > 1 || m.VaultPrimaryAddr != "" {
+		client.Transport, err = newFailoverTransport(client.Transport, addresses, len(replicas), m.VaultPrimaryAddr, v.logger)
+		if err != nil {
+			return fmt.Errorf("vault init error, invalid %s: %w", componentVaultAddrFallback, err)
+		}
+	}
+
+	if m.VaultCircuitBreakerThreshold > 0 {
+		client.Transport = newCircuitBreakerTransport(client.Transport, m.VaultCircuitBreakerThreshold, m.VaultCircuitBreakerMaxBackoff, v.logger)
+	}
+
+	// Outside of the circuit breaker, so that the requests failing fast over the limit don't open it
+	if m.VaultMaxRequestsPerSecond > 0 {
+		client.Transport = newRateLimitTransport(client.Transport, m.VaultMaxRequestsPerSecond, m.VaultRateLimitBurst, m.VaultRateLimitMode == rateLimitModeFail)
+	}
 
 	v.client = client
 
+	if m.VaultLDAPUsername != "" {
+		v.auth = ldapAuth{
+			v:         v,
+			mountPath: m.VaultLDAPMountPath,
+			username:  m.VaultLDAPUsername,
+			password:  m.VaultLDAPPassword,
+		}
+	}
+	if m.VaultGCPRole != "" {
+		v.auth = newGCPAuth(v, m.VaultGCPMountPath, m.VaultGCPRole, m.VaultGCPAuthType)
+	}
+
+	if m.VaultLazyInit {
+		// Init succeeds while Vault is down, and the first requests retry to connect until it's up
+		v.lazy = newLazyConnection(func(ctx context.Context) error {
+			return v.connect(ctx, m)
+		})
+	} else if err = v.connect(ctx, m); err != nil {
+		return err
+	}
+
+	if err = registerViews(); err != nil {
+		return fmt.Errorf("couldn't register metrics: %w", err)
+	}
+
+	if m.VaultCacheTTL > 0 {
+		v.cache = newSecretCache(m.VaultCacheTTL)
+	}
+
+	watchSecrets := trimmedValues(m.WatchSecrets)
+	if len(watchSecrets) > 0 || m.VaultTokenRenew || m.VaultTokenReauth || m.VaultAutoRenewLeases || m.VaultTokenMountPathWatch {
+		// Background tasks run until the component is closed
+		bgCtx, cancel := context.WithCancel(context.Background())
+		v.closeCancel = cancel
+
+		if len(watchSecrets) > 0 {
+			interval := m.WatchPollInterval
+			if interval <= 0 {
+				interval = defaultWatchPollInterval
+			}
+			v.startWatcher(bgCtx, watchSecrets, interval)
+		}
+		if m.VaultTokenRenew {
+			v.startTokenRenewer(bgCtx)
+		}
+		if m.VaultTokenReauth {
+			var auth authMethod = tokenFileAuth{path: v.vaultTokenMountPath}
+			if v.auth != nil {
+				auth = v.auth
+			}
+			v.startReauthenticator(bgCtx, auth)
+		}
+		if m.VaultAutoRenewLeases {
+			v.leases = newLeaseRenewer(bgCtx, v)
+		}
+		if m.VaultTokenMountPathWatch {
+			if err = v.startTokenFileWatcher(bgCtx); err != nil {
+				v.Close()
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that a component can be initialized with the given metadata, and that the configured
+// Vault server is reachable and accepts the configured token. Errors are returned instead of being logged.
+func Validate(ctx context.Context, meta secretstores.Metadata) error {
+	v := NewHashiCorpVaultSecretStore(logger.NewLogger("dapr.contrib.vault.validate")).(*vaultSecretStore)
+	if err := v.Init(ctx, meta); err != nil {
+		return err
+	}
+	defer v.Close()
+
+	if _, err := v.TokenTTL(ctx); err != nil {
+		return fmt.Errorf("vault validation error, couldn't authenticate with %s: %w", v.vaultAddress, err)
+	}
+
+	return nil
+}
+
+// Close stops the background tasks started by the component, and revokes the token it obtained by logging in.
+func (v *vaultSecretStore) Close() error {
+	if v.leases != nil {
+		v.leases.stop()
+	}
+	if v.closeCancel != nil {
+		v.closeCancel()
+	}
+	v.wg.Wait()
+
+	// After the background tasks stopped, so that the token isn't replaced by a new login meanwhile
+	v.revokeLoginToken()
+
 	return nil
 }
 
+// decodeVaultMetadata decodes the metadata of the component, expanding the references to environment variables if enabled.
+func decodeVaultMetadata(properties map[string]string) (VaultMetadata, error) {
+	m := VaultMetadata{}
+	if err := metadata.DecodeAndValidateMetadata(properties, &m); err != nil {
+		return m, err
+	}
+
+	if m.VaultExpandEnv {
+		expanded, err := expandEnvProperties(properties)
+		if err != nil {
+			return m, fmt.Errorf("vault init error, %w", err)
+		}
+		m = VaultMetadata{}
+		if err = metadata.DecodeAndValidateMetadata(expanded, &m); err != nil {
+			return m, err
+		}
+	}
+
+	return m, nil
+}
+
 func metadataToTLSConfig(meta *VaultMetadata) *tlsConfig {
 	tlsConf := tlsConfig{}
 
@@ -212,65 +702,169 @@ func metadataToTLSConfig(meta *VaultMetadata) *tlsConfig {
 	tlsConf.vaultCAPem = meta.CaPem
 	tlsConf.vaultCAPath = meta.CaPath
 	tlsConf.vaultServerName = meta.TLSServerName
+	tlsConf.vaultMinVersion = meta.TLSMinVersion
+	tlsConf.vaultClientCert = meta.ClientCert
+	tlsConf.vaultClientKey = meta.ClientKey
+	tlsConf.vaultRenegotiation = meta.VaultTLSRenegotiation
 
 	return &tlsConf
 }
 
-// GetSecret retrieves a secret using a key and returns a map of decrypted string/string values.
+// newTLSClientConfig returns the TLS configuration of the HTTP client.
+func (c *tlsConfig) newTLSClientConfig() (*tls.Config, error) {
+	renegotiation := tls.RenegotiateNever
+	if c.vaultRenegotiation != "" {
+		var ok bool
+		if renegotiation, ok = tlsRenegotiations[c.vaultRenegotiation]; !ok {
+			return nil, fmt.Errorf("invalid %s %q, accepted values are never, once or freely", componentTLSRenegotiation, c.vaultRenegotiation)
+		}
+	}
+
+	// The metadata keys of the component match the field names used by the shared helper
+	conf, err := tlsconfig.NewConfig(tlsconfig.Metadata{
+		CACert:     c.vaultCACert,
+		CAPem:      c.vaultCAPem,
+		CAPath:     c.vaultCAPath,
+		ClientCert: c.vaultClientCert,
+		ClientKey:  c.vaultClientKey,
+		SkipVerify: c.vaultSkipVerify,
+		ServerName: c.vaultServerName,
+		MinVersion: c.vaultMinVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Go disables renegotiation, which some legacy proxies require to request a client certificate
+	conf.Renegotiation = renegotiation
+
+	return conf, nil
+}
+
+// getSecret retrieves a secret from the first engine path that has it, searching them in the configured order.
 func (v *vaultSecretStore) getSecret(ctx context.Context, secret, version string) (*vaultKVResponse, error) {
-	// Create get secret url
-	var vaultSecretPathAddr string
-	if v.vaultKVPrefix == "" {
-		vaultSecretPathAddr = v.vaultAddress + "/v1/" + v.vaultEnginePath + "/data/" + secret + "?version=" + version
-	} else {
-		vaultSecretPathAddr = v.vaultAddress + "/v1/" + v.vaultEnginePath + "/data/" + v.vaultKVPrefix + "/" + secret + "?version=" + version
+	if len(v.vaultEnginePaths) == 0 {
+		return v.getSecretFromEngine(ctx, v.vaultEnginePath, secret, version)
+	}
+
+	// A folder is only reported if no engine path has the secret
+	var folderErr error
+	for _, enginePath := range v.vaultEnginePaths {
+		d, err := v.getSecretFromEngine(ctx, enginePath, secret, version)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if errors.Is(err, ErrSecretIsFolder) {
+			if folderErr == nil {
+				folderErr = err
+			}
+			continue
+		}
+
+		return d, err
+	}
+	if folderErr != nil {
+		return nil, folderErr
+	}
+
+	return nil, fmt.Errorf("getSecret %s failed, not found under any of the engine paths %s: %w",
+		secret, strings.Join(v.vaultEnginePaths, ", "), ErrNotFound)
+}
+
+// getSecretFromEngine retrieves a secret from the given engine path.
+// When case-insensitive lookup is enabled, a secret that isn't found is searched again ignoring the case of its name.
+func (v *vaultSecretStore) getSecretFromEngine(ctx context.Context, enginePath, secret, version string) (*vaultKVResponse, error) {
+	d, err := v.readSecret(ctx, enginePath, secret, version)
+	if !v.caseInsensitive || !errors.Is(err, ErrNotFound) {
+		return d, err
+	}
+
+	name, lookupErr := v.findSecretNameIgnoringCase(ctx, enginePath, secret)
+	if lookupErr != nil {
+		v.logger.Warnf("Case-insensitive lookup of secret %s failed: %v", secret, lookupErr)
+		return nil, err
+	}
+	if name == "" || name == secret {
+		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, vaultSecretPathAddr, nil)
+	return v.readSecret(ctx, enginePath, name, version)
+}
+
+// readSecret reads a secret with the exact given name from the given engine path.
+func (v *vaultSecretStore) readSecret(ctx context.Context, enginePath, secret, version string) (*vaultKVResponse, error) {
+	// Create get secret url
+	vaultSecretPathAddr := v.vaultAddress + "/v1/" + v.kvEnginePath(enginePath, "data", secret) + "?version=" + url.QueryEscape(version)
+
+	httpReq, err := v.newVaultRequest(ctx, http.MethodGet, vaultSecretPathAddr, nil)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't generate request: %w", err)
 	}
-	// Set vault token.
-	httpReq.Header.Set(vaultHTTPHeader, v.vaultToken)
-	// Set X-Vault-Request header
-	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
 
 	httpresp, err := v.client.Do(httpReq)
 	if err != nil {
+		recordCount(ctx, requestErrors, operationGet)
 		return nil, fmt.Errorf("couldn't get secret: %w", err)
 	}
 
 	defer httpresp.Body.Close()
 
 	if httpresp.StatusCode != http.StatusOK {
-		var b bytes.Buffer
-		io.Copy(&b, httpresp.Body)
-		v.logger.Debugf("getSecret %s couldn't get successful response: %#v, %s", secret, httpresp, b.String())
+		// The body isn't returned, as a misbehaving server or proxy may echo secrets in it
+		io.Copy(io.Discard, httpresp.Body)
+		v.logger.Debugf("getSecret %s couldn't get successful response, status code %d", secret, httpresp.StatusCode)
 		if httpresp.StatusCode == http.StatusNotFound {
+			if err := v.folderError(ctx, enginePath, secret); err != nil {
+				return nil, err
+			}
 			// handle not found error
 			return nil, fmt.Errorf("getSecret %s failed %w", secret, ErrNotFound)
 		}
+		if httpresp.StatusCode == http.StatusForbidden {
+			return nil, v.permissionDenied(ctx, operationGet, httpReq.URL.Path)
+		}
 
-		return nil, fmt.Errorf("couldn't get successful response, status code %d, body %s",
-			httpresp.StatusCode, b.String())
+		recordCount(ctx, requestErrors, operationGet)
+		return nil, fmt.Errorf("couldn't get successful response, status code %d", httpresp.StatusCode)
 	}
 
 	var d vaultKVResponse
 
+	b, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read response: %w", err)
+	}
+	if err := json.Unmarshal(b, &d.Info); err != nil {
+		return nil, decodeError(err)
+	}
+
 	if v.vaultValueType.isMapType() {
 		// parse the secret value to map[string]string
-		if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
-			return nil, fmt.Errorf("couldn't decode response body: %s", err)
+		if err := json.Unmarshal(b, &d); err != nil {
+			return nil, decodeError(err)
+		}
+		if len(d.Data.Data) == 0 {
+			return nil, fmt.Errorf("getSecret %s failed, no data %w", secret, ErrNotFound)
 		}
 	} else {
 		// treat the secret as string
-		b, err := io.ReadAll(httpresp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("couldn't read response: %s", err)
+		data := v.json.Get(b, DataStr, DataStr)
+		if vt := data.ValueType(); vt == jsoniter.InvalidValue || vt == jsoniter.NilValue {
+			return nil, fmt.Errorf("getSecret %s failed, no data %w", secret, ErrNotFound)
 		}
-		res := v.json.Get(b, DataStr, DataStr).ToString()
-		d.Data.Data = map[string]string{
-			secret: res,
+		if v.textRawData {
+			// return the fields of the data object as they are, each value as text
+			d.Data.Data = make(map[string]string)
+			for _, key := range data.Keys() {
+				d.Data.Data[key] = data.Get(key).ToString()
+			}
+		} else {
+			key := secret
+			if v.textValueKey != "" {
+				key = v.textValueKey
+			}
+			d.Data.Data = map[string]string{
+				key: data.ToString(),
+			}
 		}
 	}
 
@@ -279,114 +873,353 @@ func (v *vaultSecretStore) getSecret(ctx context.Context, secret, version string
 
 // GetSecret retrieves a secret using a key and returns a map of decrypted string/string values.
 func (v *vaultSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	if err := v.ensureConnected(ctx); err != nil {
+		return secretstores.GetSecretResponse{Data: nil}, err
+	}
+
+	ctx = withRequestNamespace(ctx, req.Metadata)
+	resp, err := v.getSecretResponse(ctx, req)
+	// The keys of all the versions are filtered and transformed when they're read, as they're prefixed with their version
+	if err == nil && !utils.IsTruthy(req.Metadata[allVersions]) {
+		resp.Data, err = v.transformKeys(req.Name, v.filterAllowedFields(resp.Data))
+	}
+	if err != nil && v.suppressNotFound && errors.Is(err, secretstores.ErrSecretNotFound) {
+		// Callers branch on the emptiness of the response instead
+		return secretstores.GetSecretResponse{Data: map[string]string{}}, nil
+	}
+	if err == nil && v.leases != nil {
+		v.leases.trackSecret(resp)
+	}
+	if err == nil && utils.IsTruthy(req.Metadata[includeLeaseInfo]) {
+		resp.Data, err = withLeaseInfo(req.Name, resp)
+	}
+
+	return resp, err
+}
+
+// getSecretResponse retrieves the secret of the request, according to the engine type and the request metadata.
+func (v *vaultSecretStore) getSecretResponse(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
 	// version 0 represent for latest version
 	version := "0"
 	if value, ok := req.Metadata[versionID]; ok {
 		version = value
 	}
+	if utils.IsTruthy(req.Metadata[absolutePath]) {
+		if !v.allowAbsolutePaths {
+			return secretstores.GetSecretResponse{Data: nil}, fmt.Errorf("reading secrets by absolute path is not allowed, set %s to enable it", componentAllowAbsolutePaths)
+		}
+		resp, err := v.getSecretByAbsolutePath(ctx, req.Name, req.Metadata)
+		if err == nil && v.shouldDecodeBase64(req.Metadata) {
+			resp.Data = v.decodeBase64Values(req.Name, resp.Data)
+		}
+		return resp, err
+	}
+	if v.engineType == engineTypeDatabase {
+		return v.getDatabaseCredentials(ctx, req.Name)
+	}
+	if v.engineType == engineTypeCubbyhole {
+		resp, err := v.getCubbyholeSecret(ctx, req.Name)
+		if err == nil && v.shouldDecodeBase64(req.Metadata) {
+			resp.Data = v.decodeBase64Values(req.Name, resp.Data)
+		}
+		return resp, err
+	}
+	if utils.IsTruthy(req.Metadata[allVersions]) {
+		return v.getAllSecretVersions(ctx, req.Name)
+	}
+	decodeBase64 := v.shouldDecodeBase64(req.Metadata)
+	cacheVersion := v.namespaceCacheVersion(ctx, version)
+	if v.cache != nil {
+		if resp, ok := v.cache.get(req.Name, cacheVersion); ok {
+			recordCount(ctx, secretCacheHits, operationGet)
+			if decodeBase64 {
+				resp.Data = v.decodeBase64Values(req.Name, resp.Data)
+			}
+			return resp, nil
+		}
+		recordCount(ctx, secretCacheMisses, operationGet)
+	}
+
 	d, err := v.getSecret(ctx, req.Name, version)
 	if err != nil {
 		return secretstores.GetSecretResponse{Data: nil}, err
 	}
 
 	resp := secretstores.GetSecretResponse{
-		Data: d.Data.Data,
+		Data:     d.Data.Data,
+		Metadata: d.Info.secretMetadata(),
+	}
+
+	if v.cache != nil {
+		v.cache.set(req.Name, cacheVersion, resp)
+	}
+	// Values are cached as stored in Vault, as decoding can be enabled per request
+	if decodeBase64 {
+		resp.Data = v.decodeBase64Values(req.Name, resp.Data)
 	}
 
 	return resp, nil
 }
 
 // BulkGetSecret retrieves all secrets in the store and returns a map of decrypted string/string values.
+// The "path" request metadata restricts it to the secrets under a folder of the KV prefix, such as "team/app", so
+// that the rest of a large shared mount is neither listed nor read.
+// Each secret is returned as GetSecret returns it: with vaultValueType=text, it's a single JSON value under its name,
+// even when it has several keys.
 func (v *vaultSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	switch v.engineType {
+	case engineTypeDatabase:
+		// Reading every role would generate new credentials for each of them
+		return secretstores.BulkGetSecretResponse{}, secretstores.ErrBulkGetSecretNotSupported
+	case engineTypeCubbyhole:
+		return secretstores.BulkGetSecretResponse{}, secretstores.ErrBulkGetSecretNotSupported
+	}
+	if err := v.ensureConnected(ctx); err != nil {
+		return secretstores.BulkGetSecretResponse{Data: nil}, err
+	}
+
+	ctx = withOperation(withRequestNamespace(ctx, req.Metadata), operationBulk)
 	version := "0"
 	if value, ok := req.Metadata[versionID]; ok {
 		version = value
 	}
+	path, err := bulkSecretPath(req.Metadata[bulkPath])
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{Data: nil}, err
+	}
 
 	resp := secretstores.BulkGetSecretResponse{
 		Data: map[string]map[string]string{},
 	}
+	decodeBase64 := v.shouldDecodeBase64(req.Metadata)
 
-	keys, err := v.listKeysUnderPath(ctx, "")
+	// The secrets are read by pages as they're listed, rather than once all the keys of the mount are listed
+	page := make([]string, 0, v.bulkPageSize)
+	err = v.walkKeysUnderPath(ctx, path, func(key string) error {
+		page = append(page, key)
+		if len(page) < v.bulkPageSize {
+			return nil
+		}
+		err := v.readBulkPage(ctx, page, version, decodeBase64, resp.Data)
+		page = page[:0]
+		return err
+	})
+	if err == nil && len(page) > 0 {
+		err = v.readBulkPage(ctx, page, version, decodeBase64, resp.Data)
+	}
 	if err != nil {
-		return secretstores.BulkGetSecretResponse{}, err
+		return secretstores.BulkGetSecretResponse{Data: nil}, err
 	}
 
-	for _, key := range keys {
-		keyValues := map[string]string{}
-		secrets, err := v.getSecret(ctx, key, version)
-		if err != nil {
-			if errors.Is(err, ErrNotFound) {
-				// version not exist skip
-				continue
-			}
+	return resp, nil
+}
 
-			return secretstores.BulkGetSecretResponse{Data: nil}, err
+// bulkSecretPath returns the folder, under the KV prefix, that the "path" request metadata scopes a bulk retrieval to,
+// with a trailing slash, or an empty string for the whole prefix.
+func bulkSecretPath(path string) (string, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", nil
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("invalid %s %q, it must be a folder under the KV prefix", bulkPath, path)
 		}
+	}
 
-		for k, v := range secrets.Data.Data {
-			keyValues[k] = v
-		}
-		resp.Data[key] = keyValues
+	return path + "/", nil
+}
+
+// readBulkPage reads a page of the secrets of a bulk retrieval concurrently, and adds their allowed values to res.
+// The secrets without the requested version are skipped.
+func (v *vaultSecretStore) readBulkPage(ctx context.Context, keys []string, version string, decodeBase64 bool, res map[string]map[string]string) error {
+	var (
+		lock sync.Mutex
+		wg   sync.WaitGroup
+	)
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+
+			secrets, err := v.getSecret(ctx, key, version)
+			if err != nil {
+				if !errors.Is(err, ErrNotFound) {
+					errs[i] = err
+				}
+				return
+			}
+			keyValues := make(map[string]string, len(secrets.Data.Data))
+			for k, val := range secrets.Data.Data {
+				if v.isFieldAllowed(k) {
+					keyValues[k] = val
+				}
+			}
+			if decodeBase64 {
+				keyValues = v.decodeBase64Values(key, keyValues)
+			}
+			if keyValues, err = v.transformKeys(key, keyValues); err != nil {
+				errs[i] = err
+				return
+			}
+
+			lock.Lock()
+			res[key] = keyValues
+			lock.Unlock()
+		}(i, key)
 	}
+	wg.Wait()
 
-	return resp, nil
+	return errors.Join(errs...)
 }
 
-// listKeysUnderPath get all the keys recursively under a given path.(returned keys including path as prefix)
-// path should not has `/` prefix.
-func (v *vaultSecretStore) listKeysUnderPath(ctx context.Context, path string) ([]string, error) {
-	var vaultSecretsPathAddr string
+// kvPath returns the API path of a secret under the given KV v2 endpoint (e.g. data or metadata).
+func (v *vaultSecretStore) kvPath(endpoint, secret string) string {
+	return v.kvEnginePath(v.vaultEnginePath, endpoint, secret)
+}
+
+// kvEnginePath returns the path of a secret for the given endpoint of the KV engine mounted at enginePath, such as
+// data or metadata, with the KV prefix. The name of the secret is escaped.
+func (v *vaultSecretStore) kvEnginePath(enginePath, endpoint, secret string) string {
+	return v.kvUnescapedPath(enginePath, endpoint, escapeSecretPath(secret))
+}
 
-	// Create list secrets url
+// kvUnescapedPath returns the path of a secret like kvEnginePath, without escaping its name, as written in policies.
+func (v *vaultSecretStore) kvUnescapedPath(enginePath, endpoint, secret string) string {
 	if v.vaultKVPrefix == "" {
-		vaultSecretsPathAddr = fmt.Sprintf("%s/v1/%s/metadata/%s", v.vaultAddress, v.vaultEnginePath, path)
-	} else {
-		vaultSecretsPathAddr = fmt.Sprintf("%s/v1/%s/metadata/%s/%s", v.vaultAddress, v.vaultEnginePath, v.vaultKVPrefix, path)
+		return enginePath + "/" + endpoint + "/" + secret
+	}
+
+	return enginePath + "/" + endpoint + "/" + v.vaultKVPrefix + v.separator() + secret
+}
+
+// separator returns the separator between the KV prefix and the name of the secrets.
+func (v *vaultSecretStore) separator() string {
+	if v.prefixSeparator == "" {
+		return defaultPrefixSeparator
+	}
+
+	return v.prefixSeparator
+}
+
+// hasSeparatedPrefix returns true when the KV prefix is followed by a separator other than a slash, so that the
+// prefix isn't a folder of its own.
+func (v *vaultSecretStore) hasSeparatedPrefix() bool {
+	return v.vaultKVPrefix != "" && v.separator() != "/"
+}
+
+// splitSecretFolder splits the path of a secret into its folder, with a trailing slash, and its name.
+func splitSecretFolder(secret string) (folder, name string) {
+	if i := strings.LastIndex(secret, "/"); i >= 0 {
+		return secret[:i+1], secret[i+1:]
+	}
+
+	return "", secret
+}
+
+// escapeSecretPath escapes each segment of a secret path, such as "team/app.config v2", for use in a URL: the
+// slashes separating the segments are kept, and the other reserved characters, spaces and non-ASCII characters
+// are percent-encoded. Percent signs are escaped too, so names are never decoded nor encoded twice.
+func escapeSecretPath(secret string) string {
+	segments := strings.Split(secret, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "LIST", vaultSecretsPathAddr, nil)
+	return strings.Join(segments, "/")
+}
+
+// permissionDenied records a request that Vault denied with a 403, logs its path, and returns an error wrapping
+// ErrPermissionDenied. The response isn't logged: the path is what's needed to fix the policies of the token.
+func (v *vaultSecretStore) permissionDenied(ctx context.Context, operation, path string) error {
+	recordCount(ctx, requestErrors, operation)
+	recordCount(ctx, permissionDenied, operation)
+	v.logger.Warnf("Vault denied the %s request to %s, check that the policies of the token allow it", operation, path)
+
+	return fmt.Errorf("%w: %s %s, status code %d", ErrPermissionDenied, operation, path, http.StatusForbidden)
+}
+
+// newVaultRequest creates a request to the Vault API authenticated with the component's token.
+func (v *vaultSecretStore) newVaultRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't generate request: %s", err)
+		return nil, err
+	}
+	// Set custom headers first: they are never allowed to replace the ones below.
+	for name, value := range v.vaultHeaders {
+		httpReq.Header.Set(name, value)
 	}
 	// Set vault token.
-	httpReq.Header.Set(vaultHTTPHeader, v.vaultToken)
+	httpReq.Header.Set(vaultHTTPHeader, v.getToken())
 	// Set X-Vault-Request header
 	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
-	httpresp, err := v.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't get secret: %s", err)
+	if namespace := v.namespace(ctx); namespace != "" {
+		httpReq.Header.Set(vaultNamespaceHeader, namespace)
 	}
 
-	defer httpresp.Body.Close()
-
-	if httpresp.StatusCode != http.StatusOK {
-		var b bytes.Buffer
-		io.Copy(&b, httpresp.Body)
-		v.logger.Debugf("list keys couldn't get successful response: %#v, %s", httpresp, b.String())
+	return httpReq, nil
+}
 
-		return nil, fmt.Errorf("list keys couldn't get successful response, status code: %d, status: %s, response %s",
-			httpresp.StatusCode, httpresp.Status, b.String())
+// expandEnvProperties returns a copy of the properties where every `${NAME}` reference is replaced
+// with the value of the environment variable NAME. Referencing an undefined variable is an error.
+func expandEnvProperties(properties map[string]string) (map[string]string, error) {
+	expanded := make(map[string]string, len(properties))
+	for key, val := range properties {
+		var undefined []string
+		expanded[key] = envReferencePattern.ReplaceAllStringFunc(val, func(ref string) string {
+			name := envReferencePattern.FindStringSubmatch(ref)[1]
+			envVal, ok := os.LookupEnv(name)
+			if !ok {
+				undefined = append(undefined, name)
+			}
+			return envVal
+		})
+		if len(undefined) > 0 {
+			return nil, fmt.Errorf("environment variable %s referenced by %s is not defined", strings.Join(undefined, ", "), key)
+		}
 	}
 
-	var d vaultListKVResponse
+	return expanded, nil
+}
 
-	if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
-		return nil, fmt.Errorf("couldn't decode response body: %s", err)
+// parseVaultHeaders parses custom headers given either as a JSON object or as semicolon-delimited `name=value` pairs.
+func parseVaultHeaders(val string) (map[string]string, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return nil, nil
 	}
-	res := make([]string, 0, len(d.Data.Keys))
-	for _, key := range d.Data.Keys {
-		if v.isSecretPath(key) {
-			res = append(res, path+key)
-		} else {
-			subKeys, err := v.listKeysUnderPath(ctx, path+key)
-			if err != nil {
-				return nil, err
+
+	headers := map[string]string{}
+	if strings.HasPrefix(val, "{") {
+		if err := json.Unmarshal([]byte(val), &headers); err != nil {
+			return nil, fmt.Errorf("couldn't parse JSON object: %w", err)
+		}
+	} else {
+		for _, pair := range strings.Split(val, ";") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("header %q is not in the name=value format", pair)
 			}
-			res = append(res, subKeys...)
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
 		}
 	}
 
+	res := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if name == "" {
+			return nil, errors.New("header name cannot be empty")
+		}
+		name = http.CanonicalHeaderKey(name)
+		if name == vaultHTTPHeader || name == vaultHTTPRequestHeader {
+			return nil, fmt.Errorf("header %s is reserved and cannot be overridden", name)
+		}
+		res[name] = value
+	}
+
 	return res, nil
 }
 
@@ -397,138 +1230,202 @@ func (v *vaultSecretStore) isSecretPath(key string) bool {
 
 // initVaultToken reads the vault token from the file if token is defined by mount path.
 func (v *vaultSecretStore) initVaultToken() error {
-	// Test that at least one of them are set if not return error
-	if v.vaultToken == "" && v.vaultTokenMountPath == "" {
-		return fmt.Errorf("token mount path and token not set")
-	}
-
-	// Test that both are not set. If so return error
-	if v.vaultToken != "" && v.vaultTokenMountPath != "" {
-		return fmt.Errorf("token mount path and token both set")
+	if err := validateTokenOptions(v.getToken(), v.vaultTokenMountPath); err != nil {
+		return err
 	}
 
-	if v.vaultToken != "" {
+	if v.getToken() != "" {
 		return nil
 	}
 
-	data, err := os.ReadFile(v.vaultTokenMountPath)
+	token, err := tokenFileAuth{path: v.vaultTokenMountPath}.login(context.Background())
 	if err != nil {
-		return fmt.Errorf("couldn't read vault token from mount path %s err: %s", v.vaultTokenMountPath, err)
+		return err
 	}
-	v.vaultToken = string(bytes.TrimSpace(data))
+	v.setToken(token)
 
 	return nil
 }
 
-func (v *vaultSecretStore) createHTTPClient(config *tlsConfig) (*http.Client, error) {
-	tlsClientConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+// authenticate obtains the token used by the component, by logging in if an auth method with credentials is
+// configured, or from vaultToken or vaultTokenMountPath otherwise.
+func (v *vaultSecretStore) authenticate(ctx context.Context) error {
+	if v.auth == nil {
+		return v.initVaultToken()
+	}
 
-	tlsClientConfig.InsecureSkipVerify = config.vaultSkipVerify
-	if !config.vaultSkipVerify {
-		rootCAPools, err := v.getRootCAsPools(config.vaultCAPem, config.vaultCAPath, config.vaultCACert)
-		if err != nil {
-			return nil, err
-		}
+	token, err := v.auth.login(ctx)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationLogin)
+		return fmt.Errorf("vault init error, %v failed: %w", v.auth, err)
+	}
+	v.setLoginToken(token)
 
-		tlsClientConfig.RootCAs = rootCAPools
+	return nil
+}
 
-		if config.vaultServerName != "" {
-			tlsClientConfig.ServerName = config.vaultServerName
-		}
+// TokenTTL returns the remaining time-to-live of the token used by the component, as reported by Vault.
+// Tokens that never expire, such as root tokens, report TokenTTLInfinite.
+func (v *vaultSecretStore) TokenTTL(ctx context.Context) (time.Duration, error) {
+	if err := v.ensureConnected(ctx); err != nil {
+		return 0, err
 	}
 
-	// Setup http transport
-	transport := &http.Transport{
-		TLSClientConfig: tlsClientConfig,
+	d, err := v.lookupToken(ctx)
+	if err != nil {
+		return 0, err
 	}
 
-	// Configure http2 client
-	err := http2.ConfigureTransport(transport)
+	return d.ttl(), nil
+}
+
+// lookupToken returns the information about the token used by the component.
+func (v *vaultSecretStore) lookupToken(ctx context.Context) (*vaultTokenLookupResponse, error) {
+	return v.lookupTokenAs(ctx, v.getToken())
+}
+
+// lookupTokenAs returns the information about the given token, which may not be the one used by the component yet.
+func (v *vaultSecretStore) lookupTokenAs(ctx context.Context, token string) (*vaultTokenLookupResponse, error) {
+	httpReq, err := v.newVaultRequest(ctx, http.MethodGet, v.vaultAddress+"/v1/auth/token/lookup-self", nil)
 	if err != nil {
-		return nil, errors.New("failed to configure http2")
+		return nil, fmt.Errorf("couldn't generate request: %w", err)
 	}
+	httpReq.Header.Set(vaultHTTPHeader, token)
 
-	return &http.Client{
-		Transport: transport,
-	}, nil
-}
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		recordCount(ctx, requestErrors, operationLookup)
+		return nil, fmt.Errorf("couldn't lookup token: %w", err)
+	}
+	defer httpresp.Body.Close()
 
-// getRootCAsPools returns root CAs when you give it CA Pem file, CA path, and CA Certificate. Default is system certificates.
-func (v *vaultSecretStore) getRootCAsPools(vaultCAPem string, vaultCAPath string, vaultCACert string) (*x509.CertPool, error) {
-	if vaultCAPem != "" {
-		certPool := x509.NewCertPool()
-		cert := []byte(vaultCAPem)
-		if ok := certPool.AppendCertsFromPEM(cert); !ok {
-			return nil, fmt.Errorf("couldn't read PEM")
-		}
+	if httpresp.StatusCode != http.StatusOK {
+		recordCount(ctx, requestErrors, operationLookup)
+		io.Copy(io.Discard, httpresp.Body)
+		return nil, fmt.Errorf("couldn't lookup token, status code %d", httpresp.StatusCode)
+	}
 
-		return certPool, nil
+	var d vaultTokenLookupResponse
+	if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
+		return nil, decodeError(err)
 	}
 
-	if vaultCAPath != "" {
-		certPool := x509.NewCertPool()
-		if err := readCertificateFolder(certPool, vaultCAPath); err != nil {
-			return nil, err
+	return &d, nil
+}
+
+// validateVaultAddress checks that the address of the Vault server is an http(s) URL with a valid host and port.
+func validateVaultAddress(address string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q, accepted values are http or https", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return errors.New("missing host")
+	}
+	if port := u.Port(); port != "" {
+		if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+			return fmt.Errorf("invalid port %q", port)
 		}
+	}
+
+	return nil
+}
 
-		return certPool, nil
+// vaultAddresses returns the comma-separated addresses of vaultAddr, or the default address if there are none.
+func vaultAddresses(vaultAddr string) []string {
+	addresses := trimmedValues(strings.Split(vaultAddr, ","))
+	if len(addresses) == 0 {
+		return []string{defaultVaultAddress}
 	}
 
-	if vaultCACert != "" {
-		certPool := x509.NewCertPool()
-		if err := readCertificateFile(certPool, vaultCACert); err != nil {
-			return nil, err
+	return addresses
+}
+
+// trimmedValues returns the values of a list without surrounding spaces, skipping the empty ones.
+func trimmedValues(values []string) []string {
+	res := make([]string, 0, len(values))
+	for _, val := range values {
+		if val = strings.TrimSpace(val); val != "" {
+			res = append(res, val)
 		}
+	}
+
+	return res
+}
 
-		return certPool, nil
+// parseProxyURL parses the address of the proxy used to reach Vault.
+// An empty value returns a nil URL, meaning the proxy is taken from the environment.
+func parseProxyURL(val string) (*url.URL, error) {
+	if val == "" {
+		return nil, nil
 	}
 
-	certPool, err := x509.SystemCertPool()
+	u, err := url.Parse(val)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't read system certs: %s", err)
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q, accepted values are http, https or socks5", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("missing host")
 	}
 
-	return certPool, nil
+	return u, nil
 }
 
-// readCertificateFile reads the certificate at given path.
-func readCertificateFile(certPool *x509.CertPool, path string) error {
-	// Read certificate file
-	pemFile, err := os.ReadFile(path)
+// createHTTPClient creates the client used to talk to Vault.
+// If proxyURL is nil, the proxy configured by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables is used.
+func (v *vaultSecretStore) createHTTPClient(config *tlsConfig, proxyURL *url.URL, pool connPoolConfig) (*http.Client, error) {
+	tlsClientConfig, err := config.newTLSClientConfig()
 	if err != nil {
-		return fmt.Errorf("couldn't read CA file from disk: %s", err)
+		return nil, err
 	}
 
-	if ok := certPool.AppendCertsFromPEM(pemFile); !ok {
-		return fmt.Errorf("couldn't read PEM")
+	// Setup http transport
+	transport := &http.Transport{
+		TLSClientConfig:     tlsClientConfig,
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        pool.maxIdleConns,
+		MaxIdleConnsPerHost: pool.maxIdleConnsPerHost,
+		IdleConnTimeout:     pool.idleConnTimeout,
+	}
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
 	}
 
-	return nil
-}
-
-// readCertificateFolder scans a folder for certificates.
-func readCertificateFolder(certPool *x509.CertPool, path string) error {
-	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-		if info.IsDir() {
-			return nil
-		}
-
-		return readCertificateFile(certPool, p)
-	})
+	// Configure http2 client
+	err = http2.ConfigureTransport(transport)
 	if err != nil {
-		return fmt.Errorf("couldn't read certificates at %s: %s", path, err)
+		return nil, errors.New("failed to configure http2")
 	}
 
-	return nil
+	return &http.Client{
+		Transport: transport,
+	}, nil
 }
 
-// Features returns the features available in this secret store.
+// Features returns the features available in this secret store, which depend on the configured engine and value type.
 func (v *vaultSecretStore) Features() []secretstores.Feature {
-	if v.vaultValueType == valueTypeText {
+	switch v.engineType {
+	case engineTypeDatabase:
 		return []secretstores.Feature{}
+	case engineTypeCubbyhole:
+		return []secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret}
+	}
+	if v.vaultValueType == valueTypeText && !v.textRawData {
+		return []secretstores.Feature{secretstores.FeatureBulkGetSecret}
 	}
 
-	return []secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret}
+	return []secretstores.Feature{
+		secretstores.FeatureBulkGetSecret,
+		secretstores.FeatureMultipleKeyValuesPerSecret,
+	}
 }
 
 func (v *vaultSecretStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
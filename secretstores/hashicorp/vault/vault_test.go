@@ -15,12 +15,33 @@ package vault
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"path"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
@@ -49,6 +70,46 @@ func createTempFileWithContent(t *testing.T, contents string) (fileName string,
 	return fileName, cleanUpFunc
 }
 
+// newTestVaultSecretStore returns a store already initialized to talk to a fake Vault server serving handler.
+func newTestVaultSecretStore(t *testing.T, handler http.Handler) *vaultSecretStore {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return &vaultSecretStore{
+		client:          server.Client(),
+		vaultAddress:    server.URL,
+		vaultToken:      expectedTok,
+		vaultEnginePath: defaultVaultEnginePath,
+		vaultKVPrefix:   defaultVaultKVPrefix,
+		vaultValueType:  valueTypeMap,
+		json:            jsoniter.ConfigFastest,
+		logger:          logger.NewLogger("test"),
+	}
+}
+
+// recordingTransport records the requests sent through it.
+type recordingTransport struct {
+	next     http.RoundTripper
+	lock     sync.Mutex
+	requests []*http.Request
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.lock.Lock()
+	r.requests = append(r.requests, req)
+	r.lock.Unlock()
+
+	return r.next.RoundTrip(req)
+}
+
+// recordRequests makes the store send its requests through a recordingTransport.
+func recordRequests(v *vaultSecretStore) *recordingTransport {
+	recorder := &recordingTransport{next: v.client.Transport}
+	v.client = &http.Client{Transport: recorder}
+
+	return recorder
+}
+
 func createTokenMountPathFile(t *testing.T) (fileName string, cleanUpFunc func()) {
 	return createTempFileWithContent(t, expectedTokenMountFileContents)
 }
@@ -116,9 +177,194 @@ func TestVaultTLSConfig(t *testing.T) {
 	})
 }
 
+func TestVaultTLSSharedOptions(t *testing.T) {
+	initStore := func(properties map[string]string) error {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+		properties[componentVaultToken] = expectedTok
+		return v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+	}
+
+	t.Run("invalid tlsMinVersion", func(t *testing.T) {
+		err := initStore(map[string]string{componentTLSMinVersion: "1.4"})
+		assert.ErrorContains(t, err, "invalid tlsMinVersion")
+	})
+
+	t.Run("clientCert without clientKey", func(t *testing.T) {
+		err := initStore(map[string]string{componentClientCert: string(getCertificate())})
+		assert.ErrorContains(t, err, "invalid clientKey")
+	})
+
+	t.Run("clientKey without clientCert", func(t *testing.T) {
+		err := initStore(map[string]string{componentClientKey: "/path/to/key.pem"})
+		assert.ErrorContains(t, err, "invalid clientCert")
+	})
+
+	t.Run("inline caCert", func(t *testing.T) {
+		err := initStore(map[string]string{componentCaCert: string(getCertificate()), componentTLSMinVersion: "1.3"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("vaultTLSRenegotiation", func(t *testing.T) {
+		tests := map[string]tls.RenegotiationSupport{
+			"":       tls.RenegotiateNever,
+			"never":  tls.RenegotiateNever,
+			"once":   tls.RenegotiateOnceAsClient,
+			"freely": tls.RenegotiateFreelyAsClient,
+		}
+		for value, expected := range tests {
+			v := vaultSecretStore{logger: logger.NewLogger("test")}
+			err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+				componentVaultToken:       expectedTok,
+				componentTLSRenegotiation: value,
+			}}})
+			require.NoError(t, err, value)
+
+			transport := v.client.Transport.(*circuitBreakerTransport).next.(*durationTransport).next.(*maxResponseBytesTransport).next.(*http.Transport)
+			assert.Equal(t, expected, transport.TLSClientConfig.Renegotiation, value)
+		}
+	})
+
+	t.Run("invalid vaultTLSRenegotiation", func(t *testing.T) {
+		err := initStore(map[string]string{componentTLSRenegotiation: "always"})
+		assert.ErrorContains(t, err, `invalid vaultTLSRenegotiation "always", accepted values are never, once or freely`)
+	})
+}
+
+func TestVaultTLSStrict(t *testing.T) {
+	t.Run("skipVerify is accepted when tlsStrict is not set", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken: expectedTok,
+			componentSkipVerify: "true",
+		}}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("skipVerify is rejected when tlsStrict is set", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken: expectedTok,
+			componentSkipVerify: "true",
+			componentTLSStrict:  "true",
+		}}})
+		assert.EqualError(t, err, "vault init error, skipVerify is not allowed when tlsStrict is enabled")
+	})
+
+	t.Run("skipVerify is rejected when tlsStrict is enforced by the environment", func(t *testing.T) {
+		t.Setenv(envVaultTLSStrict, "true")
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken: expectedTok,
+			componentSkipVerify: "true",
+		}}})
+		assert.EqualError(t, err, "vault init error, skipVerify is not allowed when tlsStrict is enabled")
+	})
+
+	t.Run("tlsStrict does not affect verified connections", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken: expectedTok,
+			componentCaPem:      string(getCertificate()),
+			componentTLSStrict:  "true",
+		}}})
+		assert.NoError(t, err)
+	})
+}
+
+// newTestTLSServer starts a TLS server presenting a certificate issued for serverName, and returns it with its CA in PEM format.
+func newTestTLSServer(t *testing.T, serverName string, handler http.Handler) (*httptest.Server, string) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serverDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: serverName},
+		DNSNames:     []string{serverName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caTemplate, &serverKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}},
+		MinVersion:   tls.VersionTLS12,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))
+}
+
+func TestVaultTLSServerName(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+	})
+	// The certificate is issued for a name that doesn't match the address, 127.0.0.1, the server is dialed at
+	server, caPem := newTestTLSServer(t, "vault.internal", handler)
+
+	getSecret := func(properties map[string]string) (map[string]string, error) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+		properties[componentVaultToken] = expectedTok
+		properties[componentVaultAddress] = server.URL
+		properties[componentCaPem] = caPem
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+		require.NoError(t, err)
+		t.Cleanup(func() { v.Close() })
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "secret"})
+		return resp.Data, err
+	}
+
+	t.Run("verification fails without the server name", func(t *testing.T) {
+		_, err := getSecret(map[string]string{})
+		var verifyErr *tls.CertificateVerificationError
+		assert.ErrorAs(t, err, &verifyErr)
+	})
+
+	t.Run("verification succeeds with vaultTLSServerName", func(t *testing.T) {
+		data, err := getSecret(map[string]string{componentVaultTLSServerName: "vault.internal"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, data)
+	})
+
+	t.Run("verification succeeds with tlsServerName", func(t *testing.T) {
+		data, err := getSecret(map[string]string{componentTLSServerName: "vault.internal"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, data)
+	})
+
+	t.Run("verification fails with the wrong server name", func(t *testing.T) {
+		_, err := getSecret(map[string]string{componentVaultTLSServerName: "other.internal"})
+		var verifyErr *tls.CertificateVerificationError
+		assert.ErrorAs(t, err, &verifyErr)
+	})
+}
+
 func TestVaultEnginePath(t *testing.T) {
 	t.Run("without engine path config", func(t *testing.T) {
-		v := vaultSecretStore{}
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
 
 		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{componentVaultToken: expectedTok, "skipVerify": "true"}}})
 		assert.Nil(t, err)
@@ -126,12 +372,115 @@ func TestVaultEnginePath(t *testing.T) {
 	})
 
 	t.Run("with engine path config", func(t *testing.T) {
-		v := vaultSecretStore{}
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
 
 		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{componentVaultToken: expectedTok, "skipVerify": "true", vaultEnginePath: "kv"}}})
 		assert.Nil(t, err)
 		assert.Equal(t, v.vaultEnginePath, "kv")
 	})
+
+	t.Run("with engine paths config", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{componentVaultToken: expectedTok, "skipVerify": "true", vaultEnginePaths: "kv-team, kv-shared,"}}})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"kv-team", "kv-shared"}, v.vaultEnginePaths)
+		assert.Equal(t, "kv-team", v.vaultEnginePath)
+	})
+
+	t.Run("engine path and engine paths are mutually exclusive", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{componentVaultToken: expectedTok, "skipVerify": "true", vaultEnginePath: "kv", vaultEnginePaths: "kv-team,kv-shared"}}})
+		assert.ErrorContains(t, err, "enginePath and vaultEnginePaths are mutually exclusive")
+	})
+
+	t.Run("engine paths and KV prefix are normalized", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{componentVaultToken: expectedTok, "skipVerify": "true", vaultEnginePaths: "/kv-team/, kv/shared/", componentVaultKVPrefix: "/prefix/"}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kv-team", "kv/shared"}, v.vaultEnginePaths)
+		assert.Equal(t, "prefix", v.vaultKVPrefix)
+	})
+
+	t.Run("invalid engine paths and KV prefix are rejected", func(t *testing.T) {
+		for _, tt := range []struct {
+			property string
+			value    string
+			err      string
+		}{
+			{componentVaultKVPrefix, "prefix//sub", `invalid vaultKVPrefix "prefix//sub": the path must not contain empty segments`},
+			{componentVaultKVPrefix, "../escape", `invalid vaultKVPrefix "../escape": the path must not contain relative segments`},
+			{componentVaultKVPrefix, "/", `invalid vaultKVPrefix "/": the path must not be made of slashes only`},
+			{vaultEnginePath, "kv/../sys", `invalid enginePath "kv/../sys": the path must not contain relative segments`},
+			{vaultEnginePath, "kv//team", `invalid enginePath "kv//team": the path must not contain empty segments`},
+			{vaultEnginePaths, "kv-team,./kv", `invalid vaultEnginePaths "./kv": the path must not contain relative segments`},
+		} {
+			v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+			err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{componentVaultToken: expectedTok, "skipVerify": "true", tt.property: tt.value}}})
+			assert.ErrorContains(t, err, tt.err, tt.value)
+		}
+	})
+}
+
+func TestVaultEnginePathsSearch(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/kv-team/data/dapr/shared":
+			w.Write([]byte(`{"data":{"data":{"owner":"team"}}}`))
+		case "/v1/kv-shared/data/dapr/shared":
+			w.Write([]byte(`{"data":{"data":{"owner":"shared"}}}`))
+		case "/v1/kv-shared/data/dapr/sharedonly":
+			w.Write([]byte(`{"data":{"data":{"owner":"shared"}}}`))
+		case "/v1/kv-forbidden/data/dapr/shared":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	getSecret := func(v *vaultSecretStore, name string) (map[string]string, error) {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+		return resp.Data, err
+	}
+
+	t.Run("first engine path with the secret wins", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultEnginePaths = []string{"kv-team", "kv-shared"}
+
+		data, err := getSecret(v, "shared")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"owner": "team"}, data)
+
+		data, err = getSecret(v, "sharedonly")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"owner": "shared"}, data)
+
+		v.vaultEnginePaths = []string{"kv-shared", "kv-team"}
+		data, err = getSecret(v, "shared")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"owner": "shared"}, data)
+	})
+
+	t.Run("not found under any engine path", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultEnginePaths = []string{"kv-team", "kv-shared"}
+
+		_, err := getSecret(v, "missing")
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+		assert.ErrorContains(t, err, "kv-team, kv-shared")
+	})
+
+	t.Run("other errors stop the search", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultEnginePaths = []string{"kv-forbidden", "kv-shared"}
+
+		_, err := getSecret(v, "shared")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, secretstores.ErrSecretNotFound)
+	})
 }
 
 func TestVaultTokenPrefix(t *testing.T) {
@@ -149,7 +498,7 @@ func TestVaultTokenPrefix(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		if err := target.Init(context.Background(), m); err != nil {
@@ -172,7 +521,7 @@ func TestVaultTokenPrefix(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		if err := target.Init(context.Background(), m); err != nil {
@@ -198,6 +547,28 @@ func TestVaultTokenPrefix(t *testing.T) {
 
 		assert.False(t, meta.VaultKVUsePrefix)
 	})
+
+	t.Run("vaultKVUsePrefix accepts truthy values regardless of case", func(t *testing.T) {
+		for val, expectedPrefix := range map[string]string{
+			"true":  "myCustomString",
+			"True":  "myCustomString",
+			"1":     "myCustomString",
+			"False": "",
+			"":      "",
+		} {
+			target := &vaultSecretStore{
+				logger: logger.NewLogger("test"),
+			}
+
+			err := target.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+				"vaultKVPrefix":     "myCustomString",
+				"vaultKVUsePrefix":  val,
+				componentVaultToken: expectedTok,
+			}}})
+			require.NoError(t, err)
+			assert.Equal(t, expectedPrefix, target.vaultKVPrefix, val)
+		}
+	})
 }
 
 func TestVaultTokenMountPathOrVaultTokenRequired(t *testing.T) {
@@ -213,7 +584,7 @@ func TestVaultTokenMountPathOrVaultTokenRequired(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		err := target.Init(context.Background(), m)
@@ -235,7 +606,7 @@ func TestVaultTokenMountPathOrVaultTokenRequired(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		if err := target.Init(context.Background(), m); err != nil {
@@ -257,7 +628,7 @@ func TestVaultTokenMountPathOrVaultTokenRequired(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		if err := target.Init(context.Background(), m); err != nil {
@@ -280,18 +651,39 @@ func TestVaultTokenMountPathOrVaultTokenRequired(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		err := target.Init(context.Background(), m)
 
-		assert.Equal(t, expectedTok, target.vaultToken)
-		assert.Equal(t, expectedTokMountPath, target.vaultTokenMountPath)
+		// The store isn't set up when the metadata is invalid
+		assert.Empty(t, target.vaultToken)
+		assert.Empty(t, target.vaultTokenMountPath)
 		assert.NotNil(t, err)
 		assert.Equal(t, "token mount path and token both set", err.Error())
 	})
 }
 
+func TestInitReportsAllErrors(t *testing.T) {
+	properties := map[string]string{
+		"vaultAddr":           "ftp://127.0.0.1:8200",
+		"vaultToken":          expectedTok,
+		"vaultTokenMountPath": "/tmp/vaultToken.txt",
+	}
+
+	target := &vaultSecretStore{
+		client: nil,
+		logger: logger.NewLogger("test"),
+	}
+
+	err := target.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `invalid vaultAddr "ftp://127.0.0.1:8200"`)
+	assert.ErrorContains(t, err, "token mount path and token both set")
+	assert.Nil(t, target.client)
+}
+
 func TestDefaultVaultAddress(t *testing.T) {
 	expectedTokMountPath, cleanUpFunc := createTokenMountPathFile(t)
 	defer cleanUpFunc()
@@ -307,7 +699,7 @@ func TestDefaultVaultAddress(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		if err := target.Init(context.Background(), m); err != nil {
@@ -318,6 +710,46 @@ func TestDefaultVaultAddress(t *testing.T) {
 	})
 }
 
+func TestVaultAddressValidation(t *testing.T) {
+	t.Run("valid addresses", func(t *testing.T) {
+		for _, address := range []string{
+			"http://127.0.0.1:8200",
+			"https://vault.example.com",
+			"https://vault.example.com:8200/",
+			"http://[::1]:8200",
+		} {
+			assert.NoError(t, validateVaultAddress(address), address)
+		}
+	})
+
+	t.Run("malformed addresses", func(t *testing.T) {
+		for _, address := range []string{
+			"127.0.0.1:8200",
+			"vault.example.com",
+			"localhost:8200",
+			"tcp://127.0.0.1:8200",
+			"http://",
+			"http://:8200",
+			"http://127.0.0.1:notaport",
+			"http://127.0.0.1:0",
+			"http://127.0.0.1:65536",
+			"https//vault.example.com",
+		} {
+			assert.Error(t, validateVaultAddress(address), address)
+		}
+	})
+
+	t.Run("init fails on malformed address", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress: "127.0.0.1:8200",
+			componentVaultToken:   expectedTok,
+		}}})
+		assert.ErrorContains(t, err, `vault init error, invalid vaultAddr "127.0.0.1:8200"`)
+	})
+}
+
 func TestVaultValueType(t *testing.T) {
 	t.Run("valid vault value type map", func(t *testing.T) {
 		properties := map[string]string{
@@ -332,7 +764,7 @@ func TestVaultValueType(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		err := target.Init(context.Background(), m)
@@ -353,7 +785,7 @@ func TestVaultValueType(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		err := target.Init(context.Background(), m)
@@ -373,7 +805,7 @@ func TestVaultValueType(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		err := target.Init(context.Background(), m)
@@ -394,7 +826,7 @@ func TestVaultValueType(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		err := target.Init(context.Background(), m)
@@ -422,7 +854,7 @@ func TestGetFeatures(t *testing.T) {
 
 		target := &vaultSecretStore{
 			client: nil,
-			logger: nil,
+			logger: logger.NewLogger("test"),
 		}
 
 		// This call will throw an error on Windows systems because of the of
@@ -454,4 +886,1230 @@ func TestGetFeatures(t *testing.T) {
 		f := s.Features()
 		assert.False(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(f))
 	})
+
+	t.Run("Vault does not support MULTIPLE_KEY_VALUES_PER_SECRET nor BULK_GET_SECRET with the database engine", func(t *testing.T) {
+		target := &vaultSecretStore{logger: logger.NewLogger("test")}
+		err := target.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken:      expectedTok,
+			componentVaultEngineType: "database",
+		}}})
+		require.NoError(t, err)
+
+		f := target.Features()
+		assert.False(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(f))
+		assert.False(t, secretstores.FeatureBulkGetSecret.IsPresent(f))
+	})
+
+	t.Run("Vault supports MULTIPLE_KEY_VALUES_PER_SECRET with the kv engine", func(t *testing.T) {
+		target := &vaultSecretStore{logger: logger.NewLogger("test")}
+		err := target.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken:      expectedTok,
+			componentVaultEngineType: "kv",
+		}}})
+		require.NoError(t, err)
+
+		assert.True(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(target.Features()))
+	})
+
+	t.Run("Vault supports BULK_GET_SECRET regardless of vaultValueType", func(t *testing.T) {
+		assert.True(t, secretstores.FeatureBulkGetSecret.IsPresent(NewHashiCorpVaultSecretStore(logger.NewLogger("test")).Features()))
+		assert.True(t, secretstores.FeatureBulkGetSecret.IsPresent(initVaultWithVaultValueType("map").Features()))
+		assert.True(t, secretstores.FeatureBulkGetSecret.IsPresent(initVaultWithVaultValueType("text").Features()))
+	})
+}
+
+func TestTokenTTL(t *testing.T) {
+	lookupSelfHandler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/auth/token/lookup-self", r.URL.Path)
+			assert.Equal(t, expectedTok, r.Header.Get(vaultHTTPHeader))
+			w.Write([]byte(body))
+		}
+	}
+
+	t.Run("token with TTL", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, lookupSelfHandler(`{"data":{"ttl":2764799,"expire_time":"2023-07-01T00:00:00Z"}}`))
+
+		ttl, err := v.TokenTTL(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2764799*time.Second, ttl)
+	})
+
+	t.Run("root token never expires", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, lookupSelfHandler(`{"data":{"ttl":0,"expire_time":null}}`))
+
+		ttl, err := v.TokenTTL(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, TokenTTLInfinite, ttl)
+	})
+
+	t.Run("lookup failure", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		}))
+
+		_, err := v.TokenTTL(context.Background())
+		assert.ErrorContains(t, err, "status code 403")
+	})
+}
+
+func TestGetSecretMetadata(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/dapr/versioned":
+			w.Write([]byte(`{"lease_id":"","lease_duration":0,"renewable":false,"data":{"data":{"key":"value"},` +
+				`"metadata":{"created_time":"2023-03-01T10:00:00.000000Z","deletion_time":"","destroyed":false,"version":3}}}`))
+		case "/v1/secret/data/dapr/leased":
+			w.Write([]byte(`{"lease_id":"secret/lease/abc","lease_duration":3600,"renewable":true,"data":{"data":{"key":"value"}}}`))
+		default:
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}
+	})
+
+	getMetadata := func(t *testing.T, v *vaultSecretStore, name string) map[string]string {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+		return resp.Metadata
+	}
+
+	t.Run("version and created time", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		assert.Equal(t, map[string]string{
+			"version":      "3",
+			"created_time": "2023-03-01T10:00:00.000000Z",
+		}, getMetadata(t, v, "versioned"))
+	})
+
+	t.Run("lease information", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		assert.Equal(t, map[string]string{
+			"lease_id":       "secret/lease/abc",
+			"lease_duration": "3600",
+			"renewable":      "true",
+		}, getMetadata(t, v, "leased"))
+	})
+
+	t.Run("no metadata", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		assert.Nil(t, getMetadata(t, v, "plain"))
+	})
+
+	t.Run("text value type", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultValueType = valueTypeText
+		v.textValueKey = "key"
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "versioned"})
+		require.NoError(t, err)
+		assert.Equal(t, "3", resp.Metadata["version"])
+	})
+
+	t.Run("cached responses keep the metadata", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.cache = newSecretCache(time.Minute)
+
+		getMetadata(t, v, "versioned")
+		assert.Equal(t, "3", getMetadata(t, v, "versioned")["version"])
+	})
+}
+
+func TestGetSecretNotFound(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/dapr/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		case "/v1/secret/data/dapr/empty":
+			w.Write([]byte(`{"data":{"data":null,"metadata":{"version":2}}}`))
+		case "/v1/secret/data/dapr/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		default:
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}
+	})
+
+	for _, vt := range []valueType{valueTypeMap, valueTypeText} {
+		t.Run(string(vt), func(t *testing.T) {
+			v := newTestVaultSecretStore(t, handler)
+			v.vaultValueType = vt
+
+			for _, name := range []string{"missing", "empty"} {
+				_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+				require.Error(t, err, name)
+				assert.ErrorIs(t, err, secretstores.ErrSecretNotFound, name)
+				assert.ErrorIs(t, err, ErrNotFound, name)
+			}
+
+			_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "forbidden"})
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, secretstores.ErrSecretNotFound)
+
+			_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "found"})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestVaultSuppressNotFound(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/dapr/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/v1/secret/data/dapr/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "missing"})
+		require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+		assert.Nil(t, resp.Data)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.suppressNotFound = true
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "missing"})
+		require.NoError(t, err)
+		assert.NotNil(t, resp.Data)
+		assert.Empty(t, resp.Data)
+
+		// Other errors are still returned
+		_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "forbidden"})
+		require.Error(t, err)
+
+		resp, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "found"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+	})
+}
+
+func TestVaultPrefixSeparator(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/":
+			// Secrets of other prefixes are stored next to the ones of the component
+			w.Write([]byte(`{"data":{"keys":["dapr-db","dapr-team/","dapr-","dapr/","other-db"]}}`))
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr-team/":
+			w.Write([]byte(`{"data":{"keys":["api"]}}`))
+		case r.URL.Path == "/v1/secret/data/dapr-db":
+			w.Write([]byte(`{"data":{"data":{"password":"db"}}}`))
+		case r.URL.Path == "/v1/secret/data/dapr-team/api":
+			w.Write([]byte(`{"data":{"data":{"password":"api"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	newStore := func(t *testing.T) *vaultSecretStore {
+		v := newTestVaultSecretStore(t, handler)
+		v.prefixSeparator = "-"
+		return v
+	}
+
+	t.Run("GetSecret", func(t *testing.T) {
+		v := newStore(t)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "db"}, resp.Data)
+
+		resp, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "team/api"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "api"}, resp.Data)
+	})
+
+	t.Run("BulkGetSecret", func(t *testing.T) {
+		v := newStore(t)
+
+		resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"db":       {"password": "db"},
+			"team/api": {"password": "api"},
+		}, resp.Data)
+	})
+
+	t.Run("case-insensitive lookup", func(t *testing.T) {
+		v := newStore(t)
+		v.caseInsensitive = true
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "DB"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "db"}, resp.Data)
+	})
+
+	t.Run("defaults to a slash", func(t *testing.T) {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{componentVaultToken: expectedTok}}})
+		require.NoError(t, err)
+		assert.Equal(t, "secret/data/dapr/db", v.kvPath("data", "db"))
+	})
+}
+
+func TestVaultNamespace(t *testing.T) {
+	// The same secret is seeded in two namespaces
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace := r.Header.Get(vaultNamespaceHeader)
+		switch {
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/":
+			w.Write([]byte(`{"data":{"keys":["db"]}}`))
+		case r.URL.Path == "/v1/secret/data/dapr/db" && (namespace == "team-a" || namespace == "team-b"):
+			w.Write([]byte(`{"data":{"data":{"namespace":"` + namespace + `"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	newStore := func(t *testing.T) *vaultSecretStore {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultNamespace = "team-a"
+		v.cache = newSecretCache(time.Minute)
+		return v
+	}
+
+	getSecret := func(v *vaultSecretStore, metadata map[string]string) map[string]string {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db", Metadata: metadata})
+		require.NoError(t, err)
+		return resp.Data
+	}
+
+	t.Run("GetSecret", func(t *testing.T) {
+		v := newStore(t)
+
+		assert.Equal(t, map[string]string{"namespace": "team-a"}, getSecret(v, nil))
+		assert.Equal(t, map[string]string{"namespace": "team-b"}, getSecret(v, map[string]string{requestNamespace: "team-b"}))
+		// The secrets of the namespaces are cached separately
+		assert.Equal(t, map[string]string{"namespace": "team-a"}, getSecret(v, nil))
+		assert.Equal(t, map[string]string{"namespace": "team-b"}, getSecret(v, map[string]string{requestNamespace: "/team-b/"}))
+
+		// The root namespace doesn't have the secret
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db", Metadata: map[string]string{requestNamespace: ""}})
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+	})
+
+	t.Run("BulkGetSecret", func(t *testing.T) {
+		v := newStore(t)
+
+		resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{Metadata: map[string]string{requestNamespace: "team-b"}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{"db": {"namespace": "team-b"}}, resp.Data)
+
+		resp, err = v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{"db": {"namespace": "team-a"}}, resp.Data)
+	})
+
+	t.Run("set from the metadata", func(t *testing.T) {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken:     expectedTok,
+			componentVaultNamespace: "team-a/",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "team-a", v.vaultNamespace)
+	})
+}
+
+func TestVaultSecretNameEscaping(t *testing.T) {
+	secrets := map[string]string{
+		"team/app.config v2": "/v1/secret/data/dapr/team/app.config%20v2",
+		"ünïcödé":            "/v1/secret/data/dapr/%C3%BCn%C3%AFc%C3%B6d%C3%A9",
+		"50%off":             "/v1/secret/data/dapr/50%25off",
+		"what?#":             "/v1/secret/data/dapr/what%3F%23",
+		"already%20escaped":  "/v1/secret/data/dapr/already%2520escaped",
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/":
+			w.Write([]byte(`{"data":{"keys":["team/","50%off"]}}`))
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/team/":
+			w.Write([]byte(`{"data":{"keys":["app.config v2"]}}`))
+		case strings.HasPrefix(r.URL.Path, "/v1/secret/data/dapr/"):
+			// The server decodes the path: the secret is found with its original name
+			name := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/dapr/")
+			if _, ok := secrets[name]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"data":{"data":{"name":"` + name + `"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	v := newTestVaultSecretStore(t, handler)
+	recorder := recordRequests(v)
+
+	for name, escapedPath := range secrets {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+		require.NoError(t, err, name)
+		assert.Equal(t, name, resp.Data["name"])
+
+		req := recorder.requests[len(recorder.requests)-1]
+		assert.Equal(t, escapedPath, req.URL.EscapedPath(), name)
+	}
+
+	t.Run("bulk get", func(t *testing.T) {
+		resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"team/app.config v2": {"name": "team/app.config v2"},
+			"50%off":             {"name": "50%off"},
+		}, resp.Data)
+	})
+}
+
+func TestBulkGetSecretKVPrefix(t *testing.T) {
+	// The secrets of the certification tests, by their path under the engine
+	secrets := map[string]string{
+		"dapr/conftestsecret":                            "abcd",
+		"dapr/multiplekeyvaluessecret":                   "1",
+		"secretWithNoPrefix":                             "noProblem",
+		"alternativePrefix/secretUnderAlternativePrefix": "altPrefixValue",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if folder, ok := strings.CutPrefix(r.URL.Path, "/v1/secret/metadata/"); ok && r.Method == "LIST" {
+			children := map[string]bool{}
+			for name := range secrets {
+				if rest, ok := strings.CutPrefix(name, folder); ok {
+					if i := strings.Index(rest, "/"); i >= 0 {
+						rest = rest[:i+1]
+					}
+					children[rest] = true
+				}
+			}
+			keys := make([]string, 0, len(children))
+			for key := range children {
+				keys = append(keys, key)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"keys": keys}})
+			return
+		}
+		if value, ok := secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")]; ok {
+			w.Write([]byte(`{"data":{"data":{"value":"` + value + `"}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	bulkNames := func(t *testing.T, properties map[string]string) []string {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		properties[componentVaultAddress] = server.URL
+		properties[componentVaultToken] = expectedTok
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}}))
+
+		resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		names := make([]string, 0, len(resp.Data))
+		for name, values := range resp.Data {
+			// Each secret is read with the name it's listed with
+			assert.Equal(t, secrets[path.Join(v.vaultKVPrefix, name)], values["value"], name)
+			names = append(names, name)
+		}
+		return names
+	}
+
+	t.Run("without prefix, the secrets are listed from the root of the engine", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{
+			"dapr/conftestsecret",
+			"dapr/multiplekeyvaluessecret",
+			"secretWithNoPrefix",
+			"alternativePrefix/secretUnderAlternativePrefix",
+		}, bulkNames(t, map[string]string{componentVaultKVUsePrefix: "false", componentVaultKVPrefix: "alternativePrefix"}))
+	})
+
+	t.Run("with the default prefix, only the secrets under it are listed", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"conftestsecret", "multiplekeyvaluessecret"}, bulkNames(t, map[string]string{}))
+	})
+
+	t.Run("with a prefix, only the secrets under it are listed", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"secretUnderAlternativePrefix"},
+			bulkNames(t, map[string]string{componentVaultKVPrefix: "alternativePrefix"}))
+	})
+}
+
+func TestVaultCaseInsensitiveLookup(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/":
+			w.Write([]byte(`{"data":{"keys":["MySecret","team/"]}}`))
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/team/":
+			w.Write([]byte(`{"data":{"keys":["DbPassword"]}}`))
+		case r.URL.Path == "/v1/secret/data/dapr/MySecret":
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		case r.URL.Path == "/v1/secret/data/dapr/team/DbPassword":
+			w.Write([]byte(`{"data":{"data":{"password":"secret"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		recorder := recordRequests(v)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+		// The folder of the secret isn't listed, only its path to tell a folder apart
+		require.Len(t, recorder.requests, 2)
+		assert.Equal(t, "/v1/secret/metadata/dapr/mysecret/", recorder.requests[1].URL.Path)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.caseInsensitive = true
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+
+		resp, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "team/dbpassword"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "secret"}, resp.Data)
+
+		_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "othersecret"})
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+
+		// Folders are not matched as secrets
+		_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "TEAM"})
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+	})
+
+	t.Run("enabled through metadata", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken:      expectedTok,
+			componentCaseInsensitive: "true",
+		}}})
+		require.NoError(t, err)
+		assert.True(t, v.caseInsensitive)
+	})
+}
+
+func TestVaultValueTypeTextOptions(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/dapr/secondsecret":
+			w.Write([]byte(`{"data":{"data":{"secondsecret":"efgh"}}}`))
+		case "/v1/secret/data/dapr/structured":
+			w.Write([]byte(`{"data":{"data":{"user":"admin","port":5432,"options":{"tls":true}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	getSecret := func(t *testing.T, v *vaultSecretStore, name string) map[string]string {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+		require.NoError(t, err)
+		return resp.Data
+	}
+
+	t.Run("default wraps the value under the secret name", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultValueType = valueTypeText
+
+		assert.Equal(t, map[string]string{"secondsecret": `{"secondsecret":"efgh"}`}, getSecret(t, v, "secondsecret"))
+	})
+
+	t.Run("textValueKey sets the returned key", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultValueType = valueTypeText
+		v.textValueKey = "value"
+
+		assert.Equal(t, map[string]string{"value": `{"secondsecret":"efgh"}`}, getSecret(t, v, "secondsecret"))
+	})
+
+	t.Run("textRawData returns the data object as-is", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultValueType = valueTypeText
+		v.textRawData = true
+
+		assert.Equal(t, map[string]string{"secondsecret": "efgh"}, getSecret(t, v, "secondsecret"))
+		assert.Equal(t, map[string]string{
+			"user":    "admin",
+			"port":    "5432",
+			"options": `{"tls":true}`,
+		}, getSecret(t, v, "structured"))
+		assert.True(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(v.Features()))
+	})
+
+	t.Run("textValueKey and textRawData are mutually exclusive", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken: expectedTok,
+			vaultValueType:      "text",
+			vaultTextValueKey:   "value",
+			vaultTextRawData:    "true",
+		}}})
+		assert.EqualError(t, err, "vault init error, textValueKey and textRawData are mutually exclusive")
+	})
+}
+
+func TestVaultValueTypeTextBulk(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/metadata/dapr/":
+			w.Write([]byte(`{"data":{"keys":["secondsecret","multiplekeyvaluessecret","team/"]}}`))
+		case "/v1/secret/metadata/dapr/team/":
+			w.Write([]byte(`{"data":{"keys":["app"]}}`))
+		case "/v1/secret/data/dapr/secondsecret":
+			w.Write([]byte(`{"data":{"data":{"secondsecret":"efgh"}}}`))
+		case "/v1/secret/data/dapr/multiplekeyvaluessecret":
+			w.Write([]byte(`{"data":{"data":{"first":"1","second":"2","third":"3"}}}`))
+		case "/v1/secret/data/dapr/team/app":
+			w.Write([]byte(`{"data":{"data":{"port":5432}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	// Each secret of a bulk retrieval is returned as GetSecret returns it
+	assertBulkMatchesGet := func(t *testing.T, v *vaultSecretStore, expected map[string]map[string]string) {
+		resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp.Data)
+
+		for name, values := range expected {
+			getResp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+			require.NoError(t, err)
+			assert.Equal(t, values, getResp.Data, name)
+		}
+	}
+
+	t.Run("each secret is a single JSON value under its name", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultValueType = valueTypeText
+
+		assertBulkMatchesGet(t, v, map[string]map[string]string{
+			"secondsecret":            {"secondsecret": `{"secondsecret":"efgh"}`},
+			"multiplekeyvaluessecret": {"multiplekeyvaluessecret": `{"first":"1","second":"2","third":"3"}`},
+			"team/app":                {"team/app": `{"port":5432}`},
+		})
+	})
+
+	t.Run("textValueKey", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultValueType = valueTypeText
+		v.textValueKey = "value"
+
+		assertBulkMatchesGet(t, v, map[string]map[string]string{
+			"secondsecret":            {"value": `{"secondsecret":"efgh"}`},
+			"multiplekeyvaluessecret": {"value": `{"first":"1","second":"2","third":"3"}`},
+			"team/app":                {"value": `{"port":5432}`},
+		})
+	})
+
+	t.Run("textRawData", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.vaultValueType = valueTypeText
+		v.textRawData = true
+
+		assertBulkMatchesGet(t, v, map[string]map[string]string{
+			"secondsecret":            {"secondsecret": "efgh"},
+			"multiplekeyvaluessecret": {"first": "1", "second": "2", "third": "3"},
+			"team/app":                {"port": "5432"},
+		})
+	})
+}
+
+func TestVaultAbsolutePath(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/cubbyhole/foo":
+			w.Write([]byte(`{"data":{"password":"cubby","port":8200}}`))
+		case "/v1/kv-team/data/shared/db":
+			assert.Equal(t, "2", r.URL.Query().Get("version"))
+			w.Write([]byte(`{"data":{"data":{"password":"team"},"metadata":{"created_time":"2023-01-01T00:00:00Z","version":2}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	absolute := map[string]string{absolutePath: "true"}
+
+	t.Run("secrets are read from their full path", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.allowAbsolutePaths = true
+
+		// Not found when the engine path and the prefix are prepended
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "cubbyhole/foo"})
+		require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "/cubbyhole/foo", Metadata: absolute})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "cubby", "port": "8200"}, resp.Data)
+		assert.Nil(t, resp.Metadata)
+	})
+
+	t.Run("secrets of KV version 2 engines are returned with their metadata", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.allowAbsolutePaths = true
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "kv-team/data/shared/db",
+			Metadata: map[string]string{absolutePath: "true", versionID: "2"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "team"}, resp.Data)
+		assert.Equal(t, map[string]string{"version": "2", "created_time": "2023-01-01T00:00:00Z"}, resp.Metadata)
+	})
+
+	t.Run("missing secrets are not found", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.allowAbsolutePaths = true
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "cubbyhole/missing", Metadata: absolute})
+		require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+	})
+
+	t.Run("paths are sanitized", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.allowAbsolutePaths = true
+
+		for _, name := range []string{"", "/", "cubbyhole/../sys/foo", "cubbyhole//foo", "cubbyhole/foo?list=true", "cubbyhole/%2e%2e/foo"} {
+			_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name, Metadata: absolute})
+			assert.ErrorContains(t, err, "absolute path", name)
+		}
+	})
+
+	t.Run("absolute paths must be allowed by the component", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "cubbyhole/foo", Metadata: absolute})
+		assert.ErrorContains(t, err, "reading secrets by absolute path is not allowed")
+	})
+}
+
+func TestVaultDecodeBase64(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/metadata/dapr/":
+			w.Write([]byte(`{"data":{"keys":["binary"]}}`))
+		case "/v1/secret/data/dapr/binary":
+			// "AAEC/w==" is the encoding of the bytes 0x00, 0x01, 0x02, 0xff, and "aGVsbG8=" of "hello"
+			w.Write([]byte(`{"data":{"data":{"key":"AAEC/w==","greeting":"aGVsbG8=","plain":"not base64!"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	decoded := map[string]string{"key": "\x00\x01\x02\xff", "greeting": "hello", "plain": "not base64!"}
+	raw := map[string]string{"key": "AAEC/w==", "greeting": "aGVsbG8=", "plain": "not base64!"}
+
+	getSecret := func(t *testing.T, v *vaultSecretStore, reqMetadata map[string]string) map[string]string {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "binary", Metadata: reqMetadata})
+		require.NoError(t, err)
+		return resp.Data
+	}
+
+	t.Run("values are returned as stored by default", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		assert.Equal(t, raw, getSecret(t, v, nil))
+	})
+
+	t.Run("only valid base64 values are decoded", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.decodeBase64 = true
+
+		assert.Equal(t, decoded, getSecret(t, v, nil))
+
+		resp, err := v.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{"binary": decoded}, resp.Data)
+	})
+
+	t.Run("requests override the component setting", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		assert.Equal(t, decoded, getSecret(t, v, map[string]string{componentVaultDecodeBase64: "true"}))
+
+		v.decodeBase64 = true
+		assert.Equal(t, raw, getSecret(t, v, map[string]string{componentVaultDecodeBase64: "false"}))
+	})
+
+	t.Run("cached values are decoded per request", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.cache = newSecretCache(time.Minute)
+
+		assert.Equal(t, decoded, getSecret(t, v, map[string]string{componentVaultDecodeBase64: "true"}))
+		assert.Equal(t, raw, getSecret(t, v, nil))
+		assert.Equal(t, decoded, getSecret(t, v, map[string]string{componentVaultDecodeBase64: "true"}))
+	})
+}
+
+func TestVaultHeaders(t *testing.T) {
+	t.Run("parse JSON object", func(t *testing.T) {
+		headers, err := parseVaultHeaders(`{"X-Api-Key": "mykey", "x-request-source": "dapr"}`)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"X-Api-Key": "mykey", "X-Request-Source": "dapr"}, headers)
+	})
+
+	t.Run("parse semicolon-delimited pairs", func(t *testing.T) {
+		headers, err := parseVaultHeaders("X-Api-Key=mykey; X-Request-Source = dapr;")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"X-Api-Key": "mykey", "X-Request-Source": "dapr"}, headers)
+	})
+
+	t.Run("empty value", func(t *testing.T) {
+		headers, err := parseVaultHeaders("")
+		require.NoError(t, err)
+		assert.Empty(t, headers)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		for _, val := range []string{
+			`{"X-Api-Key": 1}`,
+			"X-Api-Key",
+			"=value",
+			"x-vault-token=hijacked",
+			`{"X-Vault-Request": "false"}`,
+		} {
+			_, err := parseVaultHeaders(val)
+			assert.Error(t, err, val)
+		}
+	})
+
+	t.Run("reserved headers are rejected on init", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken:   expectedTok,
+			componentVaultHeaders: "X-Vault-Token=hijacked",
+		}}})
+		assert.ErrorContains(t, err, "header X-Vault-Token is reserved")
+	})
+
+	t.Run("custom headers are sent with GetSecret", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}))
+		v.vaultHeaders = map[string]string{
+			"X-Api-Key":        "mykey",
+			"X-Request-Source": "dapr",
+			// Cannot be configured through metadata, but must not win even if present
+			vaultHTTPHeader: "hijacked",
+		}
+		recorder := recordRequests(v)
+
+		_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		require.NoError(t, err)
+
+		require.Len(t, recorder.requests, 1)
+		header := recorder.requests[0].Header
+		assert.Equal(t, "mykey", header.Get("X-Api-Key"))
+		assert.Equal(t, "dapr", header.Get("X-Request-Source"))
+		assert.Equal(t, expectedTok, header.Get(vaultHTTPHeader))
+		assert.Equal(t, "true", header.Get(vaultHTTPRequestHeader))
+	})
+}
+
+func TestVaultExpandEnv(t *testing.T) {
+	var receivedToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedToken = r.Header.Get(vaultHTTPHeader)
+		w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_VAULT_ADDR", server.URL)
+	t.Setenv("TEST_VAULT_TOKEN", expectedTok)
+
+	initStore := func(properties map[string]string) (*vaultSecretStore, error) {
+		v := &vaultSecretStore{logger: logger.NewLogger("test"), json: jsoniter.ConfigFastest}
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+		return v, err
+	}
+
+	t.Run("references are expanded when enabled", func(t *testing.T) {
+		v, err := initStore(map[string]string{
+			componentVaultAddress:   "${TEST_VAULT_ADDR}",
+			componentVaultToken:     "${TEST_VAULT_TOKEN}",
+			componentVaultExpandEnv: "true",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, server.URL, v.vaultAddress)
+
+		_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		require.NoError(t, err)
+		assert.Equal(t, expectedTok, receivedToken)
+	})
+
+	t.Run("references are kept as literals by default", func(t *testing.T) {
+		v, err := initStore(map[string]string{
+			componentVaultAddress: server.URL,
+			componentVaultToken:   "${TEST_VAULT_TOKEN}",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "${TEST_VAULT_TOKEN}", v.vaultToken)
+	})
+
+	t.Run("undefined variables fail init", func(t *testing.T) {
+		_, err := initStore(map[string]string{
+			componentVaultAddress:   server.URL,
+			componentVaultToken:     "${TEST_VAULT_UNDEFINED}",
+			componentVaultExpandEnv: "true",
+		})
+		assert.ErrorContains(t, err, "environment variable TEST_VAULT_UNDEFINED referenced by vaultToken is not defined")
+	})
+}
+
+func TestVaultProxyURL(t *testing.T) {
+	t.Run("malformed proxy URLs are rejected", func(t *testing.T) {
+		for _, val := range []string{"://proxy", "ftp://proxy:21", "http://", "proxy.example.com:3128"} {
+			v := vaultSecretStore{logger: logger.NewLogger("test")}
+
+			err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+				componentVaultToken:    expectedTok,
+				componentVaultProxyURL: val,
+			}}})
+			assert.ErrorContains(t, err, "invalid vaultProxyURL", val)
+		}
+	})
+
+	t.Run("requests flow through the proxy", func(t *testing.T) {
+		var proxiedHost string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Requests sent to a proxy carry the absolute URL of the target
+			proxiedHost = r.URL.Host
+			assert.Equal(t, "/v1/secret/data/dapr/mysecret", r.URL.Path)
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}))
+		defer proxy.Close()
+
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress:  "http://vault.invalid:8200",
+			componentVaultToken:    expectedTok,
+			componentVaultProxyURL: proxy.URL,
+		}}})
+		require.NoError(t, err)
+
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+		assert.Equal(t, "vault.invalid:8200", proxiedHost)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultHTTPHeader) != expectedTok {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"ttl":0,"expire_time":null}}`))
+	}))
+	defer server.Close()
+
+	validate := func(properties map[string]string) error {
+		return Validate(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}})
+	}
+
+	t.Run("good config", func(t *testing.T) {
+		err := validate(map[string]string{
+			componentVaultAddress: server.URL,
+			componentVaultToken:   expectedTok,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("bad token", func(t *testing.T) {
+		err := validate(map[string]string{
+			componentVaultAddress: server.URL,
+			componentVaultToken:   "badToken",
+		})
+		assert.ErrorContains(t, err, "couldn't authenticate")
+		assert.ErrorContains(t, err, "status code 403")
+	})
+
+	t.Run("unreachable address", func(t *testing.T) {
+		unreachable := httptest.NewServer(http.NotFoundHandler())
+		unreachable.Close()
+
+		err := validate(map[string]string{
+			componentVaultAddress: unreachable.URL,
+			componentVaultToken:   expectedTok,
+		})
+		assert.ErrorContains(t, err, "couldn't authenticate")
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		err := validate(map[string]string{
+			componentVaultAddress: server.URL,
+		})
+		assert.EqualError(t, err, "token mount path and token not set")
+	})
+}
+
+func TestVaultAddrFallback(t *testing.T) {
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/secret/data/dapr/mysecret", r.URL.Path)
+		w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+	}))
+	defer standby.Close()
+
+	t.Run("invalid fallback addresses are rejected", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultToken:        expectedTok,
+			componentVaultAddrFallback: standby.URL + ",ftp://vault:21",
+		}}})
+		assert.ErrorContains(t, err, "invalid vaultAddrFallback")
+	})
+
+	t.Run("reads fail over to the standby server and keep using it", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress:      unreachable.URL,
+			componentVaultAddrFallback: standby.URL,
+			componentVaultToken:        expectedTok,
+		}}})
+		require.NoError(t, err)
+
+		breaker, ok := v.client.Transport.(*circuitBreakerTransport)
+		require.True(t, ok)
+		failover, ok := breaker.next.(*failoverTransport)
+		require.True(t, ok)
+		recorder := &recordingTransport{next: failover.next}
+		failover.next = recorder
+
+		for i := 0; i < 2; i++ {
+			resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+		}
+
+		// Only the first read tried the unreachable server
+		require.Len(t, recorder.requests, 3)
+		assert.Equal(t, unreachable.Listener.Addr().String(), recorder.requests[0].URL.Host)
+		assert.Equal(t, standby.Listener.Addr().String(), recorder.requests[1].URL.Host)
+		assert.Equal(t, standby.Listener.Addr().String(), recorder.requests[2].URL.Host)
+	})
+
+	t.Run("every server is unreachable", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress:      unreachable.URL,
+			componentVaultAddrFallback: unreachable.URL,
+			componentVaultToken:        expectedTok,
+		}}})
+		require.NoError(t, err)
+
+		_, err = v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+		assert.ErrorContains(t, err, "couldn't get secret")
+	})
+}
+
+func TestVaultReadReplicas(t *testing.T) {
+	newReplica := func(hits *atomic.Int64) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			assert.Equal(t, http.MethodGet, r.Method)
+			w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+		}))
+	}
+	var firstHits, secondHits, primaryHits atomic.Int64
+	first := newReplica(&firstHits)
+	defer first.Close()
+	second := newReplica(&secondHits)
+	defer second.Close()
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits.Add(1)
+		assert.Equal(t, "/v1/sys/leases/renew", r.URL.Path)
+		w.Write([]byte(`{"lease_id":"database/creds/app/abc","lease_duration":3600,"renewable":true}`))
+	}))
+	defer primary.Close()
+
+	initStore := func(t *testing.T, vaultAddr string) *vaultSecretStore {
+		v := &vaultSecretStore{logger: logger.NewLogger("test")}
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress:     vaultAddr,
+			componentVaultPrimaryAddr: primary.URL,
+			componentVaultToken:       expectedTok,
+		}}}))
+		return v
+	}
+	read := func(t *testing.T, v *vaultSecretStore, times int) {
+		for i := 0; i < times; i++ {
+			resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+		}
+	}
+
+	t.Run("reads are spread across the addresses", func(t *testing.T) {
+		v := initStore(t, first.URL+", "+second.URL)
+		firstHits.Store(0)
+		secondHits.Store(0)
+
+		read(t, v, 10)
+		assert.Equal(t, int64(5), firstHits.Load())
+		assert.Equal(t, int64(5), secondHits.Load())
+	})
+
+	t.Run("other requests are sent to the primary", func(t *testing.T) {
+		v := initStore(t, first.URL+","+second.URL)
+		firstHits.Store(0)
+		secondHits.Store(0)
+		primaryHits.Store(0)
+
+		_, _, err := v.renewLease(context.Background(), "database/creds/app/abc")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), primaryHits.Load())
+		assert.Zero(t, firstHits.Load()+secondHits.Load())
+	})
+
+	t.Run("reads fail over when an address is down", func(t *testing.T) {
+		down := httptest.NewServer(http.NotFoundHandler())
+		down.Close()
+		v := initStore(t, down.URL+","+second.URL)
+		secondHits.Store(0)
+
+		read(t, v, 4)
+		assert.Equal(t, int64(4), secondHits.Load())
+	})
+
+	t.Run("invalid primary address is rejected", func(t *testing.T) {
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+		err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			componentVaultAddress:     first.URL + ",ftp://vault:21",
+			componentVaultPrimaryAddr: "vault:8200",
+			componentVaultToken:       expectedTok,
+		}}})
+		assert.ErrorContains(t, err, `invalid vaultAddr "ftp://vault:21"`)
+		assert.ErrorContains(t, err, `invalid vaultPrimaryAddr "vault:8200"`)
+	})
+}
+
+func TestVaultConnectionPool(t *testing.T) {
+	const (
+		concurrency = 16
+		reads       = 10
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Keep the requests in flight long enough for them to overlap
+		time.Sleep(time.Millisecond)
+		w.Write([]byte(`{"data":{"data":{"key":"value"}}}`))
+	}))
+	defer server.Close()
+
+	// countDials initializes a store with the given properties and returns the number of
+	// connections it opened to read secrets concurrently.
+	countDials := func(t *testing.T, properties map[string]string) int64 {
+		properties[componentVaultAddress] = server.URL
+		properties[componentVaultToken] = expectedTok
+		v := vaultSecretStore{logger: logger.NewLogger("test")}
+		require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: properties}}))
+
+		var dials atomic.Int64
+		transport := v.client.Transport.(*circuitBreakerTransport).next.(*durationTransport).next.(*maxResponseBytesTransport).next.(*http.Transport)
+		dialer := &net.Dialer{}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials.Add(1)
+			return dialer.DialContext(ctx, network, addr)
+		}
+		defer transport.CloseIdleConnections()
+
+		// Readers run in waves, so all their connections become idle at the same time between two waves
+		for j := 0; j < reads; j++ {
+			var wg sync.WaitGroup
+			wg.Add(concurrency)
+			for i := 0; i < concurrency; i++ {
+				go func() {
+					defer wg.Done()
+					_, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+					assert.NoError(t, err)
+				}()
+			}
+			wg.Wait()
+		}
+
+		return dials.Load()
+	}
+
+	t.Run("negative values are rejected", func(t *testing.T) {
+		for _, key := range []string{componentMaxIdleConns, componentMaxIdleConnsPerHost, componentIdleConnTimeout} {
+			v := vaultSecretStore{logger: logger.NewLogger("test")}
+			err := v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+				componentVaultToken: expectedTok,
+				key:                 "-1",
+			}}})
+			assert.ErrorContains(t, err, "must not be negative", key)
+		}
+	})
+
+	t.Run("connections are reused under high concurrency", func(t *testing.T) {
+		defaultDials := countDials(t, map[string]string{})
+		tunedDials := countDials(t, map[string]string{
+			componentMaxIdleConns:        "64",
+			componentMaxIdleConnsPerHost: strconv.Itoa(concurrency),
+			componentIdleConnTimeout:     "1m",
+		})
+		t.Logf("%d concurrent readers in %d waves opened %d connections with the default pool, %d with the tuned pool",
+			concurrency, reads, defaultDials, tunedDials)
+
+		// Each reader needs at most one connection when the idle ones are kept
+		assert.LessOrEqual(t, tunedDials, int64(concurrency))
+		assert.Less(t, tunedDials, defaultDials)
+	})
+}
+
+// roundTripperFunc answers the requests without sending them.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestVaultClientFactory(t *testing.T) {
+	recorder := &recordingTransport{next: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"data":{"data":{"key":"value"}}}`)),
+			Request:    req,
+		}, nil
+	})}
+
+	v := &vaultSecretStore{
+		logger: logger.NewLogger("test"),
+		newClient: func(*tlsConfig, *url.URL, connPoolConfig) (*http.Client, error) {
+			return &http.Client{Transport: recorder}, nil
+		},
+	}
+	require.NoError(t, v.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		componentVaultAddress: "https://vault.example.com:8200",
+		componentVaultToken:   expectedTok,
+	}}}))
+
+	resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "mysecret"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+
+	require.Len(t, recorder.requests, 1)
+	req := recorder.requests[0]
+	assert.Equal(t, http.MethodGet, req.Method)
+	assert.Equal(t, "https://vault.example.com:8200/v1/secret/data/dapr/mysecret?version=0", req.URL.String())
+	assert.Equal(t, expectedTok, req.Header.Get(vaultHTTPHeader))
 }
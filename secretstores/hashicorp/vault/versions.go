@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// getAllSecretVersions returns the keys of all the live versions of a secret, named `v<N>.<key>`.
+// Deleted and destroyed versions are skipped. When maxVersionsReturned is set, only the most recent versions are returned.
+func (v *vaultSecretStore) getAllSecretVersions(ctx context.Context, secret string) (secretstores.GetSecretResponse, error) {
+	meta, err := v.getSecretMetadata(ctx, secret)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, err
+	}
+
+	versions := make([]int, 0, len(meta.Data.Versions))
+	for key, state := range meta.Data.Versions {
+		if state.Destroyed || state.DeletionTime != "" {
+			continue
+		}
+		version, err := strconv.Atoi(key)
+		if err != nil {
+			return secretstores.GetSecretResponse{}, fmt.Errorf("invalid version %q in the metadata of secret %s", key, secret)
+		}
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("getSecret %s failed, no live versions %w", secret, ErrNotFound)
+	}
+
+	// Most recent versions first
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	if v.maxVersionsReturned > 0 && len(versions) > v.maxVersionsReturned {
+		versions = versions[:v.maxVersionsReturned]
+	}
+
+	resp := secretstores.GetSecretResponse{
+		Data: map[string]string{},
+	}
+	for _, version := range versions {
+		d, err := v.getSecretFromEngine(ctx, v.vaultEnginePath, secret, strconv.Itoa(version))
+		if errors.Is(err, ErrNotFound) {
+			// The version was deleted after reading the metadata
+			continue
+		}
+		if err != nil {
+			return secretstores.GetSecretResponse{}, err
+		}
+
+		values, err := v.transformKeys(secret, v.filterAllowedFields(d.Data.Data))
+		if err != nil {
+			return secretstores.GetSecretResponse{}, err
+		}
+		for key, value := range values {
+			resp.Data["v"+strconv.Itoa(version)+"."+key] = value
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+func TestGetAllSecretVersions(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/metadata/dapr/db":
+			w.Write([]byte(`{"data":{"current_version":4,"versions":{` +
+				`"1":{"deletion_time":"","destroyed":false},` +
+				`"2":{"deletion_time":"","destroyed":true},` +
+				`"3":{"deletion_time":"","destroyed":false},` +
+				`"4":{"deletion_time":"","destroyed":false}}}}`))
+		case "/v1/secret/data/dapr/db":
+			version := r.URL.Query().Get("version")
+			if version == "2" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"data":{"data":{"user":"admin","password":"p%s"}}}`, version)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	getAllVersions := func(v *vaultSecretStore, name string) (map[string]string, error) {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     name,
+			Metadata: map[string]string{allVersions: "true"},
+		})
+		return resp.Data, err
+	}
+
+	t.Run("all live versions are returned", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		data, err := getAllVersions(v, "db")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"v1.user":     "admin",
+			"v1.password": "p1",
+			"v3.user":     "admin",
+			"v3.password": "p3",
+			"v4.user":     "admin",
+			"v4.password": "p4",
+		}, data)
+	})
+
+	t.Run("most recent versions are returned up to the cap", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+		v.maxVersionsReturned = 2
+
+		data, err := getAllVersions(v, "db")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"v3.user":     "admin",
+			"v3.password": "p3",
+			"v4.user":     "admin",
+			"v4.password": "p4",
+		}, data)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		v := newTestVaultSecretStore(t, handler)
+
+		_, err := getAllVersions(v, "missing")
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+	})
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+const defaultWatchPollInterval = time.Minute
+
+// SecretChangeEvent describes a new version of a watched secret.
+type SecretChangeEvent struct {
+	// Name of the secret.
+	Name string
+	// Version is the current version of the secret.
+	Version int
+	// Data is the value of the current version, or nil if the secret was deleted or couldn't be read.
+	Data map[string]string
+}
+
+// SecretChangeHandler is invoked when a watched secret changes.
+type SecretChangeHandler func(event SecretChangeEvent)
+
+// vaultKVMetadataResponse is the response data from Vault KV v2 metadata.
+type vaultKVMetadataResponse struct {
+	Data struct {
+		CurrentVersion int `json:"current_version"`
+		Versions       map[string]struct {
+			DeletionTime string `json:"deletion_time"`
+			Destroyed    bool   `json:"destroyed"`
+		} `json:"versions"`
+	} `json:"data"`
+}
+
+// SetSecretChangeHandler sets the handler invoked when a secret listed in watchSecrets changes.
+func (v *vaultSecretStore) SetSecretChangeHandler(handler SecretChangeHandler) {
+	v.watchLock.Lock()
+	defer v.watchLock.Unlock()
+
+	v.changeHandler = handler
+}
+
+// startWatcher polls the version of the watched secrets in background until the context is canceled.
+func (v *vaultSecretStore) startWatcher(ctx context.Context, names []string, interval time.Duration) {
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+
+		// Versions seen on the previous successful poll; the first poll only records them.
+		versions := make(map[string]int, len(names))
+		bo := backoff.NewExponentialBackOff()
+		bo.InitialInterval = interval
+		bo.MaxInterval = 10 * interval
+		bo.MaxElapsedTime = 0
+
+		wait := time.Duration(0)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+
+			if err := v.pollSecretVersions(ctx, names, versions); err != nil {
+				wait = bo.NextBackOff()
+				v.logger.Warnf("Failed to poll the version of watched secrets, retrying in %v: %v", wait, err)
+				continue
+			}
+			bo.Reset()
+			wait = interval
+		}
+	}()
+}
+
+// pollSecretVersions reads the current version of each secret and notifies the ones that changed.
+func (v *vaultSecretStore) pollSecretVersions(ctx context.Context, names []string, versions map[string]int) error {
+	if err := v.ensureConnected(ctx); err != nil {
+		return err
+	}
+
+	current := make(map[string]int, len(names))
+	for _, name := range names {
+		version, err := v.getSecretVersion(ctx, name)
+		if err != nil {
+			return err
+		}
+		current[name] = version
+	}
+
+	for name, version := range current {
+		previous, seen := versions[name]
+		versions[name] = version
+		if !seen || previous == version {
+			continue
+		}
+
+		v.logger.Infof("Secret %s changed to version %d", name, version)
+		if v.cache != nil {
+			v.cache.invalidate(name)
+		}
+
+		v.watchLock.RLock()
+		handler := v.changeHandler
+		v.watchLock.RUnlock()
+		if handler != nil {
+			handler(SecretChangeEvent{Name: name, Version: version, Data: v.readChangedSecret(ctx, name, version)})
+		}
+	}
+
+	return nil
+}
+
+// readChangedSecret returns the value of the version of a secret that was detected by the watcher, which is cached
+// again if the cache is enabled. Errors are logged only, so that the change is notified anyway.
+func (v *vaultSecretStore) readChangedSecret(ctx context.Context, name string, version int) map[string]string {
+	if version == 0 {
+		return nil
+	}
+
+	resp, err := v.GetSecret(ctx, secretstores.GetSecretRequest{Name: name})
+	if err != nil {
+		v.logger.Warnf("Failed to read version %d of the changed secret %s: %v", version, name, err)
+		return nil
+	}
+
+	return resp.Data
+}
+
+// getSecretVersion returns the current version of a KV v2 secret, or 0 if the secret doesn't exist.
+func (v *vaultSecretStore) getSecretVersion(ctx context.Context, secret string) (int, error) {
+	d, err := v.getSecretMetadata(ctx, secret)
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return d.Data.CurrentVersion, nil
+}
+
+// getSecretMetadata returns the metadata of a KV v2 secret, including the state of its versions.
+func (v *vaultSecretStore) getSecretMetadata(ctx context.Context, secret string) (*vaultKVMetadataResponse, error) {
+	httpReq, err := v.newVaultRequest(ctx, http.MethodGet, v.vaultAddress+"/v1/"+v.kvPath("metadata", secret), nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate request: %w", err)
+	}
+
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get secret metadata: %w", err)
+	}
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("getSecretMetadata %s failed %w", secret, ErrNotFound)
+	}
+	if httpresp.StatusCode == http.StatusForbidden {
+		return nil, v.permissionDenied(ctx, operationGet, httpReq.URL.Path)
+	}
+	if httpresp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, httpresp.Body)
+		return nil, fmt.Errorf("couldn't get secret metadata for %s, status code %d", secret, httpresp.StatusCode)
+	}
+
+	var d vaultKVMetadataResponse
+	if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
+		return nil, decodeError(err)
+	}
+
+	return &d, nil
+}
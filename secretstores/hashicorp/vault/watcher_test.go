@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// fakeVersionedVault serves a single KV v2 secret whose version can be bumped.
+type fakeVersionedVault struct {
+	version  atomic.Int64
+	failing  atomic.Bool
+	requests atomic.Int64
+}
+
+func (f *fakeVersionedVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+	if f.failing.Load() {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	version := f.version.Load()
+	switch r.URL.Path {
+	case "/v1/secret/metadata/dapr/db":
+		fmt.Fprintf(w, `{"data":{"current_version":%d}}`, version)
+	case "/v1/secret/data/dapr/db":
+		fmt.Fprintf(w, `{"data":{"data":{"password":"v%d"}}}`, version)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSecretWatcher(t *testing.T) {
+	const interval = 10 * time.Millisecond
+
+	fake := &fakeVersionedVault{}
+	fake.version.Store(1)
+	v := newTestVaultSecretStore(t, fake)
+	v.cache = newSecretCache(time.Hour)
+
+	events := make(chan SecretChangeEvent, 10)
+	v.SetSecretChangeHandler(func(event SecretChangeEvent) {
+		events <- event
+	})
+
+	getPassword := func() string {
+		resp, err := v.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db"})
+		require.NoError(t, err)
+		return resp.Data["password"]
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	v.closeCancel = cancel
+	v.startWatcher(ctx, []string{"db", "missing"}, interval)
+
+	assert.Equal(t, "v1", getPassword())
+	// Give the watcher time to record the initial versions: no event is expected for them
+	time.Sleep(5 * interval)
+	assert.Empty(t, events)
+
+	t.Run("version bump invalidates the cache and notifies once with the new value", func(t *testing.T) {
+		fake.version.Store(2)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, SecretChangeEvent{Name: "db", Version: 2, Data: map[string]string{"password": "v2"}}, event)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the change event")
+		}
+
+		// The new value is cached when it's read for the event
+		resp, cached := v.cache.get("db", "0")
+		assert.True(t, cached)
+		assert.Equal(t, "v2", resp.Data["password"])
+		assert.Equal(t, "v2", getPassword())
+
+		time.Sleep(5 * interval)
+		assert.Empty(t, events)
+	})
+
+	t.Run("errors do not emit events and the watcher recovers", func(t *testing.T) {
+		fake.failing.Store(true)
+		time.Sleep(5 * interval)
+		assert.Empty(t, events)
+
+		fake.version.Store(3)
+		fake.failing.Store(false)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, SecretChangeEvent{Name: "db", Version: 3, Data: map[string]string{"password": "v3"}}, event)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the change event")
+		}
+	})
+
+	t.Run("close stops the watcher", func(t *testing.T) {
+		require.NoError(t, v.Close())
+
+		requests := fake.requests.Load()
+		time.Sleep(5 * interval)
+		assert.Equal(t, requests, fake.requests.Load())
+	})
+}
+
+func TestWatchInterval(t *testing.T) {
+	t.Run("vaultWatchInterval is an alias of watchPollInterval", func(t *testing.T) {
+		m, err := decodeVaultMetadata(map[string]string{componentWatchInterval: "30s"})
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, m.WatchPollInterval)
+	})
+}
@@ -154,7 +154,7 @@ func (c *csmsSecretStore) getSecretNames(ctx context.Context, marker *string) ([
 
 // Features returns the features available in this secret store.
 func (c *csmsSecretStore) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetSecret}
 }
 
 func (c *csmsSecretStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
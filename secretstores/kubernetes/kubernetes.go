@@ -17,8 +17,10 @@ package kubernetes
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -63,6 +65,9 @@ func (k *kubernetesSecretStore) GetSecret(ctx context.Context, req secretstores.
 
 	secret, err := k.kubeClient.CoreV1().Secrets(namespace).Get(ctx, req.Name, meta_v1.GetOptions{}) //nolint:nosnakecase
 	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return resp, fmt.Errorf("secret %s: %w", req.Name, secretstores.ErrSecretNotFound)
+		}
 		return resp, err
 	}
 
@@ -113,7 +118,7 @@ func (k *kubernetesSecretStore) getNamespaceFromMetadata(metadata map[string]str
 
 // Features returns the features available in this secret store.
 func (k *kubernetesSecretStore) Features() []secretstores.Feature {
-	return []secretstores.Feature{}
+	return []secretstores.Feature{secretstores.FeatureBulkGetSecret}
 }
 
 func (k *kubernetesSecretStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
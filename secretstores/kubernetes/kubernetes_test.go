@@ -14,11 +14,17 @@ limitations under the License.
 package kubernetes
 
 import (
+	"context"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
+	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
 )
 
@@ -54,8 +60,35 @@ func TestGetNamespace(t *testing.T) {
 func TestGetFeatures(t *testing.T) {
 	s := kubernetesSecretStore{logger: logger.NewLogger("test")}
 	// Yes, we are skipping initialization as feature retrieval doesn't depend on it.
-	t.Run("no features are advertised", func(t *testing.T) {
+	t.Run("only BULK_GET_SECRET is advertised", func(t *testing.T) {
 		f := s.Features()
-		assert.Empty(t, f)
+		assert.Equal(t, []secretstores.Feature{secretstores.FeatureBulkGetSecret}, f)
+	})
+}
+
+func TestGetSecret(t *testing.T) {
+	store := kubernetesSecretStore{
+		logger: logger.NewLogger("test"),
+		kubeClient: fake.NewSimpleClientset(&v1.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "mysecret", Namespace: "default"}, //nolint:nosnakecase
+			Data:       map[string][]byte{"key": []byte("value")},
+		}),
+	}
+
+	t.Run("existing secret", func(t *testing.T) {
+		resp, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "mysecret",
+			Metadata: map[string]string{"namespace": "default"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"key": "value"}, resp.Data)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		_, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "missing",
+			Metadata: map[string]string{"namespace": "default"},
+		})
+		require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
 	})
 }
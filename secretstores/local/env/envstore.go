@@ -16,6 +16,7 @@ package env
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"reflect"
 	"runtime"
@@ -56,12 +57,15 @@ func (s *envSecretStore) Init(_ context.Context, meta secretstores.Metadata) err
 
 // GetSecret retrieves a secret from env var using provided key.
 func (s *envSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
-	var value string
 	name := s.metadata.Prefix + req.Name
-	if s.isKeyAllowed(name) {
-		value = os.Getenv(name)
-	} else {
+	// Env vars that can't be read are reported as missing, so they can't be told apart from the ones that aren't set
+	if !s.isKeyAllowed(name) {
 		s.logger.Warnf("Access to env var %s is forbidden", req.Name)
+		return secretstores.GetSecretResponse{}, fmt.Errorf("env var %s: %w", req.Name, secretstores.ErrSecretNotFound)
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("env var %s: %w", req.Name, secretstores.ErrSecretNotFound)
 	}
 	return secretstores.GetSecretResponse{
 		Data: map[string]string{
@@ -111,7 +115,7 @@ func (s *envSecretStore) BulkGetSecret(ctx context.Context, req secretstores.Bul
 
 // Features returns the features available in this secret store.
 func (s *envSecretStore) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetSecret}
 }
 
 func (s *envSecretStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
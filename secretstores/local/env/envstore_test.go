@@ -49,10 +49,8 @@ func TestEnvStore(t *testing.T) {
 
 	if runtime.GOOS != "windows" {
 		t.Run("Get is case-sensitive on *nix", func(t *testing.T) {
-			resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "test_secret"})
-			require.NoError(t, err)
-			require.NotNil(t, resp)
-			assert.Empty(t, resp.Data["test_secret"])
+			_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "test_secret"})
+			require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
 		})
 	} else {
 		t.Run("Get is case-insensitive on Windows", func(t *testing.T) {
@@ -63,6 +61,12 @@ func TestEnvStore(t *testing.T) {
 		})
 	}
 
+	t.Run("Get missing env var", func(t *testing.T) {
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "NOT_SET_SECRET"})
+		require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+		assert.EqualError(t, err, "env var NOT_SET_SECRET: secret not found")
+	})
+
 	t.Run("Bulk get", func(t *testing.T) {
 		resp, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
 		require.NoError(t, err)
@@ -77,26 +81,23 @@ func TestEnvStore(t *testing.T) {
 		t.Setenv("FOO", "bar")
 
 		t.Run("Get", func(t *testing.T) {
-			resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
 				Name: "APP_API_TOKEN",
 			})
-			require.NoError(t, err)
-			require.NotNil(t, resp.Data)
-			assert.Empty(t, resp.Data["APP_API_TOKEN"])
+			require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+			assert.EqualError(t, err, "env var APP_API_TOKEN: secret not found")
 
-			resp, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			_, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{
 				Name: "dapr_notallowed",
 			})
-			require.NoError(t, err)
-			require.NotNil(t, resp.Data)
-			assert.Empty(t, resp.Data["dapr_notallowed"])
+			require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+			assert.EqualError(t, err, "env var dapr_notallowed: secret not found")
 
-			resp, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			_, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{
 				Name: "DAPR_NOTALLOWED",
 			})
-			require.NoError(t, err)
-			require.NotNil(t, resp.Data)
-			assert.Empty(t, resp.Data["DAPR_NOTALLOWED"])
+			require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+			assert.EqualError(t, err, "env var DAPR_NOTALLOWED: secret not found")
 		})
 
 		t.Run("Bulk get", func(t *testing.T) {
@@ -169,12 +170,10 @@ func TestEnvStoreWithPrefix(t *testing.T) {
 			})
 			require.NoError(t, err)
 
-			resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			_, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{
 				Name: "API_TOKEN",
 			})
-			require.NoError(t, err)
-			require.NotNil(t, resp.Data)
-			assert.Empty(t, resp.Data["API_TOKEN"])
+			require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
 		})
 
 		t.Run("Get case insensitive", func(t *testing.T) {
@@ -185,12 +184,10 @@ func TestEnvStoreWithPrefix(t *testing.T) {
 			})
 			require.NoError(t, err)
 
-			resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			_, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{
 				Name: "API_TOKEN",
 			})
-			require.NoError(t, err)
-			require.NotNil(t, resp.Data)
-			assert.Empty(t, resp.Data["API_TOKEN"])
+			require.ErrorIs(t, err, secretstores.ErrSecretNotFound)
 		})
 
 		t.Run("Bulk get", func(t *testing.T) {
@@ -212,8 +209,8 @@ func TestEnvStoreWithPrefix(t *testing.T) {
 func TestGetFeatures(t *testing.T) {
 	s := envSecretStore{logger: logger.NewLogger("test")}
 	// Yes, we are skipping initialization as feature retrieval doesn't depend on it.
-	t.Run("no features are advertised", func(t *testing.T) {
+	t.Run("only BULK_GET_SECRET is advertised", func(t *testing.T) {
 		f := s.Features()
-		assert.Empty(t, f)
+		assert.Equal(t, []secretstores.Feature{secretstores.FeatureBulkGetSecret}, f)
 	})
 }
@@ -93,6 +93,7 @@ func (j *localSecretStore) Init(_ context.Context, metadata secretstores.Metadat
 		// If MultiValued is set, this secret store supports a multiple
 		// key-valyes per secret.
 		j.features = []secretstores.Feature{
+			secretstores.FeatureBulkGetSecret,
 			secretstores.FeatureMultipleKeyValuesPerSecret,
 		}
 	} else {
@@ -100,7 +101,9 @@ func (j *localSecretStore) Init(_ context.Context, metadata secretstores.Metadat
 		j.visitJSONObject(jsonConfig)
 		// MultiValued is not set: reset to its default single-value per
 		// secret (no extra feature) behavior.
-		j.features = []secretstores.Feature{}
+		j.features = []secretstores.Feature{
+			secretstores.FeatureBulkGetSecret,
+		}
 	}
 
 	return nil
@@ -110,7 +113,7 @@ func (j *localSecretStore) Init(_ context.Context, metadata secretstores.Metadat
 func (j *localSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
 	secretValue, exists := j.secrets[req.Name]
 	if !exists {
-		return secretstores.GetSecretResponse{}, fmt.Errorf("secret %s not found", req.Name)
+		return secretstores.GetSecretResponse{}, fmt.Errorf("secret %s: %w", req.Name, secretstores.ErrSecretNotFound)
 	}
 
 	var data map[string]string
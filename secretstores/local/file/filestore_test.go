@@ -136,8 +136,8 @@ func TestGetSecret(t *testing.T) {
 			Metadata: map[string]string{},
 		}
 		_, err := s.GetSecret(context.Background(), req)
-		assert.NotNil(t, err)
-		assert.Equal(t, err, fmt.Errorf("secret %s not found", req.Name))
+		assert.ErrorIs(t, err, secretstores.ErrSecretNotFound)
+		assert.EqualError(t, err, "secret NoSecret: secret not found")
 	})
 
 	t.Run("Regular (non-MultiValued) secret store does not support MULTIPLE_KEY_VALUES_PER_SECRET", func(t *testing.T) {
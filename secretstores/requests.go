@@ -19,6 +19,15 @@ type GetSecretRequest struct {
 	Metadata map[string]string `json:"metadata"`
 }
 
+// Metadata keys of the BulkGetSecret requests and responses of the stores that advertise FeatureBulkGetSecretPagination.
+const (
+	// BulkGetSecretLimitKey is the request metadata key setting the maximum number of secrets returned in a page.
+	BulkGetSecretLimitKey = "limit"
+	// BulkGetSecretContinuationTokenKey is both the response metadata key holding the token of the next page, which
+	// is missing from the last page, and the request metadata key to pass it back to get the next page.
+	BulkGetSecretContinuationTokenKey = "continuationToken"
+)
+
 // BulkGetSecretRequest describes a bulk get secret request from a secret store.
 type BulkGetSecretRequest struct {
 	Metadata map[string]string `json:"metadata"`
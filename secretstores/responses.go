@@ -16,9 +16,13 @@ package secretstores
 // GetSecretResponse describes the response object for a secret returned from a secret store.
 type GetSecretResponse struct {
 	Data map[string]string `json:"data"`
+	// Metadata contains optional information about the secret, such as its version. Stores that don't have any leave it empty.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // BulkGetSecretResponse describes the response object for all the secrets returned from a secret store.
 type BulkGetSecretResponse struct {
 	Data map[string]map[string]string `json:"data"`
+	// Metadata contains optional information about the response, such as the token of the next page.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
@@ -15,12 +15,19 @@ package secretstores
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/dapr/components-contrib/health"
 	"github.com/dapr/components-contrib/metadata"
 )
 
+// ErrSecretNotFound is returned, possibly wrapped, when the requested secret doesn't exist in the secret store.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// ErrBulkGetSecretNotSupported is returned by BulkGetSecret when the secret store doesn't advertise FeatureBulkGetSecret.
+var ErrBulkGetSecretNotSupported = errors.New("bulk get secret is not supported by this secret store")
+
 // SecretStore is the interface for a component that handles secrets management.
 type SecretStore interface {
 	metadata.ComponentWithMetadata
@@ -190,7 +190,7 @@ func (s *ssmSecretStore) getSecretNames(ctx context.Context, offset *uint64) ([]
 
 // Features returns the features available in this secret store.
 func (s *ssmSecretStore) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetSecret}
 }
 
 func (s *ssmSecretStore) GetComponentMetadata() (metadataInfo metadata.MetadataMap) {
@@ -128,6 +128,22 @@ func WithProfilingEnabled(enabled bool) Option {
 	}
 }
 
+// WithLogLevel sets the output level of the loggers of the runtime and of the components created so far, like the
+// --log-level flag of daprd does. Accepted levels are debug, info, warn, error and fatal.
+func WithLogLevel(level string) Option {
+	return func(config *runtime.Config) {
+		loggerOptions := logger.DefaultOptions()
+		loggerOptions.SetAppID(config.ID)
+		if err := loggerOptions.SetOutputLevel(level); err != nil {
+			log.Warnf("Ignoring the log level: %v", err)
+			return
+		}
+		if err := logger.ApplyOptionsToLoggers(&loggerOptions); err != nil {
+			log.Warnf("Failed to apply the log level: %v", err)
+		}
+	}
+}
+
 func NewRuntime(appID string, opts ...Option) (*runtime.DaprRuntime, *runtime.Config, error) {
 	var err error
 	runtimeConfig := runtime.NewRuntimeConfig(runtime.NewRuntimeConfigOpts{
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package embedded
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/dapr/dapr/pkg/runtime"
+	"github.com/dapr/kit/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	componentLogger := logger.NewLogger("dapr.components.test")
+	componentLogger.SetOutput(&buf)
+	t.Cleanup(func() {
+		WithLogLevel("info")(&runtime.Config{})
+	})
+
+	componentLogger.Debug("before the option")
+	assert.NotContains(t, buf.String(), "before the option")
+
+	WithLogLevel("debug")(&runtime.Config{ID: "myapp"})
+	componentLogger.Debug("after the option")
+	assert.Contains(t, buf.String(), "after the option")
+	assert.Contains(t, buf.String(), "app_id=myapp")
+
+	t.Run("an invalid level is ignored", func(t *testing.T) {
+		WithLogLevel("verbose")(&runtime.Config{})
+		assert.True(t, componentLogger.IsOutputLevelEnabled(logger.DebugLevel))
+	})
+}
+
+func TestWithEnv(t *testing.T) {
+	t.Setenv("EMBEDDED_TEST_EXISTING", "before")
+	os.Unsetenv("EMBEDDED_TEST_NEW")
+
+	WithEnv(map[string]string{
+		"EMBEDDED_TEST_EXISTING": "during",
+		"EMBEDDED_TEST_NEW":      "added",
+	})(&runtime.Config{ID: "myapp"})
+	// Set again, as when the sidecar restarts
+	WithEnv(map[string]string{"EMBEDDED_TEST_EXISTING": "again"})(&runtime.Config{ID: "myapp"})
+	assert.Equal(t, "again", os.Getenv("EMBEDDED_TEST_EXISTING"))
+	assert.Equal(t, "added", os.Getenv("EMBEDDED_TEST_NEW"))
+
+	require.NoError(t, RestoreEnv("otherapp"))
+	assert.Equal(t, "again", os.Getenv("EMBEDDED_TEST_EXISTING"))
+
+	require.NoError(t, RestoreEnv("myapp"))
+	assert.Equal(t, "before", os.Getenv("EMBEDDED_TEST_EXISTING"))
+	_, ok := os.LookupEnv("EMBEDDED_TEST_NEW")
+	assert.False(t, ok)
+
+	t.Run("restoring twice does nothing", func(t *testing.T) {
+		os.Setenv("EMBEDDED_TEST_EXISTING", "changed")
+		require.NoError(t, RestoreEnv("myapp"))
+		assert.Equal(t, "changed", os.Getenv("EMBEDDED_TEST_EXISTING"))
+	})
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package embedded
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/dapr/dapr/pkg/runtime"
+)
+
+// previousEnv holds the values the environment variables set with WithEnv had before, by app ID, until RestoreEnv
+// restores them.
+var (
+	previousEnvLock sync.Mutex
+	previousEnv     = map[string]map[string]*string{}
+)
+
+// WithEnv sets environment variables when the runtime is created, before the components are initialized, for
+// example to test the expansion of ${VAULT_TOKEN} or the credentials read from the environment by a cloud SDK.
+// RestoreEnv gives them back their previous values: the sidecar package does it when the sidecar stops, including
+// when the flow fails. The environment is shared by the process, so sidecars running at the same time see each
+// other's variables.
+func WithEnv(env map[string]string) Option {
+	return func(config *runtime.Config) {
+		previousEnvLock.Lock()
+		defer previousEnvLock.Unlock()
+
+		previous := previousEnv[config.ID]
+		if previous == nil {
+			previous = make(map[string]*string, len(env))
+			previousEnv[config.ID] = previous
+		}
+		for name, value := range env {
+			// The value from before the first option is kept when a variable is set several times
+			if _, ok := previous[name]; !ok {
+				if old, ok := os.LookupEnv(name); ok {
+					previous[name] = &old
+				} else {
+					previous[name] = nil
+				}
+			}
+			if err := os.Setenv(name, value); err != nil {
+				log.Warnf("Failed to set environment variable %s: %v", name, err)
+			}
+		}
+	}
+}
+
+// RestoreEnv restores the environment variables set with WithEnv for the runtime of appID, unsetting the ones that
+// weren't set before. It does nothing if there are none.
+func RestoreEnv(appID string) error {
+	previousEnvLock.Lock()
+	previous := previousEnv[appID]
+	delete(previousEnv, appID)
+	previousEnvLock.Unlock()
+
+	var errs []error
+	for name, value := range previous {
+		var err error
+		if value == nil {
+			err = os.Unsetenv(name)
+		} else {
+			err = os.Setenv(name, *value)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore environment variable %s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -120,3 +120,48 @@ func (c Context) Set(varName string, value interface{}) {
 
 	c.variables[varName] = value
 }
+
+// Key identifies a value of type T passed between the steps of a flow, such as the gRPC port of a sidecar published
+// by the step that starts it. Unlike the variables of Set and Get, the type of the value is checked when compiling.
+// Keys with the same name but different types identify different values.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns the key of the values of type T with the given name.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the name of the key.
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+// Set stores the value of the key in the flow of ctx. The values are scoped to a flow instance, and can be set and
+// read by runnables running in parallel.
+func (k Key[T]) Set(ctx Context, value T) {
+	ctx.varsMu.Lock()
+	defer ctx.varsMu.Unlock()
+
+	ctx.values[k] = value
+}
+
+// Get returns the value of the key in the flow of ctx, and whether it was set.
+func (k Key[T]) Get(ctx Context) (T, bool) {
+	ctx.varsMu.RLock()
+	defer ctx.varsMu.RUnlock()
+
+	value, ok := ctx.values[k].(T)
+	return value, ok
+}
+
+// MustGet returns the value of the key in the flow of ctx, and fails the test if it wasn't set.
+func (k Key[T]) MustGet(ctx Context) T {
+	value, ok := k.Get(ctx)
+	if !ok {
+		ctx.Fatalf("could not find value %q", k.name)
+	}
+
+	return value
+}
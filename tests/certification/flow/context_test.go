@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	portKey := NewKey[int]("port")
+
+	t.Run("values are passed between steps", func(t *testing.T) {
+		var port int
+		New(t, "steps").
+			Step("publish", func(ctx Context) error {
+				portKey.Set(ctx, 50001)
+				return nil
+			}).
+			Step("read", func(ctx Context) error {
+				port = portKey.MustGet(ctx)
+				return nil
+			}).
+			Run()
+
+		assert.Equal(t, 50001, port)
+	})
+
+	t.Run("keys with the same name and different types are different", func(t *testing.T) {
+		ctx := Context{T: t, Flow: New(t, "types")}
+		portKey.Set(ctx, 50001)
+		NewKey[string]("port").Set(ctx, "50002")
+
+		port, ok := portKey.Get(ctx)
+		require.True(t, ok)
+		assert.Equal(t, 50001, port)
+		name, ok := NewKey[string]("port").Get(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "50002", name)
+		// Values of keys are separate from the variables
+		assert.False(t, ctx.Get("port", new(int)))
+	})
+
+	t.Run("missing values", func(t *testing.T) {
+		ctx := Context{T: t, Flow: New(t, "missing")}
+
+		port, ok := portKey.Get(ctx)
+		assert.False(t, ok)
+		assert.Zero(t, port)
+	})
+
+	t.Run("values are scoped to a flow", func(t *testing.T) {
+		first := Context{T: t, Flow: New(t, "first")}
+		second := Context{T: t, Flow: New(t, "second")}
+		portKey.Set(first, 50001)
+
+		_, ok := portKey.Get(second)
+		assert.False(t, ok)
+	})
+
+	t.Run("values can be set by parallel runnables", func(t *testing.T) {
+		ctx := Context{Context: context.Background(), T: t, Flow: New(t, "parallel")}
+		runnables := make([]Runnable, 10)
+		for i := range runnables {
+			i := i
+			key := NewKey[int](fmt.Sprintf("port %d", i))
+			runnables[i] = func(ctx Context) error {
+				key.Set(ctx, i)
+				portKey.Set(ctx, i)
+				_, _ = portKey.Get(ctx)
+				return nil
+			}
+		}
+
+		require.NoError(t, Parallel(runnables...)(ctx))
+		for i := range runnables {
+			assert.Equal(t, i, NewKey[int](fmt.Sprintf("port %d", i)).MustGet(ctx))
+		}
+	})
+}
@@ -14,7 +14,11 @@ limitations under the License.
 package dockercompose
 
 import (
+	"context"
+	"os"
 	"os/exec"
+	"sort"
+	"time"
 
 	"github.com/dapr/components-contrib/tests/certification/flow"
 )
@@ -22,6 +26,13 @@ import (
 type Compose struct {
 	project  string
 	filename string
+	// env is added to the environment of the docker-compose commands, to set the variables of the compose file.
+	env map[string]string
+	// unique suffixes the name of the project with a random token when it's started.
+	unique bool
+	// wait is how long the step returned by ToStep waits for the services to be ready, if set.
+	wait         time.Duration
+	waitServices []string
 }
 
 func Run(project, filename string) (string, flow.Runnable, flow.Runnable) {
@@ -39,21 +50,25 @@ func (c Compose) AppID() string {
 	return c.project
 }
 
+// ToStep returns a step that starts the containers, and removes them when the flow is done. When the flow fails,
+// the logs of the services are saved first, to the directory set with EnvLogsDir.
 func (c Compose) ToStep() (string, flow.Runnable, flow.Runnable) {
-	return c.project, c.Up, c.Down
+	if c.wait > 0 {
+		return c.project, c.UpAndWait(c.wait, c.waitServices...), c.downAfterFailure
+	}
+
+	return c.project, c.Up, c.downAfterFailure
 }
 
 func Up(project, filename string) flow.Runnable {
 	return New(project, filename).Up
 }
 
+// Up starts the containers. With UniqueProject, the project is given its unique name first, which the other
+// runnables of the flow then use.
 func (c Compose) Up(ctx flow.Context) error {
-	out, err := exec.Command(
-		"docker-compose",
-		"-p", c.project,
-		"-f", c.filename,
-		"up", "-d",
-		"--remove-orphans").CombinedOutput()
+	c = c.start(ctx)
+	out, err := c.command("up", "-d", "--remove-orphans").CombinedOutput()
 	ctx.Log(string(out))
 
 	return err
@@ -64,11 +79,8 @@ func Down(project, filename string) flow.Runnable {
 }
 
 func (c Compose) Down(ctx flow.Context) error {
-	out, err := exec.Command(
-		"docker-compose",
-		"-p", c.project,
-		"-f", c.filename,
-		"down", "-v").CombinedOutput()
+	c = c.instance(ctx)
+	out, err := c.command("down", "-v").CombinedOutput()
 	ctx.Log(string(out))
 
 	return err
@@ -80,13 +92,8 @@ func Start(project, filename string, services ...string) flow.Runnable {
 
 func (c Compose) Start(services ...string) flow.Runnable {
 	return func(ctx flow.Context) error {
-		args := []string{
-			"-p", c.project,
-			"-f", c.filename,
-			"start",
-		}
-		args = append(args, services...)
-		out, err := exec.Command("docker-compose", args...).CombinedOutput()
+		c := c.instance(ctx)
+		out, err := c.command(append([]string{"start"}, services...)...).CombinedOutput()
 		ctx.Log(string(out))
 		return err
 	}
@@ -98,14 +105,37 @@ func Stop(project, filename string, services ...string) flow.Runnable {
 
 func (c Compose) Stop(services ...string) flow.Runnable {
 	return func(ctx flow.Context) error {
-		args := []string{
-			"-p", c.project,
-			"-f", c.filename,
-			"stop",
-		}
-		args = append(args, services...)
-		out, err := exec.Command("docker-compose", args...).CombinedOutput()
+		c := c.instance(ctx)
+		out, err := c.command(append([]string{"stop"}, services...)...).CombinedOutput()
 		ctx.Log(string(out))
 		return err
 	}
 }
+
+// command returns a docker-compose command for the project, with the environment set with Env.
+func (c Compose) command(args ...string) *exec.Cmd {
+	return c.commandContext(context.Background(), args...)
+}
+
+// commandContext is like command, and kills the process when ctx is done.
+func (c Compose) commandContext(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "docker-compose", append([]string{"-p", c.project, "-f", c.filename}, args...)...)
+	if len(c.env) > 0 {
+		cmd.Env = os.Environ()
+		for _, name := range sortedKeys(c.env) {
+			cmd.Env = append(cmd.Env, name+"="+c.env[name])
+		}
+	}
+
+	return cmd
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
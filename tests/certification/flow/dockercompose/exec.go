@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// defaultReplica is the index of the container commands are run in, for services with several replicas.
+const defaultReplica = 1
+
+// Exec returns a runnable that runs a command in the container of a service, for example to seal Vault or write a
+// new version of a secret in the middle of a flow. See Compose.Exec.
+func Exec(project, filename, service string, timeoutSeconds int, cmd ...string) flow.Runnable {
+	return New(project, filename).Exec(service, timeoutSeconds, cmd...)
+}
+
+// ExecOutput is like Exec, and stores the standard output of the command in key. See Compose.ExecOutput.
+func ExecOutput(project, filename, service string, timeoutSeconds int, key flow.Key[string], cmd ...string) flow.Runnable {
+	return New(project, filename).ExecOutput(service, timeoutSeconds, key, cmd...)
+}
+
+// Exec returns a runnable that runs a command in the first container of a service. The output of the command is
+// logged, and the step fails if it exits with a non-zero code or runs for longer than timeoutSeconds, when it's
+// positive.
+func (c Compose) Exec(service string, timeoutSeconds int, cmd ...string) flow.Runnable {
+	return c.ExecReplica(service, defaultReplica, timeoutSeconds, cmd...)
+}
+
+// ExecReplica is like Exec, for the replica of the service with the given index, starting at 1.
+func (c Compose) ExecReplica(service string, index, timeoutSeconds int, cmd ...string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		_, err := c.exec(ctx, service, index, timeoutSeconds, cmd)
+		return err
+	}
+}
+
+// ExecOutput is like Exec, and stores the standard output of the command in key, for the next steps to check it.
+func (c Compose) ExecOutput(service string, timeoutSeconds int, key flow.Key[string], cmd ...string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		stdout, err := c.exec(ctx, service, defaultReplica, timeoutSeconds, cmd)
+		if err != nil {
+			return err
+		}
+		key.Set(ctx, stdout)
+		return nil
+	}
+}
+
+// exec runs the command in the container and returns its standard output.
+func (c Compose) exec(ctx flow.Context, service string, index, timeoutSeconds int, cmd []string) (string, error) {
+	c = c.instance(ctx)
+	cctx := context.Context(ctx)
+	if timeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		cctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	command := c.commandContext(cctx, execArgs(service, index, cmd)...)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	err := command.Run()
+
+	ctx.Logf("Ran %q in service %s:\n%s%s", strings.Join(cmd, " "), service, stdout.String(), stderr.String())
+	switch {
+	case cctx.Err() == context.DeadlineExceeded:
+		return "", fmt.Errorf("command %q in service %s timed out after %ds", strings.Join(cmd, " "), service, timeoutSeconds)
+	case err != nil:
+		return "", fmt.Errorf("command %q in service %s failed: %w: %s", strings.Join(cmd, " "), service, err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+// execArgs returns the arguments of docker-compose to run the command in the container, without a TTY so that the
+// output can be captured.
+func execArgs(service string, index int, cmd []string) []string {
+	args := []string{
+		"exec", "-T",
+		"--index", strconv.Itoa(index),
+		service,
+	}
+
+	return append(args, cmd...)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+func TestExec(t *testing.T) {
+	c := New("project", "compose.yml")
+
+	t.Run("arguments", func(t *testing.T) {
+		assert.Equal(t,
+			[]string{"docker-compose", "-p", "project", "-f", "compose.yml", "exec", "-T", "--index", "2", "vault", "vault", "status"},
+			c.command(execArgs("vault", 2, []string{"vault", "status"})...).Args)
+	})
+
+	if _, err := exec.LookPath("docker-compose"); err != nil {
+		t.Skip("docker-compose is not available")
+	}
+
+	const filename = "testdata/slow-start.yml"
+	c = New("dockercompose-exec-test", filename)
+	output := flow.NewKey[string]("output")
+	flow.New(t, "exec").
+		Step(RunAndWait("dockercompose-exec-test", filename, 2*time.Minute, "slow")).
+		Step("capture the output", c.ExecOutput("slow", 10, output, "echo", "hello")).
+		Step("check the output", func(ctx flow.Context) error {
+			assert.Equal(t, "hello\n", output.MustGet(ctx))
+			return nil
+		}).
+		Step("a command that fails fails the step", func(ctx flow.Context) error {
+			assert.ErrorContains(t, c.Exec("slow", 10, "sh", "-c", "exit 4")(ctx), "exit status 4")
+			return nil
+		}).
+		Step("a command that runs for too long fails the step", func(ctx flow.Context) error {
+			assert.ErrorContains(t, c.Exec("slow", 1, "sleep", "30")(ctx), "timed out after 1s")
+			return nil
+		}).
+		Run()
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// EnvLogsDir is the environment variable with the directory where the logs of the services are saved when a flow
+// that started them with Run or RunAndWait fails, such as the directory of the artifacts of a CI job.
+const EnvLogsDir = "DOCKER_COMPOSE_LOGS_DIR"
+
+// LogsToFile returns a runnable that saves the logs of each service of the project, with their timestamps, to a
+// file named <project>-<service>.log in dir. See Compose.LogsToFile.
+func LogsToFile(project, filename, dir string) flow.Runnable {
+	return New(project, filename).LogsToFile(dir)
+}
+
+// LogsToFile returns a runnable that saves the logs of each service of the project, with their timestamps, to a
+// file named <project>-<service>.log in dir, and prints the paths of the files. The logs of stopped containers are
+// saved too; services whose containers were removed already have no logs, and no file.
+func (c Compose) LogsToFile(dir string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		c := c.instance(ctx)
+		out, err := c.listServices()
+		if err != nil {
+			return err
+		}
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create the directory of the logs: %w", err)
+		}
+
+		var errs []error
+		for _, service := range strings.Fields(string(out)) {
+			logs, err := c.command("logs", "--timestamps", "--no-color", service).Output()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get the logs of service %s: %w", service, err))
+				continue
+			}
+			if len(bytes.TrimSpace(logs)) == 0 {
+				ctx.Logf("No logs for service %s of project %s, its containers may have been removed already", service, c.project)
+				continue
+			}
+
+			path := filepath.Join(dir, c.project+"-"+service+".log")
+			if err = os.WriteFile(path, logs, 0o644); err != nil { //nolint:gosec
+				errs = append(errs, fmt.Errorf("failed to save the logs of service %s: %w", service, err))
+				continue
+			}
+			ctx.Logf("Saved the logs of service %s of project %s to %s", service, c.project, path)
+			ctx.AttachArtifact(path)
+		}
+
+		return errors.Join(errs...)
+	}
+}
+
+// logsDir returns the directory where the logs of the services are saved when a flow fails.
+func logsDir() string {
+	if dir := os.Getenv(EnvLogsDir); dir != "" {
+		return dir
+	}
+
+	return filepath.Join(os.TempDir(), "dockercompose-logs")
+}
+
+// downAfterFailure is the cleanup of the steps returned by Run and RunAndWait: it saves the logs of the services
+// when the flow failed, so that they can be checked after the containers are removed, then runs Down.
+func (c Compose) downAfterFailure(ctx flow.Context) error {
+	c = c.instance(ctx)
+	if ctx.T != nil && ctx.Failed() {
+		if err := c.LogsToFile(logsDir())(ctx); err != nil {
+			// The containers are removed anyway
+			ctx.Logf("Failed to save the logs of project %s: %v", c.project, err)
+		}
+	}
+
+	return c.Down(ctx)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// fakeDockerCompose puts a docker-compose script first in the PATH, which lists the services vault, seeder and
+// removed, and prints logs for the services given in FAKE_COMPOSE_LOGS only.
+func fakeDockerCompose(t *testing.T) {
+	dir := t.TempDir()
+	script := `#!/bin/sh
+shift 4
+case "$1" in
+config) printf 'vault\nseeder\nremoved\n' ;;
+logs)
+  for service in $FAKE_COMPOSE_LOGS; do
+    if [ "$service" = "$4" ]; then
+      echo "2023-07-24T10:00:00.000000000Z $4 | started"
+    fi
+  done ;;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-compose"), []byte(script), 0o755)) //nolint:gosec
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestLogsToFile(t *testing.T) {
+	fakeDockerCompose(t)
+	ctx := flow.Context{Context: context.Background(), T: t}
+
+	t.Run("the logs of each service are saved", func(t *testing.T) {
+		t.Setenv("FAKE_COMPOSE_LOGS", "vault seeder")
+		dir := filepath.Join(t.TempDir(), "artifacts")
+
+		require.NoError(t, LogsToFile("myproject", "compose.yml", dir)(ctx))
+
+		vaultLogs, err := os.ReadFile(filepath.Join(dir, "myproject-vault.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "2023-07-24T10:00:00.000000000Z vault | started\n", string(vaultLogs))
+		assert.FileExists(t, filepath.Join(dir, "myproject-seeder.log"))
+		// The containers of this service were removed already
+		assert.NoFileExists(t, filepath.Join(dir, "myproject-removed.log"))
+	})
+
+	t.Run("the project was stopped and removed already", func(t *testing.T) {
+		t.Setenv("FAKE_COMPOSE_LOGS", "")
+		dir := t.TempDir()
+
+		require.NoError(t, LogsToFile("myproject", "compose.yml", dir)(ctx))
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("the logs directory is set with an environment variable", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv(EnvLogsDir, dir)
+		assert.Equal(t, dir, logsDir())
+	})
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// Option configures a project created with RunWithOptions or NewWithOptions.
+type Option func(*Compose)
+
+// Env sets environment variables for the docker-compose commands of the project, to parameterize the compose file
+// per flow, for example with ${VAULT_IMAGE} for the image of a service. They are added to the environment of the
+// test, and override its variables with the same name.
+func Env(env map[string]string) Option {
+	return func(c *Compose) {
+		if c.env == nil {
+			c.env = make(map[string]string, len(env))
+		}
+		for name, value := range env {
+			c.env[name] = value
+		}
+	}
+}
+
+// UniqueProject suffixes the name of the project with a random token when it's started, so that flows of different
+// packages, or of different branches on the same runner, can start the same compose file concurrently. The other
+// runnables of the flow, such as Stop, Exec or LogsToFile, target the started instance when given the same project
+// name.
+func UniqueProject() Option {
+	return func(c *Compose) {
+		c.unique = true
+	}
+}
+
+// WaitFor makes the step returned by RunWithOptions wait for the given services, or all the services of the project
+// if none is given, to be ready like RunAndWait.
+func WaitFor(timeout time.Duration, services ...string) Option {
+	return func(c *Compose) {
+		c.wait = timeout
+		c.waitServices = services
+	}
+}
+
+// RunWithOptions is like Run, with options such as Env, UniqueProject or WaitFor.
+func RunWithOptions(project, filename string, opts ...Option) (string, flow.Runnable, flow.Runnable) {
+	return NewWithOptions(project, filename, opts...).ToStep()
+}
+
+// NewWithOptions is like New, with options such as Env, UniqueProject or WaitFor.
+func NewWithOptions(project, filename string, opts ...Option) Compose {
+	c := New(project, filename)
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+// Project returns the name of the project started in the flow of ctx, which has a random suffix with UniqueProject.
+func (c Compose) Project(ctx flow.Context) string {
+	return c.instance(ctx).project
+}
+
+// instanceKey is the key of the project started in a flow, by the name it was given in the flow.
+func instanceKey(project string) flow.Key[Compose] {
+	return flow.NewKey[Compose]("dockercompose/" + project)
+}
+
+// start returns the project to start: the one started already in the flow of ctx if any, or a new instance with a
+// unique name with UniqueProject. The projects with options are stored in the flow, so that the runnables created
+// from their name only get their options.
+func (c Compose) start(ctx flow.Context) Compose {
+	if ctx.Flow == nil {
+		return c.withUniqueName()
+	}
+	if started, ok := instanceKey(c.project).Get(ctx); ok {
+		return started
+	}
+	if !c.unique && len(c.env) == 0 {
+		return c
+	}
+
+	started := c.withUniqueName()
+	instanceKey(c.project).Set(ctx, started)
+	ctx.Logf("Starting project %s as %s", c.project, started.project)
+
+	return started
+}
+
+// instance returns the project started in the flow of ctx, or c if it wasn't started with options.
+func (c Compose) instance(ctx flow.Context) Compose {
+	if ctx.Flow == nil {
+		return c
+	}
+	if started, ok := instanceKey(c.project).Get(ctx); ok {
+		return started
+	}
+
+	return c
+}
+
+func (c Compose) withUniqueName() Compose {
+	if c.unique {
+		c.project = fmt.Sprintf("%s-%08x", c.project, rand.Uint32()) //nolint:gosec
+		c.unique = false
+	}
+
+	return c
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// recordDockerCompose puts a docker-compose script first in the PATH, which records its arguments along with the
+// value of IMAGE_TAG, and returns a function that reads the recorded invocations.
+func recordDockerCompose(t *testing.T) func() []string {
+	dir := t.TempDir()
+	record := filepath.Join(dir, "record")
+	script := `#!/bin/sh
+echo "IMAGE_TAG=$IMAGE_TAG $*" >> "` + record + `"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-compose"), []byte(script), 0o755)) //nolint:gosec
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return func() []string {
+		out, err := os.ReadFile(record)
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(out)), "\n")
+	}
+}
+
+func TestRunWithOptions(t *testing.T) {
+	t.Run("the runnables of the flow target the unique project with its environment", func(t *testing.T) {
+		invocations := recordDockerCompose(t)
+		var project string
+
+		flow.New(t, "unique project").
+			Step(RunWithOptions("myproject", "compose.yml", UniqueProject(), Env(map[string]string{"IMAGE_TAG": "1.13"}))).
+			Step("stop a service", Stop("myproject", "compose.yml", "vault")).
+			Step("exec in a service", Exec("myproject", "compose.yml", "vault", 0, "vault", "status")).
+			Step("get the project", func(ctx flow.Context) error {
+				project = New("myproject", "compose.yml").Project(ctx)
+				return nil
+			}).
+			Run()
+
+		require.Regexp(t, `^myproject-[0-9a-f]{8}$`, project)
+		assert.Equal(t, []string{
+			"IMAGE_TAG=1.13 -p " + project + " -f compose.yml up -d --remove-orphans",
+			"IMAGE_TAG=1.13 -p " + project + " -f compose.yml stop vault",
+			"IMAGE_TAG=1.13 -p " + project + " -f compose.yml exec -T --index 1 vault vault status",
+			"IMAGE_TAG=1.13 -p " + project + " -f compose.yml down -v",
+		}, invocations())
+	})
+
+	t.Run("projects without options keep their name", func(t *testing.T) {
+		invocations := recordDockerCompose(t)
+
+		flow.New(t, "plain project").
+			Step(RunWithOptions("myproject", "compose.yml")).
+			Run()
+
+		assert.Equal(t, []string{
+			"IMAGE_TAG= -p myproject -f compose.yml up -d --remove-orphans",
+			"IMAGE_TAG= -p myproject -f compose.yml down -v",
+		}, invocations())
+	})
+
+	t.Run("each flow gets its own project", func(t *testing.T) {
+		recordDockerCompose(t)
+		projects := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			flow.New(t, "unique project").
+				Step(RunWithOptions("myproject", "compose.yml", UniqueProject())).
+				Step("get the project", func(ctx flow.Context) error {
+					projects[New("myproject", "compose.yml").Project(ctx)] = true
+					return nil
+				}).
+				Run()
+		}
+		assert.Len(t, projects, 2)
+	})
+}
+
+func TestRunWithOptionsSideBySide(t *testing.T) {
+	if _, err := exec.LookPath("docker-compose"); err != nil {
+		t.Skip("docker-compose is not available")
+	}
+
+	const filename = "testdata/slow-start.yml"
+	var lock sync.Mutex
+	var projects []string
+
+	t.Run("instances", func(t *testing.T) {
+		for _, name := range []string{"first", "second"} {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				flow.New(t, "side by side").
+					Step(RunWithOptions("dockercompose-options-test", filename, UniqueProject(), WaitFor(2*time.Minute, "slow"))).
+					Step("the instance runs", Exec("dockercompose-options-test", filename, "slow", 10, "test", "-f", "/tmp/ready")).
+					Step("record the project", func(ctx flow.Context) error {
+						lock.Lock()
+						defer lock.Unlock()
+						projects = append(projects, New("dockercompose-options-test", filename).Project(ctx))
+						return nil
+					}).
+					Run()
+			})
+		}
+	})
+
+	require.Len(t, projects, 2)
+	assert.NotEqual(t, projects[0], projects[1])
+}
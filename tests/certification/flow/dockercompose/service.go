@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// Pause returns a runnable that freezes the processes of a service, like SIGSTOP, to test how the component behaves
+// with a server that accepts connections but never answers.
+func Pause(project, filename, service string) flow.Runnable {
+	return New(project, filename).Pause(service)
+}
+
+func (c Compose) Pause(service string) flow.Runnable {
+	return c.serviceCommand("pause", service)
+}
+
+// Unpause returns a runnable that resumes the processes of a service frozen by Pause.
+func Unpause(project, filename, service string) flow.Runnable {
+	return New(project, filename).Unpause(service)
+}
+
+func (c Compose) Unpause(service string) flow.Runnable {
+	return c.serviceCommand("unpause", service)
+}
+
+// RestartService returns a runnable that restarts the containers of a service, while the other services keep
+// running. One-off services, such as the ones that seed data, run their command again.
+func RestartService(project, filename, service string) flow.Runnable {
+	return New(project, filename).RestartService(service)
+}
+
+func (c Compose) RestartService(service string) flow.Runnable {
+	return c.serviceCommand("restart", service)
+}
+
+// serviceCommand returns a runnable that runs a docker-compose command on a single service, after checking that the
+// service exists, as docker-compose silently ignores some commands on services that don't.
+func (c Compose) serviceCommand(command, service string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		c := c.instance(ctx)
+		if err := c.checkService(service); err != nil {
+			return err
+		}
+
+		out, err := c.command(command, service).CombinedOutput()
+		ctx.Log(string(out))
+		if err != nil {
+			return fmt.Errorf("failed to %s service %s of project %s: %w", command, service, c.project, err)
+		}
+
+		return nil
+	}
+}
+
+// checkService returns an error if the project doesn't define the service.
+func (c Compose) checkService(service string) error {
+	out, err := c.listServices()
+	if err != nil {
+		return err
+	}
+
+	return findService(out, service, c.filename)
+}
+
+// listServices returns the output of docker-compose config --services, with the services of the project.
+func (c Compose) listServices() ([]byte, error) {
+	out, err := c.command("config", "--services").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the services of project %s: %w", c.project, err)
+	}
+
+	return out, nil
+}
+
+// findService returns an error if the service isn't in the output of docker-compose config --services.
+func findService(out []byte, service, filename string) error {
+	services := strings.Fields(string(out))
+	for _, s := range services {
+		if s == service {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("service %s doesn't exist in %s, the services are: %s", service, filename, strings.Join(services, ", "))
+}
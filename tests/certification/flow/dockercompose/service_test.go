@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+func TestFindService(t *testing.T) {
+	out := []byte("hashicorp_vault\nseed_conformance_secrets\n")
+
+	assert.NoError(t, findService(out, "hashicorp_vault", "compose.yml"))
+	assert.EqualError(t, findService(out, "vault", "compose.yml"),
+		"service vault doesn't exist in compose.yml, the services are: hashicorp_vault, seed_conformance_secrets")
+}
+
+func TestServiceCommands(t *testing.T) {
+	if _, err := exec.LookPath("docker-compose"); err != nil {
+		t.Skip("docker-compose is not available")
+	}
+
+	const (
+		project  = "dockercompose-service-test"
+		filename = "testdata/slow-start.yml"
+	)
+	c := New(project, filename)
+
+	// state returns the state of the containers of the service.
+	state := func(ctx flow.Context, service string) string {
+		containers, err := c.ps(ctx)
+		require.NoError(t, err)
+		for _, container := range containers {
+			if container.Service == service {
+				return container.State
+			}
+		}
+		return ""
+	}
+
+	flow.New(t, "service commands").
+		Step(RunAndWait(project, filename, 2*time.Minute, "slow")).
+		Step("pause", c.Pause("slow")).
+		Step("check the service is paused", func(ctx flow.Context) error {
+			assert.Equal(t, "paused", state(ctx, "slow"))
+			return nil
+		}).
+		Step("unpause", c.Unpause("slow")).
+		Step("check the service is running", func(ctx flow.Context) error {
+			assert.Equal(t, "running", state(ctx, "slow"))
+			return nil
+		}).
+		Step("restart", c.RestartService("slow")).
+		Step("wait for the service to be ready again", c.WaitForServices(time.Minute, "slow")).
+		Step("a service that doesn't exist fails", func(ctx flow.Context) error {
+			assert.ErrorContains(t, c.Pause("missing")(ctx), "service missing doesn't exist")
+			return nil
+		}).
+		Run()
+}
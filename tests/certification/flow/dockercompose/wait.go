@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// pollInterval is how often the status of the services is checked while waiting for them.
+const pollInterval = time.Second
+
+// RunAndWait is like Run, but the step also waits for the given services, or all the services of the project if
+// none is given, to be ready. The timeout covers both starting the containers and waiting for them.
+func RunAndWait(project, filename string, timeout time.Duration, services ...string) (string, flow.Runnable, flow.Runnable) {
+	c := New(project, filename)
+	return c.project, c.UpAndWait(timeout, services...), c.downAfterFailure
+}
+
+// UpAndWait starts the containers like Up, then waits for the given services to be ready like WaitForServices.
+func (c Compose) UpAndWait(timeout time.Duration, services ...string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		start := time.Now()
+		if err := c.Up(ctx); err != nil {
+			return err
+		}
+
+		return c.instance(ctx).WaitForServices(timeout-time.Since(start), services...)(ctx)
+	}
+}
+
+// WaitForServices returns a runnable that waits for the given services, or all the services of the project if none
+// is given, to be ready: healthy if they have a healthcheck, running if they don't, or exited with code 0 for the
+// one-off services that seed data. It fails as soon as a service exits with another code, or after timeout, with the
+// logs of the containers.
+func (c Compose) WaitForServices(timeout time.Duration, services ...string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		c := c.instance(ctx)
+		wctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		start := time.Now()
+		var pending []string
+		for {
+			containers, err := c.ps(wctx)
+			if err == nil {
+				pending, err = pendingServices(containers, services)
+				if err != nil {
+					return fmt.Errorf("%w%s", err, c.logs(services))
+				}
+				if len(pending) == 0 {
+					ctx.Logf("Services of project %s ready after %v", c.project, time.Since(start).Round(time.Millisecond))
+					return nil
+				}
+			}
+
+			select {
+			case <-wctx.Done():
+				if err == nil {
+					err = fmt.Errorf("services %s are not ready", strings.Join(pending, ", "))
+				}
+				return fmt.Errorf("project %s not ready after %v: %w%s", c.project, timeout, err, c.logs(services))
+			case <-time.After(pollInterval):
+			}
+		}
+	}
+}
+
+// ps returns the containers of the project, including the ones that exited.
+func (c Compose) ps(ctx context.Context) ([]container, error) {
+	out, err := c.commandContext(ctx, "ps", "-a", "--format", "json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the containers: %w", err)
+	}
+
+	return parseContainers(out)
+}
+
+// logs returns the logs of the given services, or of all the services if none is given, to be appended to errors.
+func (c Compose) logs(services []string) string {
+	out, err := c.command(append([]string{"logs", "--no-color"}, services...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Sprintf("\nfailed to get the logs of the containers: %v\n%s", err, out)
+	}
+
+	return "\nlogs of the containers:\n" + string(out)
+}
+
+// container is the status of a container, as listed by docker-compose ps.
+type container struct {
+	Service  string `json:"Service"`
+	State    string `json:"State"`
+	Health   string `json:"Health"`
+	ExitCode int    `json:"ExitCode"`
+}
+
+// parseContainers parses the output of docker-compose ps --format json, which is a JSON array in older versions of
+// Compose and one JSON object per line in newer ones.
+func parseContainers(out []byte) ([]container, error) {
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	var containers []container
+	if out[0] == '[' {
+		if err := json.Unmarshal(out, &containers); err != nil {
+			return nil, fmt.Errorf("failed to parse the containers: %w", err)
+		}
+		return containers, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var c container
+		err := dec.Decode(&c)
+		if errors.Is(err, io.EOF) {
+			return containers, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the containers: %w", err)
+		}
+		containers = append(containers, c)
+	}
+}
+
+// ready returns whether the container is ready, and an error if it exited with a non-zero code and never will be.
+func (c container) ready() (bool, error) {
+	switch c.State {
+	case "running":
+		return c.Health == "" || c.Health == "healthy", nil
+	case "exited":
+		if c.ExitCode != 0 {
+			return false, fmt.Errorf("service %s exited with code %d", c.Service, c.ExitCode)
+		}
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// pendingServices returns the sorted names of the given services that have containers that aren't ready yet, or
+// that have no containers yet. If no service is given, it checks all the services with containers, and all of them
+// are pending until there is at least one.
+func pendingServices(containers []container, services []string) ([]string, error) {
+	if len(services) == 0 && len(containers) == 0 {
+		return []string{"all"}, nil
+	}
+
+	pending := make(map[string]bool, len(services))
+	for _, service := range services {
+		pending[service] = true
+	}
+	seen := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		if _, wanted := pending[c.Service]; len(services) > 0 && !wanted {
+			continue
+		}
+		ready, err := c.ready()
+		if err != nil {
+			return nil, err
+		}
+		if !seen[c.Service] {
+			seen[c.Service] = true
+			pending[c.Service] = false
+		}
+		pending[c.Service] = pending[c.Service] || !ready
+	}
+
+	var names []string
+	for service, notReady := range pending {
+		if notReady {
+			names = append(names, service)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
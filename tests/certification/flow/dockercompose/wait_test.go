@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockercompose
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+func TestParseContainers(t *testing.T) {
+	expected := []container{
+		{Service: "vault", State: "running", Health: "healthy"},
+		{Service: "seed", State: "exited", ExitCode: 1},
+	}
+
+	t.Run("JSON array", func(t *testing.T) {
+		containers, err := parseContainers([]byte(`[
+			{"Name":"p-vault-1","Service":"vault","State":"running","Health":"healthy","ExitCode":0},
+			{"Name":"p-seed-1","Service":"seed","State":"exited","Health":"","ExitCode":1}
+		]`))
+		require.NoError(t, err)
+		assert.Equal(t, expected, containers)
+	})
+
+	t.Run("one object per line", func(t *testing.T) {
+		containers, err := parseContainers([]byte(
+			`{"Name":"p-vault-1","Service":"vault","State":"running","Health":"healthy","ExitCode":0}` + "\n" +
+				`{"Name":"p-seed-1","Service":"seed","State":"exited","Health":"","ExitCode":1}` + "\n"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, containers)
+	})
+
+	t.Run("no containers", func(t *testing.T) {
+		containers, err := parseContainers([]byte("\n"))
+		require.NoError(t, err)
+		assert.Empty(t, containers)
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		_, err := parseContainers([]byte("no such service"))
+		assert.Error(t, err)
+	})
+}
+
+func TestPendingServices(t *testing.T) {
+	containers := []container{
+		{Service: "healthy", State: "running", Health: "healthy"},
+		{Service: "starting", State: "running", Health: "starting"},
+		{Service: "unhealthy", State: "running", Health: "unhealthy"},
+		{Service: "nohealthcheck", State: "running"},
+		{Service: "seeded", State: "exited", ExitCode: 0},
+		{Service: "created", State: "created"},
+		{Service: "scaled", State: "running"},
+		{Service: "scaled", State: "restarting"},
+	}
+
+	tests := []struct {
+		name     string
+		services []string
+		expected []string
+	}{
+		{"ready services", []string{"healthy", "nohealthcheck", "seeded"}, nil},
+		{"services not ready", []string{"healthy", "starting", "unhealthy", "created"}, []string{"created", "starting", "unhealthy"}},
+		{"services without containers", []string{"healthy", "missing"}, []string{"missing"}},
+		{"all the containers of a service must be ready", []string{"scaled"}, []string{"scaled"}},
+		{"all services", nil, []string{"created", "scaled", "starting", "unhealthy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, err := pendingServices(containers, tt.services)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, pending)
+		})
+	}
+
+	t.Run("all services are pending until there are containers", func(t *testing.T) {
+		pending, err := pendingServices(nil, nil)
+		require.NoError(t, err)
+		assert.NotEmpty(t, pending)
+	})
+
+	t.Run("a service that exited with an error fails", func(t *testing.T) {
+		failed := append(containers, container{Service: "failed", State: "exited", ExitCode: 2})
+		_, err := pendingServices(failed, []string{"healthy", "failed"})
+		assert.EqualError(t, err, "service failed exited with code 2")
+
+		// It's ignored when waiting for other services
+		pending, err := pendingServices(failed, []string{"healthy"})
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}
+
+func TestRunAndWait(t *testing.T) {
+	if _, err := exec.LookPath("docker-compose"); err != nil {
+		t.Skip("docker-compose is not available")
+	}
+
+	const filename = "testdata/slow-start.yml"
+
+	t.Run("waits for the services to be ready", func(t *testing.T) {
+		flow.New(t, "run and wait").
+			Step(RunAndWait("dockercompose-wait-test", filename, 2*time.Minute, "slow", "oneshot")).
+			Step("check the services", func(ctx flow.Context) error {
+				containers, err := New("dockercompose-wait-test", filename).ps(ctx)
+				require.NoError(t, err)
+				pending, err := pendingServices(containers, []string{"slow", "oneshot"})
+				require.NoError(t, err)
+				assert.Empty(t, pending)
+				return nil
+			}).
+			Run()
+	})
+
+	t.Run("fails with the logs of a service that exited with an error", func(t *testing.T) {
+		c := New("dockercompose-wait-test-failing", filename)
+		flow.New(t, "run and fail").
+			Step("start", c.Up, c.Down).
+			Step("wait for the failing service", func(ctx flow.Context) error {
+				err := c.WaitForServices(time.Minute, "failing")(ctx)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "service failing exited with code 3")
+				assert.Contains(t, err.Error(), "seeding failed")
+				return nil
+			}).
+			Run()
+	})
+
+	t.Run("fails with the logs after the timeout", func(t *testing.T) {
+		c := New("dockercompose-wait-test-timeout", filename)
+		flow.New(t, "run and time out").
+			Step("start", c.Up, c.Down).
+			Step("wait for too short", func(ctx flow.Context) error {
+				err := c.WaitForServices(time.Second, "slow")(ctx)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "not ready after 1s")
+				assert.Contains(t, err.Error(), "logs of the containers")
+				return nil
+			}).
+			Run()
+	})
+}
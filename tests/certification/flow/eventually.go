@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"fmt"
+	"time"
+)
+
+// Eventually returns a runnable that checks condition every interval until it holds, and fails if it still doesn't
+// after timeout. It replaces sleeps of a guessed duration with the condition they wait for, such as a component
+// being registered or a secret being readable, and logs how long it took for the condition to hold.
+//
+// As it's checked several times, condition must not call ctx.Fatal, assert or require.
+func Eventually(timeout, interval time.Duration, condition func(ctx Context) bool) Runnable {
+	return eventually(timeout, interval, condition, realClock{})
+}
+
+func eventually(timeout, interval time.Duration, condition func(ctx Context) bool, clock Clock) Runnable {
+	return func(ctx Context) error {
+		start := clock.Now()
+		for {
+			if condition(ctx) {
+				if ctx.T != nil {
+					ctx.Logf("Condition met after %v", clock.Now().Sub(start))
+				}
+				return nil
+			}
+
+			elapsed := clock.Now().Sub(start)
+			if elapsed >= timeout {
+				return fmt.Errorf("condition not met after %v", elapsed)
+			}
+			wait := interval
+			if left := timeout - elapsed; wait > left {
+				wait = left
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("condition not met after %v: %w", clock.Now().Sub(start), ctx.Err())
+			case <-clock.After(wait):
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventually(t *testing.T) {
+	ctx := Context{Context: context.Background()}
+
+	t.Run("returns once the condition holds", func(t *testing.T) {
+		clock := &fakeClock{}
+		checks := 0
+		err := eventually(time.Minute, time.Second, func(Context) bool {
+			checks++
+			return checks == 3
+		}, clock)(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, checks)
+		assert.Equal(t, 2*time.Second, clock.now.Sub(time.Time{}))
+	})
+
+	t.Run("fails on timeout", func(t *testing.T) {
+		clock := &fakeClock{}
+		checks := 0
+		err := eventually(5*time.Second, 2*time.Second, func(Context) bool {
+			checks++
+			return false
+		}, clock)(ctx)
+
+		assert.EqualError(t, err, "condition not met after 5s")
+		// The last check happens when the timeout elapses
+		assert.Equal(t, 4, checks)
+	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := Eventually(time.Hour, time.Minute, func(Context) bool { return false })(Context{Context: cctx})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("real clock", func(t *testing.T) {
+		start := time.Now()
+		err := Eventually(time.Minute, 10*time.Millisecond, func(Context) bool {
+			return time.Since(start) > 30*time.Millisecond
+		})(ctx)
+
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
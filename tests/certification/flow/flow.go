@@ -15,6 +15,8 @@ package flow
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"sync"
 	"testing"
@@ -130,28 +132,43 @@ type Flow struct {
 	name        string
 	varsMu      sync.RWMutex
 	variables   map[string]interface{}
+	values      map[any]any
+	logs        map[string]*LogBuffer
 	tasks       []namedRunnable
 	cleanup     []string
 	uncalledMap map[string]Runnable
 	cleanupMap  map[string]Runnable
+	timeout     time.Duration
+	reportPaths []string
+	reporter    *reporter
+	now         func() time.Time
 }
 
 type namedRunnable struct {
 	name     string
 	runnable Runnable
+	timeout  time.Duration
 }
 
-func New(t *testing.T, name string) *Flow {
-	return &Flow{
+func New(t *testing.T, name string, opts ...Option) *Flow {
+	f := &Flow{
 		t:           t,
 		ctx:         context.Background(),
 		name:        name,
 		variables:   make(map[string]interface{}, 25),
+		values:      make(map[any]any, 10),
+		logs:        make(map[string]*LogBuffer, 2),
 		tasks:       make([]namedRunnable, 0, 25),
 		cleanup:     make([]string, 0, 25),
 		uncalledMap: make(map[string]Runnable, 10),
 		cleanupMap:  make(map[string]Runnable, 10),
+		now:         time.Now,
 	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
 }
 
 func (f *Flow) Name() string {
@@ -179,9 +196,9 @@ func as(source, target interface{}) bool {
 
 func (f *Flow) Step(name string, runnable Runnable, cleanup ...Runnable) *Flow {
 	if runnable != nil {
-		f.tasks = append(f.tasks, namedRunnable{name, runnable})
+		f.tasks = append(f.tasks, namedRunnable{name: name, runnable: runnable})
 	}
-	if len(cleanup) == 1 {
+	if len(cleanup) == 1 && cleanup[0] != nil {
 		f.cleanup = append(f.cleanup, name)
 		f.uncalledMap[name] = cleanup[0]
 	}
@@ -189,6 +206,18 @@ func (f *Flow) Step(name string, runnable Runnable, cleanup ...Runnable) *Flow {
 	return f
 }
 
+// Cleanup registers a runnable that is run when the flow finishes, whether its steps succeeded, failed or
+// panicked, for example to stop containers or restore the network. Unlike the cleanup of a step, it runs even if
+// the flow fails before reaching the point where it's registered.
+// Cleanups run in the reverse order of their registration, along with the cleanups of the steps. Their failures
+// are reported after the failure of the flow, if any, which they don't replace.
+func (f *Flow) Cleanup(name string, runnable Runnable) *Flow {
+	f.cleanup = append(f.cleanup, name)
+	f.cleanupMap[name] = runnable
+
+	return f
+}
+
 func (f *Flow) StepAsync(name string, task *AsyncTask, runnable Runnable, cleanup ...Runnable) *Flow {
 	r, c := Async(task, runnable, cleanup...)
 	return f.Step(name, r, c)
@@ -196,22 +225,20 @@ func (f *Flow) StepAsync(name string, task *AsyncTask, runnable Runnable, cleanu
 
 func (f *Flow) Run() {
 	f.t.Run(f.name, func(t *testing.T) {
+		f.reporter = f.newReporter(t)
 		defer func() {
-			for i := len(f.cleanup) - 1; i >= 0; i-- {
-				name := f.cleanup[i]
-				ctx := Context{
-					name:    name,
-					Context: f.ctx,
-					T:       t,
-					Flow:    f,
-				}
-				if cleanup, ok := f.cleanupMap[name]; ok {
-					cleanup(ctx)
-				}
+			if err := f.runCleanups(t); err != nil {
+				t.Errorf("Errors in cleanup: %v", err)
 			}
+			f.reporter.finish(f.capturedLogs())
 		}()
 
-		for _, r := range f.tasks {
+		var deadline time.Time
+		if f.timeout > 0 {
+			deadline = time.Now().Add(f.timeout)
+		}
+
+		for i, r := range f.tasks {
 			if c, ok := f.uncalledMap[r.name]; ok {
 				f.cleanupMap[r.name] = c
 				delete(f.uncalledMap, r.name)
@@ -224,7 +251,10 @@ func (f *Flow) Run() {
 				T:       t,
 				Flow:    f,
 			}
-			err := r.runnable(ctx)
+			timeout, errTimeout := f.stepTimeout(r, deadline)
+			f.reporter.startStep(i)
+			err := runStepWithTimeout(ctx, r.runnable, timeout, errTimeout)
+			f.reporter.end(err)
 			t.Logf("Completed step: %s", r.name)
 			if err != nil {
 				t.Fatalf("Fatal error in step %s: %v", r.name, err)
@@ -234,3 +264,41 @@ func (f *Flow) Run() {
 		}
 	})
 }
+
+// runStep runs a step, and returns the panics as errors so that the flow fails and its cleanups run.
+func runStep(ctx Context, runnable Runnable) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return runnable(ctx)
+}
+
+// runCleanups runs the cleanups of the flow in the reverse order of their registration. Every cleanup runs even if
+// the previous ones fail, and the returned error joins their errors.
+func (f *Flow) runCleanups(t *testing.T) error {
+	var errs []error
+	for i := len(f.cleanup) - 1; i >= 0; i-- {
+		name := f.cleanup[i]
+		cleanup, ok := f.cleanupMap[name]
+		if !ok {
+			continue
+		}
+		ctx := Context{
+			name:    name,
+			Context: f.ctx,
+			T:       t,
+			Flow:    f,
+		}
+		f.reporter.startCleanup(name)
+		err := runStep(ctx, cleanup)
+		f.reporter.end(err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cleanup %s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
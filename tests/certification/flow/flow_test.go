@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envFailingFlow makes TestCleanup run a failing flow, in a child process so that its failure doesn't fail the test.
+const envFailingFlow = "FLOW_TEST_FAILING_FLOW"
+
+func TestCleanup(t *testing.T) {
+	if mode := os.Getenv(envFailingFlow); mode != "" {
+		runFailingFlow(t, mode)
+		return
+	}
+
+	record := func(calls *[]string, name string) Runnable {
+		return func(_ Context) error {
+			*calls = append(*calls, name)
+			return nil
+		}
+	}
+
+	t.Run("cleanups run in reverse order along with the cleanups of the steps", func(t *testing.T) {
+		var calls []string
+		New(t, "ordering").
+			Cleanup("first cleanup", record(&calls, "first cleanup")).
+			Step("step 1", record(&calls, "step 1"), record(&calls, "step 1 cleanup")).
+			Cleanup("second cleanup", record(&calls, "second cleanup")).
+			Step("step 2", record(&calls, "step 2")).
+			Run()
+
+		assert.Equal(t, []string{
+			"step 1",
+			"step 2",
+			"second cleanup",
+			"step 1 cleanup",
+			"first cleanup",
+		}, calls)
+	})
+
+	t.Run("errors of the cleanups are joined", func(t *testing.T) {
+		var calls []string
+		errFirst := errors.New("first failed")
+		f := New(t, "errors").
+			Cleanup("first", func(_ Context) error {
+				calls = append(calls, "first")
+				return errFirst
+			}).
+			Cleanup("second", func(_ Context) error {
+				calls = append(calls, "second")
+				panic("second panicked")
+			}).
+			Cleanup("third", record(&calls, "third"))
+
+		err := f.runCleanups(t)
+		require.ErrorIs(t, err, errFirst)
+		assert.ErrorContains(t, err, "cleanup second: panic: second panicked")
+		assert.ErrorContains(t, err, "cleanup first: first failed")
+		assert.Equal(t, []string{"third", "second", "first"}, calls)
+	})
+
+	for _, mode := range []string{"failure", "panic", "fail now", "step timeout", "flow timeout"} {
+		t.Run("cleanups run after a "+mode, func(t *testing.T) {
+			// The failing step of the "fail now" mode has a timeout long enough to fail the child process if it's waited for
+			cmd := exec.Command(os.Args[0], "-test.run=^TestCleanup$", "-test.v", "-test.timeout=30s")
+			cmd.Env = append(os.Environ(), envFailingFlow+"="+mode)
+			out, err := cmd.CombinedOutput()
+			output := string(out)
+
+			var exitErr *exec.ExitError
+			require.ErrorAs(t, err, &exitErr, output)
+			assert.Contains(t, output, "Fatal error in step failing step")
+			assert.NotContains(t, output, "unreachable step ran")
+			// The cleanup of the step that wasn't reached doesn't run, the ones registered with Cleanup do
+			assert.NotContains(t, output, "unreachable step cleanup ran")
+			first := strings.Index(output, "late cleanup ran")
+			second := strings.Index(output, "early cleanup ran")
+			require.NotEqual(t, -1, first, output)
+			require.NotEqual(t, -1, second, output)
+			assert.Less(t, first, second)
+			assert.Contains(t, output, "Errors in cleanup: cleanup late: late cleanup failed")
+			if strings.HasSuffix(mode, "timeout") {
+				assert.Contains(t, output, "timed out after 100ms")
+				assert.Contains(t, output, "goroutine ")
+				assert.Contains(t, output, "step returned after its context was canceled")
+			}
+			if mode == "fail now" {
+				assert.Contains(t, output, "step failed now")
+				assert.Contains(t, output, "step exited without returning, for example with FailNow or a require assertion, and the test failed")
+			}
+		})
+	}
+}
+
+func runFailingFlow(t *testing.T, mode string) {
+	log := func(msg string) Runnable {
+		return func(_ Context) error {
+			fmt.Println(msg)
+			return nil
+		}
+	}
+
+	var opts []Option
+	var stepTimeout time.Duration
+	switch mode {
+	case "step timeout":
+		stepTimeout = 100 * time.Millisecond
+	case "flow timeout":
+		opts = append(opts, WithTimeout(100*time.Millisecond))
+	case "fail now":
+		stepTimeout = time.Minute
+	}
+
+	New(t, "failing", opts...).
+		Cleanup("early", log("early cleanup ran")).
+		StepWithTimeout("failing step", stepTimeout, func(ctx Context) error {
+			switch mode {
+			case "panic":
+				panic("step panicked")
+			case "fail now":
+				require.Fail(ctx.T, "step failed now")
+			case "step timeout", "flow timeout":
+				<-ctx.Done()
+				fmt.Println("step returned after its context was canceled")
+				return ctx.Err()
+			}
+			return errors.New("step failed")
+		}).
+		Step("unreachable step", log("unreachable step ran"), log("unreachable step cleanup ran")).
+		Cleanup("late", func(_ Context) error {
+			fmt.Println("late cleanup ran")
+			return errors.New("late cleanup failed")
+		}).
+		Run()
+}
+
+func TestStepWithTimeout(t *testing.T) {
+	ctx := Context{Context: context.Background()}
+	errTimeout := errors.New("timed out")
+
+	t.Run("the context of a step that times out is canceled", func(t *testing.T) {
+		canceled := make(chan error, 1)
+		start := time.Now()
+		err := runStepWithTimeout(ctx, func(ctx Context) error {
+			<-ctx.Done()
+			canceled <- ctx.Err()
+			return ctx.Err()
+		}, 50*time.Millisecond, errTimeout)
+
+		require.ErrorIs(t, err, errTimeout)
+		assert.ErrorIs(t, <-canceled, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), timeoutGracePeriod)
+	})
+
+	t.Run("a step that completes in time returns its result", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		assert.NoError(t, runStepWithTimeout(ctx, func(Context) error { return nil }, time.Minute, errTimeout))
+		assert.ErrorIs(t, runStepWithTimeout(ctx, func(Context) error { return errFailed }, time.Minute, errTimeout), errFailed)
+	})
+
+	t.Run("the timeout of a step is bounded by the flow timeout", func(t *testing.T) {
+		f := New(t, "bounded", WithTimeout(time.Minute))
+		r := namedRunnable{name: "step", timeout: time.Hour}
+
+		timeout, err := f.stepTimeout(r, time.Now().Add(time.Minute))
+		assert.InDelta(t, float64(time.Minute), float64(timeout), float64(time.Second))
+		assert.EqualError(t, err, "flow bounded timed out after 1m0s in step step: context deadline exceeded")
+
+		r.timeout = time.Second
+		timeout, err = f.stepTimeout(r, time.Now().Add(time.Minute))
+		assert.Equal(t, time.Second, timeout)
+		assert.EqualError(t, err, "step step timed out after 1s: context deadline exceeded")
+
+		// The flow timed out already
+		timeout, _ = f.stepTimeout(r, time.Now().Add(-time.Second))
+		assert.Negative(t, timeout)
+	})
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// LogBuffer holds the logs captured in a flow, such as the ones of a sidecar. It can be written to concurrently.
+type LogBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// String returns the logs captured so far.
+func (b *LogBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// CaptureLogs returns the buffer of the logs captured under name in the flow, creating it on the first call.
+// Writers such as sidecar.WithLogCapture write to it, and the logs are read with Logs or the log assertions.
+func (c Context) CaptureLogs(name string) *LogBuffer {
+	c.varsMu.Lock()
+	defer c.varsMu.Unlock()
+
+	buf, ok := c.logs[name]
+	if !ok {
+		buf = &LogBuffer{}
+		c.logs[name] = buf
+	}
+
+	return buf
+}
+
+// Logs returns the logs captured under name in the flow, or an empty string if none were.
+func (c Context) Logs(name string) string {
+	c.varsMu.RLock()
+	buf, ok := c.logs[name]
+	c.varsMu.RUnlock()
+	if !ok {
+		return ""
+	}
+
+	return buf.String()
+}
+
+// capturedLogs returns the logs captured in the flow, by name.
+func (f *Flow) capturedLogs() map[string]string {
+	f.varsMu.RLock()
+	defer f.varsMu.RUnlock()
+
+	logs := make(map[string]string, len(f.logs))
+	for name, buf := range f.logs {
+		logs[name] = buf.String()
+	}
+
+	return logs
+}
+
+// LogAssertOption configures AssertLogContains.
+type LogAssertOption func(*logAssertOptions)
+
+type logAssertOptions struct {
+	min int
+	max int
+}
+
+// LogOccurrences requires the pattern to match exactly n times.
+func LogOccurrences(n int) LogAssertOption {
+	return func(o *logAssertOptions) {
+		o.min, o.max = n, n
+	}
+}
+
+// LogMinOccurrences requires the pattern to match at least n times.
+func LogMinOccurrences(n int) LogAssertOption {
+	return func(o *logAssertOptions) {
+		o.min, o.max = n, -1
+	}
+}
+
+// AssertLogContains returns a runnable that fails if the regular expression pattern doesn't match the logs captured
+// under name, at least once by default. The pattern is matched against the whole logs, with `.` not matching line
+// breaks: a match spans a single line unless the pattern matches line breaks explicitly.
+//
+// As it reports failures by returning an error, it can be used with Retry to wait for a log line.
+func AssertLogContains(name, pattern string, opts ...LogAssertOption) Runnable {
+	o := logAssertOptions{min: 1, max: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(ctx Context) error {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid log pattern %q: %w", pattern, err)
+		}
+
+		n := len(re.FindAllStringIndex(ctx.Logs(name), -1))
+		if n < o.min || (o.max >= 0 && n > o.max) {
+			expected := fmt.Sprint(o.min)
+			if o.max < 0 {
+				expected = "at least " + expected
+			}
+			return fmt.Errorf("expected %s occurrences of %q in the logs of %s, found %d", expected, pattern, name, n)
+		}
+
+		return nil
+	}
+}
+
+// AssertLogNotContains returns a runnable that fails if the regular expression pattern matches the logs captured
+// under name.
+func AssertLogNotContains(name, pattern string) Runnable {
+	return func(ctx Context) error {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid log pattern %q: %w", pattern, err)
+		}
+
+		if line := re.FindString(ctx.Logs(name)); line != "" {
+			return fmt.Errorf("unexpected match of %q in the logs of %s: %s", pattern, name, line)
+		}
+
+		return nil
+	}
+}
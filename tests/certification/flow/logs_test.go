@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogs(t *testing.T) {
+	const logs = `level=info msg="component loaded: statestore"
+level=error msg="[INIT_COMPONENT_FAILURE]: initialization error occurred for my-vault: couldn't get token"
+level=info msg="component loaded: pubsub"
+`
+
+	newContext := func(t *testing.T) Context {
+		ctx := Context{T: t, Flow: New(t, "logs")}
+		fmt.Fprint(ctx.CaptureLogs("sidecar"), logs)
+		return ctx
+	}
+
+	t.Run("captures are separate", func(t *testing.T) {
+		ctx := newContext(t)
+		fmt.Fprint(ctx.CaptureLogs("other"), "other logs")
+
+		assert.Equal(t, logs, ctx.Logs("sidecar"))
+		assert.Equal(t, "other logs", ctx.Logs("other"))
+		assert.Empty(t, ctx.Logs("missing"))
+		assert.Empty(t, Context{T: t, Flow: New(t, "other flow")}.Logs("sidecar"))
+	})
+
+	t.Run("AssertLogContains", func(t *testing.T) {
+		ctx := newContext(t)
+
+		assert.NoError(t, AssertLogContains("sidecar", `INIT_COMPONENT_FAILURE.*my-vault`)(ctx))
+		assert.NoError(t, AssertLogContains("sidecar", `component loaded`, LogOccurrences(2))(ctx))
+		assert.NoError(t, AssertLogContains("sidecar", `level=info`, LogMinOccurrences(1))(ctx))
+
+		// A match doesn't span lines
+		assert.EqualError(t, AssertLogContains("sidecar", `statestore.*my-vault`)(ctx),
+			`expected at least 1 occurrences of "statestore.*my-vault" in the logs of sidecar, found 0`)
+		assert.EqualError(t, AssertLogContains("sidecar", `component loaded`, LogOccurrences(1))(ctx),
+			`expected 1 occurrences of "component loaded" in the logs of sidecar, found 2`)
+		assert.EqualError(t, AssertLogContains("sidecar", `level=info`, LogMinOccurrences(3))(ctx),
+			`expected at least 3 occurrences of "level=info" in the logs of sidecar, found 2`)
+		assert.ErrorContains(t, AssertLogContains("sidecar", `(`)(ctx), `invalid log pattern "("`)
+	})
+
+	t.Run("AssertLogNotContains", func(t *testing.T) {
+		ctx := newContext(t)
+
+		assert.NoError(t, AssertLogNotContains("sidecar", `INIT_COMPONENT_FAILURE.*other-vault`)(ctx))
+		assert.NoError(t, AssertLogNotContains("missing", `INIT_COMPONENT_FAILURE`)(ctx))
+
+		err := AssertLogNotContains("sidecar", `.*INIT_COMPONENT_FAILURE.*`)(ctx)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "initialization error occurred for my-vault: couldn't get token")
+		assert.NotContains(t, err.Error(), "statestore")
+	})
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// impairedDevice is the interface whose traffic is degraded: the services started by the flows, such as the
+// containers of docker-compose, are reached through ports published on the loopback interface.
+const impairedDevice = "lo"
+
+// impairedBand is the band of the prio qdisc the packets sent to the impaired ports are filtered into. The default
+// priority map of the qdisc only uses the first 3 bands, so no other packet goes through the netem qdisc of the 4th.
+const impairedBand = "1:4"
+
+// InjectLatency returns a runnable that delays the packets sent to the given ports, or all the packets of the
+// loopback interface if no port is given, by latency with a random variation of up to jitter, for duration or until
+// the context of the flow is canceled. With a duration of 0, the packets are delayed until RestoreImpairments runs.
+// As only the packets sent to the ports are delayed, each round trip with the services listening on them is
+// delayed by latency once.
+//
+// It's implemented with tc netem, which requires Linux and runs with sudo unless the tests run as root: call
+// SkipWithoutImpairments at the start of the test to skip it where that's not possible. Register RestoreImpairments
+// as a cleanup of the flow before, so that the traffic is restored even if the flow fails in between.
+func InjectLatency(duration, latency, jitter time.Duration, ports ...string) flow.Runnable {
+	netem := []string{"delay", tcTime(latency)}
+	if jitter > 0 {
+		netem = append(netem, tcTime(jitter))
+	}
+
+	return impair(duration, fmt.Sprintf("latency of %v ± %v", latency, jitter), netem, ports)
+}
+
+// InjectPacketLoss returns a runnable that drops the given percentage of the packets sent to the given ports, or of
+// all the packets of the loopback interface if no port is given, like InjectLatency.
+func InjectPacketLoss(duration time.Duration, percent float64, ports ...string) flow.Runnable {
+	if percent <= 0 || percent > 100 {
+		return func(ctx flow.Context) error {
+			return fmt.Errorf("invalid packet loss %v%%, it must be greater than 0 and at most 100", percent)
+		}
+	}
+	netem := []string{"loss", strconv.FormatFloat(percent, 'f', -1, 64) + "%"}
+
+	return impair(duration, fmt.Sprintf("packet loss of %v%%", percent), netem, ports)
+}
+
+// RestoreImpairments returns a runnable that removes the latency and packet loss injected in the loopback interface.
+// It does nothing if there are none.
+func RestoreImpairments() flow.Runnable {
+	return func(ctx flow.Context) error {
+		return restoreImpairments(runTC)
+	}
+}
+
+// SkipWithoutImpairments skips the test if latency and packet loss can't be injected in this environment: on other
+// systems than Linux, without tc, or without the privileges to change the qdiscs of the loopback interface.
+func SkipWithoutImpairments(t testing.TB) {
+	t.Helper()
+
+	if err := probeImpairments(runTC); err != nil {
+		t.Skipf("Latency and packet loss can't be injected: %v", err)
+	}
+}
+
+func impair(duration time.Duration, description string, netem []string, ports []string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		commands, err := impairmentCommands(netem, ports)
+		if err != nil {
+			return err
+		}
+		if err := applyImpairment(runTC, commands); err != nil {
+			return err
+		}
+		target := "all the ports"
+		if len(ports) > 0 {
+			target = "ports " + strings.Join(ports, ",")
+		}
+		ctx.Logf("Injected a %s in the traffic to %s", description, target)
+		if duration == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(duration):
+		}
+		if err := restoreImpairments(runTC); err != nil {
+			return err
+		}
+		ctx.Logf("Removed the %s from the traffic to %s", description, target)
+
+		return nil
+	}
+}
+
+// impairmentCommands returns the arguments of the tc commands that apply the netem options to the packets sent to
+// the ports: a prio qdisc, whose last band has the netem qdisc, and the filters sending the packets to the ports,
+// over IPv4 and IPv6, to that band.
+func impairmentCommands(netem []string, ports []string) ([][]string, error) {
+	root := []string{"qdisc", "add", "dev", impairedDevice, "root", "handle", "1:"}
+	if len(ports) == 0 {
+		return [][]string{append(append(root, "netem"), netem...)}, nil
+	}
+
+	commands := [][]string{
+		append(root, "prio", "bands", "4", "priomap", "1", "2", "2", "2", "1", "2", "0", "0", "1", "1", "1", "1", "1", "1", "1", "1"),
+		append([]string{"qdisc", "add", "dev", impairedDevice, "parent", impairedBand, "handle", "40:", "netem"}, netem...),
+	}
+	for _, port := range ports {
+		if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+			return nil, fmt.Errorf("invalid port %q, ranges aren't supported", port)
+		}
+		// The protocol of the filter, and the name of its IP version in the u32 selector
+		for _, ip := range [][2]string{{"ip", "ip"}, {"ipv6", "ip6"}} {
+			commands = append(commands, []string{
+				"filter", "add", "dev", impairedDevice, "parent", "1:", "protocol", ip[0], "prio", "1",
+				"u32", "match", ip[1], "dport", port, "0xffff", "flowid", impairedBand,
+			})
+		}
+	}
+
+	return commands, nil
+}
+
+// applyImpairment runs the tc commands of an impairment, and removes the impairment if one of them fails.
+func applyImpairment(run func(args ...string) error, commands [][]string) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("latency and packet loss can only be injected on Linux, not %s", runtime.GOOS)
+	}
+
+	for i, args := range commands {
+		if err := run(args...); err != nil {
+			err = fmt.Errorf("failed to run tc %s: %w", strings.Join(args, " "), err)
+			if i == 0 {
+				// Another impairment may be in place, which mustn't be removed
+				return fmt.Errorf("%w, the loopback interface may be impaired already", err)
+			}
+			return errors.Join(err, restoreImpairments(run))
+		}
+	}
+
+	return nil
+}
+
+// restoreImpairments removes the root qdisc of the loopback interface, with its filters and netem qdisc.
+func restoreImpairments(run func(args ...string) error) error {
+	err := run("qdisc", "del", "dev", impairedDevice, "root")
+	// Deleting the default qdisc fails when there's nothing to remove
+	if err != nil && !strings.Contains(err.Error(), "Cannot delete qdisc with handle of zero") &&
+		!strings.Contains(err.Error(), "No such file or directory") {
+		return fmt.Errorf("failed to remove the impairments of %s: %w", impairedDevice, err)
+	}
+
+	return nil
+}
+
+// probeImpairments checks that the qdiscs of the loopback interface can be changed, by adding and removing a netem
+// qdisc that doesn't affect the traffic.
+func probeImpairments(run func(args ...string) error) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("tc netem is only available on Linux, not %s", runtime.GOOS)
+	}
+	if _, err := exec.LookPath("tc"); err != nil {
+		return errors.New("tc is not installed")
+	}
+	if err := run("qdisc", "add", "dev", impairedDevice, "root", "handle", "1:", "netem", "delay", "0ms"); err != nil {
+		return err
+	}
+
+	return restoreImpairments(run)
+}
+
+// tcTime formats a duration for tc, which doesn't accept the units of time.Duration.String such as "1m0s".
+func tcTime(d time.Duration) string {
+	return strconv.FormatInt(d.Microseconds(), 10) + "us"
+}
+
+// runTC runs tc, with sudo unless the tests run as root. sudo doesn't prompt for a password, so that the probe
+// fails instead of hanging where it would be required.
+func runTC(args ...string) error {
+	command := "tc"
+	if os.Geteuid() != 0 {
+		args = append([]string{"-n", command}, args...)
+		command = "sudo"
+	}
+	out, err := exec.Command(command, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+func TestImpairmentCommands(t *testing.T) {
+	t.Run("latency of all the ports", func(t *testing.T) {
+		commands, err := impairmentCommands([]string{"delay", tcTime(500 * time.Millisecond), tcTime(50 * time.Millisecond)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			strings.Fields("qdisc add dev lo root handle 1: netem delay 500000us 50000us"),
+		}, commands)
+	})
+
+	t.Run("packet loss of a port", func(t *testing.T) {
+		commands, err := impairmentCommands([]string{"loss", "10%"}, []string{"8200"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			strings.Fields("qdisc add dev lo root handle 1: prio bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1"),
+			strings.Fields("qdisc add dev lo parent 1:4 handle 40: netem loss 10%"),
+			strings.Fields("filter add dev lo parent 1: protocol ip prio 1 u32 match ip dport 8200 0xffff flowid 1:4"),
+			strings.Fields("filter add dev lo parent 1: protocol ipv6 prio 1 u32 match ip6 dport 8200 0xffff flowid 1:4"),
+		}, commands)
+	})
+
+	t.Run("port ranges are rejected", func(t *testing.T) {
+		_, err := impairmentCommands([]string{"loss", "10%"}, []string{"9000:9999"})
+		assert.ErrorContains(t, err, `invalid port "9000:9999"`)
+	})
+
+	t.Run("invalid packet loss", func(t *testing.T) {
+		ctx := flow.Context{Context: context.Background(), T: t}
+		assert.ErrorContains(t, InjectPacketLoss(time.Second, 150, "8200")(ctx), "invalid packet loss 150%")
+	})
+}
+
+func TestImpairmentRestore(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("latency and packet loss can only be injected on Linux")
+	}
+
+	record := func(failAt int, failure string) (func(args ...string) error, *[]string) {
+		var calls []string
+		return func(args ...string) error {
+			calls = append(calls, strings.Join(args, " "))
+			if len(calls) == failAt {
+				return errors.New(failure)
+			}
+			return nil
+		}, &calls
+	}
+	commands, err := impairmentCommands([]string{"loss", "10%"}, []string{"8200"})
+	require.NoError(t, err)
+
+	t.Run("the qdisc is removed when a command fails", func(t *testing.T) {
+		run, calls := record(3, "tc failed")
+
+		assert.ErrorContains(t, applyImpairment(run, commands), "tc failed")
+		require.Len(t, *calls, 4)
+		assert.Equal(t, "qdisc del dev lo root", (*calls)[3])
+	})
+
+	t.Run("an existing impairment is left alone", func(t *testing.T) {
+		run, calls := record(1, "Exclusivity flag on, cannot modify")
+
+		assert.ErrorContains(t, applyImpairment(run, commands), "may be impaired already")
+		assert.Len(t, *calls, 1)
+	})
+
+	t.Run("restoring without impairment succeeds", func(t *testing.T) {
+		run, _ := record(1, "Error: Cannot delete qdisc with handle of zero.")
+		assert.NoError(t, restoreImpairments(run))
+
+		run, _ = record(1, "Operation not permitted")
+		assert.ErrorContains(t, restoreImpairments(run), "failed to remove the impairments of lo")
+	})
+}
+
+func TestInjectLatency(t *testing.T) {
+	SkipWithoutImpairments(t)
+
+	const latency = 200 * time.Millisecond
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	dial := func() time.Duration {
+		start := time.Now()
+		conn, err := net.DialTimeout("tcp", listener.Addr().String(), 5*time.Second)
+		require.NoError(t, err)
+		conn.Close()
+		return time.Since(start)
+	}
+
+	ctx := flow.Context{Context: context.Background(), T: t}
+	t.Cleanup(func() { RestoreImpairments()(ctx) })
+	require.NoError(t, InjectLatency(0, latency, 0, port)(ctx))
+	assert.GreaterOrEqual(t, dial(), latency)
+
+	require.NoError(t, RestoreImpairments()(ctx))
+	assert.Less(t, dial(), latency)
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// Direction is the direction of the traffic dropped by an Interruption, relative to the service listening on its
+// ports.
+type Direction int
+
+const (
+	// Both drops the packets sent to the ports and the ones sent from them.
+	Both Direction = iota
+	// Inbound drops the packets sent to the ports: the service doesn't receive requests.
+	Inbound
+	// Outbound drops the packets sent from the ports: the service receives requests, but its responses are lost.
+	Outbound
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Inbound:
+		return "inbound"
+	case Outbound:
+		return "outbound"
+	default:
+		return "both"
+	}
+}
+
+// chains are the iptables chains the rules are added to: INPUT for the packets delivered to the host, including on
+// the loopback interface, and OUTPUT for the packets sent by the host, including to published container ports.
+var chains = []string{"INPUT", "OUTPUT"}
+
+// Interruption drops the packets matching its options with iptables rules, tagged with a comment unique to the
+// interruption so that Restore removes them only, leaving the rules of other tests sharing the runner alone.
+// It's only supported on Linux, and runs iptables with sudo unless the tests run as root.
+//
+//	interruption := network.NewInterruption(network.WithPorts("8200"), network.WithDirection(network.Inbound))
+//	flow.New(t, "resilience").
+//		Cleanup("Restore network", interruption.Restore).
+//		Step("Interrupt network", interruption.Interrupt(time.Minute))
+type Interruption struct {
+	direction    Direction
+	protocols    []string
+	sources      []string
+	destinations []string
+	ports        []string
+	tag          string
+	// run runs an iptables command.
+	run func(command string, args ...string) error
+
+	lock    sync.Mutex
+	applied []rule
+}
+
+// InterruptionOption configures an Interruption.
+type InterruptionOption func(*Interruption)
+
+// WithDirection sets the direction of the traffic to drop, relative to the ports. It defaults to Both.
+func WithDirection(direction Direction) InterruptionOption {
+	return func(i *Interruption) {
+		i.direction = direction
+	}
+}
+
+// WithProtocols sets the protocols of the traffic to drop, tcp or udp. It defaults to both.
+func WithProtocols(protocols ...string) InterruptionOption {
+	return func(i *Interruption) {
+		i.protocols = protocols
+	}
+}
+
+// WithSources restricts the dropped packets to the ones sent from the given CIDRs or IPs.
+func WithSources(cidrs ...string) InterruptionOption {
+	return func(i *Interruption) {
+		i.sources = cidrs
+	}
+}
+
+// WithDestinations restricts the dropped packets to the ones sent to the given CIDRs or IPs.
+func WithDestinations(cidrs ...string) InterruptionOption {
+	return func(i *Interruption) {
+		i.destinations = cidrs
+	}
+}
+
+// WithPorts sets the ports of the service whose traffic is dropped, such as "8200" or ranges like "9000:9999".
+// A direction other than Both requires ports.
+func WithPorts(ports ...string) InterruptionOption {
+	return func(i *Interruption) {
+		i.ports = ports
+	}
+}
+
+// NewInterruption returns an interruption of the traffic matching the options. No traffic is dropped until
+// Interrupt runs.
+func NewInterruption(opts ...InterruptionOption) *Interruption {
+	i := &Interruption{
+		protocols: []string{"tcp", "udp"},
+		tag:       fmt.Sprintf("dapr-flow-%08x", rand.Uint32()), //nolint:gosec
+		run:       runIPTables,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+// Interrupt returns a runnable that drops the matching traffic for duration, or until the context of the flow is
+// canceled, then restores it. With a duration of 0, the traffic is dropped until Restore runs.
+// Register Restore as a cleanup of the flow before, so that the rules are removed even if the flow fails in between.
+func (i *Interruption) Interrupt(duration time.Duration) flow.Runnable {
+	return func(ctx flow.Context) error {
+		if err := i.Apply(); err != nil {
+			return err
+		}
+		ctx.Logf("Interrupted the %s network traffic of %s", i.direction, i.describe())
+		if duration == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(duration):
+		}
+
+		return i.Restore(ctx)
+	}
+}
+
+// Apply adds the rules dropping the matching traffic. The rules added before an error are removed.
+func (i *Interruption) Apply() error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("network interruptions are only supported on Linux, not %s", runtime.GOOS)
+	}
+	rules, err := i.rules()
+	if err != nil {
+		return err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, r := range rules {
+		if err := i.run(r.command, r.args("-I")...); err != nil {
+			return errors.Join(fmt.Errorf("failed to add the rule %s: %w", r, err), i.restoreLocked())
+		}
+		i.applied = append(i.applied, r)
+	}
+
+	return nil
+}
+
+// Restore removes the rules of the interruption. It can run several times, and does nothing if no rule was added.
+func (i *Interruption) Restore(ctx flow.Context) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if len(i.applied) == 0 {
+		return nil
+	}
+	if err := i.restoreLocked(); err != nil {
+		return err
+	}
+	ctx.Logf("Restored the %s network traffic of %s", i.direction, i.describe())
+
+	return nil
+}
+
+func (i *Interruption) restoreLocked() error {
+	var errs []error
+	for j := len(i.applied) - 1; j >= 0; j-- {
+		r := i.applied[j]
+		if err := i.run(r.command, r.args("-D")...); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove the rule %s: %w", r, err))
+		}
+	}
+	i.applied = nil
+
+	return errors.Join(errs...)
+}
+
+// describe returns the traffic matched by the interruption, for the logs.
+func (i *Interruption) describe() string {
+	desc := []string{"protocols " + strings.Join(i.protocols, ",")}
+	if len(i.ports) > 0 {
+		desc = append(desc, "ports "+strings.Join(i.ports, ","))
+	}
+	if len(i.sources) > 0 {
+		desc = append(desc, "from "+strings.Join(i.sources, ","))
+	}
+	if len(i.destinations) > 0 {
+		desc = append(desc, "to "+strings.Join(i.destinations, ","))
+	}
+
+	return strings.Join(desc, ", ")
+}
+
+// rule is an iptables rule dropping packets.
+type rule struct {
+	// command is iptables or ip6tables.
+	command string
+	chain   string
+	match   []string
+}
+
+// args returns the arguments of iptables to add (-I) or delete (-D) the rule.
+func (r rule) args(operation string) []string {
+	args := append([]string{operation, r.chain}, r.match...)
+	return append(args, "-j", "DROP")
+}
+
+func (r rule) String() string {
+	return r.command + " " + strings.Join(r.args("-I"), " ")
+}
+
+// rules returns the rules dropping the traffic matching the options: one for each combination of chain, protocol,
+// direction, port, source and destination, as iptables matches one of each per rule.
+func (i *Interruption) rules() ([]rule, error) {
+	if i.direction != Both && len(i.ports) == 0 {
+		return nil, fmt.Errorf("the %s direction requires ports", i.direction)
+	}
+	for _, protocol := range i.protocols {
+		if protocol != "tcp" && protocol != "udp" {
+			return nil, fmt.Errorf("unsupported protocol %q, accepted values are tcp and udp", protocol)
+		}
+	}
+	commands, err := iptablesCommands(append(append([]string(nil), i.sources...), i.destinations...))
+	if err != nil {
+		return nil, err
+	}
+
+	portMatches := [][]string{nil}
+	if len(i.ports) > 0 {
+		portMatches = nil
+		for _, port := range i.ports {
+			if i.direction == Both || i.direction == Inbound {
+				portMatches = append(portMatches, []string{"--dport", port})
+			}
+			if i.direction == Both || i.direction == Outbound {
+				portMatches = append(portMatches, []string{"--sport", port})
+			}
+		}
+	}
+
+	var rules []rule
+	for _, command := range commands {
+		for _, chain := range chains {
+			for _, protocol := range i.protocols {
+				for _, ports := range portMatches {
+					for _, source := range orNone(i.sources) {
+						for _, destination := range orNone(i.destinations) {
+							match := []string{"-p", protocol}
+							if source != "" {
+								match = append(match, "-s", source)
+							}
+							if destination != "" {
+								match = append(match, "-d", destination)
+							}
+							match = append(match, ports...)
+							match = append(match, "-m", "comment", "--comment", i.tag)
+							rules = append(rules, rule{command: command, chain: chain, match: match})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// iptablesCommands returns the commands matching the IP version of the CIDRs: iptables for IPv4, ip6tables for
+// IPv6, or both if there are no CIDRs. CIDRs of both versions can't be combined.
+func iptablesCommands(cidrs []string) ([]string, error) {
+	var v4, v6 bool
+	for _, cidr := range cidrs {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			var err error
+			if ip, _, err = net.ParseCIDR(cidr); err != nil {
+				return nil, fmt.Errorf("invalid CIDR or IP %q", cidr)
+			}
+		}
+		if ip.To4() != nil {
+			v4 = true
+		} else {
+			v6 = true
+		}
+	}
+
+	switch {
+	case v4 && v6:
+		return nil, errors.New("IPv4 and IPv6 CIDRs can't be combined in an interruption")
+	case v4:
+		return []string{"iptables"}, nil
+	case v6:
+		return []string{"ip6tables"}, nil
+	default:
+		return []string{"iptables", "ip6tables"}, nil
+	}
+}
+
+// orNone returns values, or a single empty value if there are none, to iterate over them when they're optional.
+func orNone(values []string) []string {
+	if len(values) == 0 {
+		return []string{""}
+	}
+
+	return values
+}
+
+// runIPTables runs an iptables command, with sudo unless the tests run as root.
+func runIPTables(command string, args ...string) error {
+	if os.Geteuid() != 0 {
+		args = append([]string{command}, args...)
+		command = "sudo"
+	}
+	out, err := exec.Command(command, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+
+	return nil
+}
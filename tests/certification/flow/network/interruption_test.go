@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+func TestInterruptionRules(t *testing.T) {
+	t.Run("inbound traffic to a port", func(t *testing.T) {
+		i := NewInterruption(WithPorts("8200"), WithDirection(Inbound), WithProtocols("tcp"))
+		i.tag = "tag"
+
+		rules, err := i.rules()
+		require.NoError(t, err)
+		var got []string
+		for _, r := range rules {
+			got = append(got, r.String())
+		}
+		assert.Equal(t, []string{
+			"iptables -I INPUT -p tcp --dport 8200 -m comment --comment tag -j DROP",
+			"iptables -I OUTPUT -p tcp --dport 8200 -m comment --comment tag -j DROP",
+			"ip6tables -I INPUT -p tcp --dport 8200 -m comment --comment tag -j DROP",
+			"ip6tables -I OUTPUT -p tcp --dport 8200 -m comment --comment tag -j DROP",
+		}, got)
+	})
+
+	t.Run("outbound traffic from a port to a CIDR", func(t *testing.T) {
+		i := NewInterruption(WithPorts("8200"), WithDirection(Outbound), WithProtocols("tcp"), WithDestinations("10.0.0.0/8"))
+		i.tag = "tag"
+
+		rules, err := i.rules()
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		assert.Equal(t, "iptables -I INPUT -p tcp -d 10.0.0.0/8 --sport 8200 -m comment --comment tag -j DROP", rules[0].String())
+	})
+
+	t.Run("both directions and protocols", func(t *testing.T) {
+		i := NewInterruption(WithPorts("8200", "9000:9999"), WithSources("::1"))
+
+		rules, err := i.rules()
+		require.NoError(t, err)
+		// 2 chains, 2 protocols, 2 ports in 2 directions, with ip6tables only
+		assert.Len(t, rules, 16)
+		for _, r := range rules {
+			assert.Equal(t, "ip6tables", r.command)
+		}
+	})
+
+	errs := map[string][]InterruptionOption{
+		"the inbound direction requires ports":  {WithDirection(Inbound)},
+		`unsupported protocol "icmp"`:           {WithProtocols("icmp")},
+		"IPv4 and IPv6 CIDRs can't be combined": {WithSources("127.0.0.1"), WithDestinations("::1")},
+		`invalid CIDR or IP "vault"`:            {WithDestinations("vault")},
+	}
+	for msg, opts := range errs {
+		_, err := NewInterruption(opts...).rules()
+		assert.ErrorContains(t, err, msg)
+	}
+}
+
+func TestInterruptionRestore(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("network interruptions are only supported on Linux")
+	}
+	ctx := flow.Context{Context: context.Background(), T: t}
+
+	record := func(i *Interruption, failAt int) *[]string {
+		var calls []string
+		i.run = func(command string, args ...string) error {
+			calls = append(calls, command+" "+strings.Join(args, " "))
+			if len(calls) == failAt {
+				return errors.New("iptables failed")
+			}
+			return nil
+		}
+		return &calls
+	}
+
+	t.Run("the rules are removed in reverse order, once", func(t *testing.T) {
+		i := NewInterruption(WithPorts("8200"), WithDirection(Inbound), WithProtocols("tcp"), WithSources("127.0.0.1"))
+		i.tag = "tag"
+		calls := record(i, 0)
+
+		require.NoError(t, i.Apply())
+		require.NoError(t, i.Restore(ctx))
+		require.NoError(t, i.Restore(ctx))
+		assert.Equal(t, []string{
+			"iptables -I INPUT -p tcp -s 127.0.0.1 --dport 8200 -m comment --comment tag -j DROP",
+			"iptables -I OUTPUT -p tcp -s 127.0.0.1 --dport 8200 -m comment --comment tag -j DROP",
+			"iptables -D OUTPUT -p tcp -s 127.0.0.1 --dport 8200 -m comment --comment tag -j DROP",
+			"iptables -D INPUT -p tcp -s 127.0.0.1 --dport 8200 -m comment --comment tag -j DROP",
+		}, *calls)
+	})
+
+	t.Run("the rules added before an error are removed", func(t *testing.T) {
+		i := NewInterruption(WithPorts("8200"), WithProtocols("tcp"), WithSources("127.0.0.1"))
+		calls := record(i, 3)
+
+		assert.ErrorContains(t, i.Apply(), "iptables failed")
+		require.Len(t, *calls, 5)
+		assert.True(t, strings.HasPrefix((*calls)[3], "iptables -D"))
+		assert.True(t, strings.HasPrefix((*calls)[4], "iptables -D"))
+	})
+
+	t.Run("the traffic is restored after the duration", func(t *testing.T) {
+		i := NewInterruption(WithPorts("8200"), WithProtocols("tcp"), WithSources("127.0.0.1"))
+		calls := record(i, 0)
+
+		require.NoError(t, i.Interrupt(10*time.Millisecond)(ctx))
+		assert.Len(t, *calls, 8)
+		assert.Empty(t, i.applied)
+	})
+}
+
+func TestInterruptionIPTables(t *testing.T) {
+	switch {
+	case runtime.GOOS != "linux":
+		t.Skip("network interruptions are only supported on Linux")
+	case os.Geteuid() != 0:
+		t.Skip("adding iptables rules requires running the tests as root")
+	}
+	if _, err := exec.LookPath("iptables"); err != nil {
+		t.Skip("iptables is not available")
+	}
+
+	ctx := flow.Context{Context: context.Background(), T: t}
+	i := NewInterruption(WithPorts("18200"), WithDirection(Inbound), WithProtocols("tcp"), WithDestinations("127.0.0.1"))
+	rules, err := i.rules()
+	require.NoError(t, err)
+	exists := func(r rule) bool {
+		return exec.Command(r.command, r.args("-C")...).Run() == nil
+	}
+
+	t.Cleanup(func() { i.Restore(ctx) })
+	require.NoError(t, i.Apply())
+	for _, r := range rules {
+		assert.True(t, exists(r), "rule %s wasn't added", r)
+	}
+
+	require.NoError(t, i.Restore(ctx))
+	for _, r := range rules {
+		assert.False(t, exists(r), "rule %s wasn't removed", r)
+	}
+}
@@ -108,6 +108,19 @@ func InterruptNetworkWithContext(ctx context.Context, duration time.Duration, ip
 	case <-t.C:
 	}
 	alreadyCleanedUp = true
+	restoreNetwork()
+}
+
+// RestoreNetwork removes the network interruptions, for example in a cleanup of the flow in case it fails while
+// the network is interrupted.
+func RestoreNetwork() flow.Runnable {
+	return func(ctx flow.Context) error {
+		restoreNetwork()
+		return nil
+	}
+}
+
+func restoreNetwork() {
 	throttler.Run(&throttler.Config{
 		Device:           "",
 		Stop:             true,
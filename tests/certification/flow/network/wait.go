@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+const defaultPollInterval = 250 * time.Millisecond
+
+type waitOptions struct {
+	pollInterval time.Duration
+}
+
+// WaitOption configures WaitForTCP and WaitForHTTP.
+type WaitOption func(*waitOptions)
+
+// WithPollInterval sets the time between two attempts to connect. Defaults to 250ms.
+func WithPollInterval(interval time.Duration) WaitOption {
+	return func(o *waitOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// WaitForTCP returns a runnable that waits until something accepts TCP connections on addr, such as "127.0.0.1:8200",
+// instead of sleeping for a guessed duration. It fails after timeout with the error of the last attempt.
+func WaitForTCP(addr string, timeout time.Duration, opts ...WaitOption) flow.Runnable {
+	return waitFor(fmt.Sprintf("%s to accept TCP connections", addr), timeout, opts, func(ctx flow.Context, attemptTimeout time.Duration) error {
+		dialer := net.Dialer{Timeout: attemptTimeout}
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	})
+}
+
+// WaitForHTTP returns a runnable that waits until a GET of url answers with expectStatus, such as the healthz
+// endpoint of a sidecar with http.StatusNoContent. It fails after timeout with the error of the last attempt, which
+// is either the connection error or the unexpected status code.
+func WaitForHTTP(url string, expectStatus int, timeout time.Duration, opts ...WaitOption) flow.Runnable {
+	return waitFor(fmt.Sprintf("%s to answer with status code %d", url, expectStatus), timeout, opts, func(ctx flow.Context, attemptTimeout time.Duration) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		client := http.Client{Timeout: attemptTimeout}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode != expectStatus {
+			return fmt.Errorf("status code %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// waitFor calls attempt every poll interval until it succeeds, or until there's no time left for another attempt
+// before timeout. Each attempt is given the time left before timeout.
+func waitFor(description string, timeout time.Duration, opts []WaitOption, attempt func(ctx flow.Context, attemptTimeout time.Duration) error) flow.Runnable {
+	o := waitOptions{pollInterval: defaultPollInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(ctx flow.Context) error {
+		start := time.Now()
+		deadline := start.Add(timeout)
+		var lastErr error
+		for {
+			lastErr = attempt(ctx, time.Until(deadline))
+			if lastErr == nil {
+				ctx.Logf("Waited %v for %s", time.Since(start).Round(time.Millisecond), description)
+				return nil
+			}
+
+			// The last attempt is the one the error reports, so it's never cut short by the deadline
+			if time.Until(deadline) <= o.pollInterval {
+				return fmt.Errorf("timed out after %v waiting for %s, last error: %w", timeout, description, lastErr)
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("stopped waiting for %s: %w, last error: %w", description, ctx.Err(), lastErr)
+			case <-time.After(o.pollInterval):
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// closedAddress returns an address nothing listens on, until a listener is started on it.
+func closedAddress(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	return addr
+}
+
+// startServerAfter starts an HTTP server on addr after delay.
+func startServerAfter(t *testing.T, addr string, delay time.Duration, handler http.Handler) {
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener.Close()
+	t.Cleanup(server.Close)
+
+	started := make(chan struct{})
+	go func() {
+		defer close(started)
+		time.Sleep(delay)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("couldn't listen on %s: %v", addr, err)
+			return
+		}
+		server.Listener = ln
+		server.Start()
+	}()
+	t.Cleanup(func() { <-started })
+}
+
+func TestWaitForTCP(t *testing.T) {
+	ctx := flow.Context{Context: context.Background(), T: t}
+
+	t.Run("waits for the listener", func(t *testing.T) {
+		addr := closedAddress(t)
+		startServerAfter(t, addr, 300*time.Millisecond, http.NotFoundHandler())
+
+		start := time.Now()
+		require.NoError(t, WaitForTCP(addr, 5*time.Second, WithPollInterval(50*time.Millisecond))(ctx))
+		assert.GreaterOrEqual(t, time.Since(start), 250*time.Millisecond)
+	})
+
+	t.Run("times out with the last connection error", func(t *testing.T) {
+		addr := closedAddress(t)
+
+		start := time.Now()
+		err := WaitForTCP(addr, 200*time.Millisecond, WithPollInterval(50*time.Millisecond))(ctx)
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.ErrorContains(t, err, "timed out after 200ms waiting for "+addr+" to accept TCP connections, last error: ")
+		assert.ErrorContains(t, err, "connection refused")
+	})
+
+	t.Run("stops when the flow is canceled", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := WaitForTCP(closedAddress(t), time.Minute)(flow.Context{Context: canceled, T: t})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestWaitForHTTP(t *testing.T) {
+	ctx := flow.Context{Context: context.Background(), T: t}
+
+	t.Run("waits for the expected status code", func(t *testing.T) {
+		addr := closedAddress(t)
+		var requests atomic.Int32
+		startServerAfter(t, addr, 200*time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The server isn't healthy yet on the first requests
+			if requests.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		err := WaitForHTTP("http://"+addr+"/v1.0/healthz", http.StatusNoContent, 5*time.Second, WithPollInterval(50*time.Millisecond))(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("times out with the last status code", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		err := WaitForHTTP(server.URL, http.StatusOK, 200*time.Millisecond, WithPollInterval(50*time.Millisecond))(ctx)
+		assert.EqualError(t, err, "timed out after 200ms waiting for "+server.URL+" to answer with status code 200, last error: status code 503")
+	})
+
+	t.Run("times out with the last connection error", func(t *testing.T) {
+		url := "http://" + closedAddress(t)
+
+		err := WaitForHTTP(url, http.StatusOK, 200*time.Millisecond, WithPollInterval(50*time.Millisecond))(ctx)
+		assert.ErrorContains(t, err, "connection refused")
+	})
+}
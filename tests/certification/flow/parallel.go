@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Parallel returns a Runnable that runs the given runnables concurrently, and waits for all of them to complete.
+// They share a context that is canceled as soon as one of them fails, or when the context of the step is canceled.
+// The returned error joins the errors of all the runnables that failed.
+//
+// As they don't run in the goroutine of the test, the runnables must report failures by returning an error
+// rather than calling ctx.Fatal or require.
+func Parallel(runnables ...Runnable) Runnable {
+	return func(ctx Context) error {
+		cctx, cancel := context.WithCancel(ctx.Context)
+		defer cancel()
+		pctx := Context{
+			name:    ctx.name,
+			Context: cctx,
+			T:       ctx.T,
+			Flow:    ctx.Flow,
+		}
+
+		errs := make([]error, len(runnables))
+		var wg sync.WaitGroup
+		wg.Add(len(runnables))
+		for i, runnable := range runnables {
+			go func(i int, runnable Runnable) {
+				defer wg.Done()
+				if err := runnable(pctx); err != nil {
+					errs[i] = fmt.Errorf("parallel runnable %d: %w", i, err)
+					cancel()
+				}
+			}(i, runnable)
+		}
+		wg.Wait()
+
+		return errors.Join(errs...)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallel(t *testing.T) {
+	newContext := func(t *testing.T, parent context.Context) Context {
+		return Context{name: "parallel", Context: parent, T: t}
+	}
+
+	t.Run("runnables run concurrently", func(t *testing.T) {
+		// Each runnable waits for all the others to start, which never happens if they run one after the other
+		const count = 3
+		var started sync.WaitGroup
+		started.Add(count)
+		runnables := make([]Runnable, count)
+		for i := range runnables {
+			runnables[i] = func(ctx Context) error {
+				started.Done()
+				if WaitTimeout(&started, 5*time.Second) {
+					return errors.New("the other runnables didn't start")
+				}
+				return nil
+			}
+		}
+
+		err := Parallel(runnables...)(newContext(t, context.Background()))
+		require.NoError(t, err)
+	})
+
+	t.Run("a failure cancels the others", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		canceled := make(chan struct{})
+
+		err := Parallel(
+			func(ctx Context) error {
+				return errFailed
+			},
+			func(ctx Context) error {
+				select {
+				case <-ctx.Done():
+					close(canceled)
+					return ctx.Err()
+				case <-time.After(5 * time.Second):
+					return nil
+				}
+			},
+		)(newContext(t, context.Background()))
+
+		require.ErrorIs(t, err, errFailed)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorContains(t, err, "parallel runnable 0: failed")
+		assert.ErrorContains(t, err, "parallel runnable 1: context canceled")
+		select {
+		case <-canceled:
+		default:
+			t.Fatal("the second runnable wasn't canceled")
+		}
+	})
+
+	t.Run("the context of the step cancels the runnables", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := Parallel(func(ctx Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})(newContext(t, parent))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
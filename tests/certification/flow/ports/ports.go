@@ -0,0 +1,223 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ports allocates free ports to the services of the flows, such as the sidecars. Unlike asking the system
+// for a free port and closing its listener right away, the ports are reserved: their listeners stay open until the
+// service is about to bind them, a port is never handed out twice in a process, and they're picked outside the
+// ephemeral range, where the system also picks the local ports of the outgoing connections of the machine.
+//
+// The embedded runtime binds its ports itself, after initializing the components, so a port can still be taken by
+// another process between its release and the start of the servers of the runtime: this is unlikely outside the
+// ephemeral range, but not impossible.
+package ports
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	// lowestPort is the lowest port reserved, above the ports of common services.
+	lowestPort = 10000
+	// maxAttempts is the number of ports tried per reserved port before giving up.
+	maxAttempts = 100
+	// ephemeralRangeFile has the range of the ephemeral ports on Linux.
+	ephemeralRangeFile = "/proc/sys/net/ipv4/ip_local_port_range"
+	// defaultEphemeralStart is the start of the ephemeral range of Linux by default, used when it can't be read.
+	defaultEphemeralStart = 32768
+)
+
+var (
+	allocatedLock sync.Mutex
+	// allocated are the ports reserved in this process so far, which aren't reserved again.
+	allocated = map[int]struct{}{}
+
+	ephemeralStartOnce sync.Once
+	ephemeralStart     int
+)
+
+// Reservation holds free ports, whose listeners stay open so that no other process binds them, until Release.
+type Reservation struct {
+	ports     []int
+	lock      sync.Mutex
+	listeners []net.Listener
+}
+
+// Reserve reserves n free ports on the loopback interface.
+func Reserve(n int) (*Reservation, error) {
+	r := &Reservation{
+		ports:     make([]int, 0, n),
+		listeners: make([]net.Listener, 0, n),
+	}
+	for i := 0; i < n; i++ {
+		listener, err := listenFreePort()
+		if err != nil {
+			r.Release()
+			return nil, err
+		}
+		r.listeners = append(r.listeners, listener)
+		r.ports = append(r.ports, listener.Addr().(*net.TCPAddr).Port)
+	}
+
+	return r, nil
+}
+
+// Ports returns the reserved ports, in the order they were reserved.
+func (r *Reservation) Ports() []int {
+	return append([]int(nil), r.ports...)
+}
+
+// Release closes the listeners of the ports, right before the service binds them. The ports aren't reserved again
+// by this process. Releasing several times does nothing.
+func (r *Reservation) Release() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, listener := range r.listeners {
+		listener.Close()
+	}
+	r.listeners = nil
+}
+
+// Free returns n free ports, released already, for the options that take ports such as embedded.WithDaprGRPCPort.
+// It fails the test if the ports can't be reserved.
+func Free(t testing.TB, n int) []int {
+	t.Helper()
+
+	r, err := Reserve(n)
+	if err != nil {
+		t.Fatalf("Failed to reserve %d ports: %v", n, err)
+	}
+	r.Release()
+
+	return r.ports
+}
+
+// GRPCAndHTTP returns free ports for the gRPC and HTTP APIs of a sidecar.
+func GRPCAndHTTP(t testing.TB) (int, int) {
+	t.Helper()
+
+	ports := Free(t, 2)
+	return ports[0], ports[1]
+}
+
+// Available returns true if the port can be bound on the loopback interface.
+func Available(port int) bool {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+
+	return true
+}
+
+// listenFreePort listens on a port outside the ephemeral range that wasn't allocated in the process yet, or on a
+// port picked by the system if there are none.
+func listenFreePort() (net.Listener, error) {
+	highest := ephemeralRangeStart() - 1
+	if highest > lowestPort {
+		for i := 0; i < maxAttempts; i++ {
+			port := lowestPort + rand.Intn(highest-lowestPort+1) //nolint:gosec
+			if !allocate(port) {
+				continue
+			}
+			listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+			if err == nil {
+				return listener, nil
+			}
+			// Used by another process, which may release it: it can be tried again later
+			deallocate(port)
+		}
+	}
+
+	// The listeners of the ports allocated already are kept open until a new one is found, so that the system
+	// doesn't pick them again
+	var skipped []net.Listener
+	defer func() {
+		for _, listener := range skipped {
+			listener.Close()
+		}
+	}()
+	for i := 0; i < maxAttempts; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		if allocate(listener.Addr().(*net.TCPAddr).Port) {
+			return listener, nil
+		}
+		skipped = append(skipped, listener)
+	}
+
+	return nil, errors.New("no free port left")
+}
+
+// allocate marks a port as allocated in the process, and returns false if it was already.
+func allocate(port int) bool {
+	allocatedLock.Lock()
+	defer allocatedLock.Unlock()
+
+	if _, ok := allocated[port]; ok {
+		return false
+	}
+	allocated[port] = struct{}{}
+
+	return true
+}
+
+func deallocate(port int) {
+	allocatedLock.Lock()
+	defer allocatedLock.Unlock()
+
+	delete(allocated, port)
+}
+
+// ephemeralRangeStart returns the first port of the ephemeral range, or 0 if it's unknown on this system.
+func ephemeralRangeStart() int {
+	ephemeralStartOnce.Do(func() {
+		start, err := readEphemeralRangeStart(ephemeralRangeFile)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return
+			}
+			// The default range of Linux starts below the ones of other systems, such as 49152 suggested by the IANA
+			start = defaultEphemeralStart
+		}
+		ephemeralStart = start
+	})
+
+	return ephemeralStart
+}
+
+// readEphemeralRangeStart reads the first port of the ephemeral range from a file such as ip_local_port_range,
+// which has the first and last ports of the range.
+func readEphemeralRangeStart(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("invalid port range %q in %s", strings.TrimSpace(string(b)), path)
+	}
+
+	return strconv.Atoi(fields[0])
+}
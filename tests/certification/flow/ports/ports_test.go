@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ports
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserveConcurrently(t *testing.T) {
+	const (
+		reservations = 50
+		perRes       = 8
+	)
+
+	var wg sync.WaitGroup
+	results := make([]*Reservation, reservations)
+	errs := make([]error, reservations)
+	for i := 0; i < reservations; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = Reserve(perRes)
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for i, r := range results {
+		require.NoError(t, errs[i])
+		require.Len(t, r.Ports(), perRes)
+		for _, port := range r.Ports() {
+			assert.False(t, seen[port], "port %d was reserved twice", port)
+			seen[port] = true
+			if start := ephemeralRangeStart(); start > lowestPort {
+				assert.Less(t, port, start, "port %d is in the ephemeral range", port)
+			}
+		}
+	}
+	require.Len(t, seen, reservations*perRes)
+
+	// The ports can't be bound until they're released
+	port := results[0].Ports()[0]
+	assert.False(t, Available(port))
+	for _, r := range results {
+		r.Release()
+		r.Release()
+	}
+	assert.True(t, Available(port))
+
+	// Released ports aren't reserved again
+	for _, port := range Free(t, perRes) {
+		assert.False(t, seen[port], "port %d was reserved again", port)
+	}
+}
+
+func TestReadEphemeralRangeStart(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "ip_local_port_range")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	start, err := readEphemeralRangeStart(write("32768\t60999\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 32768, start)
+
+	_, err = readEphemeralRangeStart(write("32768"))
+	assert.ErrorContains(t, err, `invalid port range "32768"`)
+
+	_, err = readEphemeralRangeStart(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
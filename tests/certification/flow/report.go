@@ -0,0 +1,380 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// EnvReportDir is the environment variable with the directory where a report of each flow is written in both the
+// JSON and the JUnit XML formats, such as the directory of the artifacts of a CI job. The reports are named after
+// the test of the flow. See WithReport.
+const EnvReportDir = "FLOW_REPORT_DIR"
+
+// The statuses of the steps and the flows in the reports.
+const (
+	reportPassed  = "passed"
+	reportFailed  = "failed"
+	reportSkipped = "skipped"
+	reportAborted = "aborted"
+)
+
+// errAbortedDuringStep is the error of a step in the reports written while it runs. It remains if the flow never
+// completes the step, for example when the timeout of go test kills the process.
+const errAbortedDuringStep = "the flow was aborted during the step"
+
+// WithReport writes a report of the flow to path: the name, status, duration and error of each step and cleanup,
+// and the artifacts attached to them with Context.AttachArtifact. The report is in the JUnit XML format if path
+// ends with ".xml", and in JSON otherwise; the option can be set twice to write both.
+//
+// The report is written before each step, with the step marked as aborted, and again once the step completes, so
+// that a flow killed during a step leaves a report showing where it stopped. When the flow finishes, the logs
+// captured in it, such as the ones of the sidecars, are saved next to the report and attached to the flow.
+func WithReport(path string) Option {
+	return func(f *Flow) {
+		f.reportPaths = append(f.reportPaths, path)
+	}
+}
+
+// AttachArtifact attaches the file at path, such as the logs of a service, to the step or the cleanup running in
+// the report of the flow, or to the flow itself outside of them. It does nothing if the flow doesn't write a report.
+func (c Context) AttachArtifact(path string) {
+	if c.Flow == nil {
+		return
+	}
+	c.Flow.reporter.attach(path)
+}
+
+// flowReport is the JSON report of a flow.
+type flowReport struct {
+	Name      string       `json:"name"`
+	Status    string       `json:"status"`
+	Duration  float64      `json:"durationSeconds"`
+	Steps     []stepReport `json:"steps"`
+	Artifacts []string     `json:"artifacts,omitempty"`
+}
+
+// stepReport is the report of a step or a cleanup of a flow.
+type stepReport struct {
+	Name      string   `json:"name"`
+	Cleanup   bool     `json:"cleanup,omitempty"`
+	Status    string   `json:"status"`
+	Duration  float64  `json:"durationSeconds"`
+	Error     string   `json:"error,omitempty"`
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// reporter records the report of a flow as it runs, and writes it. A nil reporter records nothing.
+type reporter struct {
+	mu        sync.Mutex
+	t         *testing.T
+	paths     []string
+	now       func() time.Time
+	start     time.Time
+	stepStart time.Time
+	// current is the index of the running step or cleanup, -1 if none is running.
+	current int
+	report  flowReport
+}
+
+// newReporter returns the reporter of the flow run by t, or nil if it doesn't write a report. The steps are
+// recorded as skipped until they run.
+func (f *Flow) newReporter(t *testing.T) *reporter {
+	paths := f.reportPaths
+	if dir := os.Getenv(EnvReportDir); dir != "" {
+		base := filepath.Join(dir, reportFileName(t.Name()))
+		paths = append(paths[:len(paths):len(paths)], base+".json", base+".xml")
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	r := &reporter{
+		t:       t,
+		paths:   paths,
+		now:     f.now,
+		start:   f.now(),
+		current: -1,
+		report: flowReport{
+			Name:   t.Name(),
+			Status: reportAborted,
+			Steps:  make([]stepReport, len(f.tasks)),
+		},
+	}
+	for i, task := range f.tasks {
+		r.report.Steps[i] = stepReport{Name: task.name, Status: reportSkipped}
+	}
+	r.write()
+
+	return r
+}
+
+// startStep records that the i-th step of the flow is running.
+func (r *reporter) startStep(i int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.begin(i)
+}
+
+// startCleanup records that a cleanup of the flow is running.
+func (r *reporter) startCleanup(name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.Steps = append(r.report.Steps, stepReport{Name: name, Cleanup: true})
+	r.begin(len(r.report.Steps) - 1)
+}
+
+func (r *reporter) begin(i int) {
+	r.closeRunning()
+	r.current = i
+	r.stepStart = r.now()
+	r.report.Steps[i].Status = reportAborted
+	r.report.Steps[i].Error = errAbortedDuringStep
+	r.write()
+}
+
+// end records the result of the running step or cleanup.
+func (r *reporter) end(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current < 0 {
+		return
+	}
+	step := &r.report.Steps[r.current]
+	step.Duration = seconds(r.now().Sub(r.stepStart))
+	step.Status = reportPassed
+	step.Error = ""
+	if err != nil {
+		step.Status = reportFailed
+		step.Error = err.Error()
+	}
+	r.current = -1
+	r.write()
+}
+
+// closeRunning records the result of a step that stopped the flow without returning, with t.FailNow or t.SkipNow.
+func (r *reporter) closeRunning() {
+	if r.current < 0 {
+		return
+	}
+	step := &r.report.Steps[r.current]
+	step.Duration = seconds(r.now().Sub(r.stepStart))
+	switch {
+	case r.t.Failed():
+		step.Status = reportFailed
+		step.Error = "the step stopped the flow without returning, see the logs of the test"
+	case r.t.Skipped():
+		step.Status = reportSkipped
+		step.Error = ""
+	}
+	r.current = -1
+}
+
+func (r *reporter) attach(path string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current < 0 {
+		r.report.Artifacts = append(r.report.Artifacts, path)
+		return
+	}
+	r.report.Steps[r.current].Artifacts = append(r.report.Steps[r.current].Artifacts, path)
+}
+
+// finish saves the logs captured in the flow next to the report, and records the result of the flow.
+func (r *reporter) finish(logs map[string]string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closeRunning()
+
+	names := make([]string, 0, len(logs))
+	for name := range logs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	base := strings.TrimSuffix(r.paths[0], filepath.Ext(r.paths[0]))
+	for _, name := range names {
+		path := base + "." + reportFileName(name) + ".log"
+		if err := os.WriteFile(path, []byte(logs[name]), 0o644); err != nil { //nolint:gosec
+			r.t.Logf("Failed to save the logs of %s: %v", name, err)
+			continue
+		}
+		r.report.Artifacts = append(r.report.Artifacts, path)
+	}
+
+	r.report.Duration = seconds(r.now().Sub(r.start))
+	switch {
+	case r.t.Failed():
+		r.report.Status = reportFailed
+	case r.t.Skipped():
+		r.report.Status = reportSkipped
+	default:
+		r.report.Status = reportPassed
+	}
+	r.write()
+}
+
+// write writes the report to its paths. The files are replaced at once, so that a flow killed while they're written
+// leaves the previous reports.
+func (r *reporter) write() {
+	for _, path := range r.paths {
+		var (
+			out []byte
+			err error
+		)
+		if filepath.Ext(path) == ".xml" {
+			out, err = xml.MarshalIndent(r.report.junit(), "", "  ")
+			out = append([]byte(xml.Header), out...)
+		} else {
+			out, err = json.MarshalIndent(r.report, "", "  ")
+		}
+		if err == nil {
+			err = writeFileAtomic(path, append(out, '\n'))
+		}
+		if err != nil {
+			r.t.Logf("Failed to write the report of the flow to %s: %v", path, err)
+		}
+	}
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil { //nolint:gosec
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// seconds returns a duration in seconds, rounded to the millisecond.
+func seconds(d time.Duration) float64 {
+	return d.Round(time.Millisecond).Seconds()
+}
+
+var reportFileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// reportFileName returns name with the characters that aren't safe in file names, such as the slashes of the names
+// of subtests, replaced.
+func reportFileName(name string) string {
+	return reportFileNameUnsafe.ReplaceAllString(name, "_")
+}
+
+// The JUnit XML report of a flow is a test suite with a test case for each step and cleanup. The artifacts are
+// listed in their output, with the [[ATTACHMENT|path]] lines of the JUnit attachments of Jenkins and GitLab.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	Classname string       `xml:"classname,attr"`
+	Time      string       `xml:"time,attr"`
+	Failure   *junitResult `xml:"failure,omitempty"`
+	Error     *junitResult `xml:"error,omitempty"`
+	Skipped   *junitResult `xml:"skipped,omitempty"`
+	SystemOut string       `xml:"system-out,omitempty"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func (r flowReport) junit() junitTestSuites {
+	suite := junitTestSuite{
+		Name:      r.Name,
+		Tests:     len(r.Steps),
+		Time:      fmt.Sprintf("%.3f", r.Duration),
+		SystemOut: junitAttachments(r.Artifacts),
+	}
+	for _, step := range r.Steps {
+		c := junitTestCase{
+			Name:      step.Name,
+			Classname: r.Name,
+			Time:      fmt.Sprintf("%.3f", step.Duration),
+			SystemOut: junitAttachments(step.Artifacts),
+		}
+		if step.Cleanup {
+			c.Name = "cleanup: " + step.Name
+		}
+		result := &junitResult{Message: strings.SplitN(step.Error, "\n", 2)[0], Text: step.Error}
+		switch step.Status {
+		case reportFailed:
+			c.Failure = result
+			suite.Failures++
+		case reportAborted:
+			c.Error = result
+			suite.Errors++
+		case reportSkipped:
+			c.Skipped = &junitResult{Message: "not run"}
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	return junitTestSuites{Suites: []junitTestSuite{suite}}
+}
+
+func junitAttachments(paths []string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&b, "[[ATTACHMENT|%s]]\n", path)
+	}
+
+	return b.String()
+}
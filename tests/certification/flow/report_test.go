@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the reports under testdata")
+
+// envReportFlow makes TestReport run a flow that doesn't pass, in a child process so that its failure doesn't fail
+// the test.
+const envReportFlow = "FLOW_TEST_REPORT_FLOW"
+
+// advance returns a runnable that moves the clock forward, so that the durations in the reports are the same on
+// every run.
+func advance(c *fakeClock, d time.Duration) Runnable {
+	return func(_ Context) error {
+		c.now = c.now.Add(d)
+		return nil
+	}
+}
+
+func withClock(c *fakeClock) Option {
+	return func(f *Flow) {
+		f.now = c.Now
+	}
+}
+
+func TestReport(t *testing.T) {
+	if mode := os.Getenv(envReportFlow); mode != "" {
+		runReportFlow(t, mode)
+		return
+	}
+
+	t.Run("passing flow", func(t *testing.T) {
+		dir := t.TempDir()
+		clock := &fakeClock{}
+
+		New(t, "passing", withClock(clock), WithReport(filepath.Join(dir, "report.json")), WithReport(filepath.Join(dir, "report.xml"))).
+			Step("start the server", func(ctx Context) error {
+				ctx.CaptureLogs("sidecar").Write([]byte("server started\n"))
+				ctx.AttachArtifact("artifacts/server.log")
+				return advance(clock, 1500*time.Millisecond)(ctx)
+			}).
+			Step("check the secret", advance(clock, 250*time.Millisecond)).
+			Cleanup("stop the server", advance(clock, time.Second)).
+			Run()
+
+		assertGolden(t, dir, "report.json", "passing.json")
+		assertGolden(t, dir, "report.xml", "passing.xml")
+		logs, err := os.ReadFile(filepath.Join(dir, "report.sidecar.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "server started\n", string(logs))
+	})
+
+	for _, mode := range []string{"failure", "panic", "watchdog"} {
+		mode := mode
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			// go test kills the process when the step of the watchdog flow blocks for longer than its timeout
+			cmd := exec.Command(os.Args[0], "-test.run=^TestReport$", "-test.v", "-test.timeout=2s")
+			cmd.Env = append(os.Environ(), envReportFlow+"="+mode, EnvReportDir+"="+dir)
+			out, err := cmd.CombinedOutput()
+			var exitErr *exec.ExitError
+			require.ErrorAs(t, err, &exitErr, string(out))
+
+			assertGolden(t, dir, "TestReport_"+mode+".json", mode+".json")
+			assertGolden(t, dir, "TestReport_"+mode+".xml", mode+".xml")
+		})
+	}
+}
+
+func runReportFlow(t *testing.T, mode string) {
+	clock := &fakeClock{}
+	f := New(t, mode, withClock(clock)).
+		Cleanup("stop vault", func(ctx Context) error {
+			ctx.AttachArtifact("artifacts/vault.log")
+			return advance(clock, time.Second)(ctx)
+		}).
+		Step("start vault", advance(clock, 2*time.Second))
+
+	switch mode {
+	case "failure":
+		f.Step("read the secret", func(ctx Context) error {
+			advance(clock, 500*time.Millisecond)(ctx)
+			return errors.New("secret not found\nsecond line of the error")
+		})
+	case "panic":
+		f.Step("read the secret", func(ctx Context) error {
+			panic("step panicked")
+		})
+	case "watchdog":
+		f.Step("read the secret", func(ctx Context) error {
+			select {}
+		})
+	}
+
+	f.Step("unreachable step", advance(clock, time.Second)).
+		Cleanup("late", func(_ Context) error {
+			return errors.New("late cleanup failed")
+		}).
+		Run()
+}
+
+// assertGolden compares the report written to dir with the golden file of testdata/reports, with dir replaced with
+// REPORT_DIR, or updates the golden file with -update.
+func assertGolden(t *testing.T, dir, report, golden string) {
+	t.Helper()
+
+	out, err := os.ReadFile(filepath.Join(dir, report))
+	require.NoError(t, err)
+	actual := strings.ReplaceAll(string(out), dir, "REPORT_DIR")
+
+	goldenPath := filepath.Join("testdata", "reports", golden)
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(goldenPath), 0o755))
+		require.NoError(t, os.WriteFile(goldenPath, []byte(actual), 0o644))
+	}
+	expected, err := os.ReadFile(goldenPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), actual)
+}
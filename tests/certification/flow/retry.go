@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultRetryInterval    = time.Second
+	defaultRetryMaxDuration = 30 * time.Second
+)
+
+// Clock is the source of time of Retry, which tests can replace with a fake one.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+type retryOptions struct {
+	interval    time.Duration
+	multiplier  float64
+	maxInterval time.Duration
+	maxDuration time.Duration
+	maxAttempts int
+	clock       Clock
+}
+
+// RetryOption configures Retry.
+type RetryOption func(*retryOptions)
+
+// RetryInterval sets the time to wait between two attempts, or before the second attempt with a backoff.
+// Defaults to 1 second.
+func RetryInterval(interval time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.interval = interval
+	}
+}
+
+// RetryBackoff multiplies the interval by multiplier after each attempt, up to maxInterval if it's positive.
+func RetryBackoff(multiplier float64, maxInterval time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.multiplier = multiplier
+		o.maxInterval = maxInterval
+	}
+}
+
+// RetryMaxDuration sets the time after which Retry gives up, counted from the first attempt. The last attempt
+// happens when it elapses. Defaults to 30 seconds, 0 means no limit.
+func RetryMaxDuration(maxDuration time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.maxDuration = maxDuration
+	}
+}
+
+// RetryMaxAttempts sets the number of attempts after which Retry gives up. Defaults to 0, which means no limit.
+func RetryMaxAttempts(maxAttempts int) RetryOption {
+	return func(o *retryOptions) {
+		o.maxAttempts = maxAttempts
+	}
+}
+
+// RetryClock replaces the clock used to wait between attempts.
+func RetryClock(clock Clock) RetryOption {
+	return func(o *retryOptions) {
+		o.clock = clock
+	}
+}
+
+// Retry returns a step that runs runnable until it succeeds, instead of sleeping for a guessed duration before
+// running it once. It gives up when the maximum duration or number of attempts is reached, with an error that
+// wraps the last one returned by runnable.
+//
+// As it's run several times, runnable must report failures by returning an error rather than calling
+// ctx.Fatal, assert or require.
+func Retry(name string, runnable Runnable, opts ...RetryOption) (string, Runnable) {
+	o := retryOptions{
+		interval:    defaultRetryInterval,
+		multiplier:  1,
+		maxDuration: defaultRetryMaxDuration,
+		clock:       realClock{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return name, func(ctx Context) error {
+		start := o.clock.Now()
+		interval := o.interval
+		for attempts := 1; ; attempts++ {
+			err := runnable(ctx)
+			if err == nil {
+				return nil
+			}
+
+			elapsed := o.clock.Now().Sub(start)
+			giveUp := func(cause error) error {
+				return fmt.Errorf("%s: giving up after %d attempts in %v: %w", name, attempts, elapsed, errors.Join(cause, err))
+			}
+
+			if o.maxAttempts > 0 && attempts >= o.maxAttempts {
+				return giveUp(nil)
+			}
+			wait := interval
+			if o.maxDuration > 0 {
+				if elapsed >= o.maxDuration {
+					return giveUp(nil)
+				}
+				if left := o.maxDuration - elapsed; wait > left {
+					wait = left
+				}
+			}
+
+			if ctx.T != nil {
+				ctx.Logf("%s: attempt %d failed, retrying in %v: %v", name, attempts, wait, err)
+			}
+			select {
+			case <-ctx.Done():
+				return giveUp(ctx.Err())
+			case <-o.clock.After(wait):
+			}
+
+			interval = time.Duration(float64(interval) * o.multiplier)
+			if o.maxInterval > 0 && interval > o.maxInterval {
+				interval = o.maxInterval
+			}
+		}
+	}
+}
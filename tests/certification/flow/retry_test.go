@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock advances its time by the duration waited for, without sleeping.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// attemptsRecorder fails until the given number of attempts, and records the time of each attempt since the start.
+type attemptsRecorder struct {
+	clock    *fakeClock
+	start    time.Time
+	failures int
+	attempts []time.Duration
+}
+
+func newAttemptsRecorder(failures int) *attemptsRecorder {
+	clock := &fakeClock{now: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)}
+	return &attemptsRecorder{clock: clock, start: clock.now, failures: failures}
+}
+
+func (r *attemptsRecorder) run(_ Context) error {
+	r.attempts = append(r.attempts, r.clock.now.Sub(r.start))
+	if len(r.attempts) <= r.failures {
+		return fmt.Errorf("failure %d", len(r.attempts))
+	}
+	return nil
+}
+
+func TestRetry(t *testing.T) {
+	ctx := Context{Context: context.Background()}
+
+	t.Run("returns the name of the step", func(t *testing.T) {
+		name, _ := Retry("wait for component", Do(func() error { return nil }))
+		assert.Equal(t, "wait for component", name)
+	})
+
+	t.Run("stops at the first success", func(t *testing.T) {
+		r := newAttemptsRecorder(2)
+		_, runnable := Retry("step", r.run, RetryClock(r.clock))
+
+		require.NoError(t, runnable(ctx))
+		assert.Equal(t, []time.Duration{0, time.Second, 2 * time.Second}, r.attempts)
+	})
+
+	t.Run("exponential backoff up to the max interval", func(t *testing.T) {
+		r := newAttemptsRecorder(5)
+		_, runnable := Retry("step", r.run,
+			RetryClock(r.clock),
+			RetryInterval(100*time.Millisecond),
+			RetryBackoff(2, 500*time.Millisecond),
+		)
+
+		require.NoError(t, runnable(ctx))
+		assert.Equal(t, []time.Duration{
+			0,
+			100 * time.Millisecond,
+			300 * time.Millisecond,
+			700 * time.Millisecond,
+			1200 * time.Millisecond,
+			1700 * time.Millisecond,
+		}, r.attempts)
+	})
+
+	t.Run("gives up after the max duration", func(t *testing.T) {
+		r := newAttemptsRecorder(100)
+		_, runnable := Retry("step", r.run,
+			RetryClock(r.clock),
+			RetryInterval(2*time.Second),
+			RetryMaxDuration(5*time.Second),
+		)
+
+		err := runnable(ctx)
+		require.Error(t, err)
+		// The last attempt happens when the max duration elapses
+		assert.Equal(t, []time.Duration{0, 2 * time.Second, 4 * time.Second, 5 * time.Second}, r.attempts)
+		assert.EqualError(t, err, "step: giving up after 4 attempts in 5s: failure 4")
+	})
+
+	t.Run("gives up after the max attempts", func(t *testing.T) {
+		r := newAttemptsRecorder(100)
+		_, runnable := Retry("step", r.run, RetryClock(r.clock), RetryMaxAttempts(3), RetryMaxDuration(0))
+
+		err := runnable(ctx)
+		require.Error(t, err)
+		assert.Len(t, r.attempts, 3)
+		assert.EqualError(t, err, "step: giving up after 3 attempts in 2s: failure 3")
+	})
+
+	t.Run("wraps the last error", func(t *testing.T) {
+		errNotReady := errors.New("not ready")
+		clock := &fakeClock{}
+		_, runnable := Retry("step", Do(func() error { return errNotReady }), RetryClock(clock), RetryMaxAttempts(2))
+
+		assert.ErrorIs(t, runnable(ctx), errNotReady)
+	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		attempts := 0
+		_, runnable := Retry("step", Do(func() error {
+			attempts++
+			return errors.New("not ready")
+		}), RetryInterval(time.Hour))
+
+		err := runnable(Context{Context: cctx})
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, attempts)
+	})
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	runtimev1pb "github.com/dapr/go-sdk/dapr/proto/runtime/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// metadataGetter is the part of the Dapr gRPC API used to read the metadata of a sidecar.
+type metadataGetter interface {
+	GetMetadata(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*runtimev1pb.GetMetadataResponse, error)
+}
+
+// AssertCapabilities returns a step that asserts the component registered in the sidecar
+// advertises every capability in want and none of the capabilities in notWant.
+// The capabilities are read from the metadata API of the sidecar started with appID.
+func AssertCapabilities(appID string, componentName string, want []secretstores.Feature, notWant []secretstores.Feature) flow.Runnable {
+	return func(ctx flow.Context) error {
+		return checkCapabilities(ctx, GetClient(ctx, appID).GrpcClient(), componentName, want, notWant)
+	}
+}
+
+// checkCapabilities compares the capabilities of a component with the expected ones.
+// The returned error lists every missing and unexpected capability, along with the advertised ones.
+func checkCapabilities(ctx context.Context, client metadataGetter, componentName string, want []secretstores.Feature, notWant []secretstores.Feature) error {
+	resp, err := client.GetMetadata(ctx, &emptypb.Empty{})
+	if err != nil {
+		return fmt.Errorf("failed to get the sidecar metadata: %w", err)
+	}
+
+	var component *runtimev1pb.RegisteredComponents
+	registered := make([]string, 0, len(resp.GetRegisteredComponents()))
+	for _, c := range resp.GetRegisteredComponents() {
+		registered = append(registered, c.GetName())
+		if c.GetName() == componentName {
+			component = c
+		}
+	}
+	if component == nil {
+		sort.Strings(registered)
+		return fmt.Errorf("component %s is not registered; registered components: [%s]", componentName, strings.Join(registered, ", "))
+	}
+
+	advertised := make(map[string]struct{}, len(component.GetCapabilities()))
+	for _, c := range component.GetCapabilities() {
+		advertised[c] = struct{}{}
+	}
+
+	var missing, unexpected []string
+	for _, f := range want {
+		if _, ok := advertised[string(f)]; !ok {
+			missing = append(missing, string(f))
+		}
+	}
+	for _, f := range notWant {
+		if _, ok := advertised[string(f)]; ok {
+			unexpected = append(unexpected, string(f))
+		}
+	}
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return nil
+	}
+
+	actual := append([]string(nil), component.GetCapabilities()...)
+	sort.Strings(actual)
+	var b strings.Builder
+	fmt.Fprintf(&b, "capabilities of component %s don't match:", componentName)
+	if len(missing) > 0 {
+		fmt.Fprintf(&b, "\n  missing:    [%s]", strings.Join(missing, ", "))
+	}
+	if len(unexpected) > 0 {
+		fmt.Fprintf(&b, "\n  unexpected: [%s]", strings.Join(unexpected, ", "))
+	}
+	fmt.Fprintf(&b, "\n  advertised: [%s]", strings.Join(actual, ", "))
+
+	return errors.New(b.String())
+}
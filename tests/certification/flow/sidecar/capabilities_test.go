@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	runtimev1pb "github.com/dapr/go-sdk/dapr/proto/runtime/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+type fakeMetadataGetter struct {
+	resp *runtimev1pb.GetMetadataResponse
+	err  error
+}
+
+func (f fakeMetadataGetter) GetMetadata(context.Context, *emptypb.Empty, ...grpc.CallOption) (*runtimev1pb.GetMetadataResponse, error) {
+	return f.resp, f.err
+}
+
+func TestCheckCapabilities(t *testing.T) {
+	client := fakeMetadataGetter{
+		resp: &runtimev1pb.GetMetadataResponse{
+			RegisteredComponents: []*runtimev1pb.RegisteredComponents{
+				{Name: "other", Type: "state.in-memory"},
+				{
+					Name:         "my-vault",
+					Type:         "secretstores.hashicorp.vault",
+					Capabilities: []string{string(secretstores.FeatureMultipleKeyValuesPerSecret), string(secretstores.FeatureBulkGetSecret)},
+				},
+			},
+		},
+	}
+
+	t.Run("matching capabilities", func(t *testing.T) {
+		err := checkCapabilities(context.Background(), client, "my-vault",
+			[]secretstores.Feature{secretstores.FeatureBulkGetSecret, secretstores.FeatureMultipleKeyValuesPerSecret},
+			[]secretstores.Feature{"OTHER_FEATURE"},
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("no expectations", func(t *testing.T) {
+		require.NoError(t, checkCapabilities(context.Background(), client, "my-vault", nil, nil))
+	})
+
+	t.Run("mismatching capabilities are all listed", func(t *testing.T) {
+		err := checkCapabilities(context.Background(), client, "my-vault",
+			[]secretstores.Feature{secretstores.FeatureBulkGetSecret, "MISSING_FEATURE"},
+			[]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret},
+		)
+		require.Error(t, err)
+		assert.Equal(t, "capabilities of component my-vault don't match:\n"+
+			"  missing:    [MISSING_FEATURE]\n"+
+			"  unexpected: [MULTIPLE_KEY_VALUES_PER_SECRET]\n"+
+			"  advertised: [BULK_GET_SECRET, MULTIPLE_KEY_VALUES_PER_SECRET]", err.Error())
+	})
+
+	t.Run("component not registered", func(t *testing.T) {
+		err := checkCapabilities(context.Background(), client, "missing", nil, nil)
+		require.Error(t, err)
+		assert.Equal(t, "component missing is not registered; registered components: [my-vault, other]", err.Error())
+	})
+
+	t.Run("metadata error", func(t *testing.T) {
+		fakeErr := errors.New("unavailable")
+		err := checkCapabilities(context.Background(), fakeMetadataGetter{err: fakeErr}, "my-vault", nil, nil)
+		require.ErrorIs(t, err, fakeErr)
+	})
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dapr/kit/logger"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// defaultCapturedLogger is the logger captured by WithLogCapture when no logger is given, which reports the
+// initialization errors of the components.
+const defaultCapturedLogger = "dapr.runtime"
+
+// WithLogCapture captures the output of the given loggers, dapr.runtime by default, while the sidecar runs. The logs
+// are still written to stdout, and can be read with ctx.Logs(appID) or asserted with flow.AssertLogContains.
+//
+// The loggers are shared by the sidecars running in the process: when sidecars run at the same time, for example
+// in parallel flows, each capture also gets the lines the others log.
+func WithLogCapture(loggers ...string) Option {
+	if len(loggers) == 0 {
+		loggers = []string{defaultCapturedLogger}
+	}
+
+	return func(o *options) {
+		o.capturedLoggers = append(o.capturedLoggers, loggers...)
+	}
+}
+
+// logTee writes the output of a logger to stdout and to the buffers of the captures in progress.
+type logTee struct {
+	mu      sync.RWMutex
+	writers map[io.Writer]struct{}
+}
+
+func (t *logTee) Write(p []byte) (int, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for w := range t.writers {
+		w.Write(p)
+	}
+
+	return os.Stdout.Write(p)
+}
+
+var (
+	logTeesMu sync.Mutex
+	logTees   = map[string]*logTee{}
+)
+
+// captureLogs writes the output of the loggers to w until the returned function is called.
+func captureLogs(w io.Writer, loggers []string) (stop func()) {
+	logTeesMu.Lock()
+	defer logTeesMu.Unlock()
+
+	tees := make([]*logTee, 0, len(loggers))
+	for _, name := range loggers {
+		tee, ok := logTees[name]
+		if !ok {
+			// The output of a logger is replaced only once, so that captures don't replace each other
+			tee = &logTee{writers: map[io.Writer]struct{}{}}
+			logTees[name] = tee
+			logger.NewLogger(name).SetOutput(tee)
+		}
+		tee.mu.Lock()
+		tee.writers[w] = struct{}{}
+		tee.mu.Unlock()
+		tees = append(tees, tee)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			for _, tee := range tees {
+				tee.mu.Lock()
+				delete(tee.writers, w)
+				tee.mu.Unlock()
+			}
+		})
+	}
+}
+
+// logCaptureKey is the key of the function stopping the log capture of a sidecar.
+func logCaptureKey(appID string) flow.Key[func()] {
+	return flow.NewKey[func()](appID + ".stopLogCapture")
+}
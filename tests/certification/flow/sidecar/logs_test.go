@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"testing"
+
+	"github.com/dapr/kit/logger"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+func TestCaptureLogs(t *testing.T) {
+	const loggerName = "dapr.test.capture"
+	log := logger.NewLogger(loggerName)
+
+	var first, second flow.LogBuffer
+	stopFirst := captureLogs(&first, []string{loggerName})
+	log.Info("first line")
+
+	// Another capture doesn't replace the first one
+	stopSecond := captureLogs(&second, []string{loggerName})
+	log.Info("second line")
+
+	stopFirst()
+	log.Info("third line")
+	stopSecond()
+	stopSecond()
+	log.Info("fourth line")
+
+	assert.Contains(t, first.String(), "first line")
+	assert.Contains(t, first.String(), "second line")
+	assert.NotContains(t, first.String(), "third line")
+
+	assert.NotContains(t, second.String(), "first line")
+	assert.Contains(t, second.String(), "second line")
+	assert.Contains(t, second.String(), "third line")
+	assert.NotContains(t, second.String(), "fourth line")
+}
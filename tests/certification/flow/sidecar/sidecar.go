@@ -14,13 +14,17 @@ limitations under the License.
 package sidecar
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/dapr/dapr/pkg/runtime"
 	"github.com/dapr/kit/logger"
 
 	"github.com/dapr/components-contrib/tests/certification/flow"
+	"github.com/dapr/components-contrib/tests/certification/flow/ports"
 
 	rtembedded "github.com/dapr/components-contrib/tests/certification/embedded"
 	// Go SDK
@@ -31,19 +35,28 @@ type (
 	Client struct {
 		dapr.Client
 		runtime.ComponentRegistry
-		rt *runtime.DaprRuntime
+		rt      *runtime.DaprRuntime
+		stopped bool
 	}
 
 	Sidecar struct {
 		appID                    string
 		options                  []interface{}
 		gracefulShutdownDuration time.Duration
+		// ports are the ports the sidecar listened on when it was started, which it listens on again when restarted.
+		ports *listenPorts
+	}
+
+	listenPorts struct {
+		http, grpc, internalGRPC, profile int
 	}
 
 	ClientCallback func(client *Client)
 
 	options struct {
-		clientCallback ClientCallback
+		clientCallback  ClientCallback
+		capturedLoggers []string
+		freePorts       bool
 	}
 
 	Option func(o *options)
@@ -55,12 +68,71 @@ func WithClientCallback(callback ClientCallback) Option {
 	}
 }
 
+// WithFreePorts makes the sidecar listen on ports reserved with the ports package, instead of the ports set with the
+// embedded options: they're held until the runtime starts, so that concurrent flows and sidecars don't pick the same
+// ones. Their ports are published in the flow like the ones of other sidecars, see Handle. The runtime exits the
+// process if it can't bind a port, so starting it again on other ports isn't possible.
+func WithFreePorts() Option {
+	return func(o *options) {
+		o.freePorts = true
+	}
+}
+
+// Handle refers to a sidecar of a flow by the name given to Run, which is also its app ID. Several sidecars can run in
+// a flow with distinct names and ports, for example to compare two configurations of a component against the same
+// service, each with its own client, ports, log capture and cleanup: the runnables given a handle target one of them.
+type Handle string
+
+// Name returns the name of the sidecar.
+func (h Handle) Name() string {
+	return string(h)
+}
+
+// Client returns the client of the sidecar, like GetClient.
+func (h Handle) Client(ctx flow.Context) *Client {
+	return GetClient(ctx, string(h))
+}
+
+// GRPCPort returns the port of the gRPC API of the sidecar, published when it started.
+func (h Handle) GRPCPort(ctx flow.Context) int {
+	return GRPCPortKey(string(h)).MustGet(ctx)
+}
+
+// HTTPPort returns the port of the HTTP API of the sidecar, published when it started.
+func (h Handle) HTTPPort(ctx flow.Context) int {
+	return HTTPPortKey(string(h)).MustGet(ctx)
+}
+
 func GetClient(ctx flow.Context, sidecarName string) *Client {
 	var client *Client
 	ctx.MustGet(sidecarName, &client)
 	return client
 }
 
+// GRPCPortKey returns the key of the port of the gRPC API of the sidecar with the given app ID, published in the
+// flow when the sidecar starts.
+func GRPCPortKey(appID string) flow.Key[int] {
+	return flow.NewKey[int](appID + ".grpcPort")
+}
+
+// HTTPPortKey returns the key of the port of the HTTP API of the sidecar with the given app ID, published in the
+// flow when the sidecar starts.
+func HTTPPortKey(appID string) flow.Key[int] {
+	return flow.NewKey[int](appID + ".httpPort")
+}
+
+const (
+	// shutdownTimeout is how long Stop waits for the runtime to shut down, after its graceful shutdown duration.
+	shutdownTimeout = 30 * time.Second
+	// portsReleaseTimeout is how long Restart waits for the ports of the stopped sidecar to be released.
+	portsReleaseTimeout = 30 * time.Second
+)
+
+// instanceKey is the key of the sidecar started in a flow, with the options and ports to restart it with.
+func instanceKey(appID string) flow.Key[Sidecar] {
+	return flow.NewKey[Sidecar](appID + ".sidecar")
+}
+
 func Run(appID string, options ...interface{}) (string, flow.Runnable, flow.Runnable) {
 	return New(appID, options...).ToStep()
 }
@@ -76,12 +148,17 @@ func (s Sidecar) AppID() string {
 	return s.appID
 }
 
+// Handle returns the handle of the sidecar, to target it from the runnables of the flow.
+func (s Sidecar) Handle() Handle {
+	return Handle(s.appID)
+}
+
 func (s Sidecar) ToStep() (string, flow.Runnable, flow.Runnable) {
 	return s.appID, s.Start, s.Stop
 }
 
 func Start(appID string, options ...interface{}) flow.Runnable {
-	return Sidecar{appID, options, 0}.Start
+	return Sidecar{appID: appID, options: options}.Start
 }
 
 func (s Sidecar) Start(ctx flow.Context) error {
@@ -104,11 +181,41 @@ func (s Sidecar) Start(ctx flow.Context) error {
 		}
 	}
 
+	var reservation *ports.Reservation
+	if s.ports == nil && options.freePorts {
+		var err error
+		if reservation, err = ports.Reserve(4); err != nil {
+			return fmt.Errorf("failed to reserve the ports of sidecar %s: %w", s.appID, err)
+		}
+		defer reservation.Release()
+		reserved := reservation.Ports()
+		s.ports = &listenPorts{http: reserved[0], grpc: reserved[1], internalGRPC: reserved[2], profile: reserved[3]}
+	}
+	if s.ports != nil {
+		rtoptions = append(rtoptions,
+			rtembedded.WithDaprHTTPPort(s.ports.http),
+			rtembedded.WithDaprGRPCPort(s.ports.grpc),
+			rtembedded.WithDaprInternalGRPCPort(s.ports.internalGRPC),
+			rtembedded.WithProfilePort(s.ports.profile),
+		)
+	}
+
 	rt, rtConf, err := rtembedded.NewRuntime(s.appID, rtoptions...)
 	if err != nil {
 		return err
 	}
+
+	if len(options.capturedLoggers) > 0 {
+		// Started before the runtime, to capture the initialization of the components
+		logCaptureKey(s.appID).Set(ctx, captureLogs(ctx.CaptureLogs(s.appID), options.capturedLoggers))
+	}
 	s.gracefulShutdownDuration = rtConf.GracefulShutdownDuration
+	s.ports = &listenPorts{
+		http:         rtConf.HTTPPort,
+		grpc:         rtConf.APIGRPCPort,
+		internalGRPC: rtConf.InternalGRPCPort,
+		profile:      rtConf.ProfilePort,
+	}
 
 	client := Client{
 		rt: rt,
@@ -120,6 +227,9 @@ func (s Sidecar) Start(ctx flow.Context) error {
 		return nil
 	}))
 
+	if reservation != nil {
+		reservation.Release()
+	}
 	if err = rt.Run(opts...); err != nil {
 		return err
 	}
@@ -132,6 +242,9 @@ func (s Sidecar) Start(ctx flow.Context) error {
 	client.Client = daprClient
 
 	ctx.Set(s.appID, &client)
+	instanceKey(s.appID).Set(ctx, s)
+	GRPCPortKey(s.appID).Set(ctx, rtConf.APIGRPCPort)
+	HTTPPortKey(s.appID).Set(ctx, rtConf.HTTPPort)
 
 	if options.clientCallback != nil {
 		options.clientCallback(&client)
@@ -140,17 +253,94 @@ func (s Sidecar) Start(ctx flow.Context) error {
 	return nil
 }
 
+// Stop returns a runnable that stops the sidecar started with the given app ID in the flow, mid-flow for example to
+// verify that a component is initialized again when the sidecar restarts. The runtime is given its graceful shutdown
+// duration to complete the outstanding operations, and Stop fails if it doesn't shut down within 30s afterwards.
+// Stopping a sidecar that's stopped already does nothing. The environment variables set with embedded.WithEnv are
+// restored.
 func Stop(appID string) flow.Runnable {
 	return Sidecar{appID: appID}.Stop
 }
 
-func (s Sidecar) Stop(ctx flow.Context) error {
+func (s Sidecar) Stop(ctx flow.Context) (err error) {
+	if stopLogCapture, ok := logCaptureKey(s.appID).Get(ctx); ok {
+		defer stopLogCapture()
+	}
+	// Also restored if the sidecar failed to start, as the variables are set when its runtime is created
+	defer func() {
+		err = errors.Join(err, rtembedded.RestoreEnv(s.appID))
+	}()
+	if started, ok := instanceKey(s.appID).Get(ctx); ok {
+		s = started
+	}
+
 	var client *Client
-	if ctx.Get(s.appID, &client) {
+	if !ctx.Get(s.appID, &client) || client.stopped {
+		return nil
+	}
+	client.stopped = true
+	if client.Client != nil {
+		client.Client.Close()
+	}
+
+	done := make(chan error, 1)
+	go func() {
 		client.rt.SetRunning(true)
 		client.rt.Shutdown(s.gracefulShutdownDuration)
+		done <- client.rt.WaitUntilShutdown()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(s.gracefulShutdownDuration + shutdownTimeout):
+		return fmt.Errorf("sidecar %s didn't shut down within %v", s.appID, s.gracefulShutdownDuration+shutdownTimeout)
+	}
+}
 
-		return client.rt.WaitUntilShutdown()
+// Restart returns a runnable that stops the sidecar started with the given app ID in the flow, like Stop, and starts
+// it again with the same options and on the same ports, once the stopped sidecar has released them. The clients of
+// the sidecar connected to its ports reconnect to the restarted one, while GetClient returns a new client.
+func Restart(appID string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		s, ok := instanceKey(appID).Get(ctx)
+		if !ok {
+			return fmt.Errorf("sidecar %s wasn't started in this flow", appID)
+		}
+		if err := s.Stop(ctx); err != nil {
+			return err
+		}
+		if err := waitForPortsReleased(ctx, portsReleaseTimeout, s.ports.http, s.ports.grpc, s.ports.internalGRPC, s.ports.profile); err != nil {
+			return fmt.Errorf("can't restart sidecar %s: %w", appID, err)
+		}
+		ctx.Logf("Restarting sidecar %s", appID)
+
+		return s.Start(ctx)
+	}
+}
+
+// waitForPortsReleased waits until the given ports can be listened on, as the listeners of a stopped server may be
+// closed asynchronously.
+func waitForPortsReleased(ctx flow.Context, timeout time.Duration, ports ...int) error {
+	deadline := time.Now().Add(timeout)
+	for _, port := range ports {
+		if port <= 0 {
+			continue
+		}
+		for {
+			listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+			if err == nil {
+				listener.Close()
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("port %d wasn't released within %v: %w", port, timeout, err)
+			}
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("port %d wasn't released: %w", port, ctx.Err())
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dapr/dapr/pkg/runtime"
+	"github.com/dapr/kit/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	secretstores_loader "github.com/dapr/dapr/pkg/components/secretstores"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/components-contrib/secretstores/local/env"
+	"github.com/dapr/components-contrib/tests/certification/embedded"
+	"github.com/dapr/components-contrib/tests/certification/flow"
+	"github.com/dapr/components-contrib/tests/certification/flow/ports"
+)
+
+// runtimeDir makes a directory with the configuration of the runtime, and the components directory it loads, the
+// working directory of the test.
+func runtimeDir(t *testing.T) string {
+	t.Helper()
+
+	// The runtime reads config.yaml from the working directory
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(
+		"apiVersion: dapr.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: config\nspec: {}\n"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "components"), 0o700))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	return dir
+}
+
+// runOnFreePorts returns a step that starts a sidecar without app nor components on free ports, and its gRPC port.
+func runOnFreePorts(t *testing.T, dir string, appID string) (string, flow.Runnable, flow.Runnable, int) {
+	t.Helper()
+
+	ports := ports.Free(t, 3)
+	name, start, stop := Run(appID,
+		embedded.WithoutApp(),
+		embedded.WithResourcesPath(filepath.Join(dir, "components")),
+		embedded.WithDaprGRPCPort(ports[0]),
+		embedded.WithDaprHTTPPort(ports[1]),
+		embedded.WithProfilePort(ports[2]),
+		embedded.WithGracefulShutdownDuration(0),
+	)
+
+	return name, start, stop, ports[0]
+}
+
+// serving asserts the sidecar answers on the given gRPC port.
+func serving(h Handle, grpcPort int) flow.Runnable {
+	return func(ctx flow.Context) error {
+		_, err := h.Client(ctx).GrpcClient().GetMetadata(ctx, &emptypb.Empty{})
+		assert.NoError(ctx.T, err)
+		assert.Equal(ctx.T, grpcPort, h.GRPCPort(ctx))
+		return nil
+	}
+}
+
+// notServing asserts nothing accepts connections on the gRPC port of a stopped sidecar.
+func notServing(grpcPort int) flow.Runnable {
+	return func(ctx flow.Context) error {
+		_, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", grpcPort), time.Second)
+		assert.Error(ctx.T, err, "the stopped sidecar still accepts connections")
+		return nil
+	}
+}
+
+func TestRestart(t *testing.T) {
+	const sidecar Handle = "restarted-sidecar"
+
+	name, start, stop, grpcPort := runOnFreePorts(t, runtimeDir(t), sidecar.Name())
+	f := flow.New(t, "restart a sidecar on the same ports").
+		Step(name, start, stop).
+		Step("sidecar is serving", serving(sidecar, grpcPort))
+	// The ports are bound again on each restart
+	for i := 1; i <= 3; i++ {
+		f = f.Step(fmt.Sprintf("restart %d", i), Restart(sidecar.Name())).
+			Step(fmt.Sprintf("sidecar is serving after restart %d", i), serving(sidecar, grpcPort))
+	}
+	f.Step("stop sidecar", Stop(sidecar.Name())).
+		Step("sidecar is stopped", notServing(grpcPort)).
+		Step("stopping again does nothing", Stop(sidecar.Name())).
+		Run()
+}
+
+func TestMultipleSidecars(t *testing.T) {
+	const (
+		first  Handle = "first-sidecar"
+		second Handle = "second-sidecar"
+	)
+
+	dir := runtimeDir(t)
+	firstName, firstStart, firstStop, firstPort := runOnFreePorts(t, dir, first.Name())
+	secondName, secondStart, secondStop, secondPort := runOnFreePorts(t, dir, second.Name())
+
+	flow.New(t, "run two sidecars in a flow").
+		Step(firstName, firstStart, firstStop).
+		Step(secondName, secondStart, secondStop).
+		Step("first sidecar is serving", serving(first, firstPort)).
+		Step("second sidecar is serving", serving(second, secondPort)).
+		Step("stop the first sidecar", Stop(first.Name())).
+		Step("first sidecar is stopped", notServing(firstPort)).
+		Step("second sidecar is still serving", serving(second, secondPort)).
+		Run()
+}
+
+func TestWithFreePorts(t *testing.T) {
+	const (
+		first  Handle = "first-free-ports-sidecar"
+		second Handle = "second-free-ports-sidecar"
+	)
+
+	dir := runtimeDir(t)
+	run := func(h Handle) (string, flow.Runnable, flow.Runnable) {
+		return Run(h.Name(),
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(filepath.Join(dir, "components")),
+			embedded.WithGracefulShutdownDuration(0),
+			WithFreePorts(),
+		)
+	}
+	var firstPort int
+
+	flow.New(t, "run sidecars on reserved ports").
+		Step(run(first)).
+		Step(run(second)).
+		Step("sidecars listen on distinct ports", func(ctx flow.Context) error {
+			firstPort = first.GRPCPort(ctx)
+			assert.NotEqual(t, firstPort, second.GRPCPort(ctx))
+			assert.NotEqual(t, first.HTTPPort(ctx), second.HTTPPort(ctx))
+			assert.NotEqual(t, runtime.DefaultDaprAPIGRPCPort, firstPort)
+			return nil
+		}).
+		Step("first sidecar is serving", func(ctx flow.Context) error {
+			return serving(first, firstPort)(ctx)
+		}).
+		Step("restart first sidecar", Restart(first.Name())).
+		Step("first sidecar is serving on the same port", func(ctx flow.Context) error {
+			return serving(first, firstPort)(ctx)
+		}).
+		Run()
+}
+
+// envProbe is a secret store reading the environment, which records the value of a variable when it's initialized.
+type envProbe struct {
+	secretstores.SecretStore
+	variable string
+	atInit   *string
+}
+
+func (p envProbe) Init(ctx context.Context, meta secretstores.Metadata) error {
+	*p.atInit = os.Getenv(p.variable)
+	return p.SecretStore.Init(ctx, meta)
+}
+
+func TestWithEnv(t *testing.T) {
+	const (
+		sidecar  Handle = "env-sidecar"
+		variable        = "SIDECAR_TEST_ENV"
+	)
+	os.Unsetenv(variable)
+
+	dir := runtimeDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "components", "envprobe.yaml"), []byte(
+		"apiVersion: dapr.io/v1alpha1\nkind: Component\nmetadata:\n  name: envprobe\nspec:\n  type: secretstores.envprobe\n  version: v1\n"), 0o600))
+	var atInit string
+	registry := secretstores_loader.NewRegistry()
+	registry.RegisterComponent(func(l logger.Logger) secretstores.SecretStore {
+		return envProbe{SecretStore: env.NewEnvSecretStore(l), variable: variable, atInit: &atInit}
+	}, "envprobe")
+	ports := ports.Free(t, 3)
+
+	flow.New(t, "set environment variables for a sidecar").
+		Step(Run(sidecar.Name(),
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(filepath.Join(dir, "components")),
+			embedded.WithDaprGRPCPort(ports[0]),
+			embedded.WithDaprHTTPPort(ports[1]),
+			embedded.WithProfilePort(ports[2]),
+			embedded.WithGracefulShutdownDuration(0),
+			embedded.WithEnv(map[string]string{variable: "visible"}),
+			runtime.WithSecretStores(registry),
+		)).
+		Step("the variable is set while the component initializes", func(ctx flow.Context) error {
+			assert.Equal(t, "visible", atInit)
+			secret, err := sidecar.Client(ctx).GetSecret(ctx, "envprobe", variable, nil)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{variable: "visible"}, secret)
+			return nil
+		}).
+		Step("stop sidecar", Stop(sidecar.Name())).
+		Step("the variable is cleared once the sidecar is stopped", func(ctx flow.Context) error {
+			_, ok := os.LookupEnv(variable)
+			assert.False(t, ok)
+			return nil
+		}).
+		Run()
+}
+
+func TestWaitForPortsReleased(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	ctx := flow.Context{Context: context.Background(), T: t}
+
+	err = waitForPortsReleased(ctx, 200*time.Millisecond, port)
+	assert.ErrorContains(t, err, fmt.Sprintf("port %d wasn't released within 200ms", port))
+
+	time.AfterFunc(300*time.Millisecond, func() { listener.Close() })
+	assert.NoError(t, waitForPortsReleased(ctx, 5*time.Second, port))
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"testing"
+)
+
+// Case is a case of a table of flows.
+type Case interface {
+	// CaseName returns the name of the flow of the case, which is the name of its subtest.
+	CaseName() string
+}
+
+// Table runs a flow for each case, as the subtests of a subtest named name. build adds the steps of the case to a
+// new flow named after it, and returns it.
+//
+// Each case has a flow of its own, so nothing started by a case is shared with the next ones: the values of the
+// flow, such as its sidecars, their ports reserved with sidecar.WithFreePorts and their captured logs, and the
+// docker-compose projects started with dockercompose.UniqueProject, are the case's own. The cases run one after the
+// other, as the services they start usually listen on fixed ports.
+//
+//	flow.Table(t, "vaultAddr", cases, func(f *flow.Flow, c addrCase) *flow.Flow {
+//		return f.Step(sidecar.Run(...)).Step("Check the secret", checkSecret(c.secret))
+//	})
+func Table[C Case](t *testing.T, name string, cases []C, build func(f *Flow, c C) *Flow, opts ...Option) {
+	t.Run(name, func(t *testing.T) {
+		for _, c := range cases {
+			build(New(t, c.CaseName(), opts...), c).Run()
+		}
+	})
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tableCase struct {
+	name  string
+	value int
+}
+
+func (c tableCase) CaseName() string {
+	return c.name
+}
+
+func TestTable(t *testing.T) {
+	key := NewKey[int]("value")
+	cases := []tableCase{
+		{name: "first", value: 1},
+		{name: "second", value: 2},
+	}
+
+	var (
+		subtests []string
+		values   []int
+	)
+	Table(t, "table", cases, func(f *Flow, c tableCase) *Flow {
+		return f.
+			Step("Check the flow is new", func(ctx Context) error {
+				_, ok := key.Get(ctx)
+				assert.False(ctx.T, ok, "value of another case")
+				key.Set(ctx, c.value)
+				return nil
+			}).
+			Step("Record the case", func(ctx Context) error {
+				subtests = append(subtests, ctx.T.Name())
+				values = append(values, key.MustGet(ctx))
+				return nil
+			})
+	})
+
+	assert.Equal(t, []string{"TestTable/table/first", "TestTable/table/second"}, subtests)
+	assert.Equal(t, []int{1, 2}, values)
+}
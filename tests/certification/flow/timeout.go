@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// timeoutGracePeriod is how long a step that timed out is given to return after its context is canceled.
+const timeoutGracePeriod = time.Second
+
+// errStepExited is returned for the steps with a timeout that end their goroutine without returning, which
+// FailNow and the require assertions do with runtime.Goexit.
+var errStepExited = errors.New("step exited without returning, for example with FailNow or a require assertion")
+
+// Option configures a flow.
+type Option func(*Flow)
+
+// WithTimeout fails the flow when its steps take longer than timeout in total, for example when a container or a
+// sidecar never starts. The cleanups still run, and aren't counted in the timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(f *Flow) {
+		f.timeout = timeout
+	}
+}
+
+// StepWithTimeout adds a step that fails if it takes longer than timeout. The context of the runnable is canceled
+// when the timeout is exceeded, and the flow moves on to its cleanups without waiting for the runnable to return.
+func (f *Flow) StepWithTimeout(name string, timeout time.Duration, runnable Runnable, cleanup ...Runnable) *Flow {
+	f.Step(name, runnable, cleanup...)
+	if runnable != nil {
+		f.tasks[len(f.tasks)-1].timeout = timeout
+	}
+
+	return f
+}
+
+// Timeout inserts a timeout in a step returned by helpers such as dockercompose.Run or sidecar.Run, so that it can
+// be passed to StepWithTimeout:
+//
+//	StepWithTimeout(flow.Timeout(5*time.Minute)(dockercompose.Run(project, filename)))
+func Timeout(timeout time.Duration) func(name string, runnable Runnable, cleanup ...Runnable) (string, time.Duration, Runnable, Runnable) {
+	return func(name string, runnable Runnable, cleanup ...Runnable) (string, time.Duration, Runnable, Runnable) {
+		var c Runnable
+		if len(cleanup) == 1 {
+			c = cleanup[0]
+		}
+		return name, timeout, runnable, c
+	}
+}
+
+// stepTimeout returns the time the step is allowed to run for, bounded by the time left before the deadline of the
+// flow if it has one, and the error returned when it's exceeded. A timeout of 0 means no timeout, and a negative
+// one that the flow has timed out already.
+func (f *Flow) stepTimeout(r namedRunnable, deadline time.Time) (time.Duration, error) {
+	timeout := r.timeout
+	errTimeout := fmt.Errorf("step %s timed out after %v: %w", r.name, r.timeout, context.DeadlineExceeded)
+	if deadline.IsZero() {
+		return timeout, errTimeout
+	}
+
+	if left := time.Until(deadline); timeout <= 0 || left < timeout {
+		timeout = left
+		if timeout == 0 {
+			timeout = -1
+		}
+		errTimeout = fmt.Errorf("flow %s timed out after %v in step %s: %w", f.name, f.timeout, r.name, context.DeadlineExceeded)
+	}
+
+	return timeout, errTimeout
+}
+
+// runStepWithTimeout runs a step, and returns errTimeout if it doesn't complete before timeout, after logging the
+// stacks of the goroutines to show where it's blocked.
+func runStepWithTimeout(ctx Context, runnable Runnable, timeout time.Duration, errTimeout error) error {
+	switch {
+	case timeout == 0:
+		return runStep(ctx, runnable)
+	case timeout < 0:
+		return errTimeout
+	}
+
+	cctx, cancel := context.WithTimeout(ctx.Context, timeout)
+	defer cancel()
+	tctx := Context{
+		name:    ctx.name,
+		Context: cctx,
+		T:       ctx.T,
+		Flow:    ctx.Flow,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		returned := false
+		// Sent from a defer, as FailNow and the require assertions end the goroutine with runtime.Goexit instead of
+		// returning; panics are recovered by runStep
+		defer func() {
+			if !returned {
+				err = errStepExited
+				if tctx.T != nil && tctx.T.Failed() {
+					err = fmt.Errorf("%w, and the test failed", errStepExited)
+				}
+			}
+			done <- err
+		}()
+		err = runStep(tctx, runnable)
+		returned = true
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || cctx.Err() != context.DeadlineExceeded {
+			return err
+		}
+	case <-cctx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	// The stacks are captured before the runnable is given time to return, to show where it was blocked
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+
+	// Runnables that honor the cancellation of their context return before the flow moves on
+	select {
+	case <-done:
+	case <-time.After(timeoutGracePeriod):
+	}
+
+	if ctx.T != nil {
+		ctx.Logf("%v, goroutines:\n%s", errTimeout, buf)
+	}
+
+	return errTimeout
+}
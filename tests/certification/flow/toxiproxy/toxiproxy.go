@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package toxiproxy injects faults in the connections to the services of a flow with Toxiproxy, which doesn't
+// require the privileges of iptables or tc. The component under test connects to a proxy in front of the service,
+// and toxics such as latency or connection resets are added to the proxy mid-flow.
+package toxiproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+	"github.com/dapr/components-contrib/tests/certification/flow/dockercompose"
+)
+
+const (
+	// DefaultAPIAddress is the address of the API of the Toxiproxy server started by Run.
+	DefaultAPIAddress = "http://127.0.0.1:8474"
+
+	// startTimeout is how long Run waits for the Toxiproxy server to be ready.
+	startTimeout = 2 * time.Minute
+)
+
+// Run returns a step that starts a Toxiproxy server on the network of the host, with its API on DefaultAPIAddress,
+// and stops it when the flow is done. The proxies it serves listen on the ports of the host.
+func Run(project string) (string, flow.Runnable, flow.Runnable) {
+	return dockercompose.RunWithOptions(project, composeFile(), dockercompose.WaitFor(startTimeout))
+}
+
+// composeFile returns the path of the compose file of the Toxiproxy server, next to this file.
+func composeFile() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "docker-compose-toxiproxy.yml")
+}
+
+// Toxic is a fault added to the connections of a proxy.
+type Toxic struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Stream is the direction of the traffic affected by the toxic: "downstream", from the service to the client,
+	// which is the default, or "upstream".
+	Stream string `json:"stream,omitempty"`
+	// Toxicity is the probability of the toxic being applied to a connection, from 0 to 1.
+	Toxicity   float64        `json:"toxicity"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// Latency delays the data sent by the service by latency, with a random variation of up to jitter.
+func Latency(name string, latency, jitter time.Duration) Toxic {
+	return Toxic{Name: name, Type: "latency", Toxicity: 1, Attributes: map[string]any{
+		"latency": latency.Milliseconds(),
+		"jitter":  jitter.Milliseconds(),
+	}}
+}
+
+// Bandwidth limits the rate of the data sent by the service to rate KB/s.
+func Bandwidth(name string, rate int64) Toxic {
+	return Toxic{Name: name, Type: "bandwidth", Toxicity: 1, Attributes: map[string]any{
+		"rate": rate,
+	}}
+}
+
+// ResetPeer resets the connections after timeout, or as soon as data is received if timeout is 0.
+func ResetPeer(name string, timeout time.Duration) Toxic {
+	return Toxic{Name: name, Type: "reset_peer", Toxicity: 1, Attributes: map[string]any{
+		"timeout": timeout.Milliseconds(),
+	}}
+}
+
+// Timeout stops all the data and closes the connections after timeout, or never closes them if timeout is 0.
+func Timeout(name string, timeout time.Duration) Toxic {
+	return Toxic{Name: name, Type: "timeout", Toxicity: 1, Attributes: map[string]any{
+		"timeout": timeout.Milliseconds(),
+	}}
+}
+
+// Client manages the proxies and toxics of a Toxiproxy server through its API.
+type Client struct {
+	addr string
+}
+
+// New returns a client of the Toxiproxy server with the API at addr, such as DefaultAPIAddress.
+func New(addr string) *Client {
+	return &Client{addr: strings.TrimSuffix(addr, "/")}
+}
+
+// CreateProxy returns a runnable that creates a proxy listening on listen, such as "127.0.0.1:18200", and
+// forwarding the connections to upstream. A proxy with the same name is replaced, so that reruns start afresh.
+func (c *Client) CreateProxy(name, listen, upstream string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		if err := c.deleteProxy(ctx, name); err != nil {
+			return err
+		}
+		proxy := map[string]any{"name": name, "listen": listen, "upstream": upstream, "enabled": true}
+		if err := c.do(ctx, http.MethodPost, "/proxies", proxy); err != nil {
+			return fmt.Errorf("failed to create proxy %s: %w", name, err)
+		}
+		ctx.Logf("Proxying %s to %s", listen, upstream)
+
+		return nil
+	}
+}
+
+// DeleteProxy returns a runnable that deletes a proxy, which closes its connections. It does nothing if the proxy
+// doesn't exist, so that it can be used as a cleanup.
+func (c *Client) DeleteProxy(name string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		return c.deleteProxy(ctx, name)
+	}
+}
+
+// AddToxic returns a runnable that adds a toxic to a proxy.
+func (c *Client) AddToxic(proxy string, toxic Toxic) flow.Runnable {
+	return func(ctx flow.Context) error {
+		if err := c.do(ctx, http.MethodPost, "/proxies/"+proxy+"/toxics", toxic); err != nil {
+			return fmt.Errorf("failed to add toxic %s to proxy %s: %w", toxic.Name, proxy, err)
+		}
+		ctx.Logf("Added %s toxic %s to proxy %s", toxic.Type, toxic.Name, proxy)
+
+		return nil
+	}
+}
+
+// RemoveToxic returns a runnable that removes a toxic from a proxy. It does nothing if the toxic doesn't exist.
+func (c *Client) RemoveToxic(proxy, name string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		err := c.do(ctx, http.MethodDelete, "/proxies/"+proxy+"/toxics/"+name, nil)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to remove toxic %s from proxy %s: %w", name, proxy, err)
+		}
+		ctx.Logf("Removed toxic %s from proxy %s", name, proxy)
+
+		return nil
+	}
+}
+
+// Reset returns a runnable that removes the toxics of all the proxies, and enables the disabled ones.
+func (c *Client) Reset() flow.Runnable {
+	return func(ctx flow.Context) error {
+		if err := c.do(ctx, http.MethodPost, "/reset", nil); err != nil {
+			return fmt.Errorf("failed to reset the proxies: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func (c *Client) deleteProxy(ctx context.Context, name string) error {
+	err := c.do(ctx, http.MethodDelete, "/proxies/"+name, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete proxy %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// statusError is returned when the API answers with an error status code.
+type statusError struct {
+	statusCode int
+	body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status code %d: %s", e.statusCode, e.body)
+}
+
+func isNotFound(err error) bool {
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.statusCode == http.StatusNotFound
+}
+
+// do sends a request to the API, with the JSON encoding of body if it isn't nil.
+func (c *Client) do(ctx context.Context, method, path string, body any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &statusError{statusCode: resp.StatusCode, body: strings.TrimSpace(string(respBody))}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package toxiproxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// fakeAPI records the requests sent to the API of Toxiproxy, and answers them with the status of their path.
+type fakeAPI struct {
+	lock     sync.Mutex
+	requests []string
+	bodies   []map[string]any
+	statuses map[string]int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	body, _ := io.ReadAll(r.Body)
+	var decoded map[string]any
+	if len(body) > 0 {
+		json.Unmarshal(body, &decoded)
+	}
+	f.bodies = append(f.bodies, decoded)
+
+	if status, ok := f.statuses[r.Method+" "+r.URL.Path]; ok {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error":"failed"}`))
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := flow.Context{Context: context.Background(), T: t}
+
+	newClient := func(statuses map[string]int) (*Client, *fakeAPI) {
+		api := &fakeAPI{statuses: statuses}
+		server := httptest.NewServer(api)
+		t.Cleanup(server.Close)
+		return New(server.URL + "/"), api
+	}
+
+	t.Run("creating a proxy replaces the existing one", func(t *testing.T) {
+		c, api := newClient(map[string]int{"DELETE /proxies/vault": http.StatusNotFound})
+
+		require.NoError(t, c.CreateProxy("vault", "127.0.0.1:18200", "127.0.0.1:8200")(ctx))
+		assert.Equal(t, []string{"DELETE /proxies/vault", "POST /proxies"}, api.requests)
+		assert.Equal(t, map[string]any{
+			"name":     "vault",
+			"listen":   "127.0.0.1:18200",
+			"upstream": "127.0.0.1:8200",
+			"enabled":  true,
+		}, api.bodies[1])
+	})
+
+	t.Run("toxics are added and removed", func(t *testing.T) {
+		c, api := newClient(map[string]int{"DELETE /proxies/vault/toxics/missing": http.StatusNotFound})
+
+		require.NoError(t, c.AddToxic("vault", ResetPeer("reset", 0))(ctx))
+		require.NoError(t, c.RemoveToxic("vault", "reset")(ctx))
+		require.NoError(t, c.RemoveToxic("vault", "missing")(ctx))
+		assert.Equal(t, []string{
+			"POST /proxies/vault/toxics",
+			"DELETE /proxies/vault/toxics/reset",
+			"DELETE /proxies/vault/toxics/missing",
+		}, api.requests)
+		assert.Equal(t, map[string]any{
+			"name":       "reset",
+			"type":       "reset_peer",
+			"toxicity":   float64(1),
+			"attributes": map[string]any{"timeout": float64(0)},
+		}, api.bodies[0])
+	})
+
+	t.Run("errors of the API are returned", func(t *testing.T) {
+		c, _ := newClient(map[string]int{
+			"POST /proxies":              http.StatusConflict,
+			"POST /proxies/vault/toxics": http.StatusBadRequest,
+			"DELETE /proxies/other":      http.StatusInternalServerError,
+		})
+
+		assert.ErrorContains(t, c.CreateProxy("vault", "127.0.0.1:18200", "127.0.0.1:8200")(ctx), "failed to create proxy vault: status code 409")
+		assert.ErrorContains(t, c.AddToxic("vault", Latency("slow", time.Second, 0))(ctx), "failed to add toxic slow to proxy vault: status code 400")
+		assert.ErrorContains(t, c.DeleteProxy("other")(ctx), "failed to delete proxy other: status code 500")
+	})
+
+	t.Run("toxics attributes", func(t *testing.T) {
+		assert.Equal(t, map[string]any{"latency": int64(500), "jitter": int64(50)}, Latency("slow", 500*time.Millisecond, 50*time.Millisecond).Attributes)
+		assert.Equal(t, map[string]any{"rate": int64(64)}, Bandwidth("narrow", 64).Attributes)
+		assert.Equal(t, map[string]any{"timeout": int64(1000)}, Timeout("stuck", time.Second).Attributes)
+	})
+}
+
+func TestComposeFile(t *testing.T) {
+	_, err := os.Stat(composeFile())
+	assert.NoError(t, err)
+}
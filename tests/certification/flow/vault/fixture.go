@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+	vaultseeder "github.com/dapr/components-contrib/tests/utils/secretseeder/vault"
+)
+
+// Fixture is the data that a test creates in Vault for itself, such as secrets with several versions, or secrets
+// in an engine that the server isn't started with.
+type Fixture struct {
+	// Mounts are the paths of the KV version 2 engines to mount. The engines that are mounted already are used as is.
+	Mounts []string
+	// Secrets holds the data of each version of the secrets, oldest first. The secrets are keyed by their path,
+	// including the mount of their engine, such as "secret/dapr/mysecret". The mount is the first segment of the
+	// path, unless the path is under one of Mounts.
+	Secrets map[string][]map[string]string
+}
+
+// Seeder creates a Fixture with the API of a Vault server, usually with its root token, and deletes what it created.
+// Its Seed and Cleanup methods are meant to be the runnable and the cleanup of a step of a flow.
+type Seeder struct {
+	client  *vaultseeder.Client
+	fixture Fixture
+
+	// The engines mounted and the secrets written by Seed, which Cleanup deletes
+	mounted []string
+	written []string
+}
+
+// NewSeeder returns a seeder of fixture for the Vault server at addr.
+func NewSeeder(addr, token string, fixture Fixture) *Seeder {
+	return &Seeder{
+		client:  vaultseeder.NewClient(addr, token),
+		fixture: fixture,
+	}
+}
+
+// Seed mounts the engines of the fixture and writes its secrets, one version after the other. A secret that exists
+// already is deleted first, so that the versions of every secret are numbered from 1 even when a test is rerun.
+func (s *Seeder) Seed(ctx flow.Context) error {
+	for _, mount := range s.fixture.Mounts {
+		mount = strings.Trim(mount, "/")
+		exists, err := s.client.MountExists(ctx, mount)
+		if err != nil {
+			return fmt.Errorf("failed to check the mount %s: %w", mount, err)
+		}
+		if exists {
+			ctx.Logf("Engine %s is already mounted", mount)
+			continue
+		}
+		if err = s.client.EnableKVMount(ctx, mount); err != nil {
+			return fmt.Errorf("failed to mount %s: %w", mount, err)
+		}
+		s.mounted = append(s.mounted, mount)
+		ctx.Logf("Mounted engine %s", mount)
+	}
+
+	paths := make([]string, 0, len(s.fixture.Secrets))
+	for path := range s.fixture.Secrets {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		mount, name, err := s.split(path)
+		if err != nil {
+			return fmt.Errorf("failed to seed secret %s: %w", path, err)
+		}
+		if err = s.client.DeleteSecret(ctx, mount, name); err != nil {
+			return fmt.Errorf("failed to delete the existing versions of secret %s: %w", path, err)
+		}
+		s.written = append(s.written, path)
+		for i, data := range s.fixture.Secrets[path] {
+			if err = s.client.WriteSecret(ctx, mount, name, data); err != nil {
+				return fmt.Errorf("failed to write version %d of secret %s: %w", i+1, path, err)
+			}
+		}
+		ctx.Logf("Seeded %d versions of secret %s", len(s.fixture.Secrets[path]), path)
+	}
+
+	return nil
+}
+
+// Cleanup deletes the secrets written and unmounts the engines mounted by Seed. Vault deletes the secrets of an
+// engine along with it, so only the secrets of the engines that were mounted already are deleted one by one.
+func (s *Seeder) Cleanup(ctx flow.Context) error {
+	mounted := make(map[string]bool, len(s.mounted))
+	for _, mount := range s.mounted {
+		mounted[mount] = true
+	}
+
+	var errs []error
+	for _, path := range s.written {
+		mount, name, _ := s.split(path)
+		if mounted[mount] {
+			continue
+		}
+		if err := s.client.DeleteSecret(ctx, mount, name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete secret %s: %w", path, err))
+		}
+	}
+	for _, mount := range s.mounted {
+		if err := s.client.DisableMount(ctx, mount); err != nil {
+			errs = append(errs, fmt.Errorf("failed to unmount %s: %w", mount, err))
+		}
+	}
+	s.mounted, s.written = nil, nil
+
+	return errors.Join(errs...)
+}
+
+// split splits the path of a secret into the mount of its engine and its name, like splitPath, but with the mounts
+// of the fixture that have several segments.
+func (s *Seeder) split(path string) (string, string, error) {
+	path = strings.Trim(path, "/")
+	for _, mount := range s.fixture.Mounts {
+		mount = strings.Trim(mount, "/")
+		if name, ok := strings.CutPrefix(path, mount+"/"); ok && name != "" {
+			return mount, name, nil
+		}
+	}
+
+	return splitPath(path)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+// fakeVault serves the mounts API and the data and metadata endpoints of the KV version 2 engines it has mounted.
+type fakeVault struct {
+	mu     sync.Mutex
+	mounts map[string]bool
+	// The versions of the secrets, keyed by mount and then by name
+	secrets map[string]map[string][]map[string]string
+}
+
+func newFakeVault(mounts ...string) *fakeVault {
+	f := &fakeVault{mounts: map[string]bool{}, secrets: map[string]map[string][]map[string]string{}}
+	for _, mount := range mounts {
+		f.mount(mount)
+	}
+	return f
+}
+
+func (f *fakeVault) mount(mount string) {
+	f.mounts[mount] = true
+	f.secrets[mount] = map[string][]map[string]string{}
+}
+
+// split returns the mounted engine, the endpoint and the name of a path of the KV version 2 API.
+func (f *fakeVault) split(path string) (string, string, string) {
+	for mount := range f.mounts {
+		rest, ok := strings.CutPrefix(path, mount+"/")
+		if !ok {
+			continue
+		}
+		if endpoint, name, ok := strings.Cut(rest, "/"); ok {
+			return mount, endpoint, name
+		}
+	}
+	return "", "", ""
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != testToken {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if path == "sys/mounts" {
+		data := map[string]any{}
+		for mount := range f.mounts {
+			data[mount+"/"] = map[string]string{"type": "kv"}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+		return
+	}
+	if mount, ok := strings.CutPrefix(path, "sys/mounts/"); ok {
+		switch {
+		case r.Method == http.MethodPost && !f.mounts[mount]:
+			f.mount(mount)
+		case r.Method == http.MethodDelete:
+			delete(f.mounts, mount)
+			delete(f.secrets, mount)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	mount, endpoint, name := f.split(path)
+	switch {
+	case mount == "":
+		w.WriteHeader(http.StatusNotFound)
+	case endpoint == "data" && r.Method == http.MethodPost:
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.secrets[mount][name] = append(f.secrets[mount][name], body.Data)
+		w.WriteHeader(http.StatusNoContent)
+	case endpoint == "metadata" && r.Method == http.MethodDelete:
+		delete(f.secrets[mount], name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestSeeder(t *testing.T) {
+	vault := newFakeVault("secret")
+	vault.secrets["secret"]["dapr/versioned"] = []map[string]string{{"key": "stale"}}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	ctx := flow.Context{Context: context.Background(), T: t}
+	fixture := Fixture{
+		Mounts: []string{"secret", "/team/kv/"},
+		Secrets: map[string][]map[string]string{
+			"secret/dapr/versioned": {{"key": "1"}, {"key": "2"}, {"key": "3"}},
+			"team/kv/dapr/nested":   {{"key": "value"}},
+		},
+	}
+	seeder := NewSeeder(server.URL, testToken, fixture)
+
+	require.NoError(t, seeder.Seed(ctx))
+	assert.True(t, vault.mounts["team/kv"], "the missing engine is mounted")
+	assert.Equal(t, []map[string]string{{"key": "1"}, {"key": "2"}, {"key": "3"}}, vault.secrets["secret"]["dapr/versioned"],
+		"the versions replace the existing ones")
+	assert.Equal(t, []map[string]string{{"key": "value"}}, vault.secrets["team/kv"]["dapr/nested"])
+
+	t.Run("cleanup deletes what was created", func(t *testing.T) {
+		vault.secrets["secret"]["dapr/other"] = []map[string]string{{"key": "value"}}
+
+		require.NoError(t, seeder.Cleanup(ctx))
+		assert.False(t, vault.mounts["team/kv"], "the engine mounted by the seeder is unmounted")
+		assert.True(t, vault.mounts["secret"], "the engine mounted already is kept")
+		assert.NotContains(t, vault.secrets["secret"], "dapr/versioned")
+		assert.Contains(t, vault.secrets["secret"], "dapr/other", "the secrets not seeded are kept")
+	})
+
+	t.Run("seeding can be repeated after a cleanup", func(t *testing.T) {
+		require.NoError(t, seeder.Seed(ctx))
+		assert.Len(t, vault.secrets["secret"]["dapr/versioned"], 3)
+		require.NoError(t, seeder.Cleanup(ctx))
+	})
+
+	t.Run("errors of Vault are returned", func(t *testing.T) {
+		err := NewSeeder(server.URL, "wrong-token", fixture).Seed(ctx)
+		assert.ErrorContains(t, err, "status code 403")
+	})
+
+	t.Run("the path must include the mount", func(t *testing.T) {
+		err := NewSeeder(server.URL, testToken, Fixture{
+			Secrets: map[string][]map[string]string{"nomount": {{"key": "value"}}},
+		}).Seed(ctx)
+		assert.ErrorContains(t, err, "failed to seed secret nomount")
+	})
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+	vaultseeder "github.com/dapr/components-contrib/tests/utils/secretseeder/vault"
+)
+
+// Seed returns a runnable that writes secrets to the KV version 2 engines of the Vault server at addr, so that a test
+// creates the secrets it needs instead of relying on the ones seeded along with the server. The secrets are keyed
+// by their path, including the mount of their engine, such as "secret/dapr/mysecret".
+//
+// Seeding is idempotent: a secret that already has the given values isn't written again, so reruns against the
+// same server don't create new versions of the secrets.
+func Seed(addr, token string, secrets map[string]map[string]string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		paths := make([]string, 0, len(secrets))
+		for path := range secrets {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		client := vaultseeder.NewClient(addr, token)
+		for _, path := range paths {
+			written, err := seed(ctx, client, path, secrets[path])
+			if err != nil {
+				return fmt.Errorf("failed to seed secret %s: %w", path, err)
+			}
+			if written {
+				ctx.Logf("Seeded secret %s", path)
+			} else {
+				ctx.Logf("Secret %s is already seeded", path)
+			}
+		}
+
+		return nil
+	}
+}
+
+// seed writes the values of the secret at path, unless it has them already, and returns whether it wrote them.
+func seed(ctx context.Context, client *vaultseeder.Client, path string, values map[string]string) (bool, error) {
+	mount, name, err := splitPath(path)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := client.ReadSecret(ctx, mount, name)
+	if err != nil {
+		return false, err
+	}
+	if current != nil && reflect.DeepEqual(current, values) {
+		return false, nil
+	}
+
+	if err = client.WriteSecret(ctx, mount, name, values); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// splitPath splits the path of a secret into the mount of its engine and its name.
+func splitPath(path string) (string, string, error) {
+	mount, name, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	if mount == "" || name == "" {
+		return "", "", errors.New("the path must start with the mount of the engine")
+	}
+
+	return mount, name, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+)
+
+const testToken = "test-token"
+
+// fakeKV serves the data endpoints of KV version 2 engines, and counts the writes.
+type fakeKV struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]string
+	writes  int
+}
+
+func (f *fakeKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != testToken {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		values, ok := f.secrets[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": values}})
+	case http.MethodPost:
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.secrets[path] = body.Data
+		f.writes++
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	kv := &fakeKV{secrets: map[string]map[string]string{
+		"secret/data/dapr/existing": {"key": "value"},
+	}}
+	server := httptest.NewServer(kv)
+	defer server.Close()
+
+	ctx := flow.Context{Context: context.Background(), T: t}
+	secrets := map[string]map[string]string{
+		"secret/dapr/multiplekeyvaluessecret": {"first": "1", "second": "2"},
+		"/secret/dapr/existing":               {"key": "value"},
+		"other/changed":                       {"key": "new"},
+	}
+	kv.secrets["other/data/changed"] = map[string]string{"key": "old"}
+
+	require.NoError(t, Seed(server.URL+"/", testToken, secrets)(ctx))
+	assert.Equal(t, map[string]string{"first": "1", "second": "2"}, kv.secrets["secret/data/dapr/multiplekeyvaluessecret"])
+	assert.Equal(t, map[string]string{"key": "new"}, kv.secrets["other/data/changed"])
+	assert.Equal(t, 2, kv.writes, "the secret that has the values already is not written")
+
+	t.Run("seeding again doesn't write the secrets", func(t *testing.T) {
+		require.NoError(t, Seed(server.URL, testToken, secrets)(ctx))
+		assert.Equal(t, 2, kv.writes)
+	})
+
+	t.Run("errors of Vault are returned", func(t *testing.T) {
+		err := Seed(server.URL, "wrong-token", secrets)(ctx)
+		assert.ErrorContains(t, err, "status code 403")
+	})
+
+	t.Run("the path must include the mount", func(t *testing.T) {
+		err := Seed(server.URL, testToken, map[string]map[string]string{"nomount": {"key": "value"}})(ctx)
+		assert.ErrorContains(t, err, "failed to seed secret nomount")
+	})
+}
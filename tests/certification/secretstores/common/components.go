@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+	"github.com/dapr/components-contrib/tests/certification/flow/sidecar"
+	"github.com/dapr/go-sdk/client"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Helper methods for checking component registration
+//
+
+// ComponentFound asserts the component is registered in the sidecar. It fails without asserting, so that it can be
+// retried while the component loads.
+func ComponentFound(sc sidecar.Handle, targetComponentName string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		componentFound, _, err := getComponentCapabilities(sc.GRPCPort(ctx), targetComponentName)
+		if err != nil {
+			return err
+		}
+		if !componentFound {
+			return fmt.Errorf("component %s was expected to be found but it was missing", targetComponentName)
+		}
+		ctx.Logf("component found=%s", targetComponentName)
+		return nil
+	}
+}
+
+// ComponentNotFound asserts the component isn't registered in the sidecar, as when it failed to initialize.
+func ComponentNotFound(sc sidecar.Handle, targetComponentName string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		componentFound, _, err := getComponentCapabilities(sc.GRPCPort(ctx), targetComponentName)
+		assert.NoError(ctx.T, err)
+		assert.False(ctx.T, componentFound, "Component was expected to be missing but it was found.")
+		return nil
+	}
+}
+
+func getComponentCapabilities(currentGrpcPort int, targetComponentName string) (found bool, capabilities []string, err error) {
+	daprClient, err := client.NewClientWithPort(fmt.Sprint(currentGrpcPort))
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to connect to the sidecar: %w", err)
+	}
+	defer daprClient.Close()
+
+	clientCtx := context.Background()
+
+	resp, err := daprClient.GrpcClient().GetMetadata(clientCtx, &empty.Empty{})
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to get the sidecar metadata: %w", err)
+	}
+
+	// Find the component
+	for _, component := range resp.GetRegisteredComponents() {
+		if component.GetName() == targetComponentName {
+			return true, component.GetCapabilities(), nil
+		}
+	}
+	return false, []string{}, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dapr/components-contrib/tests/certification/flow"
+	"github.com/dapr/components-contrib/tests/certification/flow/sidecar"
+)
+
+//
+// Helper functions for asserting error messages during component initialization
+//
+// They check the runtime logs captured by the sidecar, which must be started with sidecar.WithLogCapture().
+
+// initErrorMarker is logged by the runtime along with the initialization errors of the components.
+const initErrorMarker = "INIT_COMPONENT_FAILURE"
+
+// initErrorPattern matches the lines reporting an initialization error that mention subString.
+func initErrorPattern(subString string) string {
+	quoted := regexp.QuoteMeta(subString)
+	return initErrorMarker + ".*" + quoted + "|" + quoted + ".*" + initErrorMarker
+}
+
+// InitializationFailed is a condition that holds once an initialization error of the component is logged.
+func InitializationFailed(sc sidecar.Handle, componentName string) func(ctx flow.Context) bool {
+	return func(ctx flow.Context) bool {
+		return flow.AssertLogContains(sc.Name(), initErrorPattern(componentName))(ctx) == nil
+	}
+}
+
+// AssertNoInitializationErrorsForComponent checks that no initialization error of the component was logged.
+func AssertNoInitializationErrorsForComponent(sc sidecar.Handle, componentName string) flow.Runnable {
+	return flow.AssertLogNotContains(sc.Name(), initErrorPattern(componentName))
+}
+
+// AssertInitializationFailedWithErrorsForComponent checks that the component failed to initialize, with an error
+// message containing every one of the additional substrings. As init errors are aggregated, several substrings can
+// be given to check that all the problems of a configuration are reported at once.
+func AssertInitializationFailedWithErrorsForComponent(sc sidecar.Handle, componentName string, additionalSubStringsToMatch ...string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		if err := flow.AssertLogContains(sc.Name(), initErrorPattern(componentName))(ctx); err != nil {
+			return fmt.Errorf("expected an initialization error of component %s but found none: %w", componentName, err)
+		}
+
+		var errorLines []string
+		for _, line := range strings.Split(ctx.Logs(sc.Name()), "\n") {
+			if !strings.Contains(line, initErrorMarker) || !strings.Contains(line, componentName) {
+				continue
+			}
+			errorLines = append(errorLines, line)
+			if containsAll(line, additionalSubStringsToMatch) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("expected to find %q mentioned in an initialization error of component %s, found: %s",
+			additionalSubStringsToMatch, componentName, strings.Join(errorLines, "\n"))
+	}
+}
+
+func containsAll(s string, subStrings []string) bool {
+	for _, subString := range subStrings {
+		if !strings.Contains(s, subString) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,354 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/components-contrib/tests/certification/flow"
+	"github.com/dapr/components-contrib/tests/certification/flow/sidecar"
+	"github.com/dapr/go-sdk/client"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+//
+// Aux. functions for testing key presence
+//
+
+// KeyValuesInSecret asserts the secret has the given values, among others. The version of the secret can be given.
+func KeyValuesInSecret(sc sidecar.Handle, secretStoreName string, secretName string, keyValueMap map[string]string, maybeVersionID ...string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		daprClient, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			panic(err)
+		}
+		defer daprClient.Close()
+
+		metadata := map[string]string{}
+		if len(maybeVersionID) > 0 {
+			metadata["version_id"] = maybeVersionID[0]
+		}
+
+		res, err := daprClient.GetSecret(ctx, secretStoreName, secretName, metadata)
+		assert.NoError(ctx.T, err)
+		assert.NotNil(ctx.T, res)
+
+		for key, valueExpected := range keyValueMap {
+			valueInSecret, exists := res[key]
+			assert.True(ctx.T, exists, "expected key not found in key")
+			assert.Equal(ctx.T, valueExpected, valueInSecret)
+		}
+		return nil
+	}
+}
+
+// SecretIsReadable is a condition that holds once the secret can be read from the secret store.
+func SecretIsReadable(sc sidecar.Handle, secretStoreName string, secretName string) func(ctx flow.Context) bool {
+	return func(ctx flow.Context) bool {
+		daprClient, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			return false
+		}
+		defer daprClient.Close()
+
+		_, err = daprClient.GetSecret(ctx, secretStoreName, secretName, nil)
+		return err == nil
+	}
+}
+
+// SecretIsNotFound asserts the secret store reports the secret as missing, rather than failing for another reason.
+func SecretIsNotFound(sc sidecar.Handle, secretStoreName string, secretName string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		daprClient, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			panic(err)
+		}
+		defer daprClient.Close()
+
+		emptyOpt := map[string]string{}
+
+		_, err = daprClient.GetSecret(ctx, secretStoreName, secretName, emptyOpt)
+		// The component error message is propagated by the runtime
+		assert.ErrorContains(ctx.T, err, secretstores.ErrSecretNotFound.Error())
+
+		// A missing secret must not be reported like a broken store. Runtimes that don't map ErrSecretNotFound to
+		// NotFound yet report codes.Internal with the message of the component error, checked above.
+		st, ok := status.FromError(err)
+		if assert.True(ctx.T, ok, "expected a gRPC status error, got %v", err) {
+			assert.Contains(ctx.T, []codes.Code{codes.NotFound, codes.Internal}, st.Code(),
+				"expected a NotFound status code for a missing secret, got %s", st.Code())
+		}
+
+		return nil
+	}
+}
+
+// SecretRetrievalFails asserts reading the secret fails, for any reason.
+func SecretRetrievalFails(sc sidecar.Handle, secretStoreName string, secretName string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		daprClient, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			panic(err)
+		}
+		defer daprClient.Close()
+
+		emptyOpt := map[string]string{}
+
+		_, err = daprClient.GetSecret(ctx, secretStoreName, secretName, emptyOpt)
+		assert.Error(ctx.T, err)
+
+		return nil
+	}
+}
+
+// SecretRetrievalTimesOut asserts that reading the secret doesn't complete within timeout, as when the service of
+// the secret store accepts connections but doesn't answer, rather than failing fast.
+func SecretRetrievalTimesOut(sc sidecar.Handle, secretStoreName string, secretName string, timeout time.Duration) flow.Runnable {
+	return func(ctx flow.Context) error {
+		daprClient, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			return err
+		}
+		defer daprClient.Close()
+
+		tctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		_, err = daprClient.GetSecret(tctx, secretStoreName, secretName, nil)
+		assert.Equal(ctx.T, codes.DeadlineExceeded, status.Code(err), "expected a timeout, got %v", err)
+
+		return nil
+	}
+}
+
+// SecretRetrievalFailsFast asserts that reading the secret fails within timeout because nothing serves the port,
+// as when the sidecar is stopped, rather than hanging. Unlike client.NewClientWithPort, the connection isn't awaited.
+func SecretRetrievalFailsFast(sc sidecar.Handle, secretStoreName string, secretName string, timeout time.Duration) flow.Runnable {
+	return func(ctx flow.Context) error {
+		conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", sc.GRPCPort(ctx)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		daprClient := client.NewClientWithConnection(conn)
+		defer daprClient.Close()
+
+		tctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		_, err = daprClient.GetSecret(tctx, secretStoreName, secretName, nil)
+		assert.Equal(ctx.T, codes.Unavailable, status.Code(err), "expected the sidecar to be unavailable, got %v", err)
+
+		return nil
+	}
+}
+
+// SecretRetrievalLatency reads the secret the given number of times, each with timeout, and asserts that every
+// read succeeds and that the 99th percentile of their latencies stays below timeout.
+func SecretRetrievalLatency(sc sidecar.Handle, secretStoreName string, secretName string, reads int, timeout time.Duration) flow.Runnable {
+	return func(ctx flow.Context) error {
+		daprClient, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			return err
+		}
+		defer daprClient.Close()
+
+		latencies := make([]time.Duration, 0, reads)
+		for i := 0; i < reads; i++ {
+			tctx, cancel := context.WithTimeout(ctx, timeout)
+			start := time.Now()
+			_, err = daprClient.GetSecret(tctx, secretStoreName, secretName, nil)
+			latencies = append(latencies, time.Since(start))
+			cancel()
+			assert.NoError(ctx.T, err, "read %d of the secret failed", i)
+		}
+
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		p99 := latencies[(len(latencies)*99+99)/100-1]
+		ctx.Logf("Read the secret %d times: median %v, p99 %v, max %v", reads, latencies[len(latencies)/2], p99, latencies[len(latencies)-1])
+		assert.Less(ctx.T, p99, timeout, "the p99 latency of the reads exceeds the client timeout")
+
+		return nil
+	}
+}
+
+// GetBulkSecretsWorksAndFoundKeys asserts a bulk read returns some secrets, and logs them.
+func GetBulkSecretsWorksAndFoundKeys(sc sidecar.Handle, secretStoreName string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		client, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			panic(err)
+		}
+		defer client.Close()
+
+		emptyOpt := map[string]string{}
+
+		res, err := client.GetBulkSecret(ctx, secretStoreName, emptyOpt)
+		assert.NoError(ctx.T, err)
+		assert.NotNil(ctx.T, res)
+		assert.NotEmpty(ctx.T, res)
+
+		for k, v := range res {
+			ctx.Logf("Secret %s", k)
+			for i, j := range v {
+				ctx.Logf("\t key-value pair: %s : %s", i, j)
+			}
+		}
+
+		return nil
+	}
+}
+
+// GetBulkSecretsReturnsNames asserts the secrets returned by a bulk read are exactly the expected ones.
+func GetBulkSecretsReturnsNames(sc sidecar.Handle, secretStoreName string, expectedNames ...string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		client, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			panic(err)
+		}
+		defer client.Close()
+
+		res, err := client.GetBulkSecret(ctx, secretStoreName, map[string]string{})
+		assert.NoError(ctx.T, err)
+
+		names := make([]string, 0, len(res))
+		for name := range res {
+			names = append(names, name)
+		}
+		assert.ElementsMatch(ctx.T, expectedNames, names)
+
+		return nil
+	}
+}
+
+// BulkSecretsEqual asserts the secrets returned by a bulk read are exactly the expected ones, with the same keys
+// and values, and lists the differences otherwise.
+func BulkSecretsEqual(sc sidecar.Handle, secretStoreName string, expected map[string]map[string]string) flow.Runnable {
+	return bulkSecretsMatch(sc, secretStoreName, nil, expected, true)
+}
+
+// BulkSecretsEqualWithMetadata is like BulkSecretsEqual, for a bulk read with the given request metadata, such as
+// the folder that the read is restricted to.
+func BulkSecretsEqualWithMetadata(sc sidecar.Handle, secretStoreName string, metadata map[string]string, expected map[string]map[string]string) flow.Runnable {
+	return bulkSecretsMatch(sc, secretStoreName, metadata, expected, true)
+}
+
+// BulkSecretsContain is like BulkSecretsEqual, but ignores the secrets that aren't expected, for the secret stores
+// that hold other secrets as well, such as the variables of the environment.
+func BulkSecretsContain(sc sidecar.Handle, secretStoreName string, expected map[string]map[string]string) flow.Runnable {
+	return bulkSecretsMatch(sc, secretStoreName, nil, expected, false)
+}
+
+func bulkSecretsMatch(sc sidecar.Handle, secretStoreName string, metadata map[string]string, expected map[string]map[string]string, exact bool) flow.Runnable {
+	return func(ctx flow.Context) error {
+		client, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		res, err := client.GetBulkSecret(ctx, secretStoreName, metadata)
+		if !assert.NoError(ctx.T, err) {
+			return nil
+		}
+		if !exact {
+			res = selectSecrets(res, expected)
+		}
+		if diff := bulkSecretsDiff(expected, res); len(diff) > 0 {
+			assert.Fail(ctx.T, "bulk secrets differ from the expected ones", strings.Join(diff, "\n"))
+		}
+
+		return nil
+	}
+}
+
+// BulkSecretsMatchGet asserts that each secret returned by a bulk read has the values returned by reading it alone,
+// so that the options changing the values of the secrets apply to both.
+func BulkSecretsMatchGet(sc sidecar.Handle, secretStoreName string) flow.Runnable {
+	return func(ctx flow.Context) error {
+		client, err := client.NewClientWithPort(fmt.Sprint(sc.GRPCPort(ctx)))
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		res, err := client.GetBulkSecret(ctx, secretStoreName, map[string]string{})
+		if !assert.NoError(ctx.T, err) {
+			return nil
+		}
+		for name, values := range res {
+			secret, err := client.GetSecret(ctx, secretStoreName, name, map[string]string{})
+			if assert.NoError(ctx.T, err, "reading secret %s failed", name) {
+				assert.Equal(ctx.T, secret, values, "the bulk read returned other values for secret %s", name)
+			}
+		}
+
+		return nil
+	}
+}
+
+// selectSecrets returns the secrets of a bulk read that are named in expected.
+func selectSecrets(actual, expected map[string]map[string]string) map[string]map[string]string {
+	res := make(map[string]map[string]string, len(expected))
+	for name := range expected {
+		if values, ok := actual[name]; ok {
+			res[name] = values
+		}
+	}
+
+	return res
+}
+
+// bulkSecretsDiff returns the differences between the expected and actual secrets of a bulk read, sorted.
+func bulkSecretsDiff(expected, actual map[string]map[string]string) []string {
+	var diff []string
+	for name, expectedValues := range expected {
+		values, ok := actual[name]
+		if !ok {
+			diff = append(diff, fmt.Sprintf("missing secret %s", name))
+			continue
+		}
+		for key, expectedValue := range expectedValues {
+			value, ok := values[key]
+			switch {
+			case !ok:
+				diff = append(diff, fmt.Sprintf("secret %s: missing key %s", name, key))
+			case value != expectedValue:
+				diff = append(diff, fmt.Sprintf("secret %s: key %s is %q, expected %q", name, key, value, expectedValue))
+			}
+		}
+		for key := range values {
+			if _, ok := expectedValues[key]; !ok {
+				diff = append(diff, fmt.Sprintf("secret %s: unexpected key %s", name, key))
+			}
+		}
+	}
+	for name := range actual {
+		if _, ok := expected[name]; !ok {
+			diff = append(diff, fmt.Sprintf("unexpected secret %s", name))
+		}
+	}
+	sort.Strings(diff)
+
+	return diff
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkSecretsDiff(t *testing.T) {
+	expected := map[string]map[string]string{
+		"db":  {"user": "admin", "password": "secret"},
+		"api": {"key": "abcd"},
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		actual := map[string]map[string]string{
+			"api": {"key": "abcd"},
+			"db":  {"password": "secret", "user": "admin"},
+		}
+		assert.Empty(t, bulkSecretsDiff(expected, actual))
+	})
+
+	t.Run("missing and extra entries are all reported", func(t *testing.T) {
+		actual := map[string]map[string]string{
+			"db":    {"user": "root", "host": "localhost"},
+			"cache": {"url": "redis://"},
+		}
+		assert.Equal(t, []string{
+			"missing secret api",
+			`secret db: key user is "root", expected "admin"`,
+			"secret db: missing key password",
+			"secret db: unexpected key host",
+			"unexpected secret cache",
+		}, bulkSecretsDiff(expected, actual))
+	})
+
+	t.Run("no secrets", func(t *testing.T) {
+		assert.Equal(t, []string{"missing secret api", "missing secret db"}, bulkSecretsDiff(expected, nil))
+		assert.Empty(t, bulkSecretsDiff(nil, nil))
+	})
+}
+
+func TestSelectSecrets(t *testing.T) {
+	actual := map[string]map[string]string{
+		"db":   {"user": "admin"},
+		"HOME": {"HOME": "/root"},
+	}
+
+	assert.Equal(t, map[string]map[string]string{"db": {"user": "admin"}},
+		selectSecrets(actual, map[string]map[string]string{"db": {"user": "admin"}, "api": {"key": "abcd"}}))
+	assert.Empty(t, selectSecrets(actual, nil))
+}
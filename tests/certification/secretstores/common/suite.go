@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package common holds the steps shared by the certification tests of the secret stores, which target a secret store
+// loaded by a sidecar of the flow through its handle, and RunStandardSuite, the baseline scenarios that every secret
+// store is expected to pass.
+package common
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/components-contrib/tests/certification/embedded"
+	"github.com/dapr/components-contrib/tests/certification/flow"
+	"github.com/dapr/components-contrib/tests/certification/flow/network"
+	"github.com/dapr/components-contrib/tests/certification/flow/sidecar"
+	"github.com/dapr/dapr/pkg/runtime"
+)
+
+const (
+	// DefaultSidecar is the handle of the sidecar started by RunStandardSuite, unless set with WithSidecar.
+	DefaultSidecar sidecar.Handle = "secretstore-sidecar"
+	// DefaultMissingSecret is the name of the secret expected to be missing, unless set in the Seed.
+	DefaultMissingSecret = "this_secret_is_not_there"
+
+	// Starting the sidecar fails after this timeout instead of hanging the whole test run.
+	sidecarTimeout = 2 * time.Minute
+)
+
+// Seed describes the secrets that the secret store of RunStandardSuite holds.
+type Seed struct {
+	// StoreName is the name of the secret store component, as set in its YAML.
+	StoreName string
+	// Secrets are secrets of the store with all their keys and values.
+	Secrets map[string]map[string]string
+	// Exhaustive tells that the store holds no other secret, so that a bulk read returns exactly Secrets.
+	Exhaustive bool
+	// Missing is the name of a secret that the store doesn't hold, DefaultMissingSecret if empty.
+	Missing string
+	// Write, if set, writes the secrets to the service of the store, such as with vault.Seed. It runs after the
+	// steps of WithSetup and before the sidecar starts.
+	Write flow.Runnable
+}
+
+// SuiteOption configures RunStandardSuite.
+type SuiteOption func(*suite)
+
+type suite struct {
+	sidecar        sidecar.Handle
+	runtimeOptions []runtime.Option
+	setup          func(f *flow.Flow) *flow.Flow
+	features       bool
+	want, notWant  []secretstores.Feature
+	interruption   *network.Interruption
+	interruptFor   time.Duration
+	recoverWithin  time.Duration
+}
+
+// WithRuntimeOptions sets the options of the runtime of the sidecar, which must register the secret store.
+func WithRuntimeOptions(opts ...runtime.Option) SuiteOption {
+	return func(s *suite) {
+		s.runtimeOptions = append(s.runtimeOptions, opts...)
+	}
+}
+
+// WithSidecar sets the handle of the sidecar started by the suite, DefaultSidecar by default.
+func WithSidecar(sc sidecar.Handle) SuiteOption {
+	return func(s *suite) {
+		s.sidecar = sc
+	}
+}
+
+// WithSetup adds the steps that start the services of the secret store, along with their cleanups, at the start of
+// the flow.
+func WithSetup(setup func(f *flow.Flow) *flow.Flow) SuiteOption {
+	return func(s *suite) {
+		s.setup = setup
+	}
+}
+
+// WithFeatures checks that the secret store advertises every feature of want and none of notWant. The bulk read is
+// only checked when want has secretstores.FeatureBulkGetSecret, or without this option.
+func WithFeatures(want, notWant []secretstores.Feature) SuiteOption {
+	return func(s *suite) {
+		s.features = true
+		s.want = want
+		s.notWant = notWant
+	}
+}
+
+// WithNetworkInterruption interrupts the network between the secret store and its service for the given duration,
+// and checks that the secrets are read again within recoverWithin once it's restored.
+func WithNetworkInterruption(interruption *network.Interruption, duration, recoverWithin time.Duration) SuiteOption {
+	return func(s *suite) {
+		s.interruption = interruption
+		s.interruptFor = duration
+		s.recoverWithin = recoverWithin
+	}
+}
+
+// RunStandardSuite runs the baseline scenarios of a secret store in a flow: it starts a sidecar with the components
+// of componentPath, waits for the store of the seed to load without errors, and checks that:
+//
+//   - each secret of the seed is read with its keys and values;
+//   - the missing secret of the seed is reported as not found;
+//   - a bulk read returns the secrets of the seed, and only them if it's exhaustive;
+//   - the store advertises the expected features, with WithFeatures;
+//   - the secrets are read again after a network interruption, with WithNetworkInterruption.
+func RunStandardSuite(t *testing.T, componentPath string, seed Seed, opts ...SuiteOption) {
+	s := suite{sidecar: DefaultSidecar}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if len(s.runtimeOptions) == 0 {
+		t.Fatal("the runtime options registering the secret store are required, see WithRuntimeOptions")
+	}
+	missing := seed.Missing
+	if missing == "" {
+		missing = DefaultMissingSecret
+	}
+	names := make([]string, 0, len(seed.Secrets))
+	for name := range seed.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	f := flow.New(t, "Standard secret store suite of "+seed.StoreName)
+	if s.setup != nil {
+		f = s.setup(f)
+	}
+	if seed.Write != nil {
+		f.Step("Seed the secrets", seed.Write)
+	}
+	f.StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(s.sidecar.Name(),
+		embedded.WithoutApp(),
+		embedded.WithResourcesPath(componentPath),
+		s.runtimeOptions,
+		sidecar.WithLogCapture(),
+		sidecar.WithFreePorts(),
+	))).
+		Step(flow.Retry("Waiting for component to load...", ComponentFound(s.sidecar, seed.StoreName))).
+		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(s.sidecar, seed.StoreName))
+
+	readSecrets := func(when string) {
+		for _, name := range names {
+			f.Step("Read secret "+name+when, KeyValuesInSecret(s.sidecar, seed.StoreName, name, seed.Secrets[name]))
+		}
+	}
+
+	readSecrets("")
+	f.Step("Verify secret "+missing+" is not found", SecretIsNotFound(s.sidecar, seed.StoreName, missing))
+	if s.features {
+		f.Step("Verify the features of the component", sidecar.AssertCapabilities(s.sidecar.Name(), seed.StoreName, s.want, s.notWant))
+	}
+	if !s.features || secretstores.FeatureBulkGetSecret.IsPresent(s.want) {
+		if seed.Exhaustive {
+			f.Step("Verify bulk retrieval returns exactly the seeded secrets", BulkSecretsEqual(s.sidecar, seed.StoreName, seed.Secrets))
+		} else {
+			f.Step("Verify bulk retrieval returns the seeded secrets", BulkSecretsContain(s.sidecar, seed.StoreName, seed.Secrets))
+		}
+	}
+	if s.interruption != nil && len(names) > 0 {
+		f.Cleanup("Restore network", s.interruption.Restore).
+			Step("Interrupt network for "+s.interruptFor.String(), s.interruption.Interrupt(s.interruptFor)).
+			Step("Wait for component to recover", flow.Eventually(s.recoverWithin, time.Second,
+				SecretIsReadable(s.sidecar, seed.StoreName, names[0])))
+		readSecrets(" again after the interruption")
+	}
+
+	f.Run()
+}
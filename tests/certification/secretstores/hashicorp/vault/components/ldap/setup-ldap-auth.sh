@@ -0,0 +1,30 @@
+#!/bin/sh
+
+# Enables the LDAP auth method against the openldap service, and gives the
+# LDAP user the policy to read the secrets under the default prefix.
+
+set -eu
+
+vault auth enable ldap
+
+vault write auth/ldap/config \
+    url="ldap://openldap:1389" \
+    binddn="cn=admin,dc=example,dc=org" \
+    bindpass="adminpassword" \
+    userdn="ou=users,dc=example,dc=org" \
+    userattr="cn"
+
+vault policy write dapr-read - <<POLICY
+path "secret/data/dapr/*" {
+  capabilities = ["read"]
+}
+path "secret/metadata/dapr/*" {
+  capabilities = ["list"]
+}
+POLICY
+
+vault write auth/ldap/users/dapr-test-user policies=dapr-read
+
+vault kv put secret/dapr/multiplekeyvaluessecret first=1 second=2 third=3
+
+echo ✅ LDAP auth enabled and secrets set
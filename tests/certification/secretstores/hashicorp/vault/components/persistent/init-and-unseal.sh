@@ -0,0 +1,35 @@
+#!/bin/sh
+
+# Initializes the server on its first run, keeping its unseal key on the
+# vault_keys volume, then unseals it. On the first run, it also creates the
+# token used by the component and seeds the secrets, which the tests expect
+# to find again after the server restarts.
+
+set -eu
+
+INIT_FILE=/vault/keys/init.json
+
+# jsonValue prints the first string value of the key in the output of vault operator init
+jsonValue() {
+    tr -d '\n ' < "$INIT_FILE" | sed -n "s/.*\"$1\":\[*\"\([^\"]*\)\".*/\1/p"
+}
+
+FIRST_RUN=false
+if [ ! -s "$INIT_FILE" ]; then
+    vault operator init -key-shares=1 -key-threshold=1 -format=json > "$INIT_FILE"
+    FIRST_RUN=true
+fi
+
+vault operator unseal "$(jsonValue unseal_keys_b64)"
+
+if [ "$FIRST_RUN" = true ]; then
+    VAULT_TOKEN=$(jsonValue root_token)
+    export VAULT_TOKEN
+
+    vault secrets enable -path=secret kv-v2
+    # The token of the component, as set in components/default
+    vault token create -id="$VAULT_TOKEN_ID" -policy=root -orphan
+    vault kv put secret/dapr/multiplekeyvaluessecret first=1 second=2 third=3
+fi
+
+echo ✅ vault unsealed
@@ -0,0 +1,11 @@
+# Unlike the dev server, this one keeps its data on a volume, so that it survives restarts
+storage "file" {
+  path = "/vault/file"
+}
+
+listener "tcp" {
+  address     = "0.0.0.0:8200"
+  tls_disable = "true"
+}
+
+disable_mlock = true
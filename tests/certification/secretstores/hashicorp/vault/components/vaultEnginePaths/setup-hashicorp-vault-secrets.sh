@@ -0,0 +1,30 @@
+#!/bin/sh
+
+# Seeds two kv-v2 mounts with a secret of the same name, so the
+# certification test can assert the order in which they are searched.
+
+set -eu
+
+MAX_ATTEMPTS=30
+
+for attempt in `seq $MAX_ATTEMPTS`; do
+    if vault status &&
+        vault secrets enable -path=teamSecretsPath kv-v2 &&
+        vault secrets enable -path=sharedSecretsPath kv-v2 &&
+        vault kv put teamSecretsPath/dapr/sameNameSecret owner=team &&
+        vault kv put sharedSecretsPath/dapr/sameNameSecret owner=shared &&
+        vault kv put sharedSecretsPath/dapr/sharedOnlySecret owner=shared &&
+        vault kv get teamSecretsPath/dapr/sameNameSecret &&
+        vault kv get sharedSecretsPath/dapr/sharedOnlySecret ;
+    then
+        echo ✅ secrets set;
+        sleep 1;
+        exit 0;
+    else
+        echo "⏰ vault not available, waiting... - attempt $attempt of $MAX_ATTEMPTS";
+        sleep 1;
+    fi
+done;
+
+echo ❌ Failed to set secrets;
+exit 1
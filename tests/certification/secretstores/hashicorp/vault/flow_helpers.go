@@ -14,20 +14,26 @@ limitations under the License.
 package vault_test
 
 import (
+	"fmt"
+	"net/http"
+	"os"
 	"path/filepath"
+	"regexp"
 	"testing"
 	"time"
 
+	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/components-contrib/secretstores/hashicorp/vault"
+	"github.com/dapr/components-contrib/secretstores/local/file"
 	"github.com/dapr/components-contrib/tests/certification/embedded"
 	"github.com/dapr/components-contrib/tests/certification/flow"
 	"github.com/dapr/components-contrib/tests/certification/flow/dockercompose"
+	"github.com/dapr/components-contrib/tests/certification/flow/network"
 	"github.com/dapr/components-contrib/tests/certification/flow/sidecar"
+	"github.com/dapr/components-contrib/tests/certification/secretstores/common"
 	secretstores_loader "github.com/dapr/dapr/pkg/components/secretstores"
 	"github.com/dapr/dapr/pkg/runtime"
-	dapr_testing "github.com/dapr/dapr/pkg/testing"
 	"github.com/dapr/kit/logger"
-	"github.com/stretchr/testify/assert"
 )
 
 //
@@ -40,94 +46,152 @@ func componentRuntimeOptions() []runtime.Option {
 	secretStoreRegistry := secretstores_loader.NewRegistry()
 	secretStoreRegistry.Logger = log
 	secretStoreRegistry.RegisterComponent(vault.NewHashiCorpVaultSecretStore, "hashicorp.vault")
+	// Vault wrapped with the secret filter, which reads allowedSecrets and deniedSecrets, for TestVaultSecretFilter
+	secretStoreRegistry.RegisterComponent(func(l logger.Logger) secretstores.SecretStore {
+		return secretstores.NewFilteringStore(vault.NewHashiCorpVaultSecretStore(l))
+	}, "hashicorp.vault.filtered")
+	// Reads Vault first and falls back to a local file, with their metadata prefixed by "vault." and "file."
+	secretStoreRegistry.RegisterComponent(func(l logger.Logger) secretstores.SecretStore {
+		return secretstores.NewCompositeStore(
+			secretstores.CompositeDelegate{Name: "vault", Store: vault.NewHashiCorpVaultSecretStore(l)},
+			secretstores.CompositeDelegate{Name: "file", Store: file.NewLocalSecretStore(l)},
+		)
+	}, "composite")
 
 	return []runtime.Option{
 		runtime.WithSecretStores(secretStoreRegistry),
 	}
 }
 
-func GetCurrentGRPCAndHTTPPort(t *testing.T) (int, int) {
-	ports, err := dapr_testing.GetFreePorts(2)
-	assert.NoError(t, err)
+// runSidecar returns a step that starts a sidecar, named after its handle, with the components of componentPath. It
+// listens on reserved ports, including the profiling one, so that several sidecars can run in a flow.
+func runSidecar(sc sidecar.Handle, componentPath string) (string, time.Duration, flow.Runnable, flow.Runnable) {
+	return flow.Timeout(sidecarTimeout)(sidecar.Run(sc.Name(),
+		embedded.WithoutApp(),
+		embedded.WithResourcesPath(componentPath),
+		componentRuntimeOptions(),
+		sidecar.WithLogCapture(),
+		sidecar.WithFreePorts(),
+	))
+}
+
+// runVault returns a step that starts the Vault server of the compose file, and waits for it to be ready and to
+// accept connections on its published port. The project is given a unique name, so that other packages can start
+// the same compose file concurrently; the other dockercompose runnables of the flow target it when given
+// dockerComposeProjectName.
+func runVault(dockerComposeClusterYAML string) (string, flow.Runnable, flow.Runnable) {
+	name, run, stop := dockercompose.RunWithOptions(dockerComposeProjectName, dockerComposeClusterYAML,
+		dockercompose.UniqueProject(), dockercompose.WaitFor(dockerComposeTimeout))
+	waitForVault := network.WaitForTCP("127.0.0.1:"+vaultPort(dockerComposeClusterYAML), vaultListenTimeout)
+
+	return name, func(ctx flow.Context) error {
+		if err := run(ctx); err != nil {
+			return err
+		}
+		return waitForVault(ctx)
+	}, stop
+}
 
-	currentGrpcPort := ports[0]
-	currentHttpPort := ports[1]
+// publishedPortPattern matches the first port published by a compose file, which is Vault's in all of them.
+var publishedPortPattern = regexp.MustCompile(`(?m)^\s*-\s*['"]?(\d+):`)
 
-	return currentGrpcPort, currentHttpPort
+// vaultPort returns the port Vault is published on by the compose file, 8200 unless it sets another one.
+func vaultPort(dockerComposeClusterYAML string) string {
+	content, err := os.ReadFile(dockerComposeClusterYAML)
+	if err != nil {
+		return "8200"
+	}
+	if m := publishedPortPattern.FindSubmatch(content); m != nil {
+		return string(m[1])
+	}
+	return "8200"
 }
 
-//
-// Helper functions for common tests for happy case, init-but-does-not-work and fails-initialization tests
-// These test re-use the same seed secrets. They aim to check how certain flags break or keep vault working
-// instead of verifying some specific tha change key retrieval behavior.
-//
-// Those tests require a `<base dir>/<specific flow>` structure.
-
-type commonFlowSettings struct {
-	t                            *testing.T
-	currentGrpcPort              int
-	currentHttpPort              int
-	secretStoreComponentPathBase string
-	componentNamePrefix          string
+// waitForSidecar returns a step that waits until the healthz endpoint of the sidecar reports it's ready.
+func waitForSidecar(sc sidecar.Handle) (string, flow.Runnable) {
+	return "Wait for the sidecar to be healthy", func(ctx flow.Context) error {
+		url := fmt.Sprintf("http://127.0.0.1:%d/v1.0/healthz", sc.HTTPPort(ctx))
+		return network.WaitForHTTP(url, http.StatusNoContent, sidecarTimeout)(ctx)
+	}
 }
 
-func NewFlowSettings(t *testing.T) *commonFlowSettings {
-	res := commonFlowSettings{}
-	res.t = t
-	res.currentGrpcPort, res.currentHttpPort = GetCurrentGRPCAndHTTPPort(t)
-	return &res
+// testDefaultSecretIsFound asserts the secret seeded along with the Vault server of the compose files is read.
+func testDefaultSecretIsFound(sc sidecar.Handle, secretStoreName string) flow.Runnable {
+	return common.KeyValuesInSecret(sc, secretStoreName, "multiplekeyvaluessecret", map[string]string{
+		"first":  "1",
+		"second": "2",
+		"third":  "3",
+	})
 }
 
-func createPositiveTestFlow(fs *commonFlowSettings, flowDescription string, componentSuffix string, useCustomDockerCompose bool) {
-	componentPath := filepath.Join(fs.secretStoreComponentPathBase, componentSuffix)
-	componentName := fs.componentNamePrefix + componentSuffix
+func testComponentIsNotWorking(sc sidecar.Handle, targetComponentName string) flow.Runnable {
+	return common.SecretRetrievalFails(sc, targetComponentName, "multiplekeyvaluessecret")
+}
 
-	dockerComposeClusterYAML := defaultDockerComposeClusterYAML
-	if useCustomDockerCompose {
-		dockerComposeClusterYAML = filepath.Join(componentPath, "docker-compose-hashicorp-vault.yml")
-	}
+//
+// Component cases: the outcome of a component, among the happy case, init-but-does-not-work and
+// fails-initialization, when started against Vault. They re-use the same seed secrets, and check how certain flags
+// break or keep Vault working rather than some specific change of the retrieval of the secrets.
+//
 
-	flow.New(fs.t, flowDescription).
-		Step(dockercompose.Run(dockerComposeProjectName, dockerComposeClusterYAML)).
-		Step("Waiting for component to start...", flow.Sleep(5*time.Second)).
-		Step(sidecar.Run(sidecarName,
-			embedded.WithoutApp(),
-			embedded.WithResourcesPath(componentPath),
-			embedded.WithDaprGRPCPort(fs.currentGrpcPort),
-			embedded.WithDaprHTTPPort(fs.currentHttpPort),
-			componentRuntimeOptions(),
-		)).
-		Step("Waiting for component to load...", flow.Sleep(5*time.Second)).
-		Step("Verify component is registered", testComponentFound(componentName, fs.currentGrpcPort)).
-		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(componentPath)).
-		Step("Test that the default secret is found", testDefaultSecretIsFound(fs.currentGrpcPort, componentName)).
-		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
-		Run()
-}
+// outcome is the expected outcome of the component of a componentCase.
+type outcome int
+
+const (
+	// works is a component that initializes and reads the default secret.
+	works outcome = iota
+	// initOKButBroken is a component that initializes, as its problem can only be detected on use, but can't read
+	// the default secret.
+	initOKButBroken
+	// initFails is a component that fails to initialize, with an error mentioning the errors of the case.
+	initFails
+)
 
-func createInitSucceedsButComponentFailsFlow(fs *commonFlowSettings, flowDescription string, componentSuffix string, useCustomDockerCompose bool, initErrorCodes ...string) {
-	componentPath := filepath.Join(fs.secretStoreComponentPathBase, componentSuffix)
-	componentName := fs.componentNamePrefix + componentSuffix
+// componentCase is a component under a base directory of components, such as vaultAddr/missing, started against the
+// Vault server of the default compose file, or of the compose file of its directory with customDockerCompose.
+type componentCase struct {
+	description string
+	component   string
+	outcome     outcome
+	// customDockerCompose starts Vault with the docker-compose-hashicorp-vault.yml file of the component directory.
+	customDockerCompose bool
+	// errors are substrings of the initialization error of an initFails component.
+	errors []string
+}
 
-	dockerComposeClusterYAML := defaultDockerComposeClusterYAML
-	if useCustomDockerCompose {
-		dockerComposeClusterYAML = filepath.Join(componentPath, "docker-compose-hashicorp-vault.yml")
-	}
+func (c componentCase) CaseName() string {
+	return c.description
+}
 
-	flow.New(fs.t, flowDescription).
-		Step(dockercompose.Run(dockerComposeProjectName, dockerComposeClusterYAML)).
-		Step("Waiting for component to start...", flow.Sleep(5*time.Second)).
-		Step(sidecar.Run(sidecarName,
-			embedded.WithoutApp(),
-			embedded.WithResourcesPath(componentPath),
-			embedded.WithDaprGRPCPort(fs.currentGrpcPort),
-			embedded.WithDaprHTTPPort(fs.currentHttpPort),
-			componentRuntimeOptions(),
-		)).
-		Step("Waiting for component to load...", flow.Sleep(5*time.Second)).
-		Step("Verify component is registered", testComponentFound(componentName, fs.currentGrpcPort)).
-		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(componentPath)).
-		Step("Verify component does not work", testComponentIsNotWorking(componentName, fs.currentGrpcPort)).
-		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
-		Run()
+// runComponentCases runs a flow for each case, whose components are under base and named after their directory with
+// componentNamePrefix.
+func runComponentCases(t *testing.T, name, base, componentNamePrefix string, cases []componentCase) {
+	flow.Table(t, name, cases, func(f *flow.Flow, c componentCase) *flow.Flow {
+		componentPath := filepath.Join(base, c.component)
+		componentName := componentNamePrefix + c.component
+		dockerComposeClusterYAML := defaultDockerComposeClusterYAML
+		if c.customDockerCompose {
+			dockerComposeClusterYAML = filepath.Join(componentPath, "docker-compose-hashicorp-vault.yml")
+		}
+
+		f.Step(runVault(dockerComposeClusterYAML)).
+			Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
+			StepWithTimeout(runSidecar(vaultSidecar, componentPath)).
+			Step(waitForSidecar(vaultSidecar))
+
+		switch c.outcome {
+		case works:
+			return f.Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, componentName))).
+				Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentName)).
+				Step("Test that the default secret is found", testDefaultSecretIsFound(vaultSidecar, componentName))
+		case initOKButBroken:
+			return f.Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, componentName))).
+				Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentName)).
+				Step("Verify component does not work", testComponentIsNotWorking(vaultSidecar, componentName))
+		default:
+			return f.Step("Waiting for component to fail to load...", flow.Eventually(componentLoadTimeout, time.Second, common.InitializationFailed(vaultSidecar, componentName))).
+				Step("Verify component initialization failed", common.AssertInitializationFailedWithErrorsForComponent(vaultSidecar, componentName, c.errors...)).
+				Step("Verify component is not registered", common.ComponentNotFound(vaultSidecar, componentName))
+		}
+	})
 }
@@ -15,69 +15,93 @@ package vault_test
 
 import (
 	"context"
+	"fmt"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/components-contrib/tests/certification/embedded"
 	"github.com/dapr/components-contrib/tests/certification/flow"
 	"github.com/dapr/components-contrib/tests/certification/flow/dockercompose"
 	"github.com/dapr/components-contrib/tests/certification/flow/network"
+	"github.com/dapr/components-contrib/tests/certification/flow/ports"
 	"github.com/dapr/components-contrib/tests/certification/flow/sidecar"
+	"github.com/dapr/components-contrib/tests/certification/flow/toxiproxy"
+	"github.com/dapr/components-contrib/tests/certification/flow/vault"
+	"github.com/dapr/components-contrib/tests/certification/secretstores/common"
 )
 
 const (
 	sidecarName                     = "hashicorp-vault-sidecar"
 	defaultDockerComposeClusterYAML = "../../../../../.github/infrastructure/docker-compose-hashicorp-vault.yml"
 	dockerComposeProjectName        = "hashicorp-vault"
+	// The address and root token of the Vault server started by defaultDockerComposeClusterYAML
+	vaultAddr  = "http://127.0.0.1:8200"
+	vaultToken = "vault-dev-root-token-id"
 	// secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
 
 	networkInstabilityTime   = 1 * time.Minute
 	waitAfterInstabilityTime = networkInstabilityTime / 4
 	servicePortToInterrupt   = "8200"
+
+	// Starting Vault or the sidecar fails after these timeouts instead of hanging the whole test run, for example
+	// when an image can't be pulled.
+	dockerComposeTimeout = 5 * time.Minute
+	sidecarTimeout       = 2 * time.Minute
+	// The time given to Vault to accept connections once its container is healthy.
+	vaultListenTimeout = 30 * time.Second
+
+	// The time given to the sidecar to load or fail to load a component.
+	componentLoadTimeout = 30 * time.Second
 )
 
+// vaultSidecar is the handle of the sidecar started by the flows, which the assertion helpers target.
+const vaultSidecar sidecar.Handle = sidecarName
+
 func TestBasicSecretRetrieval(t *testing.T) {
-	const (
-		secretStoreComponentPath = "./components/default"
-		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
+	// Only the requests sent to Vault are dropped, leaving the traffic of the sidecar and of other tests alone
+	vaultInterruption := network.NewInterruption(
+		network.WithPorts(servicePortToInterrupt),
+		network.WithDirection(network.Inbound),
+		network.WithProtocols("tcp"),
+		network.WithDestinations("127.0.0.1"),
 	)
 
-	currentGrpcPort, currentHttpPort := GetCurrentGRPCAndHTTPPort(t)
-
 	// This test reuses the HashiCorp Vault's conformance test resources created using
 	// .github/infrastructure/docker-compose-hashicorp-vault.yml,
 	// so it reuses the tests/conformance/secretstores/secretstores.go test secrets.
-	testGetKnownSecret := testKeyValuesInSecret(currentGrpcPort, secretStoreName,
-		"secondsecret", map[string]string{
-			"secondsecret": "efgh",
-		})
-
-	testGetMissingSecret := testSecretIsNotFound(currentGrpcPort, secretStoreName, "this_secret_is_not_there")
-
-	flow.New(t, "Test component is up and we can retrieve some secrets").
-		Step(dockercompose.Run(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
-		Step("Waiting for component to start...", flow.Sleep(5*time.Second)).
-		Step(sidecar.Run(sidecarName,
-			embedded.WithoutApp(),
-			embedded.WithResourcesPath(secretStoreComponentPath),
-			embedded.WithDaprGRPCPort(currentGrpcPort),
-			embedded.WithDaprHTTPPort(currentHttpPort),
-			componentRuntimeOptions(),
-		)).
-		Step("Waiting for component to load...", flow.Sleep(5*time.Second)).
-		Step("Verify component is registered", testComponentFound(secretStoreName, currentGrpcPort)).
-		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(secretStoreComponentPath)).
-		Step("Run basic secret retrieval test", testGetKnownSecret).
-		Step("Test retrieval of secret that does not exist", testGetMissingSecret).
-		Step("Interrupt network for 1 minute",
-			network.InterruptNetwork(networkInstabilityTime, nil, nil, servicePortToInterrupt)).
-		Step("Wait for component to recover", flow.Sleep(waitAfterInstabilityTime)).
-		Step("Run basic test again to verify reconnection occurred", testGetKnownSecret).
-		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
-		Run()
+	common.RunStandardSuite(t, "./components/default", common.Seed{
+		StoreName: "my-hashicorp-vault", // as set in the component YAML
+		Secrets: map[string]map[string]string{
+			"secondsecret": {"secondsecret": "efgh"},
+			"multiplekeyvaluessecret": {
+				"first":  "1",
+				"second": "2",
+				"third":  "3",
+			},
+		},
+		Write: vault.Seed(vaultAddr, vaultToken, map[string]map[string]string{
+			"secret/dapr/multiplekeyvaluessecret": {
+				"first":  "1",
+				"second": "2",
+				"third":  "3",
+			},
+		}),
+	},
+		common.WithSidecar(vaultSidecar),
+		common.WithRuntimeOptions(componentRuntimeOptions()...),
+		common.WithSetup(func(f *flow.Flow) *flow.Flow {
+			return f.Step(runVault(defaultDockerComposeClusterYAML)).
+				Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML))
+		}),
+		common.WithFeatures([]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret, secretstores.FeatureBulkGetSecret}, nil),
+		common.WithNetworkInterruption(vaultInterruption, networkInstabilityTime, waitAfterInstabilityTime),
+	)
 }
 
 func TestMultipleKVRetrieval(t *testing.T) {
@@ -86,162 +110,261 @@ func TestMultipleKVRetrieval(t *testing.T) {
 		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
 	)
 
-	currentGrpcPort, currentHttpPort := GetCurrentGRPCAndHTTPPort(t)
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
 
 	flow.New(t, "Test retrieving multiple key values from a secret").
-		Step(dockercompose.Run(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
-		Step("Waiting for component to start...", flow.Sleep(5*time.Second)).
-		Step(sidecar.Run(sidecarName,
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		Step("Seed the secret with multiple key-values", vault.Seed(vaultAddr, vaultToken, map[string]map[string]string{
+			"secret/dapr/multiplekeyvaluessecret": {
+				"first":  "1",
+				"second": "2",
+				"third":  "3",
+			},
+		})).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
 			embedded.WithoutApp(),
 			embedded.WithResourcesPath(secretStoreComponentPath),
 			embedded.WithDaprGRPCPort(currentGrpcPort),
 			embedded.WithDaprHTTPPort(currentHttpPort),
 			componentRuntimeOptions(),
-		)).
-		Step("Waiting for component to load...", flow.Sleep(5*time.Second)).
-		Step("Verify component is registered", testComponentFound(secretStoreName, currentGrpcPort)).
-		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(secretStoreComponentPath)).
-		Step("Verify component has support for multiple key-values under the same secret",
-			testComponentHasFeature(currentGrpcPort, secretStoreName, secretstores.FeatureMultipleKeyValuesPerSecret)).
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, secretStoreComponentPath)).
+		Step("Verify component has support for multiple key-values under the same secret and bulk secret retrieval",
+			sidecar.AssertCapabilities(sidecarName, secretStoreName,
+				[]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret, secretstores.FeatureBulkGetSecret}, nil)).
 		Step("Test retrieval of a secret with multiple key-values",
-			testKeyValuesInSecret(currentGrpcPort, secretStoreName, "multiplekeyvaluessecret", map[string]string{
+			common.KeyValuesInSecret(vaultSidecar, secretStoreName, "multiplekeyvaluessecret", map[string]string{
 				"first":  "1",
 				"second": "2",
 				"third":  "3",
 			})).
 		Step("Test secret registered under a non-default vaultKVPrefix cannot be found",
-			testSecretIsNotFound(currentGrpcPort, secretStoreName, "secretUnderAlternativePrefix")).
-		Step("Test secret registered with no prefix cannot be found", testSecretIsNotFound(currentGrpcPort, secretStoreName, "secretWithNoPrefix")).
-		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+			common.SecretIsNotFound(vaultSidecar, secretStoreName, "secretUnderAlternativePrefix")).
+		Step("Test secret registered with no prefix cannot be found", common.SecretIsNotFound(vaultSidecar, secretStoreName, "secretWithNoPrefix")).
 		Run()
 }
 
-func TestVaultKVPrefix(t *testing.T) {
+// TestVaultKVPrefixes compares a non-default vaultKVPrefix and vaultKVUsePrefix=false with two sidecars, each
+// loading one configuration of the component, sharing a single Vault.
+func TestVaultKVPrefixes(t *testing.T) {
 	const (
-		secretStoreComponentPath = "./components/vaultKVPrefix"
+		secretStoreName = "my-hashicorp-vault" // as set in the component YAMLs
+
+		kvPrefixSidecar       sidecar.Handle = "hashicorp-vault-kvprefix-sidecar"
+		kvPrefixComponentPath                = "./components/vaultKVPrefix"
+		noPrefixSidecar       sidecar.Handle = "hashicorp-vault-noprefix-sidecar"
+		noPrefixComponentPath                = "./components/vaultKVUsePrefixFalse"
+	)
+
+	flow.New(t, "Test a non-default vaultKVPrefix and an empty one side by side").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		Step("Seed the secret with multiple key-values", vault.Seed(vaultAddr, vaultToken, map[string]map[string]string{
+			"secret/dapr/multiplekeyvaluessecret": {
+				"first":  "1",
+				"second": "2",
+				"third":  "3",
+			},
+		})).
+		StepWithTimeout(runSidecar(kvPrefixSidecar, kvPrefixComponentPath)).
+		StepWithTimeout(runSidecar(noPrefixSidecar, noPrefixComponentPath)).
+		Step(flow.Retry("Waiting for the component with a non-default vaultKVPrefix to load...",
+			common.ComponentFound(kvPrefixSidecar, secretStoreName))).
+		Step(flow.Retry("Waiting for the component with vaultKVUsePrefix=false to load...",
+			common.ComponentFound(noPrefixSidecar, secretStoreName))).
+		Step("Verify no errors regarding component initialization", flow.Parallel(
+			common.AssertNoInitializationErrorsForComponent(kvPrefixSidecar, kvPrefixComponentPath),
+			common.AssertNoInitializationErrorsForComponent(noPrefixSidecar, noPrefixComponentPath),
+		)).
+		Step("Verify both components have support for multiple key-values under the same secret and bulk secret retrieval", flow.Parallel(
+			sidecar.AssertCapabilities(kvPrefixSidecar.Name(), secretStoreName,
+				[]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret, secretstores.FeatureBulkGetSecret}, nil),
+			sidecar.AssertCapabilities(noPrefixSidecar.Name(), secretStoreName,
+				[]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret, secretstores.FeatureBulkGetSecret}, nil),
+		)).
+		// vaultKVPrefix
+		Step("Test retrieval of a secret under a non-default vaultKVPrefix",
+			common.KeyValuesInSecret(kvPrefixSidecar, secretStoreName, "secretUnderAlternativePrefix", map[string]string{
+				"altPrefixKey": "altPrefixValue",
+			})).
+		Step("Test secret registered with no prefix cannot be found with a non-default vaultKVPrefix",
+			common.SecretIsNotFound(kvPrefixSidecar, secretStoreName, "secretWithNoPrefix")).
+		Step("Test bulk retrieval only returns the secrets under the non-default vaultKVPrefix",
+			common.GetBulkSecretsReturnsNames(kvPrefixSidecar, secretStoreName, "secretUnderAlternativePrefix")).
+		// vaultKVUsePrefix=false
+		Step("Test retrieval of a secret registered with no prefix and assuming vaultKVUsePrefix=false",
+			common.KeyValuesInSecret(noPrefixSidecar, secretStoreName, "secretWithNoPrefix", map[string]string{
+				"noPrefixKey": "noProblem",
+			})).
+		Step("Test secret registered under the default vaultKVPrefix cannot be found with vaultKVUsePrefix=false",
+			common.SecretIsNotFound(noPrefixSidecar, secretStoreName, "multiplekeyvaluessecret")).
+		Step("Test secret registered under a non-default vaultKVPrefix cannot be found with vaultKVUsePrefix=false",
+			common.SecretIsNotFound(noPrefixSidecar, secretStoreName, "secretUnderAlternativePrefix")).
+		Step("Test bulk retrieval returns all the secrets of the engine, by their path from its root",
+			common.GetBulkSecretsReturnsNames(noPrefixSidecar, secretStoreName,
+				"secretWithNoPrefix",
+				"dapr/conftestsecret",
+				"dapr/secondsecret",
+				"dapr/multiplekeyvaluessecret",
+				"alternativePrefix/secretUnderAlternativePrefix",
+			)).
+		Run()
+}
+
+func TestVaultValueTypeText(t *testing.T) {
+	const (
+		secretStoreComponentPath = "./components/vaultValueTypeText"
 		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
 	)
 
-	currentGrpcPort, currentHttpPort := GetCurrentGRPCAndHTTPPort(t)
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
 
-	flow.New(t, "Test setting a non-default vaultKVPrefix value").
-		Step(dockercompose.Run(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
-		Step("Waiting for component to start...", flow.Sleep(5*time.Second)).
-		Step(sidecar.Run(sidecarName,
+	flow.New(t, "Test setting vaultValueType=text should cause it to behave with single-value semantics").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		Step("Seed the secret with multiple key-values", vault.Seed(vaultAddr, vaultToken, map[string]map[string]string{
+			"secret/dapr/multiplekeyvaluessecret": {
+				"first":  "1",
+				"second": "2",
+				"third":  "3",
+			},
+		})).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
 			embedded.WithoutApp(),
 			embedded.WithResourcesPath(secretStoreComponentPath),
 			embedded.WithDaprGRPCPort(currentGrpcPort),
 			embedded.WithDaprHTTPPort(currentHttpPort),
 			componentRuntimeOptions(),
-		)).
-		Step("Waiting for component to load...", flow.Sleep(5*time.Second)).
-		Step("Verify component is registered", testComponentFound(secretStoreName, currentGrpcPort)).
-		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(secretStoreComponentPath)).
-		Step("Verify component has support for multiple key-values under the same secret",
-			testComponentHasFeature(currentGrpcPort, secretStoreName, secretstores.FeatureMultipleKeyValuesPerSecret)).
-		Step("Test retrieval of a secret under a non-default vaultKVPrefix",
-			testKeyValuesInSecret(currentGrpcPort, secretStoreName, "secretUnderAlternativePrefix", map[string]string{
-				"altPrefixKey": "altPrefixValue",
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, secretStoreComponentPath)).
+		Step("Verify component supports bulk secret retrieval but NOT multiple key-values under the same secret",
+			sidecar.AssertCapabilities(sidecarName, secretStoreName,
+				[]secretstores.Feature{secretstores.FeatureBulkGetSecret},
+				[]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret})).
+		Step("Test secret store presents name/value semantics for secrets",
+			// result has a single key with tha same name as the secret and a JSON-like content
+			common.KeyValuesInSecret(vaultSidecar, secretStoreName, "secondsecret", map[string]string{
+				"secondsecret": "{\"secondsecret\":\"efgh\"}",
 			})).
-		Step("Test secret registered with no prefix cannot be found", testSecretIsNotFound(currentGrpcPort, secretStoreName, "secretWithNoPrefix")).
-		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		Step("Test a secret with multiple key-values is returned as a single JSON-like value",
+			common.KeyValuesInSecret(vaultSidecar, secretStoreName, "multiplekeyvaluessecret", map[string]string{
+				"multiplekeyvaluessecret": `{"first":"1","second":"2","third":"3"}`,
+			})).
+		Step("Test bulk retrieval returns each secret as a single JSON-like value under its name",
+			common.BulkSecretsContain(vaultSidecar, secretStoreName, map[string]map[string]string{
+				"secondsecret":            {"secondsecret": `{"secondsecret":"efgh"}`},
+				"multiplekeyvaluessecret": {"multiplekeyvaluessecret": `{"first":"1","second":"2","third":"3"}`},
+			})).
+		Step("Test bulk retrieval returns the secrets as they're retrieved one by one",
+			common.BulkSecretsMatchGet(vaultSidecar, secretStoreName)).
+		Step("Test secret registered under a non-default vaultKVPrefix cannot be found",
+			common.SecretIsNotFound(vaultSidecar, secretStoreName, "secretUnderAlternativePrefix")).
+		Step("Test secret registered with no prefix cannot be found", common.SecretIsNotFound(vaultSidecar, secretStoreName, "secretWithNoPrefix")).
 		Run()
 }
 
-func TestVaultKVUsePrefixFalse(t *testing.T) {
+func TestVaultValueTypeTextValueKey(t *testing.T) {
 	const (
-		secretStoreComponentPath = "./components/vaultKVUsePrefixFalse"
+		secretStoreComponentPath = "./components/vaultValueTypeTextValueKey"
 		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
 	)
 
-	currentGrpcPort, currentHttpPort := GetCurrentGRPCAndHTTPPort(t)
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
 
-	flow.New(t, "Test using an empty vaultKVPrefix value").
-		Step(dockercompose.Run(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
-		Step("Waiting for component to start...", flow.Sleep(5*time.Second)).
-		Step(sidecar.Run(sidecarName,
+	flow.New(t, "Test setting textValueKey with vaultValueType=text should return the value under a fixed key").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
 			embedded.WithoutApp(),
 			embedded.WithResourcesPath(secretStoreComponentPath),
 			embedded.WithDaprGRPCPort(currentGrpcPort),
 			embedded.WithDaprHTTPPort(currentHttpPort),
 			componentRuntimeOptions(),
-		)).
-		Step("Waiting for component to load...", flow.Sleep(5*time.Second)).
-		Step("Verify component is registered", testComponentFound(secretStoreName, currentGrpcPort)).
-		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(secretStoreComponentPath)).
-		Step("Verify component has support for multiple key-values under the same secret",
-			testComponentHasFeature(currentGrpcPort, secretStoreName, secretstores.FeatureMultipleKeyValuesPerSecret)).
-		Step("Test retrieval of a secret registered with no prefix and assuming vaultKVUsePrefix=false",
-			testKeyValuesInSecret(currentGrpcPort, secretStoreName, "secretWithNoPrefix", map[string]string{
-				"noPrefixKey": "noProblem",
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, secretStoreComponentPath)).
+		Step("Verify component supports bulk secret retrieval but NOT multiple key-values under the same secret",
+			sidecar.AssertCapabilities(sidecarName, secretStoreName,
+				[]secretstores.Feature{secretstores.FeatureBulkGetSecret},
+				[]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret})).
+		Step("Test secret value is returned under the configured key",
+			common.KeyValuesInSecret(vaultSidecar, secretStoreName, "secondsecret", map[string]string{
+				"value": "{\"secondsecret\":\"efgh\"}",
 			})).
-		Step("Test secret registered under the default vaultKVPrefix cannot be found",
-			testSecretIsNotFound(currentGrpcPort, secretStoreName, "multiplekeyvaluessecret")).
-		Step("Test secret registered under a non-default vaultKVPrefix cannot be found",
-			testSecretIsNotFound(currentGrpcPort, secretStoreName, "secretUnderAlternativePrefix")).
-		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
 		Run()
 }
 
-func TestVaultValueTypeText(t *testing.T) {
+func TestVaultValueTypeTextRawData(t *testing.T) {
 	const (
-		secretStoreComponentPath = "./components/vaultValueTypeText"
+		secretStoreComponentPath = "./components/vaultValueTypeTextRawData"
 		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
 	)
 
-	currentGrpcPort, currentHttpPort := GetCurrentGRPCAndHTTPPort(t)
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
 
-	flow.New(t, "Test setting vaultValueType=text should cause it to behave with single-value semantics").
-		Step(dockercompose.Run(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
-		Step("Waiting for component to start...", flow.Sleep(5*time.Second)).
-		Step(sidecar.Run(sidecarName,
+	flow.New(t, "Test setting textRawData with vaultValueType=text should return the secret data as-is").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
 			embedded.WithoutApp(),
 			embedded.WithResourcesPath(secretStoreComponentPath),
 			embedded.WithDaprGRPCPort(currentGrpcPort),
 			embedded.WithDaprHTTPPort(currentHttpPort),
 			componentRuntimeOptions(),
-		)).
-		Step("Waiting for component to load...", flow.Sleep(5*time.Second)).
-		Step("Verify component is registered", testComponentFound(secretStoreName, currentGrpcPort)).
-		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(secretStoreComponentPath)).
-		Step("Verify component DOES NOT support  multiple key-values under the same secret",
-			testComponentDoesNotHaveFeature(currentGrpcPort, secretStoreName, secretstores.FeatureMultipleKeyValuesPerSecret)).
-		Step("Test secret store presents name/value semantics for secrets",
-			// result has a single key with tha same name as the secret and a JSON-like content
-			testKeyValuesInSecret(currentGrpcPort, secretStoreName, "secondsecret", map[string]string{
-				"secondsecret": "{\"secondsecret\":\"efgh\"}",
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, secretStoreComponentPath)).
+		Step("Test secret data is returned without being wrapped under the secret name",
+			common.KeyValuesInSecret(vaultSidecar, secretStoreName, "secondsecret", map[string]string{
+				"secondsecret": "efgh",
+			})).
+		Step("Test all the fields of a secret with multiple key-values are returned",
+			common.KeyValuesInSecret(vaultSidecar, secretStoreName, "multiplekeyvaluessecret", map[string]string{
+				"first":  "1",
+				"second": "2",
+				"third":  "3",
 			})).
-		Step("Test secret registered under a non-default vaultKVPrefix cannot be found",
-			testSecretIsNotFound(currentGrpcPort, secretStoreName, "secretUnderAlternativePrefix")).
-		Step("Test secret registered with no prefix cannot be found", testSecretIsNotFound(currentGrpcPort, secretStoreName, "secretWithNoPrefix")).
-		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
 		Run()
 }
 
+func TestTokenAndTokenMountPath(t *testing.T) {
+	runComponentCases(t, "vaultTokenAndTokenMountPath", "./components/vaultTokenAndTokenMountPath/",
+		"my-hashicorp-vault-TestTokenAndTokenMountPath-", []componentCase{
+			{description: "Verify initialization success but use failure when vaultToken is not the token of the server", component: "badVaultToken", outcome: initOKButBroken},
+			{description: "Verify success when the token is read from vaultTokenMountPath", component: "tokenMountPathHappyCase", outcome: works},
+			{description: "Verify initialization failure when vaultTokenMountPath points to a broken path", component: "tokenMountPathPointsToBrokenPath", outcome: initFails, errors: []string{"couldn't read vault token from mount path"}},
+			{description: "Verify initialization failure when both vaultToken and vaultTokenMountPath are missing", component: "neither", outcome: initFails, errors: []string{"token mount path and token not set"}},
+			{description: "Verify initialization failure when both vaultToken and vaultTokenMountPath are present", component: "both", outcome: initFails, errors: []string{"token mount path and token both set"}},
+			{description: "Verify every problem is reported when both vaultToken and vaultTokenMountPath are present and vaultAddr is malformed", component: "bothAndMalformedAddress", outcome: initFails, errors: []string{"token mount path and token both set", "invalid vaultAddr"}},
+		})
+}
+
 func TestVaultAddr(t *testing.T) {
-	fs := NewFlowSettings(t)
-	fs.secretStoreComponentPathBase = "./components/vaultAddr/"
-	fs.componentNamePrefix = "my-hashicorp-vault-TestVaultAddr-"
-
-	createInitSucceedsButComponentFailsFlow(fs,
-		"Verify initialization success but use failure when vaultAddr does not point to a valid vault server address",
-		"wrongAddress",
-		false)
-
-	createPositiveTestFlow(fs,
-		"Verify success when vaultAddr is missing and skipVerify is true and vault is using a self-signed certificate",
-		"missing",
-		true)
-
-	createPositiveTestFlow(fs,
-		"Verify success when vaultAddr points to a non-standard port",
-		"nonStdPort",
-		true)
-
-	createInitSucceedsButComponentFailsFlow(fs,
-		"Verify initialization success but use failure when vaultAddr is missing and skipVerify is true and vault is using its own self-signed certificate",
-		"missingSkipVerifyFalse",
-		true)
+	runComponentCases(t, "vaultAddr", "./components/vaultAddr/", "my-hashicorp-vault-TestVaultAddr-", []componentCase{
+		// wrongAddress is a well-formed address nobody listens on: it can only be detected on first use.
+		{description: "Verify initialization success but use failure when vaultAddr does not point to a valid vault server address", component: "wrongAddress", outcome: initOKButBroken},
+		{description: "Verify initialization failure when vaultAddr is malformed", component: "malformedAddress", outcome: initFails, errors: []string{"invalid vaultAddr"}},
+		{description: "Verify success when vaultAddr is missing and skipVerify is true and vault is using a self-signed certificate", component: "missing", outcome: works, customDockerCompose: true},
+		{description: "Verify success when vaultAddr points to a non-standard port", component: "nonStdPort", outcome: works, customDockerCompose: true},
+		{description: "Verify initialization success but use failure when vaultAddr is missing and skipVerify is true and vault is using its own self-signed certificate", component: "missingSkipVerifyFalse", outcome: initOKButBroken, customDockerCompose: true},
+		{description: "Verify initialization failure when vaultAddr is missing and skipVerify is true but tlsStrict is enabled", component: "missingTlsStrict", outcome: initFails, customDockerCompose: true, errors: []string{"skipVerify is not allowed when tlsStrict is enabled"}},
+	})
+}
+
+func TestVaultAddrTLSStrictFromEnvironment(t *testing.T) {
+	// The sidecar runs in-process, so this environment-wide override applies to the component under test.
+	t.Setenv("DAPR_HASHICORP_VAULT_TLS_STRICT", "true")
+
+	runComponentCases(t, "vaultAddr", "./components/vaultAddr/", "my-hashicorp-vault-TestVaultAddr-", []componentCase{
+		{description: "Verify initialization failure when skipVerify is true and tlsStrict is enforced through the environment", component: "missing", outcome: initFails, customDockerCompose: true, errors: []string{"skipVerify is not allowed when tlsStrict is enabled"}},
+	})
 }
 
 func TestEnginePathCustomSecretsPath(t *testing.T) {
@@ -252,57 +375,157 @@ func TestEnginePathCustomSecretsPath(t *testing.T) {
 		componentName                = componentNamePrefix + componentSuffix
 	)
 
-	currentGrpcPort, currentHttpPort := GetCurrentGRPCAndHTTPPort(t)
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
 
 	componentPath := filepath.Join(secretStoreComponentPathBase, componentSuffix)
 	dockerComposeClusterYAML := filepath.Join(componentPath, "docker-compose-hashicorp-vault.yml")
 
 	flow.New(t, "Verify success when we set enginePath to a non-std value").
-		Step(dockercompose.Run(dockerComposeProjectName, dockerComposeClusterYAML)).
-		Step("Waiting for component to start...", flow.Sleep(5*time.Second)).
-		Step(sidecar.Run(sidecarName,
+		Step(runVault(dockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
 			embedded.WithoutApp(),
 			embedded.WithResourcesPath(componentPath),
 			embedded.WithDaprGRPCPort(currentGrpcPort),
 			embedded.WithDaprHTTPPort(currentHttpPort),
-			// Dapr log-level debug?
+			embedded.WithLogLevel("debug"),
 			componentRuntimeOptions(),
-		)).
-		Step("Waiting for component to load...", flow.Sleep(5*time.Second)).
-		Step("Verify component is registered", testComponentFound(componentName, currentGrpcPort)).
-		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(componentPath)).
-		Step("Verify that the custom path has secrets under it", testGetBulkSecretsWorksAndFoundKeys(currentGrpcPort, componentName)).
-		Step("Verify that the custom path-specific secret is found", testKeyValuesInSecret(currentGrpcPort, componentName,
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, componentName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentPath)).
+		Step("Verify that the custom path has exactly its secret under it", common.BulkSecretsEqual(vaultSidecar, componentName,
+			map[string]map[string]string{
+				"secretUnderCustomPath": {"the": "trick", "was": "the", "path": "parameter"},
+			})).
+		Step("Verify that the custom path-specific secret is found", common.KeyValuesInSecret(vaultSidecar, componentName,
 			"secretUnderCustomPath", map[string]string{
 				"the":  "trick",
 				"was":  "the",
 				"path": "parameter",
 			})).
-		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
 		Run()
 }
 
-func TestEnginePathSecrets(t *testing.T) {
-	fs := NewFlowSettings(t)
-	fs.secretStoreComponentPathBase = "./components/enginePath/"
-	fs.componentNamePrefix = "my-hashicorp-vault-TestEnginePath-"
+func TestVaultEnginePaths(t *testing.T) {
+	const (
+		componentPath = "./components/vaultEnginePaths"
+		componentName = "my-hashicorp-vault-TestVaultEnginePaths"
+	)
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+
+	dockerComposeClusterYAML := filepath.Join(componentPath, "docker-compose-hashicorp-vault.yml")
 
-	createPositiveTestFlow(fs,
-		"Verify success when vaultEngine explicitly uses the secrets engine",
-		"secret", false)
+	flow.New(t, "Verify vaultEnginePaths are searched in the configured order").
+		Step(runVault(dockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(componentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, componentName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentPath)).
+		Step("Verify a secret present under both engine paths is read from the first one",
+			common.KeyValuesInSecret(vaultSidecar, componentName, "sameNameSecret", map[string]string{
+				"owner": "team",
+			})).
+		Step("Verify a secret missing from the first engine path is read from the second one",
+			common.KeyValuesInSecret(vaultSidecar, componentName, "sharedOnlySecret", map[string]string{
+				"owner": "shared",
+			})).
+		Step("Verify a secret missing from every engine path is not found",
+			common.SecretIsNotFound(vaultSidecar, componentName, "multiplekeyvaluessecret")).
+		Run()
+}
+
+func TestVaultSecretFilter(t *testing.T) {
+	const (
+		componentPath = "./components/secretFilter"
+		componentName = "my-hashicorp-vault-TestSecretFilter"
+	)
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+
+	flow.New(t, "Verify allowedSecrets and deniedSecrets restrict the secrets read through the sidecar").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(componentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, componentName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentPath)).
+		Step("Verify the filter doesn't change the advertised capabilities",
+			sidecar.AssertCapabilities(sidecarName, componentName,
+				[]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret, secretstores.FeatureBulkGetSecret}, nil)).
+		Step("Verify an allowed secret is found", testDefaultSecretIsFound(vaultSidecar, componentName)).
+		Step("Verify a denied secret is not found, even though it's allowed",
+			common.SecretIsNotFound(vaultSidecar, componentName, "secondsecret")).
+		Step("Verify bulk reads only return the allowed secrets",
+			common.GetBulkSecretsReturnsNames(vaultSidecar, componentName, "conftestsecret", "multiplekeyvaluessecret")).
+		Run()
+}
+
+func TestCompositeSecretStore(t *testing.T) {
+	const (
+		componentPath = "./components/composite"
+		componentName = "my-composite-TestCompositeSecretStore"
+	)
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+
+	flow.New(t, "Verify a composite store reads Vault first and falls back to a local file").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(componentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, componentName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentPath)).
+		Step("Verify Vault takes precedence over the local file",
+			common.KeyValuesInSecret(vaultSidecar, componentName, "conftestsecret", map[string]string{"conftestsecret": "abcd"})).
+		Step("Verify secrets missing in Vault are read from the local file",
+			common.KeyValuesInSecret(vaultSidecar, componentName, "fileonlysecret", map[string]string{"fileonlysecret": "fromFile"})).
+		Step("Verify a secret missing in both stores is not found",
+			common.SecretIsNotFound(vaultSidecar, componentName, "missingsecret")).
+		Step("Verify bulk reads merge the secrets of both stores",
+			common.GetBulkSecretsReturnsNames(vaultSidecar, componentName,
+				"conftestsecret", "secondsecret", "multiplekeyvaluessecret", "fileonlysecret")).
+		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		Step("Verify Vault errors aren't masked by the local file",
+			common.SecretRetrievalFails(vaultSidecar, componentName, "fileonlysecret")).
+		Run()
+}
+
+func TestEnginePathSecrets(t *testing.T) {
+	runComponentCases(t, "enginePath", "./components/enginePath/", "my-hashicorp-vault-TestEnginePath-", []componentCase{
+		{description: "Verify success when vaultEngine explicitly uses the secrets engine", component: "secret", outcome: works},
+	})
 }
 
 func TestCaFamilyOfFields(t *testing.T) {
-	fs := NewFlowSettings(t)
-	fs.secretStoreComponentPathBase = "./components/caFamily/"
-	fs.componentNamePrefix = "my-hashicorp-vault-TestCaFamilyOfFields-"
+	const componentPathBase = "./components/caFamily/"
 
 	// Generate certificates and caPem/hashicorp-vault.yml
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	defer cancel()
 	makeCmd := exec.CommandContext(ctx, "make",
 		// Change to components/caFamily directory so files are generated relative to that directory
-		"-C", fs.secretStoreComponentPathBase,
+		"-C", componentPathBase,
 	)
 
 	if out, err := makeCmd.CombinedOutput(); err != nil {
@@ -310,29 +533,14 @@ func TestCaFamilyOfFields(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	createPositiveTestFlow(fs,
-		"Verify success when using a caCert to talk to vault with tlsServerName and enforceVerify",
-		"caCert", true)
-
-	createPositiveTestFlow(fs,
-		"Verify success when using a caPath to talk to vault with tlsServerName and enforceVerify",
-		"caCert", true)
-
-	createPositiveTestFlow(fs,
-		"Verify success when using a caPem to talk to vault with tlsServerName and enforceVerify",
-		"caPem", true)
-
-	createInitSucceedsButComponentFailsFlow(fs,
-		"Verify successful initialization but secret retrieval failure when `caPem` is set to a valid server certificate (baseline) but `tlsServerName` does not match the server name",
-		"badTlsServerName", true)
-
-	createInitSucceedsButComponentFailsFlow(fs,
-		"Verify successful initialization but secret retrieval failure when `caPem` is set to an invalid server certificate (flag under test) despite `tlsServerName` matching the server name ",
-		"badCaCert", true)
-
-	createPositiveTestFlow(fs,
-		"Verify success when using a caPem is invalid but skipVerify is on",
-		"badCaCertAndSkipVerify", true)
+	runComponentCases(t, "caFamily", componentPathBase, "my-hashicorp-vault-TestCaFamilyOfFields-", []componentCase{
+		{description: "Verify success when using a caCert to talk to vault with tlsServerName and enforceVerify", component: "caCert", outcome: works, customDockerCompose: true},
+		{description: "Verify success when using a caPath to talk to vault with tlsServerName and enforceVerify", component: "caCert", outcome: works, customDockerCompose: true},
+		{description: "Verify success when using a caPem to talk to vault with tlsServerName and enforceVerify", component: "caPem", outcome: works, customDockerCompose: true},
+		{description: "Verify successful initialization but secret retrieval failure when `caPem` is set to a valid server certificate (baseline) but `tlsServerName` does not match the server name", component: "badTlsServerName", outcome: initOKButBroken, customDockerCompose: true},
+		{description: "Verify successful initialization but secret retrieval failure when `caPem` is set to an invalid server certificate (flag under test) despite `tlsServerName` matching the server name ", component: "badCaCert", outcome: initOKButBroken, customDockerCompose: true},
+		{description: "Verify success when using a caPem is invalid but skipVerify is on", component: "badCaCertAndSkipVerify", outcome: works, customDockerCompose: true},
+	})
 }
 
 func TestVersioning(t *testing.T) {
@@ -342,31 +550,413 @@ func TestVersioning(t *testing.T) {
 	)
 	dockerComposeClusterYAML := filepath.Join(componentPath, "docker-compose-hashicorp-vault.yml")
 
-	currentGrpcPort, currentHttpPort := GetCurrentGRPCAndHTTPPort(t)
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
 
 	flow.New(t, "Verify success on retrieval of a past version of a secret").
-		Step(dockercompose.Run(dockerComposeProjectName, dockerComposeClusterYAML)).
-		Step("Waiting for component to start...", flow.Sleep(5*time.Second)).
-		Step(sidecar.Run(sidecarName,
+		Step(runVault(dockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
 			embedded.WithoutApp(),
 			embedded.WithResourcesPath(componentPath),
 			embedded.WithDaprGRPCPort(currentGrpcPort),
 			embedded.WithDaprHTTPPort(currentHttpPort),
-			// Dapr log-level debug?
+			embedded.WithLogLevel("debug"),
 			componentRuntimeOptions(),
-		)).
-		Step("Waiting for component to load...", flow.Sleep(5*time.Second)).
-		Step("Verify component is registered", testComponentFound(componentName, currentGrpcPort)).
-		Step("Verify no errors regarding component initialization", AssertNoInitializationErrorsForComponent(componentPath)).
-		Step("Verify that we can list secrets", testGetBulkSecretsWorksAndFoundKeys(currentGrpcPort, componentName)).
-		Step("Verify that the latest version of the secret is there", testKeyValuesInSecret(currentGrpcPort, componentName,
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, componentName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentPath)).
+		Step("Verify that we can list secrets", common.GetBulkSecretsWorksAndFoundKeys(vaultSidecar, componentName)).
+		Step("Verify that the latest version of the secret is there", common.KeyValuesInSecret(vaultSidecar, componentName,
 			"secretUnderTest", map[string]string{
 				"versionedKey": "latestValue",
 			})).
-		Step("Verify that a past version of the secret is there", testKeyValuesInSecret(currentGrpcPort, componentName,
+		Step("Verify that a past version of the secret is there", common.KeyValuesInSecret(vaultSidecar, componentName,
 			"secretUnderTest", map[string]string{
 				"versionedKey": "secondVersion",
 			}, "2")).
-		Step("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
+		Run()
+}
+
+func TestLDAPAuth(t *testing.T) {
+	const (
+		componentPath = "./components/ldap/"
+		componentName = "my-hashicorp-vault-TestLDAPAuth"
+	)
+	dockerComposeClusterYAML := filepath.Join(componentPath, "docker-compose-hashicorp-vault.yml")
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+
+	flow.New(t, "Verify secrets can be retrieved after logging in with the LDAP auth method").
+		Step(runVault(dockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(componentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, componentName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentPath)).
+		Step("Verify the secret is retrieved with the token of the LDAP user", testDefaultSecretIsFound(vaultSidecar, componentName)).
+		Step("Verify the password of the LDAP user is not logged", flow.AssertLogNotContains(sidecarName, "dapr-test-password")).
+		Run()
+}
+
+func TestLazyInit(t *testing.T) {
+	const (
+		componentPath = "./components/lazyInit/"
+		componentName = "my-hashicorp-vault-TestLazyInit"
+	)
+	// The component logs in with the LDAP user of the compose file of TestLDAPAuth
+	dockerComposeClusterYAML := filepath.Join("./components/ldap/", "docker-compose-hashicorp-vault.yml")
+
+	flow.New(t, "Verify a component started before Vault works once Vault is up").
+		StepWithTimeout(runSidecar(vaultSidecar, componentPath)).
+		Step(waitForSidecar(vaultSidecar)).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, componentName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentName)).
+		Step("Verify the secret can't be retrieved while Vault is down", testComponentIsNotWorking(vaultSidecar, componentName)).
+		Step(runVault(dockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
+		Step("Verify the secret is retrieved once Vault is up", testDefaultSecretIsFound(vaultSidecar, componentName)).
+		Run()
+}
+
+func TestSecretVersionWrittenMidFlow(t *testing.T) {
+	const (
+		secretStoreComponentPath = "./components/default"
+		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
+		vaultService             = "hashicorp_vault"
+		execTimeoutSeconds       = 30
+	)
+
+	// vaultCLI runs the vault CLI in the container of the server, against the server and with its root token.
+	vaultCLI := func(args ...string) []string {
+		return append([]string{"env", "VAULT_ADDR=" + vaultAddr, "VAULT_TOKEN=" + vaultToken, "vault"}, args...)
+	}
+	compose := dockercompose.New(dockerComposeProjectName, defaultDockerComposeClusterYAML)
+	metadataOutput := flow.NewKey[string]("secret metadata")
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+
+	flow.New(t, "Verify a version of a secret written while the sidecar runs is retrieved").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		Step("Write the first version of the secret", compose.Exec(vaultService, execTimeoutSeconds,
+			vaultCLI("kv", "put", "secret/dapr/secretWrittenMidFlow", "versionedKey=firstVersion")...)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(secretStoreComponentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify the first version of the secret is retrieved", common.KeyValuesInSecret(vaultSidecar, secretStoreName,
+			"secretWrittenMidFlow", map[string]string{
+				"versionedKey": "firstVersion",
+			})).
+		Step("Write a second version of the secret", compose.Exec(vaultService, execTimeoutSeconds,
+			vaultCLI("kv", "put", "secret/dapr/secretWrittenMidFlow", "versionedKey=secondVersion")...)).
+		Step("Read the metadata of the secret", compose.ExecOutput(vaultService, execTimeoutSeconds, metadataOutput,
+			vaultCLI("kv", "metadata", "get", "-format=json", "secret/dapr/secretWrittenMidFlow")...)).
+		Step("Verify Vault has two versions of the secret", func(ctx flow.Context) error {
+			assert.Contains(t, metadataOutput.MustGet(ctx), `"current_version": 2`)
+			return nil
+		}).
+		Step("Verify the second version of the secret is retrieved", common.KeyValuesInSecret(vaultSidecar, secretStoreName,
+			"secretWrittenMidFlow", map[string]string{
+				"versionedKey": "secondVersion",
+			})).
+		Step("Verify the first version of the secret is still retrieved by its version", common.KeyValuesInSecret(vaultSidecar, secretStoreName,
+			"secretWrittenMidFlow", map[string]string{
+				"versionedKey": "firstVersion",
+			}, "1")).
+		Run()
+}
+
+func TestVaultPaused(t *testing.T) {
+	const (
+		secretStoreComponentPath = "./components/default"
+		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
+		vaultService             = "hashicorp_vault"
+		vaultPauseTime           = 30 * time.Second
+		retrievalTimeout         = 5 * time.Second
+	)
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+
+	flow.New(t, "Verify the component recovers from a frozen Vault without restarting the sidecar").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(secretStoreComponentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify the secret is retrieved", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Cleanup("Unpause Vault", dockercompose.Unpause(dockerComposeProjectName, defaultDockerComposeClusterYAML, vaultService)).
+		Step("Pause Vault", dockercompose.Pause(dockerComposeProjectName, defaultDockerComposeClusterYAML, vaultService)).
+		Step("Verify retrieving the secret times out", common.SecretRetrievalTimesOut(vaultSidecar, secretStoreName,
+			"multiplekeyvaluessecret", retrievalTimeout)).
+		Step("Keep Vault paused", flow.Sleep(vaultPauseTime-retrievalTimeout)).
+		Step("Unpause Vault", dockercompose.Unpause(dockerComposeProjectName, defaultDockerComposeClusterYAML, vaultService)).
+		Step("Wait for component to recover", flow.Eventually(waitAfterInstabilityTime, time.Second,
+			common.SecretIsReadable(vaultSidecar, secretStoreName, "multiplekeyvaluessecret"))).
+		Step("Verify the secret is retrieved again", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Run()
+}
+
+func TestVaultDegradedNetwork(t *testing.T) {
+	const (
+		secretStoreComponentPath = "./components/default"
+		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
+		latency                  = 500 * time.Millisecond
+		jitter                   = 50 * time.Millisecond
+		packetLossPercent        = 10
+		// The timeout of each read by the client: retries of the component must complete within it
+		clientTimeout = 5 * time.Second
+		reads         = 50
+	)
+
+	// Injecting latency and packet loss requires privileges that some runners don't have
+	network.SkipWithoutImpairments(t)
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+
+	flow.New(t, "Verify secrets are retrieved within the client timeout on a slow and lossy network").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(secretStoreComponentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify the secret is retrieved", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Cleanup("Restore network", network.RestoreImpairments()).
+		// Only the requests sent to Vault are delayed, so each round trip with Vault is delayed once
+		Step("Inject latency in the requests to Vault", network.InjectLatency(0, latency, jitter, servicePortToInterrupt)).
+		Step("Verify the secret is retrieved under latency", common.SecretRetrievalLatency(vaultSidecar, secretStoreName,
+			"multiplekeyvaluessecret", reads, clientTimeout)).
+		Step("Remove the latency", network.RestoreImpairments()).
+		Step("Inject packet loss in the requests to Vault", network.InjectPacketLoss(0, packetLossPercent, servicePortToInterrupt)).
+		Step("Verify the secret is retrieved under packet loss", common.SecretRetrievalLatency(vaultSidecar, secretStoreName,
+			"multiplekeyvaluessecret", reads, clientTimeout)).
+		Step("Remove the packet loss", network.RestoreImpairments()).
+		Step("Verify the secret is retrieved again", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Run()
+}
+
+func TestVaultConnectionsReset(t *testing.T) {
+	const (
+		secretStoreComponentPath = "./components/toxiproxy"
+		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
+		toxiproxyProjectName     = "hashicorp-vault-toxiproxy"
+		proxyName                = "vault"
+		// The address of the proxy, which vaultAddr points to in the component YAML
+		proxyAddress = "127.0.0.1:18200"
+		resetToxic   = "reset-connections"
+	)
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+	proxies := toxiproxy.New(toxiproxy.DefaultAPIAddress)
+
+	flow.New(t, "Verify reads recover from connections reset between the component and Vault").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(dockerComposeTimeout)(toxiproxy.Run(toxiproxyProjectName))).
+		Step("Proxy the connections to Vault", proxies.CreateProxy(proxyName, proxyAddress, "127.0.0.1:"+servicePortToInterrupt)).
+		Cleanup("Delete the proxy", proxies.DeleteProxy(proxyName)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(secretStoreComponentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify the secret is retrieved through the proxy", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Step("Reset the connections to Vault", proxies.AddToxic(proxyName, toxiproxy.ResetPeer(resetToxic, 0))).
+		// The reads fail over to vaultAddrFallback, which bypasses the proxy
+		Step("Verify the secret is retrieved despite the resets", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Step("Stop resetting the connections", proxies.RemoveToxic(proxyName, resetToxic)).
+		Step("Verify the secret is retrieved again", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Run()
+}
+
+func TestSidecarRestarted(t *testing.T) {
+	const (
+		secretStoreComponentPath = "./components/default"
+		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
+		retrievalTimeout         = 5 * time.Second
+	)
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+
+	flow.New(t, "Verify the component is initialized again when the sidecar restarts").
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(secretStoreComponentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify the secret is retrieved", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Step("Stop the sidecar", sidecar.Stop(sidecarName)).
+		Step("Verify retrieving the secret fails fast", common.SecretRetrievalFailsFast(vaultSidecar, secretStoreName,
+			"multiplekeyvaluessecret", retrievalTimeout)).
+		StepWithTimeout("Restart the sidecar", sidecarTimeout, sidecar.Restart(sidecarName)).
+		Step(flow.Retry("Waiting for component to load again...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify the secret is retrieved after the restart", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Run()
+}
+
+func TestVaultRestarted(t *testing.T) {
+	const (
+		secretStoreComponentPath = "./components/default"
+		secretStoreName          = "my-hashicorp-vault" // as set in the component YAML
+		componentPath            = "./components/persistent/"
+	)
+	// Unlike the dev server of the other flows, this one keeps its data on a volume
+	dockerComposeClusterYAML := filepath.Join(componentPath, "docker-compose-hashicorp-vault.yml")
+
+	currentGrpcPort, currentHttpPort := ports.GRPCAndHTTP(t)
+
+	flow.New(t, "Verify the component reconnects to a restarted Vault, which keeps its secrets").
+		Step(runVault(dockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, dockerComposeClusterYAML)).
+		StepWithTimeout(flow.Timeout(sidecarTimeout)(sidecar.Run(sidecarName,
+			embedded.WithoutApp(),
+			embedded.WithResourcesPath(secretStoreComponentPath),
+			embedded.WithDaprGRPCPort(currentGrpcPort),
+			embedded.WithDaprHTTPPort(currentHttpPort),
+			componentRuntimeOptions(),
+			sidecar.WithLogCapture(),
+		))).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, secretStoreName))).
+		Step("Verify the secret is retrieved", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Step("Restart Vault", dockercompose.RestartService(dockerComposeProjectName, dockerComposeClusterYAML, "hashicorp_vault")).
+		Step("Unseal the restarted Vault", dockercompose.RestartService(dockerComposeProjectName, dockerComposeClusterYAML, "vault_unseal")).
+		Step("Wait for Vault to be unsealed", dockercompose.New(dockerComposeProjectName, dockerComposeClusterYAML).
+			WaitForServices(dockerComposeTimeout)).
+		Step("Wait for component to reconnect", flow.Eventually(waitAfterInstabilityTime, time.Second,
+			common.SecretIsReadable(vaultSidecar, secretStoreName, "multiplekeyvaluessecret"))).
+		Step("Verify the secret seeded before the restart is retrieved", testDefaultSecretIsFound(vaultSidecar, secretStoreName)).
+		Run()
+}
+
+// seededComponentName is the name of the component of ./components/seeded.
+const seededComponentName = "my-hashicorp-vault-TestSeeded"
+
+// seededFlow returns a flow that starts Vault, seeds fixture into it and starts a sidecar with the component of
+// ./components/seeded, which reads the seededSecrets engine. The engine is mounted by the seeder, so fixture must
+// include it in its mounts. The secrets and the engine are deleted once the flow is done.
+func seededFlow(t *testing.T, name string, fixture vault.Fixture) *flow.Flow {
+	const componentPath = "./components/seeded"
+	seeder := vault.NewSeeder(vaultAddr, vaultToken, fixture)
+
+	return flow.New(t, name).
+		Step(runVault(defaultDockerComposeClusterYAML)).
+		Cleanup("Stop HashiCorp Vault server", dockercompose.Stop(dockerComposeProjectName, defaultDockerComposeClusterYAML)).
+		Step("Seed the secrets of the flow", seeder.Seed, seeder.Cleanup).
+		StepWithTimeout(runSidecar(vaultSidecar, componentPath)).
+		Step(waitForSidecar(vaultSidecar)).
+		Step(flow.Retry("Waiting for component to load...", common.ComponentFound(vaultSidecar, seededComponentName))).
+		Step("Verify no errors regarding component initialization", common.AssertNoInitializationErrorsForComponent(vaultSidecar, componentPath))
+}
+
+func TestSeededSecretVersions(t *testing.T) {
+	seededFlow(t, "Verify each version of a secret seeded with several versions is retrieved", vault.Fixture{
+		Mounts: []string{"seededSecrets"},
+		Secrets: map[string][]map[string]string{
+			"seededSecrets/dapr/versionedSecret": {
+				{"versionedKey": "firstVersion"},
+				{"versionedKey": "secondVersion", "addedKey": "added"},
+				{"versionedKey": "latestValue"},
+			},
+		},
+	}).
+		Step("Verify the latest version of the secret is retrieved", common.KeyValuesInSecret(vaultSidecar, seededComponentName,
+			"versionedSecret", map[string]string{
+				"versionedKey": "latestValue",
+			})).
+		Step("Verify the first version of the secret is retrieved", common.KeyValuesInSecret(vaultSidecar, seededComponentName,
+			"versionedSecret", map[string]string{
+				"versionedKey": "firstVersion",
+			}, "1")).
+		Step("Verify the second version of the secret is retrieved with all of its keys", common.KeyValuesInSecret(vaultSidecar, seededComponentName,
+			"versionedSecret", map[string]string{
+				"versionedKey": "secondVersion",
+				"addedKey":     "added",
+			}, "2")).
+		Run()
+}
+
+func TestSeededNestedBulkListing(t *testing.T) {
+	seededFlow(t, "Verify bulk retrieval lists the secrets of nested folders", vault.Fixture{
+		Mounts: []string{"seededSecrets"},
+		Secrets: map[string][]map[string]string{
+			"seededSecrets/dapr/topLevel":                   {{"level": "0"}},
+			"seededSecrets/dapr/team/app/first":             {{"level": "2"}},
+			"seededSecrets/dapr/team/app/config/second":     {{"level": "3"}},
+			"seededSecrets/dapr/team/other/deeply/nested/a": {{"level": "4"}},
+		},
+	}).
+		Step("Verify all the secrets are listed, with their folders in their names",
+			common.BulkSecretsEqual(vaultSidecar, seededComponentName, map[string]map[string]string{
+				"topLevel":                   {"level": "0"},
+				"team/app/first":             {"level": "2"},
+				"team/app/config/second":     {"level": "3"},
+				"team/other/deeply/nested/a": {"level": "4"},
+			})).
+		Step("Verify the path metadata restricts the listing to a folder and its subfolders",
+			common.BulkSecretsEqualWithMetadata(vaultSidecar, seededComponentName, map[string]string{"path": "team/app"},
+				map[string]map[string]string{
+					"team/app/first":         {"level": "2"},
+					"team/app/config/second": {"level": "3"},
+				})).
+		Step("Verify a nested secret is retrieved by its name", common.KeyValuesInSecret(vaultSidecar, seededComponentName,
+			"team/app/config/second", map[string]string{
+				"level": "3",
+			})).
+		Run()
+}
+
+func TestSeededLargeSecret(t *testing.T) {
+	// Large enough to exceed the default buffers along the way, and well under the 4 MiB limit of gRPC messages
+	large := map[string]string{
+		"largeValue": strings.Repeat("0123456789abcdef", 64*1024),
+	}
+	for i := 0; i < 200; i++ {
+		large[fmt.Sprintf("key%03d", i)] = strings.Repeat("v", 1024)
+	}
+
+	seededFlow(t, "Verify a large secret with many keys is retrieved whole", vault.Fixture{
+		Mounts: []string{"seededSecrets"},
+		Secrets: map[string][]map[string]string{
+			"seededSecrets/dapr/largeSecret": {large},
+		},
+	}).
+		Step("Verify the large secret is retrieved", common.KeyValuesInSecret(vaultSidecar, seededComponentName,
+			"largeSecret", large)).
+		Step("Verify the large secret is retrieved by a bulk read", common.BulkSecretsEqual(vaultSidecar, seededComponentName,
+			map[string]map[string]string{"largeSecret": large})).
 		Run()
 }
@@ -14,61 +14,36 @@ limitations under the License.
 package envstore_test
 
 import (
-	"fmt"
 	"testing"
 
-	"github.com/stretchr/testify/assert"
-
 	// SecretStores
 
+	"github.com/dapr/components-contrib/secretstores"
 	secretstore_env "github.com/dapr/components-contrib/secretstores/local/env"
 	secretstores_loader "github.com/dapr/dapr/pkg/components/secretstores"
 	"github.com/dapr/dapr/pkg/runtime"
-	dapr_testing "github.com/dapr/dapr/pkg/testing"
 	"github.com/dapr/kit/logger"
 
-	"github.com/dapr/components-contrib/tests/certification/embedded"
 	"github.com/dapr/components-contrib/tests/certification/flow"
-	"github.com/dapr/components-contrib/tests/certification/flow/sidecar"
-	"github.com/dapr/go-sdk/client"
-)
-
-const (
-	sidecarName = "keyvault-sidecar"
+	"github.com/dapr/components-contrib/tests/certification/secretstores/common"
 )
 
-func TestEnv(t *testing.T) {
-	ports, err := dapr_testing.GetFreePorts(2)
-	assert.NoError(t, err)
-
-	currentGrpcPort := ports[0]
-	currentHttpPort := ports[1]
-
-	t.Setenv("certtestsecret", "abcd")
-
-	testGetKnownSecret := func(ctx flow.Context) error {
-		client, err := client.NewClientWithPort(fmt.Sprint(currentGrpcPort))
-		if err != nil {
-			panic(err)
-		}
-		defer client.Close()
-
-		res, err := client.GetSecret(ctx, "envvar-secret-store", "certtestsecret", nil)
-		assert.NoError(t, err)
-		assert.Equal(t, "abcd", res["certtestsecret"])
-		return nil
-	}
-
-	flow.New(t, "environment secret store reads expected value").
-		Step(sidecar.Run(sidecarName,
-			embedded.WithoutApp(),
-			embedded.WithComponentsPath("./components/"),
-			embedded.WithDaprGRPCPort(currentGrpcPort),
-			embedded.WithDaprHTTPPort(currentHttpPort),
-			componentRuntimeOptions(),
-		)).
-		Step("Getting known secret", testGetKnownSecret).
-		Run()
+func TestStandardSuite(t *testing.T) {
+	common.RunStandardSuite(t, "./components/", common.Seed{
+		StoreName: "envvar-secret-store", // as set in the component YAML
+		// The store also returns the other variables of the environment
+		Secrets: map[string]map[string]string{
+			"certtestsecret": {"certtestsecret": "abcd"},
+		},
+		Write: func(ctx flow.Context) error {
+			ctx.T.Setenv("certtestsecret", "abcd")
+			return nil
+		},
+	},
+		common.WithRuntimeOptions(componentRuntimeOptions()...),
+		common.WithFeatures([]secretstores.Feature{secretstores.FeatureBulkGetSecret},
+			[]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret}),
+	)
 }
 
 func componentRuntimeOptions() []runtime.Option {
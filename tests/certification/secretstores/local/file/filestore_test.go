@@ -21,6 +21,7 @@ import (
 
 	// SecretStores
 
+	"github.com/dapr/components-contrib/secretstores"
 	secretstore_file "github.com/dapr/components-contrib/secretstores/local/file"
 	secretstores_loader "github.com/dapr/dapr/pkg/components/secretstores"
 	"github.com/dapr/dapr/pkg/runtime"
@@ -30,6 +31,7 @@ import (
 	"github.com/dapr/components-contrib/tests/certification/embedded"
 	"github.com/dapr/components-contrib/tests/certification/flow"
 	"github.com/dapr/components-contrib/tests/certification/flow/sidecar"
+	"github.com/dapr/components-contrib/tests/certification/secretstores/common"
 	"github.com/dapr/go-sdk/client"
 )
 
@@ -37,6 +39,21 @@ const (
 	sidecarName = "keyvault-sidecar"
 )
 
+func TestStandardSuite(t *testing.T) {
+	common.RunStandardSuite(t, "./components/defaultnestedseparator/", common.Seed{
+		StoreName: "file-secret-store", // as set in the component YAML
+		Secrets: map[string]map[string]string{
+			"certtestsecret":      {"certtestsecret": "abcd"},
+			"nestedsecret:secret": {"nestedsecret:secret": "efgh"},
+		},
+		Exhaustive: true,
+	},
+		common.WithRuntimeOptions(componentRuntimeOptions()...),
+		common.WithFeatures([]secretstores.Feature{secretstores.FeatureBulkGetSecret},
+			[]secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret}),
+	)
+}
+
 func TestEnv(t *testing.T) {
 	ports, err := dapr_testing.GetFreePorts(2)
 	assert.NoError(t, err)
@@ -44,23 +61,6 @@ func TestEnv(t *testing.T) {
 	currentGrpcPort := ports[0]
 	currentHttpPort := ports[1]
 
-	testGetKnownSecret := func(ctx flow.Context) error {
-		client, err := client.NewClientWithPort(fmt.Sprint(currentGrpcPort))
-		if err != nil {
-			panic(err)
-		}
-		defer client.Close()
-
-		res, err := client.GetSecret(ctx, "file-secret-store", "certtestsecret", nil)
-		assert.NoError(t, err)
-		assert.Equal(t, "abcd", res["certtestsecret"])
-
-		res, err = client.GetSecret(ctx, "file-secret-store", "nestedsecret:secret", nil)
-		assert.NoError(t, err)
-		assert.Equal(t, "efgh", res["nestedsecret:secret"])
-		return nil
-	}
-
 	testGetKnownSecretWithCustomSeparator := func(ctx flow.Context) error {
 		client, err := client.NewClientWithPort(fmt.Sprint(currentGrpcPort))
 		if err != nil {
@@ -78,17 +78,6 @@ func TestEnv(t *testing.T) {
 		return nil
 	}
 
-	flow.New(t, "file secret store reads expected value").
-		Step(sidecar.Run(sidecarName,
-			embedded.WithoutApp(),
-			embedded.WithComponentsPath("./components/defaultnestedseparator/"),
-			embedded.WithDaprGRPCPort(currentGrpcPort),
-			embedded.WithDaprHTTPPort(currentHttpPort),
-			componentRuntimeOptions(),
-		)).
-		Step("Getting known secret", testGetKnownSecret).
-		Run()
-
 	flow.New(t, "file secret store reads expected value with custom nested separator").
 		Step(sidecar.Run(sidecarName,
 			embedded.WithoutApp(),
@@ -112,6 +112,9 @@ import (
 	"github.com/dapr/components-contrib/tests/utils/configupdater"
 	cu_postgres "github.com/dapr/components-contrib/tests/utils/configupdater/postgres"
 	cu_redis "github.com/dapr/components-contrib/tests/utils/configupdater/redis"
+	"github.com/dapr/components-contrib/tests/utils/secretseeder"
+	ss_seeder_env "github.com/dapr/components-contrib/tests/utils/secretseeder/env"
+	ss_seeder_vault "github.com/dapr/components-contrib/tests/utils/secretseeder/vault"
 	wf_temporal "github.com/dapr/components-contrib/workflows/temporal"
 )
 
@@ -374,8 +377,14 @@ func (tc *TestConfiguration) Run(t *testing.T) {
 				require.NoErrorf(t, err, "error running conformance test for component %s", comp.Component)
 				store := loadSecretStore(comp)
 				require.NotNilf(t, store, "error running conformance test for component %s", comp.Component)
-				storeConfig := conf_secret.NewTestConfig(comp.Component, comp.Operations)
-				conf_secret.ConformanceTests(t, props, store, storeConfig)
+				cachingOpts, cachingEnabled, err := secretstores.CachingOptionsFromMetadata(props)
+				require.NoErrorf(t, err, "error running conformance test for component %s", comp.Component)
+				if cachingEnabled {
+					store = secretstores.NewCachingStore(store, cachingOpts)
+				}
+				storeConfig, err := conf_secret.NewTestConfig(comp.Component, comp.Operations, comp.Config)
+				require.NoErrorf(t, err, "error running conformance test for component %s", comp.Component)
+				conf_secret.ConformanceTests(t, props, store, loadSecretSeeder(comp), storeConfig)
 			case "pubsub":
 				filepath := fmt.Sprintf("../config/pubsub/%s", componentConfigPath)
 				props, err := tc.loadComponentsAndProperties(t, filepath)
@@ -511,6 +520,18 @@ func loadSecretStore(tc TestComponent) secretstores.SecretStore {
 	return store
 }
 
+// loadSecretSeeder returns the seeder of the secrets read by the secret store, or nil if there's none.
+func loadSecretSeeder(tc TestComponent) secretseeder.Seeder {
+	switch tc.Component {
+	case "local.env":
+		return ss_seeder_env.NewEnvSecretSeeder()
+	case "hashicorp.vault":
+		return ss_seeder_vault.NewVaultSecretSeeder()
+	default:
+		return nil
+	}
+}
+
 func loadCryptoProvider(tc TestComponent) contribCrypto.SubtleCrypto {
 	var component contribCrypto.SubtleCrypto
 	switch tc.Component {
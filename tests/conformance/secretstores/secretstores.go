@@ -15,33 +15,94 @@ package secretstores
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/components-contrib/tests/conformance/utils"
+	"github.com/dapr/components-contrib/tests/utils/secretseeder"
+	"github.com/dapr/kit/config"
 )
 
-// creating this struct so that it can be expanded later.
+const (
+	defaultPaginationSecrets  = 50
+	defaultPaginationPageSize = 10
+
+	// contextDeadline is the deadline of the requests sent to a slow endpoint.
+	contextDeadline = 100 * time.Millisecond
+	// maxContextDeadlineWait is how long the requests sent to a slow endpoint may take to return after their deadline.
+	maxContextDeadlineWait = 2 * time.Second
+)
+
+// specialCharacterNames are the names of the secrets seeded to test that names are escaped in the requests.
+var specialCharacterNames = []string{
+	"team/app.config v2",
+	"conftest.special/ünïcödé",
+	"conftest 50%off",
+}
+
 type TestConfig struct {
 	utils.CommonConfig
+
+	// PaginationSecrets is the number of secrets seeded to test the pagination of bulk reads.
+	PaginationSecrets int `mapstructure:"paginationSecrets"`
+	// PaginationPageSize is the number of secrets requested per page when testing the pagination of bulk reads.
+	PaginationPageSize int `mapstructure:"paginationPageSize"`
+	// SlowEndpointMetadataKey is the metadata property with the address of the server, replaced with the address of a
+	// server that never responds to test that the deadlines of the requests are honored.
+	SlowEndpointMetadataKey string `mapstructure:"slowEndpointMetadataKey"`
+	// SkipContextDeadline skips the test of the deadlines of the requests, for stores that can't be pointed at a slow
+	// endpoint.
+	SkipContextDeadline bool `mapstructure:"skipContextDeadline"`
+	// ValueTypeText tells that the store returns each secret as a single JSON value of all its keys, under the name
+	// of the secret, like hashicorp.vault with vaultValueType=text.
+	ValueTypeText bool `mapstructure:"valueTypeText"`
 }
 
-func NewTestConfig(name string, operations []string) TestConfig {
+func NewTestConfig(name string, operations []string, configMap map[string]interface{}) (TestConfig, error) {
 	tc := TestConfig{
 		CommonConfig: utils.CommonConfig{
 			ComponentType: "secretstores",
 			ComponentName: name,
 			Operations:    utils.NewStringSet(operations...),
 		},
+		PaginationSecrets:  defaultPaginationSecrets,
+		PaginationPageSize: defaultPaginationPageSize,
+	}
+
+	err := config.Decode(configMap, &tc)
+	if err != nil {
+		return tc, err
 	}
 
-	return tc
+	return tc, nil
 }
 
-func ConformanceTests(t *testing.T, props map[string]string, store secretstores.SecretStore, config TestConfig) {
+// expectedSecret returns the values that the store returns for a secret with the given keys and values.
+func (tc TestConfig) expectedSecret(t *testing.T, name string, data map[string]string) map[string]string {
+	if !tc.ValueTypeText {
+		return data
+	}
+	// The keys are sorted, as in the JSON documents of the secrets returned by Vault
+	value, err := json.Marshal(data)
+	require.NoError(t, err)
+
+	return map[string]string{name: string(value)}
+}
+
+// ConformanceTests runs the conformance tests for a secret store.
+// The seeder creates additional secrets for the tests that need them, which are skipped when it's nil.
+func ConformanceTests(t *testing.T, props map[string]string, store secretstores.SecretStore, seeder secretseeder.Seeder, config TestConfig) {
 	// TODO add support for metadata
 	// For local env var based component test
 	t.Setenv("conftestsecret", "abcd")
@@ -73,9 +134,9 @@ func ConformanceTests(t *testing.T, props map[string]string, store secretstores.
 			Name: "conftestsecret",
 		}
 		getSecretResponse := secretstores.GetSecretResponse{
-			Data: map[string]string{
+			Data: config.expectedSecret(t, "conftestsecret", map[string]string{
 				"conftestsecret": "abcd",
-			},
+			}),
 		}
 
 		t.Run("get", func(t *testing.T) {
@@ -84,6 +145,18 @@ func ConformanceTests(t *testing.T, props map[string]string, store secretstores.
 			assert.NotNil(t, resp, "expected value to be returned")
 			assert.NotNil(t, resp.Data, "expected value to be returned")
 			assert.Equal(t, getSecretResponse.Data, resp.Data, "expected values to be equal")
+
+			// Metadata is optional: stores either don't return any, or return well-formed entries
+			for k := range resp.Metadata {
+				assert.NotEmpty(t, k, "expected metadata keys not to be empty")
+			}
+		})
+
+		t.Run("get missing secret", func(t *testing.T) {
+			_, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{
+				Name: "conftestsecret-does-not-exist",
+			})
+			assert.Truef(t, errors.Is(err, secretstores.ErrSecretNotFound), "expected ErrSecretNotFound for a missing secret, got %v", err)
 		})
 	})
 
@@ -101,6 +174,11 @@ func ConformanceTests(t *testing.T, props map[string]string, store secretstores.
 
 		t.Run("bulkget", func(t *testing.T) {
 			resp, err := store.BulkGetSecret(context.Background(), bulkReq)
+			if !secretstores.FeatureBulkGetSecret.IsPresent(store.Features()) {
+				assert.Truef(t, errors.Is(err, secretstores.ErrBulkGetSecretNotSupported), "expected ErrBulkGetSecretNotSupported from a store that doesn't advertise %s, got %v", secretstores.FeatureBulkGetSecret, err)
+				return
+			}
+
 			assert.NoError(t, err, "expected no error on getting secret %v", bulkReq)
 			assert.NotNil(t, resp, "expected value to be returned")
 			assert.NotNil(t, resp.Data, "expected value to be returned")
@@ -113,8 +191,196 @@ func ConformanceTests(t *testing.T, props map[string]string, store secretstores.
 			// store contains all that we expected, but it is possible that
 			// it may have more.
 			for k, m := range expectedData {
-				assert.Equal(t, m, resp.Data[k], "expected values to be equal")
+				assert.Equal(t, config.expectedSecret(t, k, m), resp.Data[k], "expected values to be equal")
+			}
+		})
+	})
+
+	// Bulkget with pagination
+	t.Run("bulkGet pagination", func(t *testing.T) {
+		if !secretstores.FeatureBulkGetSecret.IsPresent(store.Features()) {
+			t.Skipf("the store doesn't advertise %s", secretstores.FeatureBulkGetSecret)
+		}
+		if seeder == nil {
+			t.Skip("no seeder for the component")
+		}
+
+		require.NoError(t, seeder.Init(props), "expected no error on initializing seeder")
+		seeded := make(map[string]map[string]string, config.PaginationSecrets)
+		names := make([]string, 0, config.PaginationSecrets)
+		for i := 0; i < config.PaginationSecrets; i++ {
+			name := fmt.Sprintf("conftestpage%04d", i)
+			seeded[name] = map[string]string{name: strconv.Itoa(i)}
+			names = append(names, name)
+		}
+		require.NoError(t, seeder.Seed(context.Background(), seeded), "expected no error on seeding secrets")
+		t.Cleanup(func() {
+			assert.NoError(t, seeder.Delete(context.Background(), names), "expected no error on deleting seeded secrets")
+		})
+
+		limit := strconv.Itoa(config.PaginationPageSize)
+
+		if !secretstores.FeatureBulkGetSecretPagination.IsPresent(store.Features()) {
+			t.Run("stores without pagination return every secret", func(t *testing.T) {
+				resp, err := store.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+					Metadata: map[string]string{secretstores.BulkGetSecretLimitKey: limit},
+				})
+				require.NoError(t, err)
+				for name, data := range seeded {
+					assert.Equal(t, config.expectedSecret(t, name, data), resp.Data[name], "expected seeded secret %s to be returned", name)
+				}
+				assert.Empty(t, resp.Metadata[secretstores.BulkGetSecretContinuationTokenKey], "expected no continuation token")
+			})
+			return
+		}
+
+		t.Run("pages have no duplicates nor gaps", func(t *testing.T) {
+			received := map[string]map[string]string{}
+			token := ""
+			// Stores may have more secrets than the seeded ones, but not more pages than secrets
+			for page := 0; ; page++ {
+				require.LessOrEqual(t, page, len(seeded)+len(received), "expected the last page to be reached")
+
+				reqMetadata := map[string]string{secretstores.BulkGetSecretLimitKey: limit}
+				if token != "" {
+					reqMetadata[secretstores.BulkGetSecretContinuationTokenKey] = token
+				}
+				resp, err := store.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{Metadata: reqMetadata})
+				require.NoError(t, err, "expected no error on getting page %d", page)
+				assert.LessOrEqual(t, len(resp.Data), config.PaginationPageSize, "expected page %d not to exceed the limit", page)
+
+				for name, data := range resp.Data {
+					_, duplicate := received[name]
+					assert.False(t, duplicate, "expected secret %s to be returned only once", name)
+					received[name] = data
+				}
+
+				token = resp.Metadata[secretstores.BulkGetSecretContinuationTokenKey]
+				if token == "" {
+					break
+				}
+			}
+
+			for name, data := range seeded {
+				assert.Equal(t, config.expectedSecret(t, name, data), received[name], "expected seeded secret %s to be returned", name)
+			}
+		})
+	})
+
+	// Names with slashes, dots, spaces, percent signs and non-ASCII characters
+	t.Run("special characters in names", func(t *testing.T) {
+		if seeder == nil {
+			t.Skip("no seeder for the component")
+		}
+
+		require.NoError(t, seeder.Init(props), "expected no error on initializing seeder")
+		seeded := make(map[string]map[string]string, len(specialCharacterNames))
+		for _, name := range specialCharacterNames {
+			seeded[name] = map[string]string{name: "value of " + name}
+		}
+		require.NoError(t, seeder.Seed(context.Background(), seeded), "expected no error on seeding secrets")
+		t.Cleanup(func() {
+			assert.NoError(t, seeder.Delete(context.Background(), specialCharacterNames), "expected no error on deleting seeded secrets")
+		})
+
+		for name, data := range seeded {
+			resp, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+			require.NoError(t, err, "expected no error on getting secret %s", name)
+			assert.Equal(t, config.expectedSecret(t, name, data), resp.Data, "expected the value of secret %s", name)
+		}
+	})
+
+	// A secret with several keys, returned as a single value by the stores with text values
+	t.Run("multiple keys", func(t *testing.T) {
+		if seeder == nil {
+			t.Skip("no seeder for the component")
+		}
+		if !config.ValueTypeText && !secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(store.Features()) {
+			t.Skipf("the store doesn't advertise %s", secretstores.FeatureMultipleKeyValuesPerSecret)
+		}
+
+		const name = "conftestmultiplekeys"
+		data := map[string]string{"first": "1", "second": "2", "third": "3"}
+		require.NoError(t, seeder.Init(props), "expected no error on initializing seeder")
+		require.NoError(t, seeder.Seed(context.Background(), map[string]map[string]string{name: data}), "expected no error on seeding secrets")
+		t.Cleanup(func() {
+			assert.NoError(t, seeder.Delete(context.Background(), []string{name}), "expected no error on deleting seeded secrets")
+		})
+		expected := config.expectedSecret(t, name, data)
+
+		resp, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: name})
+		require.NoError(t, err, "expected no error on getting secret %s", name)
+		assert.Equal(t, expected, resp.Data, "expected the value of secret %s", name)
+
+		// Bulk reads return the secrets as they're returned one by one
+		if secretstores.FeatureBulkGetSecret.IsPresent(store.Features()) {
+			bulkResp, err := store.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+			require.NoError(t, err, "expected no error on getting all the secrets")
+			assert.Equal(t, expected, bulkResp.Data[name], "expected secret %s to be returned like by a get", name)
+		}
+	})
+
+	// Context deadline: this must be the last test, as it points the store at a server that never responds
+	t.Run("context deadline", func(t *testing.T) {
+		if config.SkipContextDeadline {
+			t.Skip("the store can't be pointed at a slow endpoint")
+		}
+		require.NotEmpty(t, config.SlowEndpointMetadataKey, "expected slowEndpointMetadataKey or skipContextDeadline to be configured")
+
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+		}))
+		t.Cleanup(func() {
+			close(release)
+			server.Close()
+		})
+
+		slowProps := make(map[string]string, len(props))
+		for k, v := range props {
+			slowProps[k] = v
+		}
+		slowProps[config.SlowEndpointMetadataKey] = server.URL
+		err := store.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{
+			Properties: slowProps,
+		}})
+		require.NoError(t, err, "expected no error on initializing store with a slow endpoint")
+
+		assertDeadlineHonored := func(t *testing.T, call func(ctx context.Context) error) {
+			ctx, cancel := context.WithTimeout(context.Background(), contextDeadline)
+			defer cancel()
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- call(ctx)
+			}()
+
+			select {
+			case err := <-errCh:
+				assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected a context deadline error, got %v", err)
+			case <-time.After(contextDeadline + maxContextDeadlineWait):
+				assert.Failf(t, "deadline ignored", "expected the request to return within %v of its deadline", maxContextDeadlineWait)
+			}
+		}
+
+		t.Run("get", func(t *testing.T) {
+			assertDeadlineHonored(t, func(ctx context.Context) error {
+				_, err := store.GetSecret(ctx, secretstores.GetSecretRequest{Name: "conftestsecret"})
+				return err
+			})
+		})
+
+		t.Run("bulkget", func(t *testing.T) {
+			if !secretstores.FeatureBulkGetSecret.IsPresent(store.Features()) {
+				t.Skipf("the store doesn't advertise %s", secretstores.FeatureBulkGetSecret)
 			}
+			assertDeadlineHonored(t, func(ctx context.Context) error {
+				_, err := store.BulkGetSecret(ctx, secretstores.BulkGetSecretRequest{})
+				return err
+			})
 		})
 	})
 }
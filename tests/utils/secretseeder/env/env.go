@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package env
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/tests/utils/secretseeder"
+)
+
+// SecretSeeder seeds the secrets read by the local.env secret store as environment variables of the process.
+type SecretSeeder struct {
+	prefix string
+}
+
+func NewEnvSecretSeeder() secretseeder.Seeder {
+	return &SecretSeeder{}
+}
+
+func (s *SecretSeeder) Init(props map[string]string) error {
+	m := struct {
+		Prefix string
+	}{}
+	if err := metadata.DecodeMetadata(props, &m); err != nil {
+		return err
+	}
+	s.prefix = m.Prefix
+
+	return nil
+}
+
+// Seed sets an environment variable for each secret, which must have a single key with the same name.
+func (s *SecretSeeder) Seed(_ context.Context, secrets map[string]map[string]string) error {
+	for name, data := range secrets {
+		value, ok := data[name]
+		if !ok || len(data) != 1 {
+			return fmt.Errorf("secret %s must have a single key with the same name", name)
+		}
+		if err := os.Setenv(s.prefix+name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SecretSeeder) Delete(_ context.Context, names []string) error {
+	for _, name := range names {
+		if err := os.Unsetenv(s.prefix + name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
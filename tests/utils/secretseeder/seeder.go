@@ -0,0 +1,26 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretseeder
+
+import "context"
+
+// Seeder creates secrets in the secret store used by a test, for the tests that need more secrets than the fixtures have.
+type Seeder interface {
+	// Init configures the seeder with the metadata of the secret store component.
+	Init(props map[string]string) error
+	// Seed creates the given secrets, or overwrites them if they already exist.
+	Seed(ctx context.Context, secrets map[string]map[string]string) error
+	// Delete deletes the given secrets. Secrets that don't exist are ignored.
+	Delete(ctx context.Context, names []string) error
+}
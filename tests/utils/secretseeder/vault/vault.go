@@ -0,0 +1,270 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/tests/utils/secretseeder"
+)
+
+const (
+	defaultVaultAddress = "https://127.0.0.1:8200"
+	defaultEnginePath   = "secret"
+	defaultKVPrefix     = "dapr"
+)
+
+// SecretSeeder writes secrets to the KV version 2 engine of HashiCorp Vault, where the hashicorp.vault secret store
+// configured with the same metadata reads them. It uses the HTTP API, so it doesn't need the Vault CLI.
+type SecretSeeder struct {
+	client     *Client
+	enginePath string
+	kvPrefix   string
+}
+
+// seederMetadata holds the metadata of the secret store component read by the seeder.
+type seederMetadata struct {
+	VaultAddr           string
+	VaultToken          string
+	VaultTokenMountPath string
+	EnginePath          string
+	VaultKVPrefix       string
+	VaultKVUsePrefix    bool `mddefault:"true"`
+}
+
+func NewVaultSecretSeeder() secretseeder.Seeder {
+	return &SecretSeeder{}
+}
+
+func (s *SecretSeeder) Init(props map[string]string) error {
+	m := seederMetadata{}
+	if err := metadata.DecodeAndValidateMetadata(props, &m); err != nil {
+		return err
+	}
+
+	address := m.VaultAddr
+	if address == "" {
+		address = defaultVaultAddress
+	}
+
+	token := m.VaultToken
+	if token == "" && m.VaultTokenMountPath != "" {
+		b, err := os.ReadFile(m.VaultTokenMountPath)
+		if err != nil {
+			return fmt.Errorf("couldn't read the vault token: %w", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token == "" {
+		return fmt.Errorf("vaultToken or vaultTokenMountPath is required")
+	}
+	s.client = NewClient(address, token)
+
+	s.enginePath = m.EnginePath
+	if s.enginePath == "" {
+		s.enginePath = defaultEnginePath
+	}
+
+	if m.VaultKVUsePrefix {
+		s.kvPrefix = m.VaultKVPrefix
+		if s.kvPrefix == "" {
+			s.kvPrefix = defaultKVPrefix
+		}
+	}
+
+	return nil
+}
+
+// Seed writes a new version of each secret.
+func (s *SecretSeeder) Seed(ctx context.Context, secrets map[string]map[string]string) error {
+	for name, data := range secrets {
+		if err := s.client.WriteSecret(ctx, s.enginePath, s.name(name), data); err != nil {
+			return fmt.Errorf("couldn't seed secret %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Delete deletes every version of the secrets.
+func (s *SecretSeeder) Delete(ctx context.Context, names []string) error {
+	for _, name := range names {
+		if err := s.client.DeleteSecret(ctx, s.enginePath, s.name(name)); err != nil {
+			return fmt.Errorf("couldn't delete secret %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// name returns the name of a secret in the engine, with the prefix of the component.
+func (s *SecretSeeder) name(name string) string {
+	if s.kvPrefix == "" {
+		return name
+	}
+
+	return s.kvPrefix + "/" + name
+}
+
+// Client calls the HTTP API of HashiCorp Vault to manage the secrets of KV version 2 engines. It's shared by the
+// conformance seeder and the certification flows, which address secrets by their engine mount and name instead of
+// by the metadata of a component.
+type Client struct {
+	client  *http.Client
+	address string
+	token   string
+}
+
+// NewClient returns a client of the Vault server at address that authenticates with token.
+func NewClient(address, token string) *Client {
+	return &Client{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+	}
+}
+
+// ReadSecret returns the data of the latest version of a secret, or nil if it doesn't exist.
+func (c *Client) ReadSecret(ctx context.Context, mount, name string) (map[string]string, error) {
+	body, err := c.do(ctx, http.MethodGet, c.path(mount, "data", name), nil)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("couldn't parse the secret: %w", err)
+	}
+
+	return secret.Data.Data, nil
+}
+
+// WriteSecret writes a new version of a secret.
+func (c *Client) WriteSecret(ctx context.Context, mount, name string, data map[string]string) error {
+	body, err := json.Marshal(map[string]any{"data": data})
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, http.MethodPost, c.path(mount, "data", name), body)
+
+	return err
+}
+
+// DeleteSecret deletes every version of a secret.
+func (c *Client) DeleteSecret(ctx context.Context, mount, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.path(mount, "metadata", name), nil)
+
+	return err
+}
+
+// MountExists returns whether a secrets engine is mounted at path.
+func (c *Client) MountExists(ctx context.Context, path string) (bool, error) {
+	body, err := c.do(ctx, http.MethodGet, "/v1/sys/mounts", nil)
+	if err != nil {
+		return false, err
+	}
+
+	var mounts struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err = json.Unmarshal(body, &mounts); err != nil {
+		return false, fmt.Errorf("couldn't parse the mounts: %w", err)
+	}
+	_, ok := mounts.Data[strings.Trim(path, "/")+"/"]
+
+	return ok, nil
+}
+
+// EnableKVMount mounts a KV version 2 secrets engine at path.
+func (c *Client) EnableKVMount(ctx context.Context, path string) error {
+	body, err := json.Marshal(map[string]any{
+		"type":    "kv",
+		"options": map[string]string{"version": "2"},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, http.MethodPost, "/v1/sys/mounts/"+strings.Trim(path, "/"), body)
+
+	return err
+}
+
+// DisableMount unmounts the secrets engine at path, which deletes all of its secrets.
+func (c *Client) DisableMount(ctx context.Context, path string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/v1/sys/mounts/"+strings.Trim(path, "/"), nil)
+
+	return err
+}
+
+// path returns the API path of a secret under the given KV version 2 endpoint, with the segments of its name escaped.
+func (c *Client) path(mount, endpoint, name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return "/v1/" + strings.Trim(mount, "/") + "/" + endpoint + "/" + strings.Join(segments, "/")
+}
+
+// errNotFound is returned by do when Vault responds with 404.
+var errNotFound = errors.New("not found")
+
+// do sends a request to Vault and returns the body of the response.
+func (c *Client) do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.address+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s %s", errNotFound, method, path)
+	case resp.StatusCode >= http.StatusBadRequest:
+		return nil, fmt.Errorf("status code %d, body %s", resp.StatusCode, respBody)
+	}
+
+	return respBody, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretSeeder(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		requests []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lock.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Vault-Token")+" "+string(body))
+		lock.Unlock()
+		if r.URL.Path == "/v1/kv/data/forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	t.Run("secrets are written under the prefix of the component", func(t *testing.T) {
+		requests = nil
+		s := NewVaultSecretSeeder()
+		require.NoError(t, s.Init(map[string]string{"vaultAddr": server.URL, "vaultToken": "token"}))
+
+		require.NoError(t, s.Seed(context.Background(), map[string]map[string]string{"first": {"first": "1"}}))
+		require.NoError(t, s.Delete(context.Background(), []string{"first"}))
+		assert.Equal(t, []string{
+			`POST /v1/secret/data/dapr/first token {"data":{"first":"1"}}`,
+			`DELETE /v1/secret/metadata/dapr/first token `,
+		}, requests)
+	})
+
+	t.Run("engine path and prefix are read from the component metadata", func(t *testing.T) {
+		requests = nil
+		s := NewVaultSecretSeeder()
+		require.NoError(t, s.Init(map[string]string{
+			"vaultAddr":        server.URL,
+			"vaultToken":       "token",
+			"enginePath":       "kv",
+			"vaultKVUsePrefix": "false",
+		}))
+
+		require.NoError(t, s.Seed(context.Background(), map[string]map[string]string{"first": {"first": "1"}}))
+		assert.Equal(t, []string{`POST /v1/kv/data/first token {"data":{"first":"1"}}`}, requests)
+
+		err := s.Seed(context.Background(), map[string]map[string]string{"forbidden": {"forbidden": "1"}})
+		assert.ErrorContains(t, err, "couldn't seed secret forbidden: status code 403")
+	})
+
+	t.Run("names are escaped", func(t *testing.T) {
+		requests = nil
+		s := NewVaultSecretSeeder()
+		require.NoError(t, s.Init(map[string]string{"vaultAddr": server.URL, "vaultToken": "token"}))
+
+		require.NoError(t, s.Delete(context.Background(), []string{"team/app.config v2", "50%off"}))
+		// The server records the decoded paths
+		assert.Equal(t, []string{
+			`DELETE /v1/secret/metadata/dapr/team/app.config v2 token `,
+			`DELETE /v1/secret/metadata/dapr/50%off token `,
+		}, requests)
+	})
+
+	t.Run("a token is required", func(t *testing.T) {
+		s := NewVaultSecretSeeder()
+		assert.Error(t, s.Init(map[string]string{"vaultAddr": server.URL}))
+	})
+}
+
+func TestClientReadSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/dapr/first":
+			_, _ = w.Write([]byte(`{"data":{"data":{"first":"1"},"metadata":{"version":2}}}`))
+		case "/v1/secret/data/dapr/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := NewClient(server.URL+"/", "token")
+
+	data, err := c.ReadSecret(context.Background(), "secret", "dapr/first")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"first": "1"}, data)
+
+	data, err = c.ReadSecret(context.Background(), "/secret/", "dapr/missing")
+	require.NoError(t, err)
+	assert.Nil(t, data, "a missing secret is not an error")
+
+	_, err = c.ReadSecret(context.Background(), "secret", "dapr/forbidden")
+	assert.ErrorContains(t, err, "status code 403")
+}
+
+func TestClientMounts(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		requests []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lock.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		lock.Unlock()
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"secret/":{"type":"kv"},"data":{"secret/":{"type":"kv"},"team/kv/":{"type":"kv"}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	c := NewClient(server.URL, "token")
+
+	for path, exists := range map[string]bool{"secret": true, "/team/kv/": true, "other": false} {
+		ok, err := c.MountExists(context.Background(), path)
+		require.NoError(t, err)
+		assert.Equal(t, exists, ok, path)
+	}
+
+	requests = nil
+	require.NoError(t, c.EnableKVMount(context.Background(), "/team/kv/"))
+	require.NoError(t, c.DisableMount(context.Background(), "team/kv"))
+	assert.Equal(t, []string{
+		`POST /v1/sys/mounts/team/kv {"options":{"version":"2"},"type":"kv"}`,
+		`DELETE /v1/sys/mounts/team/kv `,
+	}, requests)
+}